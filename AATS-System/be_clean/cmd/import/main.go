@@ -0,0 +1,80 @@
+// Command import loads job postings or historical applications from CSV into
+// the database pointed at by DATABASE_URL (same rules as POST /api/import/*).
+//
+//	go run ./cmd/import -kind jobs -file jobs.csv -dry-run
+//	go run ./cmd/import -kind applications -file people.csv -map "email=E-mail,job_external_id=Job Code"
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"aats-backend-clean/handlers"
+	"aats-backend-clean/models"
+)
+
+// parseMapping accepts "field=Header,field2=Header 2" or a path to a JSON file.
+func parseMapping(v string) (map[string]string, error) {
+	mapping := map[string]string{}
+	if v == "" {
+		return mapping, nil
+	}
+	if strings.HasSuffix(v, ".json") {
+		raw, err := os.ReadFile(v)
+		if err != nil {
+			return nil, err
+		}
+		return mapping, json.Unmarshal(raw, &mapping)
+	}
+	for _, pair := range strings.Split(v, ",") {
+		k, h, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping %q, expected field=Header", pair)
+		}
+		mapping[strings.TrimSpace(k)] = strings.TrimSpace(h)
+	}
+	return mapping, nil
+}
+
+func main() {
+	kind := flag.String("kind", "", "what to import: jobs | applications")
+	file := flag.String("file", "", "CSV file to import")
+	mapFlag := flag.String("map", "", "column mapping: field=Header,... or a .json file")
+	dryRun := flag.Bool("dry-run", false, "validate and report without saving")
+	flag.Parse()
+	if *kind == "" || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	mapping, err := parseMapping(*mapFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	_ = godotenv.Load(".env")
+	models.ConnectDatabase()
+
+	report, err := handlers.ImportCSV(models.DB, *kind, f, mapping, *dryRun)
+	if report != nil {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !report.DryRun && !report.Committed {
+		log.Fatal("import has invalid rows, nothing was saved")
+	}
+}
@@ -0,0 +1,48 @@
+// Command openapi regenerates openapi/openapi.json from main.go and the
+// handlers. Run it from the module directory after changing routes or
+// request bodies; -check only reports whether the committed spec is stale
+// (exit status 1), for CI.
+//
+//	go run ./cmd/openapi
+//	go run ./cmd/openapi -check
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"aats-backend-clean/openapi"
+)
+
+func main() {
+	root := flag.String("root", ".", "module directory (where main.go is)")
+	check := flag.Bool("check", false, "fail if the committed spec differs instead of writing it")
+	flag.Parse()
+
+	doc, err := openapi.Generate(*root)
+	if err != nil {
+		log.Fatalf("generate: %v", err)
+	}
+	raw, err := openapi.Marshal(doc)
+	if err != nil {
+		log.Fatalf("marshal: %v", err)
+	}
+	out := filepath.Join(*root, "openapi", "openapi.json")
+	if *check {
+		cur, _ := os.ReadFile(out)
+		if !bytes.Equal(cur, raw) {
+			fmt.Fprintln(os.Stderr, out+" is out of date with the handlers; run: go run ./cmd/openapi")
+			os.Exit(1)
+		}
+		fmt.Println("openapi.json is up to date")
+		return
+	}
+	if err := os.WriteFile(out, raw, 0o644); err != nil {
+		log.Fatalf("write: %v", err)
+	}
+	fmt.Printf("wrote %s (%d paths)\n", out, len(doc.Paths))
+}
@@ -0,0 +1,549 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+	"aats-backend-clean/worker"
+)
+
+const (
+	// bump the version when facts gain a column so the next refresh rebuilds
+	// every row instead of only the changed ones
+	analyticsWatermarkName = "applications.v2"
+	analyticsChunkSize     = 500
+	// analyticsOverlap re-reads a short window before the watermark so rows
+	// committed by transactions that started before the last refresh are not
+	// missed. Recomputing a fact is idempotent.
+	analyticsOverlap = time.Minute
+)
+
+var analyticsMu sync.Mutex
+
+// StartAnalyticsRefresher refreshes the analytics aggregates now and then
+// every interval. Call after the DB is connected.
+func StartAnalyticsRefresher(every time.Duration) {
+	run := func() {
+		if n, err := RefreshAnalytics(models.DB, false); err != nil {
+			log.Printf("analytics refresh failed: %v", err)
+		} else if n > 0 {
+			log.Printf("analytics refresh: %d application(s) updated", n)
+		}
+	}
+	go run()
+	worker.Every(every, run)
+}
+
+// buildAnalyticsFact derives the fact row and stage durations of one
+// application from its timeline. tls must be sorted by date.
+func buildAnalyticsFact(app models.Application, department string, tls []models.ApplicationTimeline, eval *models.Evaluation, now time.Time) (models.AnalyticsApplicationFact, []models.AnalyticsStageDuration) {
+	f := models.AnalyticsApplicationFact{
+		ApplicationID: app.ID,
+		JobID:         app.JobID,
+		Department:    department,
+		Source:        app.Source,
+		SubmittedDate: app.SubmittedDate,
+		Status:        app.Status,
+		RefreshedAt:   now,
+	}
+
+	events := make([]utils.StatusEvent, 0, len(tls)+1)
+	for _, t := range tls {
+		events = append(events, utils.StatusEvent{Status: t.Status, At: t.Date})
+	}
+	if len(events) == 0 {
+		events = append(events, utils.StatusEvent{Status: app.Status, At: app.SubmittedDate})
+	}
+
+	f.StageReached = utils.StageRank(app.Status)
+	if f.StageReached < 0 {
+		f.StageReached = 0
+	}
+	prev := ""
+	for i, e := range events {
+		if r := utils.StageRank(e.Status); r > f.StageReached {
+			f.StageReached = r
+		}
+		if f.FirstResponseHours == nil && e.Status != "submitted" {
+			h := e.At.Sub(app.SubmittedDate).Hours()
+			f.FirstResponseHours = &h
+		}
+		if e.Status == "hired" && f.HiredAt == nil {
+			at := e.At
+			h := at.Sub(app.SubmittedDate).Hours()
+			f.HiredAt, f.TimeToHireHours = &at, &h
+		}
+		if e.Status == "rejected" && app.Status == "rejected" && prev != "rejected" {
+			at := e.At
+			f.RejectedAt = &at
+			f.RejectedFrom = prev
+			if i < len(tls) {
+				f.RejectionReason = tls[i].Description
+			}
+		}
+		prev = e.Status
+	}
+	if eval != nil {
+		f.HasEvaluation = true
+		f.TechnicalSkills = eval.TechnicalSkills
+		f.Communication = eval.Communication
+		f.ProblemSolving = eval.ProblemSolving
+		f.CulturalFit = eval.CulturalFit
+		f.OverallScore = float64(eval.OverallScore)
+	}
+
+	spans := utils.StageSpans(events)
+	durations := make([]models.AnalyticsStageDuration, 0, len(spans))
+	for i, s := range spans {
+		durations = append(durations, models.AnalyticsStageDuration{ApplicationID: app.ID, Seq: i, Stage: s.Stage, EnteredAt: s.EnteredAt, Hours: s.Hours})
+	}
+	return f, durations
+}
+
+// changedApplicationIDs lists applications whose facts may be stale since wm.
+func changedApplicationIDs(db *gorm.DB, wm time.Time) ([]string, error) {
+	set := map[string]struct{}{}
+	add := func(q *gorm.DB) error {
+		var ids []string
+		if err := q.Pluck("DISTINCT application_id", &ids).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			set[id] = struct{}{}
+		}
+		return nil
+	}
+	var appIDs []string
+	if err := db.Model(&models.Application{}).Where("updated_at > ? OR created_at > ?", wm, wm).Pluck("id", &appIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range appIDs {
+		set[id] = struct{}{}
+	}
+	if err := add(db.Model(&models.ApplicationTimeline{}).Where("created_at > ?", wm)); err != nil {
+		return nil, err
+	}
+	if err := add(db.Model(&models.Evaluation{}).Where("evaluated_at > ?", wm)); err != nil {
+		return nil, err
+	}
+	// a job moving department changes the dimension of all its applications
+	jobs := db.Model(&models.JobPosting{}).Select("id").Where("updated_at > ?", wm)
+	appIDs = nil
+	if err := db.Model(&models.Application{}).Where("job_id IN (?)", jobs).Pluck("id", &appIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range appIDs {
+		set[id] = struct{}{}
+	}
+
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// RefreshAnalytics recomputes the facts of applications changed since the
+// last refresh (all of them when full is true) and returns how many were
+// recomputed.
+func RefreshAnalytics(db *gorm.DB, full bool) (int, error) {
+	analyticsMu.Lock()
+	defer analyticsMu.Unlock()
+
+	start := time.Now()
+	var wm models.AnalyticsWatermark
+	db.Where("name = ?", analyticsWatermarkName).Limit(1).Find(&wm)
+
+	var ids []string
+	var err error
+	if full || wm.Watermark.IsZero() {
+		err = db.Model(&models.Application{}).Pluck("id", &ids).Error
+		if err == nil {
+			// rows of deleted applications
+			live := db.Model(&models.Application{}).Select("id")
+			err = db.Where("application_id NOT IN (?)", live).Delete(&models.AnalyticsApplicationFact{}).Error
+			if err == nil {
+				err = db.Where("application_id NOT IN (?)", live).Delete(&models.AnalyticsStageDuration{}).Error
+			}
+		}
+	} else {
+		ids, err = changedApplicationIDs(db, wm.Watermark.Add(-analyticsOverlap))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(ids); i += analyticsChunkSize {
+		chunk := ids[i:min(i+analyticsChunkSize, len(ids))]
+		if err := refreshAnalyticsChunk(db, chunk, start); err != nil {
+			return i, err
+		}
+	}
+
+	wm.Name = analyticsWatermarkName
+	wm.Watermark = start
+	if err := db.Save(&wm).Error; err != nil {
+		return len(ids), err
+	}
+	return len(ids), nil
+}
+
+func refreshAnalyticsChunk(db *gorm.DB, ids []string, now time.Time) error {
+	var apps []models.Application
+	if err := db.Select("id, job_id, status, source, submitted_date").Where("id IN ?", ids).Find(&apps).Error; err != nil {
+		return err
+	}
+	jobIDs := make([]string, 0, len(apps))
+	for _, a := range apps {
+		jobIDs = append(jobIDs, a.JobID)
+	}
+	dept := map[string]string{}
+	var jobs []models.JobPosting
+	db.Select("id, department").Where("id IN ?", jobIDs).Find(&jobs)
+	for _, j := range jobs {
+		dept[j.ID] = j.Department
+	}
+	tlMap := map[string][]models.ApplicationTimeline{}
+	var tls []models.ApplicationTimeline
+	if err := db.Where("application_id IN ?", ids).Order("date asc, created_at asc").Find(&tls).Error; err != nil {
+		return err
+	}
+	for _, t := range tls {
+		tlMap[t.ApplicationID] = append(tlMap[t.ApplicationID], t)
+	}
+	evalMap := map[string]*models.Evaluation{}
+	var evals []models.Evaluation
+	db.Where("application_id IN ?", ids).Find(&evals)
+	for i := range evals {
+		evalMap[evals[i].ApplicationID] = &evals[i]
+	}
+
+	facts := make([]models.AnalyticsApplicationFact, 0, len(apps))
+	durations := []models.AnalyticsStageDuration{}
+	for _, a := range apps {
+		f, d := buildAnalyticsFact(a, dept[a.JobID], tlMap[a.ID], evalMap[a.ID], now)
+		facts = append(facts, f)
+		durations = append(durations, d...)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("application_id IN ?", ids).Delete(&models.AnalyticsApplicationFact{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("application_id IN ?", ids).Delete(&models.AnalyticsStageDuration{}).Error; err != nil {
+			return err
+		}
+		if len(facts) > 0 {
+			if err := tx.CreateInBatches(facts, 200).Error; err != nil {
+				return err
+			}
+		}
+		if len(durations) > 0 {
+			return tx.CreateInBatches(durations, 200).Error
+		}
+		return nil
+	})
+}
+
+// analyticsScope filters facts by job_id, department, source and the submitted date
+// range (from/to) when withDates is set.
+func analyticsScope(c *gin.Context, withDates bool) *gorm.DB {
+	q := models.DB.Model(&models.AnalyticsApplicationFact{})
+	if v := c.Query("job_id"); v != "" {
+		q = q.Where("job_id = ?", v)
+	}
+	if v := c.Query("department"); v != "" {
+		q = q.Where("department = ?", v)
+	}
+	if v := c.Query("source"); v != "" {
+		q = q.Where("source = ?", v)
+	}
+	if withDates {
+		if t, ok := parseDateParam(c.Query("from"), false); ok {
+			q = q.Where("submitted_date >= ?", t)
+		}
+		if t, ok := parseDateParam(c.Query("to"), true); ok {
+			q = q.Where("submitted_date < ?", t)
+		}
+	}
+	return q
+}
+
+func analyticsRefreshedAt() *time.Time {
+	var wm models.AnalyticsWatermark
+	if models.DB.Where("name = ?", analyticsWatermarkName).Limit(1).Find(&wm).RowsAffected == 0 {
+		return nil
+	}
+	return &wm.Watermark
+}
+
+// POST /api/analytics/refresh?full=1 (HR)
+func RefreshAnalyticsNow(c *gin.Context) {
+	full := c.Query("full") == "1" || c.Query("full") == "true"
+	n, err := RefreshAnalytics(models.DB, full)
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"refreshed": n, "refreshed_at": analyticsRefreshedAt()})
+}
+
+type funnelRow struct {
+	Key       string
+	Submitted int64
+	Screening int64
+	Interview int64
+	Offer     int64
+	Hired     int64
+	Rejected  int64
+}
+
+func funnelStages(r funnelRow) []gin.H {
+	counts := []int64{r.Submitted, r.Screening, r.Interview, r.Offer, r.Hired}
+	out := make([]gin.H, 0, len(counts))
+	for i, n := range counts {
+		st := gin.H{"stage": utils.FunnelStages[i], "count": n}
+		if i > 0 {
+			st["conversion"] = ratio(n, counts[i-1])
+			st["conversion_from_start"] = ratio(n, counts[0])
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+func ratio(a, b int64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(int(float64(a)*10000/float64(b))) / 10000
+}
+
+// GET /api/analytics/funnel?job_id=&department=&source=&from=&to=&group_by=job|department|source (HR)
+// count = applications that reached the stage (or a later one);
+// conversion = share of the previous stage that got this far.
+func AnalyticsFunnel(c *gin.Context) {
+	sel := "count(*) as submitted, " +
+		"SUM(CASE WHEN stage_reached >= 1 THEN 1 ELSE 0 END) as screening, " +
+		"SUM(CASE WHEN stage_reached >= 2 THEN 1 ELSE 0 END) as interview, " +
+		"SUM(CASE WHEN stage_reached >= 3 THEN 1 ELSE 0 END) as offer, " +
+		"SUM(CASE WHEN stage_reached >= 4 THEN 1 ELSE 0 END) as hired, " +
+		"SUM(CASE WHEN status = 'rejected' THEN 1 ELSE 0 END) as rejected"
+
+	var total funnelRow
+	if err := analyticsScope(c, true).Select(sel).Scan(&total).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	resp := gin.H{"refreshed_at": analyticsRefreshedAt(), "total": total.Submitted, "rejected": total.Rejected, "stages": funnelStages(total)}
+
+	groupCol := map[string]string{"job": "job_id", "department": "department", "source": "source"}[c.Query("group_by")]
+	if groupCol != "" {
+		var rows []funnelRow
+		if err := analyticsScope(c, true).Select(groupCol + " as key, " + sel).Group(groupCol).Order("submitted desc").Scan(&rows).Error; err != nil {
+			respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+			return
+		}
+		labels := map[string]string{}
+		if groupCol == "job_id" {
+			ids := make([]string, 0, len(rows))
+			for _, r := range rows {
+				ids = append(ids, r.Key)
+			}
+			var jobs []models.JobPosting
+			models.DB.Select("id, title").Where("id IN ?", ids).Find(&jobs)
+			for _, j := range jobs {
+				labels[j.ID] = j.Title
+			}
+		}
+		groups := make([]gin.H, 0, len(rows))
+		for _, r := range rows {
+			label := labels[r.Key]
+			if label == "" {
+				label = r.Key
+			}
+			groups = append(groups, gin.H{"key": r.Key, "label": label, "total": r.Submitted, "rejected": r.Rejected, "stages": funnelStages(r)})
+		}
+		resp["group_by"] = c.Query("group_by")
+		resp["groups"] = groups
+	}
+	respond.OK(c, resp)
+}
+
+// GET /api/analytics/time-in-stage?job_id=&department=&source=&from=&to= (HR) — hours
+func AnalyticsTimeInStage(c *gin.Context) {
+	scope := analyticsScope(c, true)
+	var spans []models.AnalyticsStageDuration
+	if err := models.DB.Select("stage, hours").Where("application_id IN (?)", scope.Select("application_id")).Find(&spans).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	byStage := map[string][]float64{}
+	for _, s := range spans {
+		byStage[s.Stage] = append(byStage[s.Stage], s.Hours)
+	}
+	// hired is terminal, so it never has a completed stay
+	stages := []gin.H{}
+	for _, st := range utils.FunnelStages[:len(utils.FunnelStages)-1] {
+		stages = append(stages, gin.H{"stage": st, "hours": utils.Summarize(byStage[st])})
+	}
+
+	var toHire, firstResp []float64
+	analyticsScope(c, true).Where("time_to_hire_hours IS NOT NULL").Pluck("time_to_hire_hours", &toHire)
+	analyticsScope(c, true).Where("first_response_hours IS NOT NULL").Pluck("first_response_hours", &firstResp)
+
+	respond.OK(c, gin.H{
+		"refreshed_at":           analyticsRefreshedAt(),
+		"unit":                   "hours",
+		"stages":                 stages,
+		"time_to_hire":           utils.Summarize(toHire),
+		"time_to_first_response": utils.Summarize(firstResp),
+	})
+}
+
+// GET /api/analytics/rejections?job_id=&department=&source=&from=&to= (HR)
+func AnalyticsRejections(c *gin.Context) {
+	type byStage struct {
+		RejectedFrom string `json:"stage"`
+		N            int64  `json:"count"`
+	}
+	type byReason struct {
+		RejectionReason string `json:"reason"`
+		N               int64  `json:"count"`
+	}
+	var stages []byStage
+	var reasons []byReason
+	if err := analyticsScope(c, true).Where("status = ?", "rejected").Select("rejected_from, count(*) as n").Group("rejected_from").Order("n desc").Scan(&stages).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	if err := analyticsScope(c, true).Where("status = ?", "rejected").Select("rejection_reason, count(*) as n").Group("rejection_reason").Order("n desc").Limit(50).Scan(&reasons).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	var total int64
+	for _, s := range stages {
+		total += s.N
+	}
+	respond.OK(c, gin.H{"refreshed_at": analyticsRefreshedAt(), "total": total, "by_stage": stages, "by_reason": reasons})
+}
+
+// GET /api/analytics/evaluations?job_id=&department=&source=&from=&to= (HR)
+// Distribution = number of evaluations per score; overall is bucketed by
+// its integer part.
+func AnalyticsEvaluations(c *gin.Context) {
+	var facts []models.AnalyticsApplicationFact
+	if err := analyticsScope(c, true).Where("has_evaluation = ?", true).
+		Select("technical_skills, communication, problem_solving, cultural_fit, overall_score").Find(&facts).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	type dist map[int]int
+	criteria := map[string]dist{"technical_skills": {}, "communication": {}, "problem_solving": {}, "cultural_fit": {}, "overall": {}}
+	overall := make([]float64, 0, len(facts))
+	for _, f := range facts {
+		criteria["technical_skills"][f.TechnicalSkills]++
+		criteria["communication"][f.Communication]++
+		criteria["problem_solving"][f.ProblemSolving]++
+		criteria["cultural_fit"][f.CulturalFit]++
+		criteria["overall"][int(f.OverallScore)]++
+		overall = append(overall, f.OverallScore)
+	}
+	out := gin.H{}
+	for name, d := range criteria {
+		keys := make([]int, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Ints(keys)
+		buckets := make([]gin.H, 0, len(keys))
+		for _, k := range keys {
+			buckets = append(buckets, gin.H{"score": k, "count": d[k]})
+		}
+		out[name] = buckets
+	}
+	respond.OK(c, gin.H{"refreshed_at": analyticsRefreshedAt(), "count": len(facts), "overall": utils.Summarize(overall), "distribution": out})
+}
+
+// GET /api/analytics/trends?bucket=day|week|month&job_id=&department=&source=&from=&to= (HR)
+// Each event is counted in the bucket of its own date (applications by
+// submission, hires by hire date, rejections by rejection date).
+func AnalyticsTrends(c *gin.Context) {
+	bucket := c.DefaultQuery("bucket", "week")
+	if bucket != "day" && bucket != "week" && bucket != "month" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "bucket", "allowed": "day, week, month"})
+		return
+	}
+	from, hasFrom := parseDateParam(c.Query("from"), false)
+	to, hasTo := parseDateParam(c.Query("to"), true)
+
+	type point struct {
+		Bucket       string `json:"bucket"`
+		Applications int    `json:"applications"`
+		Hired        int    `json:"hired"`
+		Rejected     int    `json:"rejected"`
+	}
+	points := map[string]*point{}
+	// one grouped query per event, each filtered and bucketed by its own date
+	for _, ev := range []struct {
+		col   string
+		count func(p *point) *int
+	}{
+		{"submitted_date", func(p *point) *int { return &p.Applications }},
+		{"hired_at", func(p *point) *int { return &p.Hired }},
+		{"rejected_at", func(p *point) *int { return &p.Rejected }},
+	} {
+		q := analyticsScope(c, false).Where(ev.col + " IS NOT NULL")
+		if hasFrom {
+			q = q.Where(ev.col+" >= ?", from)
+		}
+		if hasTo {
+			q = q.Where(ev.col+" < ?", to)
+		}
+		var rows []struct {
+			Bucket string
+			N      int
+		}
+		expr := bucketExpr(models.DB, ev.col, bucket)
+		if err := q.Select(expr + " AS bucket, COUNT(*) AS n").Group(expr).Scan(&rows).Error; err != nil {
+			respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+			return
+		}
+		for _, r := range rows {
+			if points[r.Bucket] == nil {
+				points[r.Bucket] = &point{Bucket: r.Bucket}
+			}
+			*ev.count(points[r.Bucket]) = r.N
+		}
+	}
+	series := make([]point, 0, len(points))
+	for _, p := range points {
+		series = append(series, *p)
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Bucket < series[j].Bucket })
+	respond.OK(c, gin.H{"refreshed_at": analyticsRefreshedAt(), "bucket": bucket, "series": series})
+}
+
+// bucketExpr is the SQL for the first day (YYYY-MM-DD) of the day, week or
+// month col falls in, in db's dialect. Weeks start on Monday (ISO).
+func bucketExpr(db *gorm.DB, col, bucket string) string {
+	if db.Dialector.Name() == "sqlite" {
+		switch bucket {
+		case "month":
+			return "strftime('%Y-%m-01', " + col + ")"
+		case "week":
+			return "date(" + col + ", '-' || ((CAST(strftime('%w', " + col + ") AS INTEGER) + 6) % 7) || ' days')"
+		}
+		return "date(" + col + ")"
+	}
+	return "to_char(date_trunc('" + bucket + "', " + col + "), 'YYYY-MM-DD')"
+}
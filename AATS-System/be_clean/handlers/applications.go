@@ -1,17 +1,23 @@
 ﻿package handlers
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
-	glogger "gorm.io/gorm/logger"
 
+	"aats-backend-clean/i18n"
 	"aats-backend-clean/models"
+	"aats-backend-clean/utils"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/services"
+	"aats-backend-clean/store"
 )
 
 // CreateApplicationBody request body
@@ -23,145 +29,199 @@ CoverLetter string `json:"cover_letter"`
 Education   string `json:"education"`
 Experience  string `json:"experience"`
 Skills      string `json:"skills"`
+ScreeningAnswers []ScreeningAnswerBody `json:"screening_answers"`
+TrackingBody // source, utm_*, referrer, apply_link
+ReferralToken string `json:"referral_token"` // from the referral invite link
+TalentPoolConsent *bool `json:"talent_pool_consent"` // keep my profile for future openings
+CustomFields map[string]interface{} `json:"custom_fields"` // see /api/custom-fields?entity=application
 }
 
 // POST /api/applications
 func CreateApplication(c *gin.Context) {
 var body CreateApplicationBody
 if err := c.ShouldBindJSON(&body); err != nil {
-c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
 return
 }
 
-var job models.JobPosting
-if err := models.DB.Where("id = ?", body.JobID).First(&job).Error; err != nil {
-c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
-return
+app, screening, p := submitApplication(c, body)
+if p != nil {
+	respond.Fail(c, p)
+	return
+}
+hideScreening(c.GetString("user_role"), &app, screening.Answers)
+respond.Created(c, gin.H{"application": app, "screening": gin.H{"outcome": app.ScreeningOutcome, "answers": screening.Answers}})
+}
+
+// submitApplication applies every rule of a new application (policy,
+// screening, custom fields, referral, attribution) and saves it. Shared by
+// POST /api/applications and the legacy apply route.
+func submitApplication(c *gin.Context, body CreateApplicationBody) (models.Application, screeningResult, *respond.Problem) {
+var app models.Application
+var screening screeningResult
+job, err := svc().Jobs.Get(body.JobID, true)
+if err != nil {
+return app, screening, toProblem(err)
 }
 
 uidv, _ := c.Get("user_id")
 applicantID := body.ApplicantID
 if applicantID == "" {
 if uidv == nil {
-c.JSON(http.StatusUnauthorized, gin.H{"error": "missing applicant_id and not authenticated"})
-return
+return app, screening, respond.New(http.StatusUnauthorized, "UNAUTHENTICATED")
 }
 applicantID = uidv.(string)
 } else {
 if uidv != nil {
 urv, _ := c.Get("user_role")
 if urv != "hr" && applicantID != uidv.(string) {
-c.JSON(http.StatusForbidden, gin.H{"error": "cannot create application for other users"})
-return
+return app, screening, respond.New(http.StatusForbidden, "APPLICATION_FOR_OTHER_USER")
 }
 }
 }
 
-app := models.Application{
-ID:            uuid.NewString(),
-JobID:         body.JobID,
-ApplicantID:   applicantID,
-Resume:        body.ResumeURL,
-CoverLetter:   body.CoverLetter,
-Education:     body.Education,
-Experience:    body.Experience,
-Skills:        body.Skills,
-Status:        "submitted",
-SubmittedDate: time.Now(),
-CreatedAt:     time.Now(),
+app = models.Application{
+ID:          uuid.NewString(),
+JobID:       body.JobID,
+ApplicantID: applicantID,
+Resume:      body.ResumeURL,
+CoverLetter: body.CoverLetter,
+Education:   body.Education,
+Experience:  body.Experience,
+Skills:      body.Skills,
 }
 
-// --- Enforce application policy ---
-// 1) Max 5 concurrent applications (exclude 'rejected' and 'hired')
-var activeCount int64
-if err := models.DB.Model(&models.Application{}).
-	Where("applicant_id = ? AND status NOT IN (?)", applicantID, []string{"rejected", "hired"}).
-	Count(&activeCount).Error; err == nil {
-	if activeCount >= 5 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "คุณมีใบสมัครคงค้างมากกว่า/เท่ากับ 5 ตำแหน่ง โปรดยกเลิกหรือรอผลก่อนสมัครใหม่"})
-		return
-	}
+// Validate screening answers before anything is written
+screening, screenErr := evaluateScreening(job.ID, body.ScreeningAnswers)
+if screenErr != nil {
+	return app, screening, screenErr
 }
 
-// 2) Prevent duplicate active application for same job and enforce re-apply waiting period
-var existing models.Application
-if err := models.DB.Where("applicant_id = ? AND job_id = ?", applicantID, body.JobID).Order("submitted_date desc").First(&existing).Error; err == nil {
-	// If existing application is active (not rejected/withdrawn/hired), reject
-	if existing.Status != "rejected" && existing.Status != "withdrawn" && existing.Status != "hired" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "คุณได้สมัครตำแหน่งนี้ไว้แล้ว (สถานะ: " + existing.Status + ")"})
-		return
+// Custom fields: required ones must be filled, HR-only ones are not for candidates
+urv, _ := c.Get("user_role")
+customWrites, fieldErrs := validateCustomFields(models.DB, CustomEntityApplication, body.CustomFields, true, urv != "hr")
+if fieldErrs != nil {
+	return app, screening, respond.New(http.StatusBadRequest, "CUSTOM_FIELDS_INVALID", gin.H{"fields": fieldErrs})
+}
+
+var referral models.Referral
+if body.ReferralToken != "" {
+	var refErr *respond.Problem
+	if referral, refErr = findReferralForApply(body.ReferralToken, job.ID); refErr != nil {
+		return app, screening, refErr
 	}
+}
 
-	// If previously rejected, determine when rejection happened and whether it was after interview or during screening
-	if existing.Status == "rejected" {
-		// Find the most recent rejected timeline entry
-		var rejTimeline models.ApplicationTimeline
-		rejErr := models.DB.Where("application_id = ? AND status = ?", existing.ID, "rejected").Order("date desc").First(&rejTimeline).Error
-		var rejectedAt time.Time
-		if rejErr == nil {
-			rejectedAt = rejTimeline.Date
-		} else {
-			// fallback to UpdatedAt on application
-			rejectedAt = existing.UpdatedAt
-		}
+// Normalise skills against the taxonomy and score the candidate against the job
+applyMatchScore(loadSkillTaxonomy(models.DB), &app, job)
 
-		// Check if there was an interview before rejection
-		var interviewCount int64
-		models.DB.Model(&models.ApplicationTimeline{}).
-			Where("application_id = ? AND status = ? AND date < ?", existing.ID, "interview", rejectedAt).
-			Count(&interviewCount)
-
-		// Determine waiting period
-		waitMonths := 3
-		reason := "การคัดกรอง"
-		if interviewCount > 0 {
-			waitMonths = 6
-			reason = "หลังสัมภาษณ์"
-		}
+// Fingerprint the uploaded resume for duplicate detection
+app.ResumeHash = resumeFileHash(app.Resume)
 
-		allowedAt := rejectedAt.AddDate(0, waitMonths, 0)
-		if time.Now().Before(allowedAt) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถสมัครซ้ำได้ทันที - ถูกปฏิเสธ (" + reason + ") ต้องรอ " + strconv.Itoa(waitMonths) + " เดือน หลังจากวันที่ " + rejectedAt.Format("2006-01-02")})
-			return
-		}
+// Attribution: apply link, source channel or UTM parameters
+applyTracking(models.DB, &app, body.TrackingBody)
+if referral.ID != "" {
+	app.ReferralID = referral.ID
+	app.Source = SourceReferral
+	if app.Resume == "" {
+		app.Resume = referral.ResumeURL
 	}
+}
 
-	// If previously withdrawn, allow immediate re-apply; otherwise continue
-}
-
-// --- New policy: prevent cross-job re-apply for a short period after being hired ---
-// If the applicant was hired for any job recently, block applying to other jobs for 3 months
-{
-	var lastHired models.Application
-	if err := models.DB.Where("applicant_id = ? AND status = ?", applicantID, "hired").Order("updated_at desc").First(&lastHired).Error; err == nil {
-		// If the last hired record is for a different job, enforce a 3-month cooldown
-		if lastHired.JobID != "" && lastHired.JobID != body.JobID {
-			hiredAt := lastHired.UpdatedAt
-			waitMonths := 3
-			allowedAt := hiredAt.AddDate(0, waitMonths, 0)
-			if time.Now().Before(allowedAt) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถสมัครตำแหน่งอื่นได้ทันที - คุณได้รับการจ้างงานเมื่อ " + hiredAt.Format("2006-01-02") + ", กรุณารอ " + strconv.Itoa(waitMonths) + " เดือน ก่อนสมัครตำแหน่งอื่น หรือติดต่อ HR หากสถานะการจ้างงานเปลี่ยนแปลง"})
-				return
-			}
+// Save under the application policy (active cap, re-apply waits, hire
+// cooldown; rules come from /api/policies), together with everything the
+// application brings along: a failure leaves no trace of it
+err = data().Transaction(func(tx store.Store) error {
+	tl, err := svc().Applications.On(tx).Submit(&app)
+	if err != nil {
+		return err
+	}
+	db := dataDB(tx)
+	if err := emitTimelineEvent(db, app, tl, "", true); err != nil {
+		return err
+	}
+	if referral.ID != "" {
+		if err := db.Model(&models.Referral{}).Where("id = ?", referral.ID).Update("application_id", app.ID).Error; err != nil {
+			return err
 		}
 	}
+	if err := writeCustomFields(db, app.ID, customWrites, applicantID); err != nil {
+		return err
+	}
+	// Store answers and apply knockout rules (may auto-reject or flag)
+	if err := recordScreening(db, &app, screening); err != nil {
+		return err
+	}
+	// Talent pool: only the candidate can give or withdraw consent; pooled
+	// profiles pick up the skills from the new application
+	if body.TalentPoolConsent != nil && uidv != nil && applicantID == uidv.(string) {
+		if err := setTalentPoolConsent(db, applicantID, *body.TalentPoolConsent); err != nil {
+			return err
+		}
+	}
+	return refreshPoolProfiles(db, applicantID)
+})
+var refused *services.PolicyError
+if errors.As(err, &refused) {
+	return app, screening, policyProblem(c, refused.Violations)
 }
-
-if err := models.DB.Create(&app).Error; err != nil {
-c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot create application"})
-return
+if err != nil {
+	return app, screening, toProblem(err)
 }
-
-tl := models.ApplicationTimeline{
-ID:            uuid.NewString(),
-ApplicationID: app.ID,
-Status:        "submitted",
-Date:          app.SubmittedDate,
-Description:   "Application submitted",
+return app, screening, nil
 }
-models.DB.Create(&tl)
 
-c.JSON(http.StatusCreated, gin.H{"ok": true, "application": app})
+// parseDateParam accepts YYYY-MM-DD or RFC3339. For a bare date used as an
+// upper bound, endOfDay moves it to the start of the next day.
+func parseDateParam(v string, endOfDay bool) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// applicationFilter reads the query filters shared by the list and export
+// endpoints (job_id, status, q, from/to, screening, tag, reviewer, source).
+func applicationFilter(c *gin.Context, role, uid string) store.ApplicationFilter {
+	f := store.ApplicationFilter{
+		JobID:  c.Query("job_id"),
+		Status: c.Query("status"),
+		// Screening filters: screening_outcome=passed|flagged|rejected, and
+		// question_id+answer to match a specific screening answer.
+		ScreeningOutcome: c.Query("screening_outcome"),
+		QuestionID:       c.Query("question_id"),
+		Answer:           c.Query("answer"),
+		// Bulk-action filters: tag and assigned reviewer
+		Tag:        strings.ToLower(strings.TrimSpace(c.Query("tag"))),
+		ReviewerID: c.Query("reviewer_id"),
+		// Attribution filters
+		Source:      c.Query("source"),
+		UTMCampaign: c.Query("utm_campaign"),
+		Query:       c.Query("q"),
+		// Custom fields: cf.<key>=value, cf.<key>.min / cf.<key>.max
+		Custom: customMatches(c, CustomEntityApplication, !applicantOnly(role)),
+	}
+	// If authenticated candidate, restrict to their own applications.
+	// Otherwise, allow using applicant_id query param (useful for dev/admin or unauthenticated testing)
+	if applicantOnly(role) && uid != "" {
+		f.ApplicantID = uid
+	} else {
+		f.ApplicantID = c.Query("applicant_id")
+	}
+	// submitted date range: from (inclusive) / to (inclusive for bare dates)
+	if t, ok := parseDateParam(c.Query("from"), false); ok {
+		f.SubmittedFrom = t
+	}
+	if t, ok := parseDateParam(c.Query("to"), true); ok {
+		f.SubmittedBefore = t
+	}
+	return f
 }
 
 // GET /api/applications
@@ -193,11 +253,6 @@ if cursorQ != "" {
 	}
 }
 
-jobID := c.Query("job_id")
-applicantID := c.Query("applicant_id")
-status := c.Query("status")
-q := c.Query("q")
-
 role := ""
 if rv, ok := c.Get("user_role"); ok {
 role = rv.(string)
@@ -213,30 +268,8 @@ includeDetails := includeDetailsQ == "1" || includeDetailsQ == "true" || include
 	// Select only essential columns for listing to reduce I/O when details are
 	// not requested. When include_details is true we keep the full model so the
 	// frontend receives complete application payloads (resume, education, etc.).
-	tx := models.DB.Model(&models.Application{})
-	if !includeDetails {
-		tx = tx.Select("id, job_id, applicant_id, status, submitted_date, updated_at")
-	}
-
-	// If authenticated candidate, restrict to their own applications
-	if role == "candidate" && uid != "" {
-		tx = tx.Where("applicant_id = ?", uid)
-	} else {
-		// Otherwise, allow using applicant_id query param (useful for dev/admin or unauthenticated testing)
-		if applicantID != "" {
-			tx = tx.Where("applicant_id = ?", applicantID)
-		}
-	}
-
-if jobID != "" {
-	tx = tx.Where("job_id = ?", jobID)
-}
-if status != "" {
-tx = tx.Where("status = ?", status)
-}
-if q != "" {
-tx = tx.Where("(cover_letter ILIKE ? OR education ILIKE ? OR experience ILIKE ? OR skills ILIKE ?)", "%"+q+"%", "%"+q+"%", "%"+q+"%", "%"+q+"%")
-}
+	f := applicationFilter(c, role, uid)
+	f.Brief = !includeDetails
 
 // Optionally skip the COUNT(*) if caller provides skip_count=true. Counting
 // large tables can be expensive; callers that page through results can request
@@ -246,21 +279,42 @@ skipCount := skipCountQ == "1" || skipCountQ == "true" || skipCountQ == "yes"
 
 var total int64 = -1
 if !skipCount {
-	tx.Count(&total)
+	n, err := svc().Applications.Count(f)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	total = n
+}
+
+// Sorting: sort=submitted_date|match_score|updated_at|cf.<key>, order=asc|desc (default desc).
+// Keyset pagination is only meaningful for the default submitted_date desc order.
+sortQ := c.DefaultQuery("sort", "submitted_date")
+f.SortBy, f.Asc = "submitted_date", c.Query("order") == "asc"
+if sortQ == "match_score" || sortQ == "updated_at" {
+	f.SortBy = sortQ
+}
+if f.SortBy != "submitted_date" || f.Asc {
+	useKeyset = false
+}
+if strings.HasPrefix(sortQ, "cf.") {
+	if f.CustomOrder = customFieldOrder(CustomEntityApplication, sortQ, !f.Asc, !applicantOnly(role)); f.CustomOrder != nil {
+		useKeyset = false
+	}
 }
 
-var apps []models.Application
+pg := store.Page{Offset: offset, Limit: limit}
 if useKeyset {
 	// keyset: fetch rows with submitted_date < cursorTime
-	if err := tx.Where("submitted_date < ?", cursorTime).Order("submitted_date desc").Limit(limit).Find(&apps).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch applications"})
-		return
-	}
-} else {
-	if err := tx.Order("submitted_date desc").Offset(offset).Limit(limit).Find(&apps).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch applications"})
-		return
+	if f.SubmittedBefore.IsZero() || cursorTime.Before(f.SubmittedBefore) {
+		f.SubmittedBefore = cursorTime
 	}
+	pg.Offset = 0
+}
+apps, err := svc().Applications.List(f, pg)
+if err != nil {
+	fail(c, err)
+	return
 }
 
 // Enrich each application with can_reapply metadata
@@ -273,6 +327,41 @@ type AppWithMeta struct {
 	JobTitle       string     `json:"job_title,omitempty"`
 	JobDepartment  string     `json:"job_department,omitempty"`
 	JobLocation    string     `json:"job_location,omitempty"`
+	MatchScore     *float64   `json:"match_score,omitempty"` // staff only
+	MatchBreakdown *utils.MatchBreakdown `json:"match_breakdown,omitempty"`
+	CustomFields   map[string]interface{} `json:"custom_fields,omitempty"`
+}
+pageIDs := make([]string, 0, len(apps))
+for _, a := range apps {
+	pageIDs = append(pageIDs, a.ID)
+}
+customByApp := loadCustomFields(models.DB, CustomEntityApplication, pageIDs, !applicantOnly(role))
+withMatch := func(meta *AppWithMeta, a models.Application) {
+	meta.CustomFields = customByApp[a.ID]
+	if applicantOnly(role) {
+		return // the score and the must-have gaps are HR's
+	}
+	score := a.MatchScore
+	meta.MatchScore, meta.MatchBreakdown = &score, matchBreakdown(a)
+}
+
+// reapplyMeta fills the re-apply fields of a rejected application from the
+// policy in force for its job (cached per job for this request)
+policyFor := map[string]utils.PolicyConfig{}
+reapplyMeta := func(meta *AppWithMeta, a models.Application, rejectedAt time.Time, interviewed bool) {
+	cfg, ok := policyFor[a.JobID]
+	if !ok {
+		cfg, _ = svc().Applications.Policy(a.JobID, time.Now())
+		policyFor[a.JobID] = cfg
+	}
+	waitMonths, reason := utils.ReapplyWaitMonths(cfg, interviewed)
+	allowedAt := rejectedAt.AddDate(0, waitMonths, 0)
+	meta.WaitingMonths = waitMonths
+	meta.RejectionStage = reason
+	meta.CanReapplyDate = &allowedAt
+	if time.Now().After(allowedAt) {
+		meta.CanReapply = true
+	}
 }
 
 // Check if caller asked for details to be included in the list response. This
@@ -287,324 +376,157 @@ type AppListItem struct {
 
 var items []AppListItem
 
-if includeDetails && len(apps) > 0 {
-	// Batch fetch jobs and timelines for the returned applications to avoid per-app queries.
-	jobIDsSet := map[string]struct{}{}
-	appIDs := make([]string, 0, len(apps))
-	for _, a := range apps {
-		if a.JobID != "" {
-			jobIDsSet[a.JobID] = struct{}{}
-		}
-		appIDs = append(appIDs, a.ID)
-	}
-
-	// jobs
-	jobIDs := make([]string, 0, len(jobIDsSet))
-	for k := range jobIDsSet {
-		jobIDs = append(jobIDs, k)
+// Batch fetch jobs, timelines, evaluations and applicants for the returned
+// applications to avoid per-app queries.
+rel, err := svc().Applications.Related(apps)
+if err != nil {
+	fail(c, err)
+	return
+}
+for _, a := range apps {
+	var screening []models.ScreeningAnswer
+	if includeDetails {
+		screening = loadScreeningAnswers(a.ID)
 	}
-	jobMap := map[string]models.JobPosting{}
-	if len(jobIDs) > 0 {
-		var jobs []models.JobPosting
-		models.DB.Where("id IN ?", jobIDs).Find(&jobs)
-		for _, j := range jobs {
-			jobMap[j.ID] = j
-		}
+	hideScreening(role, &a, screening)
+	meta := AppWithMeta{Application: a}
+	withMatch(&meta, a)
+
+	// If withdrawn -> can reapply immediately
+	if a.Status == "withdrawn" {
+		t := a.UpdatedAt
+		meta.CanReapply = true
+		meta.CanReapplyDate = &t
 	}
 
-	// latest rejected timeline per application (if any)
-	type rejRec struct {
-		ApplicationID string    `gorm:"column:application_id" json:"application_id"`
-		Date          time.Time `gorm:"column:date" json:"date"`
-	}
-	rejMap := map[string]time.Time{}
-	if len(appIDs) > 0 {
-		var rejRows []rejRec
-		models.DB.Model(&models.ApplicationTimeline{}).
-			Select("application_id, max(date) as date").
-			Where("application_id IN ? AND status = ?", appIDs, "rejected").
-			Group("application_id").
-			Find(&rejRows)
-		for _, r := range rejRows {
-			rejMap[r.ApplicationID] = r.Date
-		}
+	// attach job title/department/location if available (for all statuses)
+	job, hasJob := rel.Jobs[a.JobID]
+	if hasJob {
+		meta.JobTitle = job.Title
+		meta.JobDepartment = job.Department
+		meta.JobLocation = job.Location
 	}
 
-	// interview existence per application (has there ever been an interview?)
-	type intRec struct {
-		ApplicationID string `gorm:"column:application_id"`
-		Cnt           int64  `gorm:"column:cnt"`
-	}
-	interviewMap := map[string]bool{}
-	if len(appIDs) > 0 {
-		var intRows []intRec
-		models.DB.Model(&models.ApplicationTimeline{}).
-			Select("application_id, count(*) as cnt").
-			Where("application_id IN ? AND status = ?", appIDs, "interview").
-			Group("application_id").
-			Find(&intRows)
-		for _, r := range intRows {
-			interviewMap[r.ApplicationID] = r.Cnt > 0
-		}
+	// If rejected -> determine when and whether interview occurred before rejection
+	if a.Status == "rejected" {
+		rejectedAt, interviewed := services.Closure(a, rel.Timelines[a.ID])
+		reapplyMeta(&meta, a, rejectedAt, interviewed)
 	}
 
-	// Build metadata using maps
-	// Build response items. If includeDetails is requested, each item will
-	// be an object { application: <Application>, meta: <AppWithMeta fields>, raw: { timeline, notes, evaluation, job, applicant } }
-	// use the outer AppListItem and items variable declared above
-
-	for _, a := range apps {
-		meta := AppWithMeta{Application: a}
-		meta.CanReapply = false
-		meta.CanReapplyDate = nil
-		meta.WaitingMonths = 0
-		meta.RejectionStage = ""
-
-		if a.Status == "withdrawn" {
-			t := a.UpdatedAt
-			meta.CanReapply = true
-			meta.CanReapplyDate = &t
-			meta.WaitingMonths = 0
-		}
+	var raw interface{} = nil
+	if includeDetails {
+		timelines := make([]models.ApplicationTimeline, len(rel.Timelines[a.ID])) // never null in the response
+		copy(timelines, rel.Timelines[a.ID])
+		localizeTimeline(respond.Language(c), timelines)
 
-		if a.JobID != "" {
-			if j, ok := jobMap[a.JobID]; ok {
-				meta.JobTitle = j.Title
-				meta.JobDepartment = j.Department
-				meta.JobLocation = j.Location
-			}
-		}
+		// notes (only those the caller may see)
+		var notes []models.Note
+		visibleNotes(c, models.DB).Where("application_id = ?", a.ID).Order("created_at desc").Find(&notes)
 
-		if a.Status == "rejected" {
-			var rejectedAt time.Time
-			if d, ok := rejMap[a.ID]; ok {
-				rejectedAt = d
-			} else {
-				rejectedAt = a.UpdatedAt
-			}
-
-			waitMonths := 3
-			reason := "screening"
-			if interviewMap[a.ID] {
-				waitMonths = 6
-				reason = "interview"
-			}
-			allowedAt := rejectedAt.AddDate(0, waitMonths, 0)
-			meta.WaitingMonths = waitMonths
-			meta.RejectionStage = reason
-			meta.CanReapplyDate = &allowedAt
-			if time.Now().After(allowedAt) {
-				meta.CanReapply = true
-			}
+		// only expose evaluation when application status indicates interview or later
+		var ev *models.Evaluation = nil
+		if e, ok := rel.Evaluations[a.ID]; ok && services.EvaluationOpen(a.Status) {
+			ev = &e
 		}
-
-		var raw interface{} = nil
-		if includeDetails {
-			// timeline
-			var timelines []models.ApplicationTimeline
-			models.DB.Where("application_id = ?", a.ID).Order("date desc").Find(&timelines)
-
-			// notes
-			var notes []models.Note
-			models.DB.Where("application_id = ?", a.ID).Order("created_at desc").Find(&notes)
-
-			// evaluation (best-effort, use silent logger)
-			var ev *models.Evaluation = nil
-			{
-				var e models.Evaluation
-				dbSilent := models.DB.Session(&gorm.Session{Logger: glogger.Default.LogMode(glogger.Silent)})
-				if err := dbSilent.Where("application_id = ?", a.ID).First(&e).Error; err == nil {
-					// only expose evaluation when application status indicates interview or later
-					if a.Status == "interview" || a.Status == "offer" || a.Status == "hired" {
-						ev = &e
-					}
-				}
-			}
-
-			// job
-			var job *models.JobPosting = nil
-			if a.JobID != "" {
-				var j models.JobPosting
-				dbSilent := models.DB.Session(&gorm.Session{Logger: glogger.Default.LogMode(glogger.Silent)})
-				if err := dbSilent.Where("id = ?", a.JobID).First(&j).Error; err == nil {
-					job = &j
-				}
-			}
-
-			// applicant
-			var applicant *models.User = nil
-			if a.ApplicantID != "" {
-				var u models.User
-				dbSilent := models.DB.Session(&gorm.Session{Logger: glogger.Default.LogMode(glogger.Silent)})
-				if err := dbSilent.Where("id = ?", a.ApplicantID).First(&u).Error; err == nil {
-					applicant = &u
-				}
-			}
-
-			raw = map[string]interface{}{
-				"application": a,
-				"timeline":    timelines,
-				"notes":       notes,
-				"evaluation":  ev,
-				"job":         job,
-				"applicant":   applicant,
-			}
+		var jobRaw *models.JobPosting = nil
+		if hasJob {
+			jobRaw = &job
 		}
-
-		items = append(items, AppListItem{Application: a, AppMeta: meta, Raw: raw})
-	}
-} else {
-	// fallback: existing per-app best-effort lookups (unchanged)
-	for _, a := range apps {
-		meta := AppWithMeta{Application: a}
-
-		// default: cannot reapply
-		meta.CanReapply = false
-		meta.CanReapplyDate = nil
-		meta.WaitingMonths = 0
-		meta.RejectionStage = ""
-
-		// If withdrawn -> can reapply immediately
-		if a.Status == "withdrawn" {
-			t := a.UpdatedAt
-			meta.CanReapply = true
-			meta.CanReapplyDate = &t
-			meta.WaitingMonths = 0
-		}
-
-		// attach job title/department/location if available (for all statuses)
-		var job models.JobPosting
-		if a.JobID != "" {
-			// use silent session for best-effort lookup to avoid noisy logs when not found
-			dbSilent := models.DB.Session(&gorm.Session{Logger: glogger.Default.LogMode(glogger.Silent)})
-			if err := dbSilent.Where("id = ?", a.JobID).First(&job).Error; err == nil {
-				meta.JobTitle = job.Title
-				meta.JobDepartment = job.Department
-				meta.JobLocation = job.Location
-			}
+		var applicant *models.User = nil
+		if u, ok := rel.Applicants[a.ApplicantID]; ok {
+			applicant = &u
 		}
 
-		// If rejected -> determine when and whether interview occurred before rejection
-		if a.Status == "rejected" {
-			var rejTimeline models.ApplicationTimeline
-			rejErr := models.DB.Where("application_id = ? AND status = ?", a.ID, "rejected").Order("date desc").First(&rejTimeline).Error
-			var rejectedAt time.Time
-			if rejErr == nil {
-				rejectedAt = rejTimeline.Date
-			} else {
-				rejectedAt = a.UpdatedAt
-			}
-
-			var interviewCount int64
-			models.DB.Model(&models.ApplicationTimeline{}).
-				Where("application_id = ? AND status = ? AND date < ?", a.ID, "interview", rejectedAt).
-				Count(&interviewCount)
-
-			waitMonths := 3
-			reason := "screening"
-			if interviewCount > 0 {
-				waitMonths = 6
-				reason = "interview"
-			}
-			allowedAt := rejectedAt.AddDate(0, waitMonths, 0)
-			meta.WaitingMonths = waitMonths
-			meta.RejectionStage = reason
-			meta.CanReapplyDate = &allowedAt
-			if time.Now().After(allowedAt) {
-				meta.CanReapply = true
-			}
+		raw = map[string]interface{}{
+			"application": a,
+			"screening":   screening,
+			"timeline":    timelines,
+			"notes":       notes,
+			"evaluation":  ev,
+			"job":         jobRaw,
+			"applicant":   applicant,
 		}
-
-		items = append(items, AppListItem{Application: a, AppMeta: meta})
 	}
+
+	items = append(items, AppListItem{Application: a, AppMeta: meta, Raw: raw})
 }
 
-	c.JSON(http.StatusOK, gin.H{
-		"ok":       true,
-		"page":     page,
-		"limit":    limit,
-		"total":    total,
-		"apps":     items,
+	respond.Page(c, gin.H{
+		"apps": items,
 		"returned": len(items),
-	})
+	}, gin.H{"page": page, "limit": limit, "total": total})
+}
+
+// matchBreakdown is the stored match breakdown of a, nil when there is none.
+func matchBreakdown(a models.Application) *utils.MatchBreakdown {
+	var b utils.MatchBreakdown
+	if a.MatchBreakdown == "" || json.Unmarshal([]byte(a.MatchBreakdown), &b) != nil {
+		return nil
+	}
+	return &b
 }
 
 // GET /api/applications/:id
 func GetApplication(c *gin.Context) {
 id := c.Param("id")
 if id == "" {
-	c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"})
+	respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "id"})
 	return
 }
-var app models.Application
-if err := models.DB.Where("id = ?", id).First(&app).Error; err != nil {
-	c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+// candidates only see their own applications
+app, err := svc().Applications.View(id, c.GetString("user_id"), applicantOnly(c.GetString("user_role")))
+if err != nil {
+	fail(c, err)
 	return
 }
-
-if rv, ok := c.Get("user_role"); ok && rv == "candidate" {
-if uidv, ok2 := c.Get("user_id"); ok2 {
-if app.ApplicantID != uidv.(string) {
-c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
-return
-}
-}
+rel, err := svc().Applications.Related([]models.Application{app})
+if err != nil {
+	fail(c, err)
+	return
 }
 
-var timelines []models.ApplicationTimeline
-models.DB.Where("application_id = ?", id).Order("date desc").Find(&timelines)
+timelines := make([]models.ApplicationTimeline, len(rel.Timelines[app.ID])) // never null in the response
+copy(timelines, rel.Timelines[app.ID])
+localizeTimeline(respond.Language(c), timelines)
 
+// notes respect their visibility level; candidates see none
 var notes []models.Note
-models.DB.Where("application_id = ?", id).Order("created_at desc").Find(&notes)
-
+visibleNotes(c, models.DB).Where("application_id = ?", id).Order("created_at desc").Find(&notes)
 
 // evaluation may not exist — treat missing record as null, and only return it when status >= interview
-	var eval *models.Evaluation = nil
-	{
-		var ev models.Evaluation
-		dbSilent := models.DB.Session(&gorm.Session{Logger: glogger.Default.LogMode(glogger.Silent)})
-		if err := dbSilent.Where("application_id = ?", id).First(&ev).Error; err == nil {
-			if app.Status == "interview" || app.Status == "offer" || app.Status == "hired" {
-				eval = &ev
-			}
-		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-			// unexpected error, but don't fail the whole request
-		}
-	}
+var eval *models.Evaluation = nil
+if ev, ok := rel.Evaluations[app.ID]; ok && services.EvaluationOpen(app.Status) {
+	eval = &ev
+}
 
-// job may not exist (best-effort)
+// job and applicant may not exist (best-effort)
 var job *models.JobPosting = nil
-{
-	var j models.JobPosting
-	if app.JobID != "" {
-		dbSilent := models.DB.Session(&gorm.Session{Logger: glogger.Default.LogMode(glogger.Silent)})
-		if err := dbSilent.Where("id = ?", app.JobID).First(&j).Error; err == nil {
-			job = &j
-		}
-	}
+if j, ok := rel.Jobs[app.JobID]; ok {
+	job = &j
 }
-
-// applicant (user) may not exist — best-effort
 var applicant *models.User = nil
-{
-	var u models.User
-	if app.ApplicantID != "" {
-		dbSilent := models.DB.Session(&gorm.Session{Logger: glogger.Default.LogMode(glogger.Silent)})
-		if err := dbSilent.Where("id = ?", app.ApplicantID).First(&u).Error; err == nil {
-			applicant = &u
-		}
-	}
+if u, ok := rel.Applicants[app.ApplicantID]; ok {
+	applicant = &u
 }
 
-c.JSON(http.StatusOK, gin.H{
-"ok":          true,
-	"application": app,
-	"job":         job,
-	"applicant":   applicant,
-	"timeline":    timelines,
-	"notes":       notes,
-	"evaluation":  eval,
-})
+screening := loadScreeningAnswers(app.ID)
+hideScreening(c.GetString("user_role"), &app, screening)
+
+out := gin.H{
+"application": app,
+"screening": screening,
+"tags": loadApplicationTags(app.ID),
+"custom_fields": customFieldsOf(models.DB, CustomEntityApplication, app.ID, !applicantOnly(c.GetString("user_role"))),
+"job": job,
+"applicant": applicant,
+"timeline": timelines,
+"notes": notes,
+"evaluation": eval,
+}
+if !applicantOnly(c.GetString("user_role")) {
+	out["match_score"], out["match_breakdown"] = app.MatchScore, matchBreakdown(app)
+}
+respond.OK(c, out)
 }
 
 // PATCH /api/applications/:id/status
@@ -614,60 +536,76 @@ Description string `json:"description"`
 }
 
 func UpdateApplicationStatus(c *gin.Context) {
-id := c.Param("id")
 var body UpdateStatusBody
 if err := c.ShouldBindJSON(&body); err != nil {
-c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
 return
 }
 
-var app models.Application
-if err := models.DB.Where("id = ?", id).First(&app).Error; err != nil {
-c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+change, err := changeStatus(c.Param("id"), body.Status, i18n.Text(body.Description))
+if err != nil {
+fail(c, err)
 return
 }
 
-old := app.Status
- // If attempting to mark as offer or hired, ensure an HM evaluation exists first
- if body.Status == "hired" || body.Status == "offer" {
-	 var ev models.Evaluation
-	 dbSilent := models.DB.Session(&gorm.Session{Logger: glogger.Default.LogMode(glogger.Silent)})
-	 if err := dbSilent.Where("application_id = ?", id).First(&ev).Error; err != nil {
-		 if errors.Is(err, gorm.ErrRecordNotFound) {
-			 c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถเปลี่ยนสถานะเป็น 'offer'/'hired' ได้: กรุณารอ HM ให้คะแนนก่อน"})
-			 return
-		 }
-		 c.JSON(http.StatusInternalServerError, gin.H{"error": "ตรวจสอบ evaluation ล้มเหลว"})
-		 return
-	 }
-
-	 // optional: ensure evaluator is a user with role 'hm'
-	 var evaluator models.User
-	 if ev.EvaluatorID != "" {
-		 if err := dbSilent.Where("id = ?", ev.EvaluatorID).First(&evaluator).Error; err == nil {
-			 if evaluator.Role != "hm" {
-				 c.JSON(http.StatusBadRequest, gin.H{"error": "การประเมินต้องมาจาก HM ก่อนจึงจะเปลี่ยนสถานะเป็น offer/hired ได้"})
-				 return
-			 }
-		 }
-	 }
- }
-
- app.Status = body.Status
-app.UpdatedAt = time.Now()
-if err := models.DB.Save(&app).Error; err != nil {
-c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot update application status"})
-return
+respond.OK(c, gin.H{"previous_status": change.Previous, "new_status": change.Application.Status, "timeline": change.Timeline})
+}
+
+// validateStatusChange is ApplicationService.ValidateStatusChange as a
+// problem; nil when the change is allowed.
+func validateStatusChange(app models.Application, newStatus string) *respond.Problem {
+	if err := svc().Applications.ValidateStatusChange(app, newStatus); err != nil {
+		return toProblem(err)
+	}
+	return nil
 }
 
-tl := models.ApplicationTimeline{
-ID:            uuid.NewString(),
-ApplicationID: id,
-Status:        body.Status,
-Date:          time.Now(),
-Description:   body.Description,
+// changeStatus is a staff status change of application id with what
+// follows it (statusChanged), in one transaction.
+func changeStatus(id, newStatus string, desc i18n.Msg) (services.StatusChange, error) {
+	var change services.StatusChange
+	err := data().Transaction(func(tx store.Store) error {
+		var err error
+		if change, err = svc().Applications.On(tx).ChangeStatus(id, newStatus, desc); err != nil {
+			return err
+		}
+		return statusChanged(dataDB(tx), change.Application, change.Timeline, change.Previous)
+	})
+	return change, err
+}
+
+// applyStatusChange saves the new status and its timeline entry on db and
+// runs statusChanged, all in one transaction (nested when db is one). It
+// saves the whole row: app must have been read under lockApplication in the
+// same transaction.
+func applyStatusChange(db *gorm.DB, app *models.Application, newStatus string, desc i18n.Msg) (models.ApplicationTimeline, error) {
+	previous := app.Status
+	var tl models.ApplicationTimeline
+	err := store.New(db).Transaction(func(tx store.Store) error {
+		var err error
+		if tl, err = svc().Applications.On(tx).SetStatus(app, newStatus, desc); err != nil {
+			return err
+		}
+		return statusChanged(dataDB(tx), *app, tl, previous)
+	})
+	return tl, err
+}
+
+// statusChanged runs what follows a status change: the webhook event, the
+// HRIS handoff on hire and the referral bonus.
+func statusChanged(db *gorm.DB, app models.Application, tl models.ApplicationTimeline, previous string) error {
+	if err := emitTimelineEvent(db, app, tl, previous, false); err != nil {
+		return err
+	}
+	if app.Status == "hired" && previous != "hired" {
+		if err := queueNewHireHandoff(db, app); err != nil {
+			return err
+		}
+	}
+	return syncReferralBonus(db, app)
 }
-models.DB.Create(&tl)
 
-c.JSON(http.StatusOK, gin.H{"ok": true, "previous_status": old, "new_status": app.Status, "timeline": tl})
+// applicantOnly reports whether a role only ever sees its own applications.
+func applicantOnly(role interface{}) bool {
+	return role == "candidate" || role == "employee"
 }
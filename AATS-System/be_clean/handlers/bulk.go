@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+	"aats-backend-clean/worker"
+)
+
+// maxBulkItems caps a single bulk request; larger selections should be split.
+const maxBulkItems = 5000
+
+// BulkBody selects applications either by id or by job (+ current status)
+// and carries the parameters of every bulk action.
+type BulkBody struct {
+	ApplicationIDs []string `json:"application_ids"`
+	JobID          string   `json:"job_id"`
+	CurrentStatus  string   `json:"current_status"`
+
+	Status      string   `json:"status"`      // action: status
+	Description string   `json:"description"` // action: status
+	AddTags     []string `json:"add_tags"`    // action: tag
+	RemoveTags  []string `json:"remove_tags"` // action: tag
+	ReviewerID  string   `json:"reviewer_id"` // action: assign
+	TemplateID  string   `json:"template_id"` // action: message
+	Subject     string   `json:"subject"`     // action: message (without template)
+	Body        string   `json:"body"`        // action: message (without template)
+}
+
+// bulkParams is what gets persisted in BulkJob.Params
+type bulkParams struct {
+	Status      string   `json:"status,omitempty"`
+	Description string   `json:"description,omitempty"`
+	AddTags     []string `json:"add_tags,omitempty"`
+	RemoveTags  []string `json:"remove_tags,omitempty"`
+	ReviewerID  string   `json:"reviewer_id,omitempty"`
+	Reviewer    string   `json:"reviewer,omitempty"`
+	Subject     string   `json:"subject,omitempty"`
+	Body        string   `json:"body,omitempty"`
+	ActorID     string   `json:"actor_id,omitempty"`
+}
+
+var bulkPool *worker.Pool
+
+// StartBulkRunner starts the background workers for bulk jobs and re-queues
+// jobs left unfinished by a previous process. Call after the DB is connected.
+func StartBulkRunner(workers int) {
+	bulkPool = worker.NewPool(workers, 1024, RunBulkJob)
+	var pending []models.BulkJob
+	models.DB.Where("state IN ?", []string{"queued", "running"}).Order("created_at asc").Find(&pending)
+	for _, j := range pending {
+		bulkPool.Enqueue(j.ID)
+	}
+	if len(pending) > 0 {
+		log.Printf("bulk runner: resumed %d unfinished job(s)", len(pending))
+	}
+}
+
+// enqueueBulkJob hands a saved job to the runner; false when its queue is
+// full.
+func enqueueBulkJob(id string) bool {
+	if bulkPool == nil {
+		go RunBulkJob(id) // runner not started (tests/tools): run detached
+		return true
+	}
+	return bulkPool.Enqueue(id)
+}
+
+func normalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// resolveBulkTargets returns the application ids selected by the body.
+func resolveBulkTargets(body BulkBody) ([]string, *respond.Problem) {
+	if len(body.ApplicationIDs) > 0 {
+		seen := map[string]bool{}
+		ids := []string{}
+		for _, id := range body.ApplicationIDs {
+			if id != "" && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+	if body.JobID == "" {
+		return nil, respond.New(http.StatusBadRequest, "ONE_OF_REQUIRED", gin.H{"fields": "application_ids, job_id"})
+	}
+	q := models.DB.Model(&models.Application{}).Where("job_id = ?", body.JobID)
+	if body.CurrentStatus != "" {
+		q = q.Where("status = ?", body.CurrentStatus)
+	}
+	var ids []string
+	if err := q.Order("submitted_date asc").Pluck("id", &ids).Error; err != nil {
+		return nil, respond.New(http.StatusInternalServerError, "INTERNAL_ERROR")
+	}
+	return ids, nil
+}
+
+// createBulkJob validates the selection, persists job + items and queues it.
+func createBulkJob(c *gin.Context, action string, body BulkBody, params bulkParams) {
+	ids, p := resolveBulkTargets(body)
+	if p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if len(ids) == 0 {
+		respond.Error(c, http.StatusBadRequest, "NO_APPLICATIONS_SELECTED")
+		return
+	}
+	if len(ids) > maxBulkItems {
+		respond.Error(c, http.StatusBadRequest, "BULK_TOO_MANY", gin.H{"max": maxBulkItems})
+		return
+	}
+	uid, _ := c.Get("user_id")
+	params.ActorID, _ = uid.(string)
+	raw, _ := json.Marshal(params)
+
+	job := models.BulkJob{
+		ID:        uuid.NewString(),
+		Action:    action,
+		Params:    string(raw),
+		State:     "queued",
+		Total:     len(ids),
+		CreatedBy: params.ActorID,
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		items := make([]models.BulkJobItem, 0, len(ids))
+		for i, id := range ids {
+			items = append(items, models.BulkJobItem{ID: uuid.NewString(), BulkJobID: job.ID, Position: i, ApplicationID: id, State: "pending"})
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	if !enqueueBulkJob(job.ID) {
+		// dropped rather than left queued: the client retries, and the
+		// retry must not run alongside this job after a restart
+		log.Printf("bulk runner: queue full, job %s dropped", job.ID)
+		models.DB.Where("bulk_job_id = ?", job.ID).Delete(&models.BulkJobItem{})
+		models.DB.Where("id = ?", job.ID).Delete(&models.BulkJob{})
+		c.Header("Retry-After", "30")
+		respond.Error(c, http.StatusServiceUnavailable, "BULK_QUEUE_FULL")
+		return
+	}
+	respond.Accepted(c, gin.H{"job": job})
+}
+
+// POST /api/applications/bulk/status (HR)
+func BulkUpdateStatus(c *gin.Context) {
+	var body BulkBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.Status == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "status"})
+		return
+	}
+	createBulkJob(c, "status", body, bulkParams{Status: body.Status, Description: body.Description})
+}
+
+// POST /api/applications/bulk/tags (HR)
+func BulkTag(c *gin.Context) {
+	var body BulkBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	add, remove := normalizeTags(body.AddTags), normalizeTags(body.RemoveTags)
+	if len(add) == 0 && len(remove) == 0 {
+		respond.Error(c, http.StatusBadRequest, "ONE_OF_REQUIRED", gin.H{"fields": "add_tags, remove_tags"})
+		return
+	}
+	createBulkJob(c, "tag", body, bulkParams{AddTags: add, RemoveTags: remove})
+}
+
+// POST /api/applications/bulk/assign (HR) — reviewer must be hr/hm
+func BulkAssignReviewer(c *gin.Context) {
+	var body BulkBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.ReviewerID == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "reviewer_id"})
+		return
+	}
+	var reviewer models.User
+	if err := models.DB.Where("id = ?", body.ReviewerID).First(&reviewer).Error; err != nil {
+		respond.Error(c, http.StatusBadRequest, "REVIEWER_NOT_FOUND")
+		return
+	}
+	if reviewer.Role != "hr" && reviewer.Role != "hm" {
+		respond.Error(c, http.StatusBadRequest, "REVIEWER_ROLE_INVALID")
+		return
+	}
+	createBulkJob(c, "assign", body, bulkParams{ReviewerID: reviewer.ID, Reviewer: reviewer.Name})
+}
+
+// POST /api/applications/bulk/message (HR) — template_id or subject+body
+func BulkMessage(c *gin.Context) {
+	var body BulkBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	subject, text := body.Subject, body.Body
+	if body.TemplateID != "" {
+		var tpl models.MessageTemplate
+		if err := models.DB.Where("id = ?", body.TemplateID).First(&tpl).Error; err != nil {
+			respond.Error(c, http.StatusBadRequest, "TEMPLATE_NOT_FOUND")
+			return
+		}
+		subject, text = tpl.Subject, tpl.Body
+	}
+	if strings.TrimSpace(text) == "" {
+		respond.Error(c, http.StatusBadRequest, "ONE_OF_REQUIRED", gin.H{"fields": "template_id, body"})
+		return
+	}
+	createBulkJob(c, "message", body, bulkParams{Subject: subject, Body: text})
+}
+
+// RunBulkJob processes the pending items of a bulk job. Items already done
+// are skipped, so re-running a job after a crash is safe.
+func RunBulkJob(id string) {
+	var job models.BulkJob
+	if err := models.DB.Where("id = ?", id).First(&job).Error; err != nil {
+		log.Printf("bulk runner: job %s not found: %v", id, err)
+		return
+	}
+	var params bulkParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		finishBulkJob(&job, "failed", "invalid job parameters")
+		return
+	}
+
+	now := time.Now()
+	if job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+	job.State = "running"
+	models.DB.Model(&models.BulkJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{"state": job.State, "started_at": job.StartedAt})
+
+	var items []models.BulkJobItem
+	models.DB.Where("bulk_job_id = ? AND state = ?", job.ID, "pending").Order("position asc").Find(&items)
+	for _, item := range items {
+		err := processBulkItem(job.Action, params, item.ApplicationID)
+		done := time.Now()
+		updates := map[string]interface{}{"state": "succeeded", "error": "", "processed_at": &done}
+		if err != nil {
+			updates["state"] = "failed"
+			updates["error"] = err.Error()
+		}
+		models.DB.Model(&models.BulkJobItem{}).Where("id = ?", item.ID).Updates(updates)
+		refreshBulkCounters(&job)
+	}
+
+	state := "completed"
+	if job.Failed > 0 {
+		state = "completed_with_errors"
+		if job.Succeeded == 0 {
+			state = "failed"
+		}
+	}
+	finishBulkJob(&job, state, "")
+}
+
+func refreshBulkCounters(job *models.BulkJob) {
+	type row struct {
+		State string
+		N     int
+	}
+	var rows []row
+	models.DB.Model(&models.BulkJobItem{}).Select("state, count(*) as n").Where("bulk_job_id = ?", job.ID).Group("state").Scan(&rows)
+	job.Succeeded, job.Failed = 0, 0
+	for _, r := range rows {
+		switch r.State {
+		case "succeeded":
+			job.Succeeded = r.N
+		case "failed":
+			job.Failed = r.N
+		}
+	}
+	job.Processed = job.Succeeded + job.Failed
+	models.DB.Model(&models.BulkJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"processed": job.Processed, "succeeded": job.Succeeded, "failed": job.Failed,
+	})
+}
+
+func finishBulkJob(job *models.BulkJob, state, errMsg string) {
+	now := time.Now()
+	job.State = state
+	job.Error = errMsg
+	job.FinishedAt = &now
+	models.DB.Model(&models.BulkJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"state": state, "error": errMsg, "finished_at": &now,
+	})
+}
+
+// processBulkItem applies one action to one application in one transaction
+// holding the application's lock. Every successful item gets a timeline
+// entry.
+func processBulkItem(action string, p bulkParams, appID string) error {
+	return data().Transaction(func(tx store.Store) error {
+		app, err := lockApplication(tx, appID)
+		if err != nil {
+			return err
+		}
+		db := dataDB(tx)
+
+		switch action {
+		case "status":
+			if err := svc().Applications.On(tx).ValidateStatusChange(app, p.Status); err != nil {
+				return err
+			}
+			desc := i18n.Text(p.Description)
+			if p.Description == "" {
+				desc = i18n.M("timeline.BULK_STATUS_CHANGE")
+			}
+			_, err := applyStatusChange(db, &app, p.Status, desc)
+			return err
+
+		case "tag":
+			for _, t := range p.AddTags {
+				tag := models.ApplicationTag{ID: uuid.NewString(), ApplicationID: app.ID, Tag: t, CreatedBy: p.ActorID}
+				if err := db.Where("application_id = ? AND tag = ?", app.ID, t).FirstOrCreate(&tag).Error; err != nil {
+					return err
+				}
+			}
+			if len(p.RemoveTags) > 0 {
+				if err := db.Where("application_id = ? AND tag IN ?", app.ID, p.RemoveTags).Delete(&models.ApplicationTag{}).Error; err != nil {
+					return err
+				}
+			}
+			note := i18n.M("timeline.TAGS_CHANGED", map[string]any{"added": p.AddTags, "removed": p.RemoveTags})
+			switch {
+			case len(p.RemoveTags) == 0:
+				note = i18n.M("timeline.TAGS_ADDED", map[string]any{"tags": p.AddTags})
+			case len(p.AddTags) == 0:
+				note = i18n.M("timeline.TAGS_REMOVED", map[string]any{"tags": p.RemoveTags})
+			}
+			return addTimelineNote(db, app, note)
+
+		case "assign":
+			if err := db.Model(&models.Application{}).Where("id = ?", app.ID).Update("reviewer_id", p.ReviewerID).Error; err != nil {
+				return err
+			}
+			return addTimelineNote(db, app, i18n.M("timeline.REVIEWER_ASSIGNED", map[string]any{"reviewer": p.Reviewer}))
+
+		case "message":
+			subject, err := sendTemplatedMessage(db, app, p.Subject, p.Body)
+			if err != nil {
+				return err
+			}
+			return addTimelineNote(db, app, i18n.M("timeline.MESSAGE_SENT", map[string]any{"subject": subject}))
+		}
+		return errors.New("unknown bulk action")
+	})
+}
+
+// addTimelineNote appends a timeline entry that keeps the current status.
+func addTimelineNote(db *gorm.DB, app models.Application, desc i18n.Msg) error {
+	tl := timelineEntry(app.ID, app.Status, time.Now(), desc)
+	if err := db.Create(&tl).Error; err != nil {
+		return err
+	}
+	return emitTimelineEvent(db, app, tl, app.Status, false)
+}
+
+// GET /api/bulk-jobs (HR) — most recent first
+func ListBulkJobs(c *gin.Context) {
+	page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+	q := models.DB.Model(&models.BulkJob{})
+	if st := c.Query("state"); st != "" {
+		q = q.Where("state = ?", st)
+	}
+	var total int64
+	q.Count(&total)
+	var jobs []models.BulkJob
+	if err := q.Order("created_at desc").Offset(offset).Limit(limit).Find(&jobs).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Page(c, gin.H{"jobs": jobs}, gin.H{"page": page, "limit": limit, "total": total})
+}
+
+// GET /api/bulk-jobs/:id (HR) — job state and progress
+func GetBulkJob(c *gin.Context) {
+	var job models.BulkJob
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "BULK_JOB_NOT_FOUND")
+		return
+	}
+	progress := 0.0
+	if job.Total > 0 {
+		progress = float64(job.Processed) * 100 / float64(job.Total)
+	}
+	respond.OK(c, gin.H{"job": job, "progress": progress})
+}
+
+// GET /api/bulk-jobs/:id/items?state=failed (HR) — per-item results
+func ListBulkJobItems(c *gin.Context) {
+	page, limit, offset := utils.ParsePagination(c, 1, 50, 500, "limit")
+	q := models.DB.Model(&models.BulkJobItem{}).Where("bulk_job_id = ?", c.Param("id"))
+	if st := c.Query("state"); st != "" {
+		q = q.Where("state = ?", st)
+	}
+	var total int64
+	q.Count(&total)
+	var items []models.BulkJobItem
+	if err := q.Order("position asc").Offset(offset).Limit(limit).Find(&items).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Page(c, gin.H{"items": items}, gin.H{"page": page, "limit": limit, "total": total})
+}
+
+// loadApplicationTags returns the tags of an application, sorted.
+func loadApplicationTags(appID string) []string {
+	tags := []string{}
+	models.DB.Model(&models.ApplicationTag{}).Where("application_id = ?", appID).Order("tag asc").Pluck("tag", &tags)
+	return tags
+}
+
+// GET /api/tags?q=&scope=application|talent_pool&limit= (HR/HM) — tag
+// autocomplete: tags starting with q, most used first
+func AutocompleteTags(c *gin.Context) {
+	var model interface{} = &models.ApplicationTag{}
+	switch c.DefaultQuery("scope", "application") {
+	case "application":
+	case "talent_pool":
+		model = &models.TalentPoolTag{}
+	default:
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "scope", "allowed": "application, talent_pool"})
+		return
+	}
+	_, limit, _ := utils.ParsePagination(c, 1, 10, 50, "limit")
+	type tagCount struct {
+		Tag   string `json:"tag"`
+		Count int64  `json:"count"`
+	}
+	q := models.DB.Model(model).Select("tag, COUNT(*) AS count").Group("tag").Order("count desc, tag asc").Limit(limit)
+	if prefix := strings.ToLower(strings.TrimSpace(c.Query("q"))); prefix != "" {
+		// tags are stored lower-cased (normalizeTags); escape LIKE wildcards
+		prefix = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+		q = q.Where(`tag LIKE ? ESCAPE '\'`, prefix+"%")
+	}
+	tags := []tagCount{}
+	if err := q.Scan(&tags).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"tags": tags})
+}
@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+)
+
+// Records that can carry custom fields.
+const (
+	CustomEntityApplication = "application"
+	CustomEntityJob         = "job"
+	CustomEntityUser        = "user"
+)
+
+// customEntityTables maps an entity to its table, for existence checks.
+var customEntityTables = map[string]string{
+	CustomEntityApplication: "applications",
+	CustomEntityJob:         "job_postings",
+	CustomEntityUser:        "users",
+}
+
+// customEntityNotFound is the error code for a missing record of an entity.
+var customEntityNotFound = map[string]string{
+	CustomEntityApplication: "APPLICATION_NOT_FOUND",
+	CustomEntityJob:         "JOB_NOT_FOUND",
+	CustomEntityUser:        "USER_NOT_FOUND",
+}
+
+var customFieldKeyRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// CustomFieldBody request body for custom field definitions. Entity, key and
+// type cannot change after creation.
+type CustomFieldBody struct {
+	Entity   string   `json:"entity"`
+	Key      string   `json:"key"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type"`
+	Options  []string `json:"options"`
+	Required *bool    `json:"required"`
+	HROnly   *bool    `json:"hr_only"`
+	Position *int     `json:"position"`
+}
+
+// customFieldWrite is a validated value waiting to be stored; a nil value
+// clears the field.
+type customFieldWrite struct {
+	field models.CustomField
+	value *utils.CustomValue
+}
+
+// fieldDef converts a stored definition for validation.
+func fieldDef(f models.CustomField) utils.FieldDef {
+	var opts []string
+	_ = json.Unmarshal([]byte(f.Options), &opts)
+	return utils.FieldDef{Key: f.Key, Type: f.Type, Options: opts}
+}
+
+// loadFieldDefs returns an entity's fields by key.
+func loadFieldDefs(db *gorm.DB, entity string) map[string]models.CustomField {
+	var fields []models.CustomField
+	db.Where("entity = ?", entity).Find(&fields)
+	out := make(map[string]models.CustomField, len(fields))
+	for _, f := range fields {
+		out[f.Key] = f
+	}
+	return out
+}
+
+// isEmptyCustomValue: null, "" and [] clear a field.
+func isEmptyCustomValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(t) == ""
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+// validateCustomFields checks submitted values against the entity's fields.
+// creating enforces required fields; byCandidate rejects HR-only fields.
+// Returns the writes, or a message per offending key.
+func validateCustomFields(db *gorm.DB, entity string, raw map[string]interface{}, creating, byCandidate bool) ([]customFieldWrite, map[string]string) {
+	defs := loadFieldDefs(db, entity)
+	errs := map[string]string{}
+	writes := []customFieldWrite{}
+	for key, v := range raw {
+		f, ok := defs[key]
+		switch {
+		case !ok:
+			errs[key] = "unknown field"
+		case f.HROnly && byCandidate:
+			errs[key] = "cannot be set by candidates"
+		case isEmptyCustomValue(v):
+			if f.Required {
+				errs[key] = "is required"
+			} else {
+				writes = append(writes, customFieldWrite{field: f})
+			}
+		default:
+			val, err := utils.ValidateCustomValue(fieldDef(f), v)
+			if err != nil {
+				errs[key] = err.Error()
+			} else {
+				writes = append(writes, customFieldWrite{field: f, value: &val})
+			}
+		}
+	}
+	if creating {
+		for key, f := range defs {
+			if _, given := raw[key]; f.Required && !given && !(f.HROnly && byCandidate) {
+				errs[key] = "is required"
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return writes, nil
+}
+
+// writeCustomFields stores validated values on one record.
+func writeCustomFields(tx *gorm.DB, entityID string, writes []customFieldWrite, by string) error {
+	for _, w := range writes {
+		if w.value == nil {
+			if err := tx.Where("field_id = ? AND entity_id = ?", w.field.ID, entityID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		row := models.CustomFieldValue{FieldID: w.field.ID, EntityID: entityID}
+		tx.Where("field_id = ? AND entity_id = ?", w.field.ID, entityID).Limit(1).Find(&row)
+		if row.ID == "" {
+			row.ID = uuid.NewString()
+		}
+		row.ValueText, row.ValueNumber, row.ValueDate, row.UpdatedBy = w.value.Text, w.value.Number, w.value.Date, by
+		if err := tx.Save(&row).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadCustomFields returns the custom values of records, by record id and
+// field key. hrView includes HR-only fields.
+func loadCustomFields(db *gorm.DB, entity string, ids []string, hrView bool) map[string]map[string]interface{} {
+	out := map[string]map[string]interface{}{}
+	if len(ids) == 0 {
+		return out
+	}
+	byID := map[string]models.CustomField{}
+	for _, f := range loadFieldDefs(db, entity) {
+		if hrView || !f.HROnly {
+			byID[f.ID] = f
+		}
+	}
+	if len(byID) == 0 {
+		return out
+	}
+	fieldIDs := make([]string, 0, len(byID))
+	for id := range byID {
+		fieldIDs = append(fieldIDs, id)
+	}
+	var rows []models.CustomFieldValue
+	db.Where("entity_id IN ? AND field_id IN ?", ids, fieldIDs).Find(&rows)
+	for _, r := range rows {
+		f := byID[r.FieldID]
+		if out[r.EntityID] == nil {
+			out[r.EntityID] = map[string]interface{}{}
+		}
+		out[r.EntityID][f.Key] = utils.DecodeCustomValue(f.Type, utils.CustomValue{Text: r.ValueText, Number: r.ValueNumber, Date: r.ValueDate})
+	}
+	return out
+}
+
+// customFieldsOf is loadCustomFields for one record, never nil.
+func customFieldsOf(db *gorm.DB, entity, id string, hrView bool) map[string]interface{} {
+	if m := loadCustomFields(db, entity, []string{id}, hrView)[id]; m != nil {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+// customMatches reads the cf.<key>=value, cf.<key>.min= and cf.<key>.max=
+// query filters. Enum filters accept a comma separated list, multi_select
+// filters match records that include the value, min/max apply to number and
+// date fields. A filter on an unknown (or hidden) field matches nothing.
+func customMatches(c *gin.Context, entity string, hrView bool) []store.CustomMatch {
+	var defs map[string]models.CustomField
+	var out []store.CustomMatch
+	for param, vals := range c.Request.URL.Query() {
+		if !strings.HasPrefix(param, "cf.") || len(vals) == 0 || vals[0] == "" {
+			continue
+		}
+		if defs == nil {
+			defs = loadFieldDefs(models.DB, entity)
+		}
+		key, op := strings.TrimPrefix(param, "cf."), ""
+		if i := strings.LastIndex(key, "."); i > 0 {
+			key, op = key[:i], key[i+1:]
+		}
+		f, ok := defs[key]
+		if !ok || (f.HROnly && !hrView) {
+			out = append(out, store.CustomMatch{Op: store.CustomNone})
+			continue
+		}
+		v := vals[0]
+		m := store.CustomMatch{FieldID: f.ID, Op: store.CustomNone}
+		switch {
+		case op == "min" || op == "max":
+			parsed, err := utils.ValidateCustomValue(utils.FieldDef{Type: f.Type}, v)
+			switch {
+			case err != nil:
+			case f.Type == utils.FieldNumber:
+				m.Op, m.Number = store.CustomMinNumber, *parsed.Number
+				if op == "max" {
+					m.Op = store.CustomMaxNumber
+				}
+			case f.Type == utils.FieldDate:
+				m.Op, m.Date = store.CustomMinDate, *parsed.Date
+				if op == "max" {
+					m.Op = store.CustomMaxDate
+				}
+			}
+		case op != "":
+		case f.Type == utils.FieldNumber || f.Type == utils.FieldDate:
+			if parsed, err := utils.ValidateCustomValue(fieldDef(f), v); err == nil {
+				m.Op, m.Text = store.CustomEquals, parsed.Text
+			}
+		case f.Type == utils.FieldMultiSelect:
+			parsed, err := utils.ValidateCustomValue(fieldDef(f), []interface{}{v})
+			var picked []string
+			if err == nil && json.Unmarshal([]byte(parsed.Text), &picked) == nil && len(picked) > 0 {
+				quoted, _ := json.Marshal(picked[0])
+				m.Op, m.Text = store.CustomContains, string(quoted)
+			}
+		case f.Type == utils.FieldEnum:
+			m.Op = store.CustomAnyOf
+			for _, part := range strings.Split(v, ",") {
+				m.AnyOf = append(m.AnyOf, strings.ToLower(strings.TrimSpace(part)))
+			}
+		default:
+			m.Op, m.Text = store.CustomEqualFold, v
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// customFieldOrder is the order for sort=cf.<key>, nil for an unknown (or
+// hidden) field.
+func customFieldOrder(entity, sort string, desc, hrView bool) *store.CustomOrder {
+	f, ok := loadFieldDefs(models.DB, entity)[strings.TrimPrefix(sort, "cf.")]
+	if !ok || (f.HROnly && !hrView) {
+		return nil
+	}
+	return &store.CustomOrder{FieldID: f.ID, Type: f.Type, Desc: desc}
+}
+
+// fieldFromBody validates body into f. Returns a problem on bad input.
+func fieldFromBody(f *models.CustomField, body CustomFieldBody, creating bool) *respond.Problem {
+	if creating {
+		if _, ok := customEntityTables[body.Entity]; !ok {
+			return respond.New(http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "entity", "allowed": "application, job, user"})
+		}
+		if !customFieldKeyRe.MatchString(body.Key) {
+			return respond.New(http.StatusBadRequest, "CUSTOM_FIELD_KEY_INVALID")
+		}
+		if !utils.ValidFieldType(body.Type) {
+			return respond.New(http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "type", "allowed": "text, number, date, enum, multi_select"})
+		}
+		f.Entity, f.Key, f.Type = body.Entity, body.Key, body.Type
+	}
+	if label := strings.TrimSpace(body.Label); label != "" {
+		f.Label = label
+	} else if creating {
+		f.Label = f.Key
+	}
+	if body.Options != nil || creating {
+		opts, err := utils.ValidateFieldOptions(f.Type, body.Options)
+		if err != nil {
+			return respond.New(http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "options", "reason": err.Error()})
+		}
+		raw, _ := json.Marshal(opts)
+		if opts == nil {
+			raw = []byte("[]")
+		}
+		f.Options = string(raw)
+	}
+	if body.Required != nil {
+		f.Required = *body.Required
+	}
+	if body.HROnly != nil {
+		f.HROnly = *body.HROnly
+	}
+	if body.Position != nil {
+		f.Position = *body.Position
+	}
+	return nil
+}
+
+// customFieldView is how a definition is returned.
+func customFieldView(f models.CustomField) gin.H {
+	return gin.H{
+		"id": f.ID, "entity": f.Entity, "key": f.Key, "label": f.Label, "type": f.Type, "options": fieldDef(f).Options,
+		"required": f.Required, "hr_only": f.HROnly, "position": f.Position,
+	}
+}
+
+// GET /api/custom-fields?entity= — HR sees every field, others only the
+// fields candidates may see (for the apply form)
+func ListCustomFields(c *gin.Context) {
+	q := models.DB.Order("entity asc, position asc, key asc")
+	if e := c.Query("entity"); e != "" {
+		q = q.Where("entity = ?", e)
+	}
+	if rv, _ := c.Get("user_role"); rv != "hr" && rv != "hm" {
+		q = q.Where("hr_only = ?", false)
+	}
+	var fields []models.CustomField
+	if err := q.Find(&fields).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, customFieldView(f))
+	}
+	respond.OK(c, gin.H{"fields": out})
+}
+
+// POST /api/custom-fields (HR)
+func CreateCustomField(c *gin.Context) {
+	var body CustomFieldBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	f := models.CustomField{ID: uuid.NewString(), CreatedBy: createdBy}
+	if p := fieldFromBody(&f, body, true); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if err := models.DB.Create(&f).Error; err != nil {
+		respond.Error(c, http.StatusConflict, "CUSTOM_FIELD_KEY_TAKEN")
+		return
+	}
+	respond.Created(c, gin.H{"field": customFieldView(f)})
+}
+
+// PUT /api/custom-fields/:id (HR) — label, options, required, hr_only, position
+func UpdateCustomField(c *gin.Context) {
+	var f models.CustomField
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&f).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "CUSTOM_FIELD_NOT_FOUND")
+		return
+	}
+	var body CustomFieldBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if (body.Entity != "" && body.Entity != f.Entity) || (body.Key != "" && body.Key != f.Key) || (body.Type != "" && body.Type != f.Type) {
+		respond.Error(c, http.StatusBadRequest, "CUSTOM_FIELD_IMMUTABLE")
+		return
+	}
+	if p := fieldFromBody(&f, body, false); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if err := models.DB.Save(&f).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"field": customFieldView(f)})
+}
+
+// DELETE /api/custom-fields/:id (HR) — also deletes the stored values
+func DeleteCustomField(c *gin.Context) {
+	var f models.CustomField
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&f).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "CUSTOM_FIELD_NOT_FOUND")
+		return
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("field_id = ?", f.ID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&f).Error
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{})
+}
+
+// customRecordExists checks :entity/:id names an existing record.
+func customRecordExists(c *gin.Context) (string, bool) {
+	entity := c.Param("entity")
+	table, ok := customEntityTables[entity]
+	if !ok {
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "entity", "allowed": "application, job, user"})
+		return entity, false
+	}
+	var n int64
+	models.DB.Table(table).Where("id = ?", c.Param("id")).Count(&n)
+	if n == 0 {
+		respond.Error(c, http.StatusNotFound, customEntityNotFound[entity])
+		return entity, false
+	}
+	return entity, true
+}
+
+// GET /api/custom-values/:entity/:id (HR/HM)
+func GetCustomValues(c *gin.Context) {
+	entity, ok := customRecordExists(c)
+	if !ok {
+		return
+	}
+	respond.OK(c, gin.H{"custom_fields": customFieldsOf(models.DB, entity, c.Param("id"), true)})
+}
+
+// PUT /api/custom-values/:entity/:id (HR) — {"fields": {"key": value|null}};
+// keys not sent are left as they are
+func UpdateCustomValues(c *gin.Context) {
+	var body struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.Fields) == 0 {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "fields"})
+		return
+	}
+	entity, ok := customRecordExists(c)
+	if !ok {
+		return
+	}
+	writes, errs := validateCustomFields(models.DB, entity, body.Fields, false, false)
+	if errs != nil {
+		respond.Error(c, http.StatusBadRequest, "CUSTOM_FIELDS_INVALID", gin.H{"fields": errs})
+		return
+	}
+	uid, _ := c.Get("user_id")
+	by, _ := uid.(string)
+	if err := models.DB.Transaction(func(tx *gorm.DB) error { return writeCustomFields(tx, c.Param("id"), writes, by) }); err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"custom_fields": customFieldsOf(models.DB, entity, c.Param("id"), true), "updated_at": time.Now()})
+}
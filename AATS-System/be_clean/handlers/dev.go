@@ -17,6 +17,8 @@ import (
 
 	"aats-backend-clean/models"
 	"aats-backend-clean/utils"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/services"
 )
 
 // POST /api/dev/seed (enriched seed, idempotent + safer)
@@ -24,7 +26,7 @@ func SeedDev(c *gin.Context) {
 		// เปิดใช้เฉพาะโหมด dev/debug เท่านั้น
 		// ถ้าไม่ใช่ debug mode จะไม่อนุญาตให้ seed ข้อมูล
 	if gin.Mode() != gin.DebugMode {
-		c.JSON(http.StatusForbidden, gin.H{"ok": false, "error": "dev seed is disabled in non-debug mode"})
+		respond.Error(c, http.StatusForbidden, "DEV_SEED_DISABLED")
 		return
 	}
 
@@ -34,7 +36,7 @@ func SeedDev(c *gin.Context) {
 	   defer func() {
 		   if r := recover(); r != nil {
 			   tx.Rollback()
-			   c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": "seed panic"})
+			   respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 		   }
 	   }()
 
@@ -79,7 +81,7 @@ func SeedDev(c *gin.Context) {
 		var found models.User
 		if err := tx.Where("email = ?", u.Email).Assign(user).FirstOrCreate(&found).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+			respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 			return
 		}
 		created[u.Email] = found
@@ -156,6 +158,7 @@ func SeedDev(c *gin.Context) {
 		if jobs[i].ID == "" {
 			jobs[i].ID = uuid.NewString()
 		}
+		services.PrepareJob(&jobs[i])
 		j := jobs[i]
 		var rec models.JobPosting
 		// Use Attrs so existing records are found by title+department and new ones are created with our attributes
@@ -163,7 +166,7 @@ func SeedDev(c *gin.Context) {
 			Attrs(j).
 			FirstOrCreate(&rec).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+			respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 			return
 		}
 		jobMap[j.Title+"|"+j.Department] = rec.ID
@@ -228,7 +231,7 @@ func SeedDev(c *gin.Context) {
 			Assign(*a).
 			FirstOrCreate(&found).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+			respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 			return
 		}
 		a.ID = found.ID // sync id if record already existed
@@ -288,7 +291,7 @@ func SeedDev(c *gin.Context) {
 		EvaluatedAt: now.AddDate(0, 0, -2),
 	}); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 		return
 	}
 	if err := putEval(models.Evaluation{
@@ -300,7 +303,7 @@ func SeedDev(c *gin.Context) {
 		EvaluatedAt: now.AddDate(0, -5, 0),
 	}); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 		return
 	}
 
@@ -338,13 +341,12 @@ func SeedDev(c *gin.Context) {
 	})
 
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 		return
 	}
 
 		// ส่ง response กลับเมื่อ seed สำเร็จ
-		c.JSON(http.StatusOK, gin.H{
-		"ok": true,
+		respond.OK(c, gin.H{
 		"data": gin.H{
 			"users": gin.H{
 				"hr":        created["hr@aats.com"].Email,
@@ -362,7 +364,7 @@ func SeedDev(c *gin.Context) {
 func SeedMore(c *gin.Context) {
 		// Endpoint นี้ใช้สำหรับสร้างผู้สมัครและใบสมัครจำนวนมาก (bulk seed)
 		if gin.Mode() != gin.DebugMode {
-		c.JSON(http.StatusForbidden, gin.H{"ok": false, "error": "dev seed is disabled in non-debug mode"})
+		respond.Error(c, http.StatusForbidden, "DEV_SEED_DISABLED")
 		return
 	}
 
@@ -392,7 +394,7 @@ func SeedMore(c *gin.Context) {
 	   defer func() {
 		   if r := recover(); r != nil {
 			   tx.Rollback()
-			   c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": "seed_more panic"})
+			   respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 		   }
 	   }()
 
@@ -410,7 +412,7 @@ func SeedMore(c *gin.Context) {
 	tx.Find(&jobs)
 	if len(jobs) == 0 {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": "no jobs available to seed applications"})
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 		return
 	}
 
@@ -439,7 +441,7 @@ func SeedMore(c *gin.Context) {
 		var found models.User
 		if err := tx.Where("email = ?", email).Assign(user).FirstOrCreate(&found).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+			respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 			return
 		}
 		created[email] = found
@@ -470,7 +472,7 @@ func SeedMore(c *gin.Context) {
 				Assign(app).
 				FirstOrCreate(&existing).Error; err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+				respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 				return
 			}
 			appsCreated = append(appsCreated, existing.ID)
@@ -494,7 +496,7 @@ func SeedMore(c *gin.Context) {
 				}
 				if e := tx.Create(&tl).Error; e != nil {
 					tx.Rollback()
-					c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": e.Error()})
+					respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 					return
 				}
 			}
@@ -515,7 +517,7 @@ func SeedMore(c *gin.Context) {
 				if err := tx.Where("application_id = ? AND content = ?", n.ApplicationID, n.Content).
 					FirstOrCreate(&n).Error; err != nil {
 					tx.Rollback()
-					c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+					respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 					return
 				}
 			}
@@ -543,7 +545,7 @@ func SeedMore(c *gin.Context) {
 				if err := tx.Where("application_id = ?", ev.ApplicationID).
 					FirstOrCreate(&ev).Error; err != nil {
 					tx.Rollback()
-					c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+					respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 					return
 				}
 			}
@@ -554,18 +556,18 @@ func SeedMore(c *gin.Context) {
 
 		// ส่ง response กลับเมื่อ seed สำเร็จ
 		if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"ok": true, "created_applications": appsCreated})
+	respond.OK(c, gin.H{"created_applications": appsCreated})
 }
 
 // POST /api/dev/seed_more_fill - populate timelines/notes/evaluations for seed apps
 func SeedMoreFill(c *gin.Context) {
 		// Endpoint นี้ใช้สำหรับเติม timeline/notes/evaluations ให้กับใบสมัครที่ seed ไว้
 		if gin.Mode() != gin.DebugMode {
-		c.JSON(http.StatusForbidden, gin.H{"ok": false, "error": "dev seed is disabled in non-debug mode"})
+		respond.Error(c, http.StatusForbidden, "DEV_SEED_DISABLED")
 		return
 	}
 
@@ -574,7 +576,7 @@ func SeedMoreFill(c *gin.Context) {
 	   defer func() {
 		   if r := recover(); r != nil {
 			   tx.Rollback()
-			   c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": "seed_more_fill panic"})
+			   respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 		   }
 	   }()
 
@@ -583,7 +585,7 @@ func SeedMoreFill(c *gin.Context) {
 	tx.Where("cover_letter LIKE ?", "สมัครเพื่อทดสอบข้อมูล %").Find(&apps)
 	if len(apps) == 0 {
 		tx.Commit()
-		c.JSON(http.StatusOK, gin.H{"ok": true, "message": "no seed applications found"})
+		respond.OK(c, gin.H{"message": "no seed applications found"})
 		return
 	}
 
@@ -664,14 +666,13 @@ func SeedMoreFill(c *gin.Context) {
 
 		// ส่ง response กลับเมื่อเติมข้อมูลสำเร็จ
 		if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"ok": false, "error": err.Error()})
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"ok": true,
-		"timelines_created":   createdTL,
-		"notes_created":       createdNotes,
+	respond.OK(c, gin.H{
+		"timelines_created": createdTL,
+		"notes_created": createdNotes,
 		"evaluations_created": createdEvals,
 	})
 }
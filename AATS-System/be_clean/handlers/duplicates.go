@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+)
+
+// MergeBody request body for POST /api/candidate-merges
+type MergeBody struct {
+	SurvivorID string `json:"survivor_id"` // profile that is kept
+	MergedID   string `json:"merged_id"`   // profile folded into the survivor
+}
+
+// mergeMoves lists what belongs to a candidate and moves with a merge.
+// Timelines, notes, evaluations, offers and resumes hang off applications
+// and follow them.
+var mergeMoves = []struct{ table, column string }{
+	{"applications", "applicant_id"},
+	{"candidate_notes", "candidate_id"},
+	{"notifications", "user_id"},
+	{"talent_pool_members", "candidate_id"},
+	{"talent_pool_invites", "candidate_id"},
+	{"custom_field_values", "entity_id"}, // the user custom fields of the profile
+}
+
+// activeCandidates scopes a users query to candidates that have not been
+// merged away.
+func activeCandidates(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.User{}).Where("role = ? AND COALESCE(merged_into, '') = ''", "candidate")
+}
+
+// resumeFileHash hashes a resume uploaded through /api/uploads/resume.
+// External URLs are not fetched and give "".
+func resumeFileHash(url string) string {
+	if !strings.HasPrefix(url, "/uploads/resumes/") {
+		return ""
+	}
+	h, err := utils.HashFile(filepath.Join(".", "uploads", "resumes", filepath.Base(url)))
+	if err != nil {
+		return ""
+	}
+	return h
+}
+
+// duplicateProfile collects what the detector compares for one user,
+// hashing resumes of applications that predate resume hashing.
+func duplicateProfile(db *gorm.DB, u models.User) utils.DuplicateProfile {
+	p := utils.DuplicateProfile{ID: u.ID, Name: u.Name, Phone: u.PhoneKey}
+	if p.Phone == "" {
+		p.Phone = utils.NormalizePhone(u.Phone)
+	}
+	var apps []models.Application
+	db.Select("id, resume, resume_hash").Where("applicant_id = ? AND resume <> ''", u.ID).Find(&apps)
+	for _, a := range apps {
+		if a.ResumeHash == "" {
+			if a.ResumeHash = resumeFileHash(a.Resume); a.ResumeHash != "" {
+				db.Model(&models.Application{}).Where("id = ?", a.ID).Update("resume_hash", a.ResumeHash)
+			}
+		}
+		if a.ResumeHash != "" {
+			p.ResumeHashes = append(p.ResumeHashes, a.ResumeHash)
+		}
+	}
+	return p
+}
+
+// duplicatePair orders two user ids the way DuplicateCandidate stores them.
+func duplicatePair(a, b string) (string, string) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
+// detectDuplicates compares a candidate with the profiles that share a phone
+// number, a resume file or part of the name, and records every pair scoring
+// at least utils.DuplicateThreshold. Dismissed and merged pairs keep their
+// status. Returns the number of pairs found.
+func detectDuplicates(db *gorm.DB, userID string) (int, error) {
+	var u models.User
+	if activeCandidates(db).Where("id = ?", userID).Limit(1).Find(&u).RowsAffected == 0 {
+		return 0, nil
+	}
+	me := duplicateProfile(db, u)
+
+	ids := map[string]bool{}
+	var found []string
+	if me.Phone != "" {
+		activeCandidates(db).Where("phone_key = ? AND id <> ?", me.Phone, u.ID).Pluck("id", &found)
+		for _, id := range found {
+			ids[id] = true
+		}
+	}
+	if len(me.ResumeHashes) > 0 {
+		found = nil
+		db.Model(&models.Application{}).Where("resume_hash IN ? AND applicant_id <> ?", me.ResumeHashes, u.ID).Distinct().Pluck("applicant_id", &found)
+		for _, id := range found {
+			ids[id] = true
+		}
+	}
+	if words := strings.Fields(utils.NormalizeName(u.Name)); len(words) > 0 {
+		q := activeCandidates(db).Where("id <> ?", u.ID)
+		cond := db.Where("1 = 0")
+		for _, w := range words {
+			if r := []rune(w); len(r) > 3 {
+				w = string(r[:3])
+			}
+			cond = cond.Or("LOWER(name) LIKE ?", "%"+w+"%")
+		}
+		found = nil
+		q.Where(cond).Limit(200).Pluck("id", &found)
+		for _, id := range found {
+			ids[id] = true
+		}
+	}
+
+	n := 0
+	for id := range ids {
+		var other models.User
+		if activeCandidates(db).Where("id = ?", id).Limit(1).Find(&other).RowsAffected == 0 {
+			continue
+		}
+		score, reasons := utils.ScoreDuplicate(me, duplicateProfile(db, other))
+		if score < utils.DuplicateThreshold {
+			continue
+		}
+		raw, _ := json.Marshal(reasons)
+		a, b := duplicatePair(u.ID, other.ID)
+		var pair models.DuplicateCandidate
+		if db.Where("user_id = ? AND other_user_id = ?", a, b).Limit(1).Find(&pair).RowsAffected > 0 {
+			pair.Score, pair.Reasons = score, string(raw)
+			if err := db.Save(&pair).Error; err != nil {
+				return n, err
+			}
+		} else {
+			pair = models.DuplicateCandidate{ID: uuid.NewString(), UserID: a, OtherUserID: b, Score: score, Reasons: string(raw), Status: "open"}
+			if err := db.Create(&pair).Error; err != nil {
+				return n, err
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
+// candidateSummary is how a user appears in duplicate and merge listings.
+func candidateSummary(db *gorm.DB, id string) gin.H {
+	var u models.User
+	db.Select("id, email, name, phone, merged_into, created_at").Where("id = ?", id).Limit(1).Find(&u)
+	var apps int64
+	db.Model(&models.Application{}).Where("applicant_id = ?", id).Count(&apps)
+	return gin.H{"id": id, "email": u.Email, "name": u.Name, "phone": u.Phone, "merged_into": u.MergedInto, "registered_at": u.CreatedAt, "applications": apps}
+}
+
+// GET /api/duplicates?status=open&page=&limit= (HR) — highest score first
+func ListDuplicates(c *gin.Context) {
+	page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+	status := c.DefaultQuery("status", "open")
+	q := models.DB.Model(&models.DuplicateCandidate{}).Where("status = ?", status)
+	if status == "open" {
+		// a pair stops being actionable once either side was merged elsewhere
+		merged := models.DB.Model(&models.User{}).Select("id").Where("COALESCE(merged_into, '') <> ''")
+		q = q.Where("user_id NOT IN (?) AND other_user_id NOT IN (?)", merged, merged)
+	}
+	var total int64
+	q.Count(&total)
+	var pairs []models.DuplicateCandidate
+	if err := q.Order("score desc, created_at desc").Offset(offset).Limit(limit).Find(&pairs).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(pairs))
+	for _, p := range pairs {
+		var reasons []string
+		_ = json.Unmarshal([]byte(p.Reasons), &reasons)
+		out = append(out, gin.H{
+			"id": p.ID, "score": p.Score, "reasons": reasons, "status": p.Status, "detected_at": p.CreatedAt,
+			"candidates": []gin.H{candidateSummary(models.DB, p.UserID), candidateSummary(models.DB, p.OtherUserID)},
+		})
+	}
+	respond.Page(c, gin.H{"duplicates": out}, gin.H{"page": page, "limit": limit, "total": total})
+}
+
+// POST /api/duplicates/scan (HR) — re-run detection over every candidate
+func ScanDuplicates(c *gin.Context) {
+	// profiles created before phone keys existed
+	var legacy []models.User
+	models.DB.Select("id, phone").Where("phone <> '' AND COALESCE(phone_key, '') = ''").Find(&legacy)
+	for _, u := range legacy {
+		models.DB.Model(&models.User{}).Where("id = ?", u.ID).Update("phone_key", utils.NormalizePhone(u.Phone))
+	}
+	var ids []string
+	activeCandidates(models.DB).Order("created_at asc").Pluck("id", &ids)
+	pairs := 0
+	for _, id := range ids {
+		n, err := detectDuplicates(models.DB, id)
+		if err != nil {
+			respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+			return
+		}
+		pairs += n
+	}
+	var open int64
+	models.DB.Model(&models.DuplicateCandidate{}).Where("status = ?", "open").Count(&open)
+	respond.OK(c, gin.H{"scanned": len(ids), "pairs_found": pairs, "open": open})
+}
+
+// GET /api/candidates/:id/duplicates (HR) — detect now and list the pairs
+// this candidate is part of
+func CandidateDuplicates(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := detectDuplicates(models.DB, id); err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	var pairs []models.DuplicateCandidate
+	models.DB.Where("(user_id = ? OR other_user_id = ?) AND status = ?", id, id, "open").Order("score desc").Find(&pairs)
+	out := make([]gin.H, 0, len(pairs))
+	for _, p := range pairs {
+		other := p.OtherUserID
+		if other == id {
+			other = p.UserID
+		}
+		var reasons []string
+		_ = json.Unmarshal([]byte(p.Reasons), &reasons)
+		out = append(out, gin.H{"id": p.ID, "score": p.Score, "reasons": reasons, "candidate": candidateSummary(models.DB, other)})
+	}
+	respond.OK(c, gin.H{"duplicates": out})
+}
+
+// POST /api/duplicates/:id/dismiss (HR) — not the same person; later scans
+// leave the pair dismissed
+func DismissDuplicate(c *gin.Context) {
+	var pair models.DuplicateCandidate
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&pair).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "DUPLICATE_NOT_FOUND")
+		return
+	}
+	if pair.Status != "open" {
+		respond.Error(c, http.StatusBadRequest, "DUPLICATE_ALREADY_RESOLVED", gin.H{"status": pair.Status})
+		return
+	}
+	uid, _ := c.Get("user_id")
+	now := time.Now()
+	pair.Status, pair.ReviewedBy, pair.ReviewedAt = "dismissed", uid.(string), &now
+	if err := models.DB.Save(&pair).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"duplicate": pair})
+}
+
+// POST /api/candidate-merges (HR) — folds merged_id into survivor_id:
+// applications (with their timelines, notes, offers and resumes), candidate
+// notes, notifications, pool memberships, invites and the user custom field
+// values the survivor lacks move to the survivor and the merged profile can
+// no longer sign in. Everything moved is recorded so the merge can be undone.
+func MergeCandidates(c *gin.Context) {
+	var body MergeBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.SurvivorID == "" || body.MergedID == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELDS_REQUIRED", gin.H{"fields": "survivor_id, merged_id"})
+		return
+	}
+	if body.SurvivorID == body.MergedID {
+		respond.Error(c, http.StatusBadRequest, "MERGE_SAME_CANDIDATE")
+		return
+	}
+	var survivor, merged models.User
+	if activeCandidates(models.DB).Where("id = ?", body.SurvivorID).Limit(1).Find(&survivor).RowsAffected == 0 ||
+		activeCandidates(models.DB).Where("id = ?", body.MergedID).Limit(1).Find(&merged).RowsAffected == 0 {
+		respond.Error(c, http.StatusNotFound, "MERGE_PROFILES_INVALID")
+		return
+	}
+	uid, _ := c.Get("user_id")
+	mergedBy, _ := uid.(string)
+	now := time.Now()
+
+	merge := models.CandidateMerge{ID: uuid.NewString(), SurvivorID: survivor.ID, MergedID: merged.ID, Status: "merged", MergedBy: mergedBy}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		moved := map[string][]string{}
+		for _, m := range mergeMoves {
+			q := tx.Table(m.table).Where(m.column+" = ?", merged.ID)
+			if m.table == "talent_pool_members" {
+				// the survivor's own membership wins where both were pooled
+				q = q.Where("pool_id NOT IN (?)", tx.Model(&models.TalentPoolMember{}).Select("pool_id").Where("candidate_id = ?", survivor.ID))
+			}
+			if m.table == "custom_field_values" {
+				// likewise the survivor's own value of a field
+				q = q.Where("field_id IN (?)", tx.Model(&models.CustomField{}).Select("id").Where("entity = ?", CustomEntityUser)).
+					Where("field_id NOT IN (?)", tx.Model(&models.CustomFieldValue{}).Select("field_id").Where("entity_id = ?", survivor.ID))
+			}
+			var ids []string
+			if err := q.Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			if err := tx.Table(m.table).Where("id IN ?", ids).Update(m.column, survivor.ID).Error; err != nil {
+				return err
+			}
+			moved[m.table] = ids
+		}
+		var apps []models.Application
+		tx.Where("id IN ?", moved["applications"]).Find(&apps)
+		for _, a := range apps {
+			if err := addTimelineNote(tx, a, i18n.M("timeline.PROFILE_MERGED", map[string]any{"from": merged.Email, "to": survivor.Email})); err != nil {
+				return err
+			}
+		}
+		raw, _ := json.Marshal(moved)
+		merge.Moved = string(raw)
+
+		a, b := duplicatePair(survivor.ID, merged.ID)
+		var pair models.DuplicateCandidate
+		if tx.Where("user_id = ? AND other_user_id = ?", a, b).Limit(1).Find(&pair).RowsAffected > 0 {
+			merge.DuplicateID = pair.ID
+			if err := tx.Model(&pair).Updates(map[string]interface{}{"status": "merged", "reviewed_by": mergedBy, "reviewed_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", merged.ID).Update("merged_into", survivor.ID).Error; err != nil {
+			return err
+		}
+		return tx.Create(&merge).Error
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	refreshPoolProfiles(models.DB, survivor.ID)
+	respond.Created(c, gin.H{"merge": merge, "survivor": candidateSummary(models.DB, survivor.ID)})
+}
+
+// GET /api/candidate-merges?candidate_id= (HR) — the merge audit trail
+func ListCandidateMerges(c *gin.Context) {
+	q := models.DB.Order("created_at desc")
+	if id := c.Query("candidate_id"); id != "" {
+		q = q.Where("survivor_id = ? OR merged_id = ?", id, id)
+	}
+	var merges []models.CandidateMerge
+	if err := q.Find(&merges).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(merges))
+	for _, m := range merges {
+		var moved map[string][]string
+		_ = json.Unmarshal([]byte(m.Moved), &moved)
+		counts := map[string]int{}
+		for table, ids := range moved {
+			counts[table] = len(ids)
+		}
+		out = append(out, gin.H{
+			"id": m.ID, "status": m.Status, "moved": counts, "merged_by": m.MergedBy, "merged_at": m.CreatedAt,
+			"undone_by": m.UndoneBy, "undone_at": m.UndoneAt,
+			"survivor": candidateSummary(models.DB, m.SurvivorID), "merged": candidateSummary(models.DB, m.MergedID),
+		})
+	}
+	respond.OK(c, gin.H{"merges": out})
+}
+
+// POST /api/candidate-merges/:id/undo (HR) — moves back what the merge moved
+// and reactivates the merged profile. Records the survivor has gained since
+// stay with the survivor.
+func UndoCandidateMerge(c *gin.Context) {
+	var merge models.CandidateMerge
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&merge).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "MERGE_NOT_FOUND")
+		return
+	}
+	if merge.Status != "merged" {
+		respond.Error(c, http.StatusBadRequest, "MERGE_ALREADY_RESOLVED", gin.H{"status": merge.Status})
+		return
+	}
+	var survivor, merged models.User
+	models.DB.Where("id = ?", merge.SurvivorID).Limit(1).Find(&survivor)
+	models.DB.Where("id = ?", merge.MergedID).Limit(1).Find(&merged)
+	if survivor.MergedInto != "" {
+		respond.Error(c, http.StatusConflict, "MERGE_SURVIVOR_MERGED_AGAIN")
+		return
+	}
+	if merged.MergedInto != survivor.ID {
+		respond.Error(c, http.StatusConflict, "MERGE_PROFILE_CHANGED")
+		return
+	}
+	var moved map[string][]string
+	_ = json.Unmarshal([]byte(merge.Moved), &moved)
+	uid, _ := c.Get("user_id")
+	undoneBy, _ := uid.(string)
+	now := time.Now()
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		for _, m := range mergeMoves {
+			ids := moved[m.table]
+			if len(ids) == 0 {
+				continue
+			}
+			if err := tx.Table(m.table).Where("id IN ? AND "+m.column+" = ?", ids, survivor.ID).Update(m.column, merged.ID).Error; err != nil {
+				return err
+			}
+		}
+		var apps []models.Application
+		tx.Where("id IN ? AND applicant_id = ?", moved["applications"], merged.ID).Find(&apps)
+		for _, a := range apps {
+			if err := addTimelineNote(tx, a, i18n.M("timeline.PROFILE_MERGE_UNDONE", map[string]any{"to": merged.Email})); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", merged.ID).Update("merged_into", "").Error; err != nil {
+			return err
+		}
+		if merge.DuplicateID != "" {
+			if err := tx.Model(&models.DuplicateCandidate{}).Where("id = ?", merge.DuplicateID).Update("status", "open").Error; err != nil {
+				return err
+			}
+		}
+		merge.Status, merge.UndoneBy, merge.UndoneAt = "undone", undoneBy, &now
+		return tx.Save(&merge).Error
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	refreshPoolProfiles(models.DB, survivor.ID)
+	refreshPoolProfiles(models.DB, merged.ID)
+	respond.OK(c, gin.H{"merge": merge})
+}
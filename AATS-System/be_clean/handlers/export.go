@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+)
+
+// exportBatchSize is how many applications are enriched and written at once.
+const exportBatchSize = 500
+
+// exportRow is one application joined with the data shown in the export.
+type exportRow struct {
+	App        models.Application
+	Job        models.JobPosting
+	Applicant  models.User
+	Eval       *models.Evaluation
+	LastChange time.Time
+	Custom     map[string]interface{} // application custom fields by key
+	JobCustom  map[string]interface{} // job custom fields by key
+}
+
+// exportColumn describes one column; roles limits who may see it (nil = all
+// roles allowed to export).
+type exportColumn struct {
+	header string
+	roles  []string
+	value  func(r exportRow) interface{}
+}
+
+func evalValue(r exportRow, f func(e *models.Evaluation) interface{}) interface{} {
+	if r.Eval == nil {
+		return nil
+	}
+	return f(r.Eval)
+}
+
+// exportColumns in output order. Contact details are HR only; hiring
+// managers get the same sheet with those columns removed.
+var exportColumns = []exportColumn{
+	{"application_id", nil, func(r exportRow) interface{} { return r.App.ID }},
+	{"submitted_date", nil, func(r exportRow) interface{} { return r.App.SubmittedDate }},
+	{"job_title", nil, func(r exportRow) interface{} { return r.Job.Title }},
+	{"department", nil, func(r exportRow) interface{} { return r.Job.Department }},
+	{"applicant_name", nil, func(r exportRow) interface{} { return r.Applicant.Name }},
+	{"applicant_email", []string{"hr"}, func(r exportRow) interface{} { return r.Applicant.Email }},
+	{"applicant_phone", []string{"hr"}, func(r exportRow) interface{} { return r.Applicant.Phone }},
+	{"stage", nil, func(r exportRow) interface{} { return r.App.Status }},
+	{"last_status_change", nil, func(r exportRow) interface{} {
+		if r.LastChange.IsZero() {
+			return nil
+		}
+		return r.LastChange
+	}},
+	{"source", nil, func(r exportRow) interface{} { return r.App.Source }},
+	{"utm_campaign", nil, func(r exportRow) interface{} { return r.App.UTMCampaign }},
+	{"match_score", nil, func(r exportRow) interface{} { return r.App.MatchScore }},
+	{"screening_outcome", nil, func(r exportRow) interface{} { return r.App.ScreeningOutcome }},
+	{"technical_skills", nil, func(r exportRow) interface{} {
+		return evalValue(r, func(e *models.Evaluation) interface{} { return e.TechnicalSkills })
+	}},
+	{"communication", nil, func(r exportRow) interface{} {
+		return evalValue(r, func(e *models.Evaluation) interface{} { return e.Communication })
+	}},
+	{"problem_solving", nil, func(r exportRow) interface{} {
+		return evalValue(r, func(e *models.Evaluation) interface{} { return e.ProblemSolving })
+	}},
+	{"cultural_fit", nil, func(r exportRow) interface{} {
+		return evalValue(r, func(e *models.Evaluation) interface{} { return e.CulturalFit })
+	}},
+	{"overall_score", nil, func(r exportRow) interface{} {
+		return evalValue(r, func(e *models.Evaluation) interface{} { return e.OverallScore })
+	}},
+	{"evaluator", nil, func(r exportRow) interface{} {
+		return evalValue(r, func(e *models.Evaluation) interface{} { return e.EvaluatorName })
+	}},
+}
+
+// columnsForRole drops the columns a role may not see.
+func columnsForRole(role string) []exportColumn {
+	cols := make([]exportColumn, 0, len(exportColumns))
+	for _, col := range exportColumns {
+		if col.roles == nil {
+			cols = append(cols, col)
+			continue
+		}
+		for _, r := range col.roles {
+			if r == role {
+				cols = append(cols, col)
+				break
+			}
+		}
+	}
+	return cols
+}
+
+// customExportColumns adds a cf_<key> column per application custom field and
+// a job_cf_<key> column per job custom field, in their display order.
+func customExportColumns() []exportColumn {
+	var fields []models.CustomField
+	models.DB.Where("entity IN ?", []string{CustomEntityApplication, CustomEntityJob}).Order("entity asc, position asc, key asc").Find(&fields)
+	cols := make([]exportColumn, 0, len(fields))
+	for _, f := range fields {
+		key := f.Key
+		if f.Entity == CustomEntityJob {
+			cols = append(cols, exportColumn{"job_cf_" + key, nil, func(r exportRow) interface{} { return customCell(r.JobCustom[key]) }})
+		} else {
+			cols = append(cols, exportColumn{"cf_" + key, nil, func(r exportRow) interface{} { return customCell(r.Custom[key]) }})
+		}
+	}
+	return cols
+}
+
+// customCell joins multi_select values so both CSV and XLSX get one text cell.
+func customCell(v interface{}) interface{} {
+	if list, ok := v.([]string); ok {
+		return strings.Join(list, "; ")
+	}
+	return v
+}
+
+// enrichExportBatch loads jobs, applicants, evaluations, timelines and custom
+// fields for a batch of applications with one query each.
+func enrichExportBatch(apps []models.Application) ([]exportRow, error) {
+	rel, err := svc().Applications.Related(apps)
+	if err != nil {
+		return nil, err
+	}
+	appIDs := make([]string, 0, len(apps))
+	jobIDs := []string{}
+	for _, a := range apps {
+		appIDs = append(appIDs, a.ID)
+		jobIDs = append(jobIDs, a.JobID)
+	}
+	appCustom := loadCustomFields(models.DB, CustomEntityApplication, appIDs, true)
+	jobCustom := loadCustomFields(models.DB, CustomEntityJob, jobIDs, true)
+
+	rows := make([]exportRow, 0, len(apps))
+	for _, a := range apps {
+		r := exportRow{
+			App:       a,
+			Job:       rel.Jobs[a.JobID],
+			Applicant: rel.Applicants[a.ApplicantID],
+			Custom:    appCustom[a.ID],
+			JobCustom: jobCustom[a.JobID],
+		}
+		if ev, ok := rel.Evaluations[a.ID]; ok {
+			r.Eval = &ev
+		}
+		// last status change = latest timeline entry whose status differs from the
+		// entry before it (notes/tags keep the status and are not changes)
+		tls := rel.Timelines[a.ID] // newest first
+		for i := len(tls) - 1; i >= 0; i-- {
+			if i == len(tls)-1 || tls[i].Status != tls[i+1].Status {
+				r.LastChange = tls[i].Date
+			}
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+// exportCell formats a value for CSV. Text a spreadsheet would run as a
+// formula is quoted (see utils.CSVText).
+func exportCell(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return utils.CSVText(t)
+	case int:
+		return strconv.Itoa(t)
+	case float32:
+		return strconv.FormatFloat(float64(t), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case time.Time:
+		return t.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// GET /api/applications/export?format=csv|xlsx (HR/HM)
+// Accepts the same filters as GET /api/applications and streams every
+// matching application (no paging).
+func ExportApplications(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "format", "allowed": "csv, xlsx"})
+		return
+	}
+	rv, _ := c.Get("user_role")
+	role, _ := rv.(string)
+	uv, _ := c.Get("user_id")
+	uid, _ := uv.(string)
+	cols := append(columnsForRole(role), customExportColumns()...)
+
+	f := applicationFilter(c, role, uid)
+
+	filename := "applications-" + time.Now().Format("20060102") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	header := make([]interface{}, len(cols))
+	for i, col := range cols {
+		header[i] = col.header
+	}
+
+	// writeRow/flush hide the difference between the two formats. begin sends
+	// the headers and the header row once the first application is read, so a
+	// failing query still gets an error response.
+	var writeRow func(values []interface{}) error
+	var flush func() error
+	var closeOut func() error
+	started := false
+	begin := func() error {
+		if format == "csv" {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Status(http.StatusOK)
+			c.Writer.Write([]byte("\xEF\xBB\xBF")) // BOM so Excel reads Thai text as UTF-8
+			w := csv.NewWriter(c.Writer)
+			writeRow = func(values []interface{}) error {
+				rec := make([]string, len(values))
+				for i, v := range values {
+					rec[i] = exportCell(v)
+				}
+				return w.Write(rec)
+			}
+			flush = func() error { w.Flush(); c.Writer.Flush(); return w.Error() }
+			closeOut = flush
+		} else {
+			c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			c.Status(http.StatusOK)
+			x, err := utils.NewXLSXWriter(c.Writer, "Applications")
+			if err != nil {
+				return err
+			}
+			writeRow = x.WriteRow
+			flush = func() error { err := x.Flush(); c.Writer.Flush(); return err }
+			closeOut = x.Close
+		}
+		started = true
+		return writeRow(header)
+	}
+
+	writeBatch := func(batch []models.Application) error {
+		rows, err := enrichExportBatch(batch)
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			values := make([]interface{}, len(cols))
+			for i, col := range cols {
+				values[i] = col.value(r)
+			}
+			if err := writeRow(values); err != nil {
+				return err
+			}
+		}
+		return flush()
+	}
+
+	batch := make([]models.Application, 0, exportBatchSize)
+	err := svc().Applications.Each(f, func(a models.Application) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		batch = append(batch, a)
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		err := writeBatch(batch)
+		batch = batch[:0]
+		return err
+	})
+	if err == nil && !started {
+		err = begin() // no matches: just the header row
+	}
+	if err == nil && len(batch) > 0 {
+		err = writeBatch(batch)
+	}
+	if err != nil {
+		if !started {
+			fail(c, err)
+		}
+		return // headers are already sent, so the stream can only be cut short
+	}
+	closeOut()
+}
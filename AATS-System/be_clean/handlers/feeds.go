@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+)
+
+// feedMaxJobs caps how many jobs one feed lists (newest first).
+const feedMaxJobs = 1000
+
+var feedBoardSlugRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// FeedBoardBody request body for job board feed settings.
+type FeedBoardBody struct {
+	Slug        string            `json:"slug"`
+	Name        string            `json:"name"`
+	RootElement string            `json:"root_element"`
+	ItemElement string            `json:"item_element"`
+	Fields      []utils.FeedField `json:"fields"`
+	Departments []string          `json:"departments"`
+	Enabled     *bool             `json:"enabled"`
+}
+
+// requestBaseURL is scheme://host of the current request, honouring a
+// reverse proxy's X-Forwarded-Proto.
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if p := c.GetHeader("X-Forwarded-Proto"); p != "" {
+		scheme = p
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// feedJobs loads the published jobs of a feed, optionally limited to some
+// departments, and when the feed last changed (its updated date). Feeds are
+// built from the database on every request, so publishing or closing a job
+// shows up at once; the change time covers jobs that left the feed too
+// (closed, edited or past their closing date).
+func feedJobs(c *gin.Context, departments []string) ([]utils.FeedJob, time.Time) {
+	now := time.Now()
+	scope := func(q *gorm.DB) *gorm.DB {
+		if len(departments) > 0 {
+			q = q.Where("LOWER(department) IN ?", departments)
+		}
+		return q
+	}
+
+	var jobs []models.JobPosting
+	scope(publishedJobs(models.DB, now)).Order("posted_date desc, id desc").Limit(feedMaxJobs).Find(&jobs)
+
+	var changed struct {
+		UpdatedAt   time.Time
+		ClosingDate time.Time
+	}
+	scope(models.DB.Model(&models.JobPosting{})).Select("updated_at").Order("updated_at desc").Limit(1).Scan(&changed)
+	lastMod := changed.UpdatedAt
+	scope(models.DB.Model(&models.JobPosting{})).Select("closing_date").
+		Where("status = ? AND closing_date <= ? AND closing_date > ?", "active", now, time.Time{}).
+		Order("closing_date desc").Limit(1).Scan(&changed)
+	if changed.ClosingDate.After(lastMod) {
+		lastMod = changed.ClosingDate
+	}
+
+	ids := make([]string, 0, len(jobs))
+	for _, j := range jobs {
+		ids = append(ids, j.ID)
+	}
+	custom := loadCustomFields(models.DB, CustomEntityJob, ids, false)
+	base := requestBaseURL(c)
+	out := make([]utils.FeedJob, 0, len(jobs))
+	for _, j := range jobs {
+		info := jobPostingInfo(j)
+		if info.URL == "" {
+			info.URL = base + "/api/public/jobs/" + j.Slug
+		}
+		values := map[string]string{}
+		for k, v := range custom[j.ID] {
+			values[k] = exportCell(customCell(v))
+		}
+		out = append(out, utils.FeedJob{JobPostingInfo: info, Slug: j.Slug, UpdatedAt: j.UpdatedAt, Custom: values})
+	}
+	return out, lastMod
+}
+
+// feedMeta describes a feed served at the current URL, titled in the
+// request's language.
+func feedMeta(c *gin.Context, updated time.Time) utils.FeedMeta {
+	org := companyName()
+	lang := respond.Language(c)
+	link := strings.TrimRight(os.Getenv("CAREERS_BASE_URL"), "/")
+	if link == "" {
+		link = requestBaseURL(c) + "/api/public/jobs"
+	}
+	return utils.FeedMeta{
+		Title:        i18n.T(lang, "feeds.TITLE", map[string]any{"org": org}),
+		Description:  i18n.T(lang, "feeds.DESCRIPTION", map[string]any{"org": org}),
+		Link:         link,
+		SelfURL:      requestBaseURL(c) + c.Request.URL.RequestURI(),
+		Publisher:    org,
+		PublisherURL: os.Getenv("COMPANY_URL"),
+		Updated:      updated,
+	}
+}
+
+// writeFeed sends a feed with an ETag, answering If-None-Match with 304.
+// The ETag is taken over the body, so any change a reader could see
+// (including custom field values and deleted jobs) gives a new tag. There
+// is no Last-Modified: the newest updated_at goes backwards when a job is
+// deleted, and custom field edits do not touch it, so If-Modified-Since
+// would keep stale feeds.
+func writeFeed(c *gin.Context, contentType string, body []byte) {
+	etag := utils.FeedETag(string(body))
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, no-cache") // readers may cache but must revalidate
+	c.Header("Vary", "Accept-Language")
+	c.Header("Content-Language", string(respond.Language(c)))
+	if utils.ETagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// GET /api/feeds/jobs.rss?department= — published jobs as RSS 2.0
+func JobsRSSFeed(c *gin.Context) {
+	jobs, lastMod := feedJobs(c, splitFilter(c.Query("department")))
+	writeFeed(c, "application/rss+xml; charset=utf-8", utils.RSSFeed(feedMeta(c, lastMod), jobs))
+}
+
+// GET /api/feeds/jobs.atom?department= — published jobs as Atom 1.0
+func JobsAtomFeed(c *gin.Context) {
+	jobs, lastMod := feedJobs(c, splitFilter(c.Query("department")))
+	writeFeed(c, "application/atom+xml; charset=utf-8", utils.AtomFeed(feedMeta(c, lastMod), jobs))
+}
+
+// GET /api/feeds/boards/:board — XML job feed in the board's layout
+// (:board is the board slug, optionally with .xml)
+func JobBoardFeed(c *gin.Context) {
+	var board models.JobFeedBoard
+	slug := strings.TrimSuffix(c.Param("board"), ".xml")
+	if err := models.DB.Where("slug = ? AND enabled = ?", slug, true).First(&board).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "FEED_NOT_FOUND")
+		return
+	}
+	var fields []utils.FeedField
+	var departments []string
+	_ = json.Unmarshal([]byte(board.Fields), &fields)
+	_ = json.Unmarshal([]byte(board.Departments), &departments)
+	jobs, lastMod := feedJobs(c, splitFilter(strings.Join(departments, ",")))
+	if board.UpdatedAt.After(lastMod) {
+		lastMod = board.UpdatedAt // a mapping change rewrites the whole feed
+	}
+	body := utils.JobXMLFeed(feedMeta(c, lastMod), board.RootElement, board.ItemElement, fields, jobs)
+	writeFeed(c, "application/xml; charset=utf-8", body)
+}
+
+// feedBoardView is how board settings are returned.
+func feedBoardView(b models.JobFeedBoard) gin.H {
+	var fields []utils.FeedField
+	departments := []string{}
+	_ = json.Unmarshal([]byte(b.Fields), &fields)
+	_ = json.Unmarshal([]byte(b.Departments), &departments)
+	return gin.H{
+		"id": b.ID, "slug": b.Slug, "name": b.Name, "root_element": b.RootElement, "item_element": b.ItemElement,
+		"fields": fields, "departments": departments, "enabled": b.Enabled,
+		"feed_path": "/api/feeds/boards/" + b.Slug + ".xml", "updated_at": b.UpdatedAt,
+	}
+}
+
+// boardFromBody validates body into b. Returns a problem on bad input.
+func boardFromBody(b *models.JobFeedBoard, body FeedBoardBody, creating bool) *respond.Problem {
+	if body.Slug != "" || creating {
+		if !feedBoardSlugRe.MatchString(body.Slug) {
+			return respond.New(http.StatusBadRequest, "FEED_BOARD_SLUG_INVALID")
+		}
+		b.Slug = body.Slug
+	}
+	if name := strings.TrimSpace(body.Name); name != "" {
+		b.Name = name
+	} else if creating {
+		b.Name = b.Slug
+	}
+	if body.RootElement != "" || creating {
+		if body.RootElement == "" {
+			body.RootElement = "source"
+		}
+		if !utils.ValidXMLName(body.RootElement) {
+			return respond.New(http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "root_element"})
+		}
+		b.RootElement = body.RootElement
+	}
+	if body.ItemElement != "" || creating {
+		if body.ItemElement == "" {
+			body.ItemElement = "job"
+		}
+		if !utils.ValidXMLName(body.ItemElement) {
+			return respond.New(http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "item_element"})
+		}
+		b.ItemElement = body.ItemElement
+	}
+	if body.Fields != nil || creating {
+		if body.Fields == nil {
+			body.Fields = utils.DefaultJobXMLFields
+		}
+		if err := utils.ValidateFeedFields(body.Fields); err != nil {
+			return respond.New(http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "fields", "reason": err.Error()})
+		}
+		raw, _ := json.Marshal(body.Fields)
+		b.Fields = string(raw)
+	}
+	if body.Departments != nil || creating {
+		raw, _ := json.Marshal(splitFilter(strings.Join(body.Departments, ",")))
+		b.Departments = string(raw)
+	}
+	if body.Enabled != nil {
+		b.Enabled = *body.Enabled
+	}
+	return nil
+}
+
+// GET /api/feed-boards (HR)
+func ListFeedBoards(c *gin.Context) {
+	var boards []models.JobFeedBoard
+	if err := models.DB.Order("name asc").Find(&boards).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(boards))
+	for _, b := range boards {
+		out = append(out, feedBoardView(b))
+	}
+	respond.OK(c, gin.H{"boards": out, "default_fields": utils.DefaultJobXMLFields})
+}
+
+// POST /api/feed-boards (HR) — fields default to the common XML job feed layout
+func CreateFeedBoard(c *gin.Context) {
+	var body FeedBoardBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	b := models.JobFeedBoard{ID: uuid.NewString(), Enabled: true, CreatedBy: createdBy}
+	if p := boardFromBody(&b, body, true); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if err := models.DB.Create(&b).Error; err != nil {
+		respond.Error(c, http.StatusConflict, "FEED_BOARD_SLUG_TAKEN")
+		return
+	}
+	respond.Created(c, gin.H{"board": feedBoardView(b)})
+}
+
+// PUT /api/feed-boards/:id (HR)
+func UpdateFeedBoard(c *gin.Context) {
+	var b models.JobFeedBoard
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&b).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "FEED_BOARD_NOT_FOUND")
+		return
+	}
+	var body FeedBoardBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if p := boardFromBody(&b, body, false); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if err := models.DB.Save(&b).Error; err != nil {
+		respond.Error(c, http.StatusConflict, "FEED_BOARD_SLUG_TAKEN")
+		return
+	}
+	respond.OK(c, gin.H{"board": feedBoardView(b)})
+}
+
+// DELETE /api/feed-boards/:id (HR)
+func DeleteFeedBoard(c *gin.Context) {
+	res := models.DB.Where("id = ?", c.Param("id")).Delete(&models.JobFeedBoard{})
+	if res.Error != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	if res.RowsAffected == 0 {
+		respond.Error(c, http.StatusNotFound, "FEED_BOARD_NOT_FOUND")
+		return
+	}
+	respond.OK(c, gin.H{})
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/hris"
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+	"aats-backend-clean/worker"
+)
+
+// hrisConnector is set by StartHRISHandoff; nil means no HRIS is configured
+// and hires are not queued.
+var hrisConnector hris.Connector
+
+// StartHRISHandoff sends queued new hires through conn every interval.
+// Handoffs left "sending" by a previous process are retried. Call after the
+// DB is connected.
+func StartHRISHandoff(every time.Duration, conn hris.Connector) {
+	if conn == nil {
+		log.Println("HRIS handoff disabled (HRIS_CONNECTOR not set)")
+		return
+	}
+	hrisConnector = conn
+	models.DB.Model(&models.HRISHandoff{}).Where("state = ?", "sending").Update("state", "pending")
+	worker.Every(every, ProcessHRISHandoffs)
+}
+
+// queueNewHireHandoff queues a hired application for the HRIS, unless one is
+// already queued or sent. Pass the transaction that sets the status.
+func queueNewHireHandoff(db *gorm.DB, app models.Application) error {
+	if hrisConnector == nil {
+		return nil
+	}
+	var n int64
+	db.Model(&models.HRISHandoff{}).Where("application_id = ? AND state IN ?", app.ID, []string{"pending", "sending", "succeeded"}).Count(&n)
+	if n > 0 {
+		return nil
+	}
+	return db.Create(&models.HRISHandoff{
+		ID: uuid.NewString(), ApplicationID: app.ID, Connector: hrisConnector.Name(),
+		State: "pending", NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// buildNewHire maps an application, its applicant, job and offer to the
+// record the HRIS receives. It runs on every attempt so corrections made
+// after a failure are picked up.
+func buildNewHire(db *gorm.DB, h models.HRISHandoff) (hris.NewHire, error) {
+	var app models.Application
+	if err := db.Where("id = ?", h.ApplicationID).First(&app).Error; err != nil {
+		return hris.NewHire{}, hris.Permanent(err)
+	}
+	var user models.User
+	db.Where("id = ?", app.ApplicantID).Limit(1).Find(&user)
+	var job models.JobPosting
+	db.Where("id = ?", app.JobID).Limit(1).Find(&job)
+	rec := hris.NewHire{
+		ApplicationID: app.ID, CandidateID: user.ID, FullName: user.Name, Email: user.Email, Phone: user.Phone,
+		JobID: job.ID, JobTitle: job.Title, Department: job.Department, Location: job.Location,
+		HiredAt: h.CreatedAt, Source: app.Source,
+	}
+	rec.SplitName()
+	// the accepted offer carries the agreed terms; otherwise the latest one sent
+	var offer models.Offer
+	if db.Where("application_id = ? AND status = ?", app.ID, "accepted").Order("responded_at desc").Limit(1).Find(&offer).RowsAffected == 0 {
+		db.Where("application_id = ? AND status IN ?", app.ID, []string{"sent", "approved"}).Order("created_at desc").Limit(1).Find(&offer)
+	}
+	if offer.ID != "" {
+		rec.OfferID, rec.Salary, rec.Currency, rec.Benefits = offer.ID, offer.Salary, offer.Currency, offer.Benefits
+		if !offer.StartDate.IsZero() {
+			rec.StartDate = offer.StartDate.Format("2006-01-02")
+		}
+	}
+	return rec, nil
+}
+
+// ProcessHRISHandoffs sends every due handoff once.
+func ProcessHRISHandoffs() {
+	var due []models.HRISHandoff
+	models.DB.Select("id").Where("state = ? AND next_attempt_at <= ?", "pending", time.Now()).
+		Order("next_attempt_at asc").Limit(50).Find(&due)
+	for _, d := range due {
+		res := models.DB.Model(&models.HRISHandoff{}).Where("id = ? AND state = ?", d.ID, "pending").Update("state", "sending")
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
+		}
+		sendHRISHandoff(d.ID)
+	}
+}
+
+// sendHRISHandoff makes one attempt and records the outcome: synced, retry
+// later with backoff, or failed (permanent error or out of attempts), which
+// tells the application's owners.
+func sendHRISHandoff(id string) {
+	var h models.HRISHandoff
+	if err := models.DB.Where("id = ?", id).First(&h).Error; err != nil {
+		return
+	}
+	if hrisConnector == nil {
+		models.DB.Model(&h).Updates(map[string]interface{}{"state": "pending"})
+		return
+	}
+	h.Attempts++
+	rec, err := buildNewHire(models.DB, h)
+	var res hris.Result
+	if err == nil {
+		payload, _ := json.Marshal(rec)
+		h.Payload = string(payload)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		res, err = hrisConnector.Send(ctx, rec)
+		cancel()
+	}
+	now := time.Now()
+	switch {
+	case err == nil:
+		h.State, h.LastError, h.ExternalID, h.SyncedAt = "succeeded", "", res.ExternalID, &now
+	case hris.IsPermanent(err) || h.Attempts >= hris.MaxAttempts:
+		h.State, h.LastError = "failed", err.Error()
+	default:
+		h.State, h.LastError, h.NextAttemptAt = "pending", err.Error(), now.Add(hris.Backoff(h.Attempts))
+	}
+	h.Connector = hrisConnector.Name()
+	models.DB.Save(&h)
+	if h.State == "failed" {
+		var app models.Application
+		if models.DB.Where("id = ?", h.ApplicationID).Limit(1).Find(&app).RowsAffected > 0 {
+			notifyApplicationOwners(models.DB, app, i18n.M("notifications.HRIS_HANDOFF_FAILED_TITLE"),
+				i18n.M("notifications.HRIS_HANDOFF_FAILED", map[string]any{"error": clip(h.LastError, 200)}))
+		}
+	}
+}
+
+// hrisHandoffView adds who and what the handoff is for.
+func hrisHandoffView(h models.HRISHandoff, names map[string][2]string) gin.H {
+	n := names[h.ApplicationID]
+	return gin.H{
+		"id": h.ID, "application_id": h.ApplicationID, "candidate_name": n[0], "job_title": n[1],
+		"connector": h.Connector, "state": h.State, "attempts": h.Attempts, "next_attempt_at": h.NextAttemptAt,
+		"last_error": h.LastError, "external_id": h.ExternalID, "synced_at": h.SyncedAt,
+		"created_at": h.CreatedAt, "updated_at": h.UpdatedAt,
+	}
+}
+
+// GET /api/hris/handoffs?state=failed&page=&limit= (HR) — failed handoffs by
+// default; state=all lists everything
+func ListHRISHandoffs(c *gin.Context) {
+	page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+	q := models.DB.Model(&models.HRISHandoff{})
+	if st := c.DefaultQuery("state", "failed"); st != "all" {
+		q = q.Where("state = ?", st)
+	}
+	var total int64
+	q.Count(&total)
+	var list []models.HRISHandoff
+	if err := q.Order("updated_at desc").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	ids := make([]string, 0, len(list))
+	for _, h := range list {
+		ids = append(ids, h.ApplicationID)
+	}
+	var rows []struct{ ID, Name, Title string }
+	if len(ids) > 0 {
+		models.DB.Table("applications").Select("applications.id, users.name, job_postings.title").
+			Joins("LEFT JOIN users ON users.id = applications.applicant_id").
+			Joins("LEFT JOIN job_postings ON job_postings.id = applications.job_id").
+			Where("applications.id IN ?", ids).Scan(&rows)
+	}
+	names := map[string][2]string{}
+	for _, r := range rows {
+		names[r.ID] = [2]string{r.Name, r.Title}
+	}
+	out := make([]gin.H, 0, len(list))
+	for _, h := range list {
+		out = append(out, hrisHandoffView(h, names))
+	}
+	connector := ""
+	if hrisConnector != nil {
+		connector = hrisConnector.Name()
+	}
+	respond.Page(c, gin.H{"connector": connector, "handoffs": out}, gin.H{"page": page, "limit": limit, "total": total})
+}
+
+// GET /api/hris/handoffs/:id (HR) — with the record last sent
+func GetHRISHandoff(c *gin.Context) {
+	var h models.HRISHandoff
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&h).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "HRIS_HANDOFF_NOT_FOUND")
+		return
+	}
+	view := hrisHandoffView(h, nil)
+	var payload interface{}
+	if h.Payload != "" {
+		payload = json.RawMessage(h.Payload)
+	}
+	view["payload"] = payload
+	respond.OK(c, gin.H{"handoff": view})
+}
+
+// POST /api/hris/handoffs/:id/retry (HR) — queue a failed handoff again
+// with a fresh set of attempts
+func RetryHRISHandoff(c *gin.Context) {
+	var h models.HRISHandoff
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&h).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "HRIS_HANDOFF_NOT_FOUND")
+		return
+	}
+	if h.State != "failed" {
+		respond.Error(c, http.StatusConflict, "HRIS_HANDOFF_NOT_FAILED")
+		return
+	}
+	h.State, h.Attempts, h.NextAttemptAt = "pending", 0, time.Now()
+	if err := models.DB.Save(&h).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Accepted(c, gin.H{"handoff": hrisHandoffView(h, nil)})
+}
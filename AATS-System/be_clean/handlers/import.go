@@ -0,0 +1,515 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/services"
+	"aats-backend-clean/utils"
+)
+
+// Fields accepted by the CSV importers. A mapping points a field at the CSV
+// header that holds it; unmapped fields are looked up by their own name.
+var (
+	ImportJobFields = []string{
+		"external_id", "title", "department", "location", "experience_level", "description",
+		"requirements", "responsibilities", "must_have_skills", "nice_to_have_skills",
+		"min_experience_years", "status", "posted_date", "closing_date",
+	}
+	ImportApplicationFields = []string{
+		"external_id", "job_external_id", "job_id", "email", "name", "phone",
+		"status", "resume", "cover_letter", "education", "experience", "skills",
+		"submitted_date", "screening_date", "interview_date", "offer_date", "rejected_date", "hired_date",
+	}
+)
+
+// historyStatuses are the statuses that may carry a "<status>_date" column;
+// each filled date becomes an ApplicationTimeline row.
+var historyStatuses = []string{"submitted", "screening", "interview", "offer", "rejected", "hired"}
+
+const importHistoryNote = "timeline.IMPORTED_HISTORY"
+
+// maxImportErrors caps the errors listed in a report.
+const maxImportErrors = 500
+
+// ImportRowError is one problem found in the file (row 1 is the header).
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport summarises an import or a dry run.
+type ImportReport struct {
+	Kind              string           `json:"kind"`
+	DryRun            bool             `json:"dry_run"`
+	Committed         bool             `json:"committed"`
+	Rows              int              `json:"rows"`
+	Created           int              `json:"created"`
+	Updated           int              `json:"updated"`
+	ApplicantsCreated int              `json:"applicants_created,omitempty"`
+	TimelineRows      int              `json:"timeline_rows,omitempty"`
+	Errors            []ImportRowError `json:"errors"`
+}
+
+func (r *ImportReport) addError(row int, field, msg string) {
+	if len(r.Errors) < maxImportErrors {
+		r.Errors = append(r.Errors, ImportRowError{Row: row, Field: field, Message: msg})
+	}
+}
+
+// errImportRollback aborts the transaction of a dry run or an invalid file.
+var errImportRollback = errors.New("import rolled back")
+
+// importRow gives access to one CSV record by field name.
+type importRow struct {
+	rec []string
+	col map[string]int
+}
+
+func (r importRow) get(field string) string {
+	if i, ok := r.col[field]; ok && i < len(r.rec) {
+		return strings.TrimSpace(r.rec[i])
+	}
+	return ""
+}
+
+// importList splits "a|b|c" (also ; or newline) into a JSON array string.
+func importList(v string) string {
+	if v == "" {
+		return ""
+	}
+	parts := strings.FieldsFunc(v, func(r rune) bool { return r == '|' || r == ';' || r == '\n' })
+	out := []string{}
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return utils.SkillListJSON(out)
+}
+
+// importDate accepts YYYY-MM-DD, DD/MM/YYYY and RFC3339.
+func importDate(v string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "02/01/2006", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD)", v)
+}
+
+// ImportCSV loads jobs ("jobs") or historical applications ("applications")
+// from CSV. Everything happens in one transaction: if any row is invalid the
+// whole file is rolled back, and a dry run always rolls back after reporting.
+// The returned error is for problems with the file itself or the database;
+// row problems are listed in the report.
+func ImportCSV(db *gorm.DB, kind string, r io.Reader, mapping map[string]string, dryRun bool) (*ImportReport, error) {
+	var fields, required []string
+	switch kind {
+	case "jobs":
+		fields, required = ImportJobFields, []string{"title"}
+	case "applications":
+		fields, required = ImportApplicationFields, []string{"email"}
+	default:
+		return nil, fmt.Errorf("unknown import kind %q (jobs|applications)", kind)
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CSV header: %w", err)
+	}
+	headerIdx := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		headerIdx[h] = i
+	}
+
+	known := map[string]bool{}
+	for _, f := range fields {
+		known[f] = true
+	}
+	col := map[string]int{}
+	for _, f := range fields {
+		name := f
+		if m, ok := mapping[f]; ok && m != "" {
+			name = m
+		}
+		if i, ok := headerIdx[strings.ToLower(strings.TrimSpace(name))]; ok {
+			col[f] = i
+		} else if _, mapped := mapping[f]; mapped {
+			return nil, fmt.Errorf("mapped column %q for %s not found in header", name, f)
+		}
+	}
+	for f := range mapping {
+		if !known[f] {
+			return nil, fmt.Errorf("unknown field %q in mapping", f)
+		}
+	}
+	for _, f := range required {
+		if _, ok := col[f]; !ok {
+			return nil, fmt.Errorf("required column %q is missing", f)
+		}
+	}
+	if kind == "applications" {
+		_, byExt := col["job_external_id"]
+		_, byID := col["job_id"]
+		if !byExt && !byID {
+			return nil, errors.New("applications need a job_external_id or job_id column")
+		}
+	}
+
+	report := &ImportReport{Kind: kind, DryRun: dryRun, Errors: []ImportRowError{}}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		im := &importer{tx: tx, report: report, tax: loadSkillTaxonomy(tx), users: map[string]string{}, jobs: map[string]models.JobPosting{}}
+		line := 1
+		for {
+			rec, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			line++
+			if err != nil {
+				report.addError(line, "", err.Error())
+				continue
+			}
+			report.Rows++
+			row := importRow{rec: rec, col: col}
+			if kind == "jobs" {
+				err = im.job(line, row)
+			} else {
+				err = im.application(line, row)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if dryRun || len(report.Errors) > 0 {
+			return errImportRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRollback) {
+		return report, err
+	}
+	report.Committed = err == nil
+	return report, nil
+}
+
+// importer holds per-run caches; methods return an error only for database
+// failures, row problems go to the report.
+type importer struct {
+	tx     *gorm.DB
+	report *ImportReport
+	tax    *utils.SkillTaxonomy
+	users  map[string]string            // lower(email) -> user id
+	jobs   map[string]models.JobPosting // id / "ext:"+external id -> job
+}
+
+func (im *importer) job(line int, row importRow) error {
+	title := row.get("title")
+	if title == "" {
+		im.report.addError(line, "title", "title is required")
+		return nil
+	}
+
+	var job models.JobPosting
+	ext := row.get("external_id")
+	found := false
+	if ext != "" {
+		res := im.tx.Where("external_id = ?", ext).Limit(1).Find(&job)
+		if res.Error != nil {
+			return res.Error
+		}
+		found = res.RowsAffected > 0
+	}
+	if !found {
+		job = models.JobPosting{ID: uuid.NewString(), ExternalID: ext, Status: "active", PostedDate: time.Now()}
+	}
+
+	job.Title = title
+	set := func(field string, dst *string, conv func(string) string) {
+		if v := row.get(field); v != "" {
+			if conv != nil {
+				v = conv(v)
+			}
+			*dst = v
+		}
+	}
+	set("department", &job.Department, nil)
+	set("location", &job.Location, nil)
+	set("experience_level", &job.ExperienceLevel, nil)
+	set("description", &job.Description, nil)
+	set("status", &job.Status, strings.ToLower)
+	set("requirements", &job.Requirements, importList)
+	set("responsibilities", &job.Responsibilities, importList)
+	set("must_have_skills", &job.MustHaveSkills, func(v string) string { return normalizeSkillsJSON(im.tax, importList(v)) })
+	set("nice_to_have_skills", &job.NiceToHaveSkills, func(v string) string { return normalizeSkillsJSON(im.tax, importList(v)) })
+	if v := row.get("min_experience_years"); v != "" {
+		var years float64
+		if _, err := fmt.Sscanf(v, "%g", &years); err != nil || years < 0 {
+			im.report.addError(line, "min_experience_years", "must be a non-negative number")
+			return nil
+		}
+		job.MinExperienceYears = years
+	}
+	for field, dst := range map[string]*time.Time{"posted_date": &job.PostedDate, "closing_date": &job.ClosingDate} {
+		if v := row.get(field); v != "" {
+			t, err := importDate(v)
+			if err != nil {
+				im.report.addError(line, field, err.Error())
+				return nil
+			}
+			*dst = t
+		}
+	}
+
+	services.PrepareJob(&job)
+	if err := im.tx.Save(&job).Error; err != nil {
+		return err
+	}
+	if found {
+		im.report.Updated++
+	} else {
+		im.report.Created++
+	}
+	return nil
+}
+
+// findJob resolves the job of an application row by external id or id.
+func (im *importer) findJob(row importRow) (models.JobPosting, bool, error) {
+	key, q := "", im.tx
+	if ext := row.get("job_external_id"); ext != "" {
+		key, q = "ext:"+ext, q.Where("external_id = ?", ext)
+	} else if id := row.get("job_id"); id != "" {
+		key, q = id, q.Where("id = ?", id)
+	} else {
+		return models.JobPosting{}, false, nil
+	}
+	if j, ok := im.jobs[key]; ok {
+		return j, true, nil
+	}
+	var job models.JobPosting
+	res := q.Limit(1).Find(&job)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return job, false, res.Error
+	}
+	im.jobs[key] = job
+	return job, true, nil
+}
+
+// applicant returns the user id for an email, creating a candidate account
+// (without a usable password) the first time the email is seen.
+func (im *importer) applicant(email, name, phone string) (string, error) {
+	key := strings.ToLower(email)
+	if id, ok := im.users[key]; ok {
+		return id, nil
+	}
+	var u models.User
+	res := im.tx.Where("LOWER(email) = ?", key).Limit(1).Find(&u)
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 0 {
+		hash, err := utils.HashPassword(uuid.NewString())
+		if err != nil {
+			return "", err
+		}
+		u = models.User{ID: uuid.NewString(), Email: key, Password: hash, Role: "candidate", Name: name, Phone: phone, PhoneKey: utils.NormalizePhone(phone)}
+		if err := im.tx.Create(&u).Error; err != nil {
+			return "", err
+		}
+		im.report.ApplicantsCreated++
+	}
+	im.users[key] = u.ID
+	return u.ID, nil
+}
+
+type historyEntry struct {
+	status string
+	date   time.Time
+}
+
+func (im *importer) application(line int, row importRow) error {
+	email := row.get("email")
+	if email == "" || !strings.Contains(email, "@") {
+		im.report.addError(line, "email", "a valid email is required")
+		return nil
+	}
+	job, ok, err := im.findJob(row)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		im.report.addError(line, "job_external_id", "job not found")
+		return nil
+	}
+
+	// history dates, oldest first
+	history := []historyEntry{}
+	for _, st := range historyStatuses {
+		v := row.get(st + "_date")
+		if v == "" {
+			continue
+		}
+		t, err := importDate(v)
+		if err != nil {
+			im.report.addError(line, st+"_date", err.Error())
+			return nil
+		}
+		history = append(history, historyEntry{st, t})
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].date.Before(history[j].date) })
+
+	status := strings.ToLower(row.get("status"))
+	if status == "" {
+		status = "submitted"
+		if len(history) > 0 {
+			status = history[len(history)-1].status
+		}
+	}
+	valid := false
+	for _, st := range append(historyStatuses, "withdrawn") {
+		valid = valid || st == status
+	}
+	if !valid {
+		im.report.addError(line, "status", "unknown status "+status)
+		return nil
+	}
+
+	applicantID, err := im.applicant(email, row.get("name"), row.get("phone"))
+	if err != nil {
+		return err
+	}
+
+	// upsert: external id first, then the same applicant + job, so a file
+	// with external ids also updates applications made before it
+	var app models.Application
+	found := false
+	if ext := row.get("external_id"); ext != "" {
+		res := im.tx.Where("external_id = ?", ext).Limit(1).Find(&app)
+		if res.Error != nil {
+			return res.Error
+		}
+		found = res.RowsAffected > 0
+	}
+	if !found {
+		res := im.tx.Where("applicant_id = ? AND job_id = ?", applicantID, job.ID).Limit(1).Find(&app)
+		if res.Error != nil {
+			return res.Error
+		}
+		found = res.RowsAffected > 0
+	}
+	if !found {
+		app = models.Application{ID: uuid.NewString(), SubmittedDate: time.Now()}
+	}
+	if ext := row.get("external_id"); ext != "" {
+		app.ExternalID = ext
+	}
+	app.JobID = job.ID
+	app.ApplicantID = applicantID
+	app.Status = status
+	for field, dst := range map[string]*string{"resume": &app.Resume, "cover_letter": &app.CoverLetter, "education": &app.Education, "experience": &app.Experience} {
+		if v := row.get(field); v != "" {
+			*dst = v
+		}
+	}
+	if v := row.get("skills"); v != "" {
+		app.Skills = importList(v)
+	}
+	if len(history) > 0 {
+		app.SubmittedDate = history[0].date
+	}
+	applyMatchScore(im.tax, &app, job)
+	if err := im.tx.Save(&app).Error; err != nil {
+		return err
+	}
+
+	// history rows are replaced on re-import so running a file twice is safe
+	if err := im.tx.Where("application_id = ? AND (description_key = ? OR description = ?)", app.ID, importHistoryNote, i18n.T(i18n.Default, importHistoryNote, nil)).Delete(&models.ApplicationTimeline{}).Error; err != nil {
+		return err
+	}
+	if len(history) == 0 && !found {
+		history = append(history, historyEntry{app.Status, app.SubmittedDate})
+	}
+	for _, h := range history {
+		tl := timelineEntry(app.ID, h.status, h.date, i18n.M(importHistoryNote))
+		if err := im.tx.Create(&tl).Error; err != nil {
+			return err
+		}
+		im.report.TimelineRows++
+	}
+
+	if found {
+		im.report.Updated++
+	} else {
+		im.report.Created++
+	}
+	return nil
+}
+
+// importFromRequest handles POST /api/import/:kind. multipart form: file
+// (CSV), mapping (JSON object field -> CSV header), dry_run=true|false.
+func importFromRequest(c *gin.Context, kind string) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "file"})
+		return
+	}
+	mapping := map[string]string{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			respond.Error(c, http.StatusBadRequest, "IMPORT_MAPPING_INVALID")
+			return
+		}
+	}
+	dryRun := c.DefaultPostForm("dry_run", c.Query("dry_run"))
+	f, err := fh.Open()
+	if err != nil {
+		respond.Error(c, http.StatusBadRequest, "FILE_UNREADABLE")
+		return
+	}
+	defer f.Close()
+
+	report, err := ImportCSV(models.DB, kind, f, mapping, dryRun == "1" || dryRun == "true")
+	if err != nil {
+		if report == nil {
+			respond.Error(c, http.StatusBadRequest, "IMPORT_FILE_INVALID", gin.H{"reason": err.Error()})
+		} else {
+			respond.Error(c, http.StatusInternalServerError, "IMPORT_FAILED", gin.H{"report": report})
+		}
+		return
+	}
+	if !report.DryRun && !report.Committed {
+		respond.Error(c, http.StatusUnprocessableEntity, "IMPORT_ROWS_INVALID", gin.H{"report": report})
+		return
+	}
+	respond.OK(c, gin.H{"report": report})
+}
+
+// POST /api/import/jobs (HR)
+func ImportJobs(c *gin.Context) { importFromRequest(c, "jobs") }
+
+// POST /api/import/applications (HR)
+func ImportApplications(c *gin.Context) { importFromRequest(c, "applications") }
+
+// GET /api/import/fields (HR) — fields available for column mapping
+func ImportFields(c *gin.Context) {
+	respond.OK(c, gin.H{"jobs": ImportJobFields, "applications": ImportApplicationFields})
+}
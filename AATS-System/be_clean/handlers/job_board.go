@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/services"
+	"aats-backend-clean/utils"
+)
+
+// Job board sort orders. Both are keyset paginated on (key, id).
+const (
+	JobSortNewest  = "newest"  // posted_date desc
+	JobSortClosing = "closing" // closing_date asc, open-ended jobs last
+)
+
+// openEndedClosing stands in for a zero closing date when sorting by
+// closing date, so open-ended jobs come after every real deadline.
+var openEndedClosing = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// BackfillJobBoard prepares jobs saved before the job board existed. main
+// runs it once at startup; jobs saved since get their slug and search text
+// from services.PrepareJob.
+func BackfillJobBoard(db *gorm.DB) error {
+	for {
+		var jobs []models.JobPosting
+		if err := db.Where("slug IS NULL OR slug = '' OR search_text IS NULL OR search_text = ''").Limit(500).Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		for i := range jobs {
+			services.PrepareJob(&jobs[i])
+			if err := db.Model(&models.JobPosting{}).Where("id = ?", jobs[i].ID).
+				UpdateColumns(map[string]interface{}{"slug": jobs[i].Slug, "search_text": jobs[i].SearchText}).Error; err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// publishedJobs limits a query to jobs visible on the public board: active
+// and not past their closing date (a zero closing date never closes).
+func publishedJobs(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Model(&models.JobPosting{}).
+		Where("status = ?", "active").
+		Where("(closing_date > ? OR closing_date = ?)", now, time.Time{})
+}
+
+// careersJobURL is the public page of a job on the careers site, when
+// CAREERS_BASE_URL is set.
+func careersJobURL(slug string) string {
+	base := strings.TrimRight(os.Getenv("CAREERS_BASE_URL"), "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/jobs/" + slug
+}
+
+// publicJobView is the shape of a job on the public board; internal fields
+// (created_by, external ids, search text) are left out.
+func publicJobView(j models.JobPosting) gin.H {
+	var closing *time.Time
+	if !j.ClosingDate.IsZero() {
+		closing = &j.ClosingDate
+	}
+	return gin.H{
+		"id": j.ID, "slug": j.Slug, "url": careersJobURL(j.Slug),
+		"title": j.Title, "department": j.Department, "location": j.Location, "experience_level": j.ExperienceLevel,
+		"description":          j.Description,
+		"requirements":         utils.ParseSkillList(j.Requirements),
+		"responsibilities":     utils.ParseSkillList(j.Responsibilities),
+		"must_have_skills":     utils.ParseSkillList(j.MustHaveSkills),
+		"nice_to_have_skills":  utils.ParseSkillList(j.NiceToHaveSkills),
+		"min_experience_years": j.MinExperienceYears,
+		"posted_date":          j.PostedDate,
+		"closing_date":         closing,
+	}
+}
+
+// companyName is the hiring organization shown on the job board and in
+// feeds (COMPANY_NAME).
+func companyName() string {
+	if org := os.Getenv("COMPANY_NAME"); org != "" {
+		return org
+	}
+	return "AATS"
+}
+
+// jobPostingInfo collects what the JSON-LD and the feeds publish about a job.
+func jobPostingInfo(j models.JobPosting) utils.JobPostingInfo {
+	return utils.JobPostingInfo{
+		ID:                 j.ID,
+		Title:              j.Title,
+		Description:        j.Description,
+		Requirements:       utils.ParseSkillList(j.Requirements),
+		Responsibilities:   utils.ParseSkillList(j.Responsibilities),
+		Skills:             append(utils.ParseSkillList(j.MustHaveSkills), utils.ParseSkillList(j.NiceToHaveSkills)...),
+		Department:         j.Department,
+		Location:           j.Location,
+		ExperienceLevel:    j.ExperienceLevel,
+		MinExperienceYears: j.MinExperienceYears,
+		PostedDate:         j.PostedDate,
+		ClosingDate:        j.ClosingDate,
+		URL:                careersJobURL(j.Slug),
+		OrganizationName:   companyName(),
+		OrganizationURL:    os.Getenv("COMPANY_URL"),
+		Country:            "TH",
+	}
+}
+
+// jobPostingLD builds the schema.org markup of a job.
+func jobPostingLD(j models.JobPosting) map[string]interface{} {
+	return utils.JobPostingJSONLD(jobPostingInfo(j))
+}
+
+// splitFilter reads a comma separated, case-insensitive filter value.
+func splitFilter(v string) []string {
+	out := []string{}
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// filterPublicJobs applies the job board filters: q (every term must appear
+// in the title, description, skills…; "quoted phrases" stay together),
+// department and experience_level (comma separated, exact), location
+// (substring).
+//
+// q is a case-insensitive substring match on search_text, not a ranked
+// full-text search: there is no stemming and results keep the board's sort.
+// Word-based search (tsvector, FTS5) cannot split Thai, which is written
+// without spaces. On Postgres a trigram index keeps the LIKE fast; SQLite
+// scans the published jobs.
+func filterPublicJobs(c *gin.Context, q *gorm.DB) *gorm.DB {
+	for _, term := range utils.SearchTerms(c.Query("q")) {
+		term = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+		q = q.Where(`search_text LIKE ? ESCAPE '\'`, "%"+term+"%")
+	}
+	if deps := splitFilter(c.Query("department")); len(deps) > 0 {
+		q = q.Where("LOWER(department) IN ?", deps)
+	}
+	if levels := splitFilter(c.Query("experience_level")); len(levels) > 0 {
+		q = q.Where("LOWER(experience_level) IN ?", levels)
+	}
+	if loc := strings.ToLower(strings.TrimSpace(c.Query("location"))); loc != "" {
+		q = q.Where("LOWER(location) LIKE ?", "%"+loc+"%")
+	}
+	return q
+}
+
+// GET /api/public/jobs?q=&department=&location=&experience_level=&sort=newest|closing&limit=&cursor=
+// Published jobs only; pass next_cursor back as cursor for the next page.
+func PublicListJobs(c *gin.Context) {
+	sort := c.DefaultQuery("sort", JobSortNewest)
+	if sort != JobSortNewest && sort != JobSortClosing {
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "sort", "allowed": "newest, closing"})
+		return
+	}
+	_, limit, _ := utils.ParsePagination(c, 1, 20, 50, "limit")
+
+	q := filterPublicJobs(c, publishedJobs(models.DB, time.Now()))
+	var total int64
+	q.Session(&gorm.Session{}).Count(&total)
+
+	// open-ended jobs sort as if they closed at the end of time
+	closingKey := "CASE WHEN closing_date = ? THEN ? ELSE closing_date END"
+	if cur := c.Query("cursor"); cur != "" {
+		pos, err := utils.DecodeCursor(cur)
+		if err != nil || pos.Sort != sort {
+			respond.Error(c, http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "cursor"})
+			return
+		}
+		if sort == JobSortNewest {
+			q = q.Where("(posted_date < ? OR (posted_date = ? AND id < ?))", pos.Key, pos.Key, pos.ID)
+		} else {
+			q = q.Where("("+closingKey+" > ? OR ("+closingKey+" = ? AND id > ?))",
+				time.Time{}, openEndedClosing, pos.Key, time.Time{}, openEndedClosing, pos.Key, pos.ID)
+		}
+	}
+	if sort == JobSortNewest {
+		q = q.Order("posted_date desc, id desc")
+	} else {
+		q = q.Order(clause.OrderBy{Expression: clause.Expr{SQL: closingKey + " asc, id asc", Vars: []interface{}{time.Time{}, openEndedClosing}, WithoutParentheses: true}})
+	}
+
+	var jobs []models.JobPosting
+	if err := q.Limit(limit + 1).Find(&jobs).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	next := ""
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+		last := jobs[limit-1]
+		key := last.PostedDate
+		if sort == JobSortClosing {
+			key = last.ClosingDate
+			if key.IsZero() {
+				key = openEndedClosing
+			}
+		}
+		next = utils.EncodeCursor(utils.JobCursor{Sort: sort, Key: key, ID: last.ID})
+	}
+	out := make([]gin.H, 0, len(jobs))
+	for _, j := range jobs {
+		out = append(out, publicJobView(j))
+	}
+	respond.Page(c, gin.H{"jobs": out}, gin.H{"total": total, "next_cursor": next})
+}
+
+// GET /api/public/jobs/:slug — a published job by slug (or id), with its
+// schema.org JobPosting markup in json_ld
+func PublicGetJob(c *gin.Context) {
+	var job models.JobPosting
+	key := c.Param("slug")
+	if err := publishedJobs(models.DB, time.Now()).Where("slug = ? OR id = ?", key, key).First(&job).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "JOB_NOT_FOUND")
+		return
+	}
+	view := publicJobView(job)
+	view["custom_fields"] = customFieldsOf(models.DB, CustomEntityJob, job.ID, false)
+	respond.OK(c, gin.H{"job": view, "json_ld": jobPostingLD(job)})
+}
+
+// GET /api/public/job-facets — filter values with the number of published
+// jobs for each, for building the job board filters
+func PublicJobFacets(c *gin.Context) {
+	type facet struct {
+		Value string `json:"value"`
+		Count int64  `json:"count"`
+	}
+	now := time.Now()
+	out := gin.H{}
+	for _, col := range []string{"department", "location", "experience_level"} {
+		list := []facet{}
+		publishedJobs(models.DB, now).Select(col + " AS value, COUNT(*) AS count").
+			Where(col + " <> ''").Group(col).Order("count desc, value asc").Scan(&list)
+		out[col+"s"] = list
+	}
+	respond.OK(c, out)
+}
@@ -1,13 +1,20 @@
 package handlers // แพ็กเกจ handlers สำหรับจัดการ API endpoint
 
 import (
+	"log"           // สำหรับบันทึก error ของ webhook
 	"net/http"      // สำหรับ HTTP status และ response
+	"strings"       // สำหรับตรวจ prefix ของ sort
 	"time"          // สำหรับจัดการวันที่
 
 	"github.com/gin-gonic/gin" // Gin framework สำหรับสร้าง API
 	"github.com/google/uuid"   // สำหรับสร้าง UUID
+	"gorm.io/gorm"             // สำหรับ transaction
 
 	"aats-backend-clean/models" // import models สำหรับเชื่อมต่อ DB
+	"aats-backend-clean/utils"  // import utils สำหรับ normalize ทักษะ
+	"aats-backend-clean/respond"
+	"aats-backend-clean/services" // สำหรับอ่าน/แก้ไขงานผ่าน service
+	"aats-backend-clean/store"    // สำหรับ filter และ transaction
 )
 
 // โครงสร้างข้อมูลสำหรับรับ request ในการสร้าง/แก้ไขงาน
@@ -16,41 +23,81 @@ type CreateJobBody struct {
 	Department       string `json:"department"`                         // แผนก
 	Location         string `json:"location"`                           // สถานที่ทำงาน
 	ExperienceLevel  string `json:"experience_level"`                   // ระดับประสบการณ์
+	EmploymentType   string `json:"employment_type"`                    // full-time|part-time|contract|internship
 	Description      string `json:"description"`                        // รายละเอียดงาน
 	Requirements     string `json:"requirements"`                       // คุณสมบัติ
 	Responsibilities string `json:"responsibilities"`                   // หน้าที่รับผิดชอบ
 	Status           string `json:"status"`       // สถานะงาน (active/closed/draft)
 	ClosingDate      string `json:"closing_date"` // วันปิดรับสมัคร (ISO date)
+	MustHaveSkills     []string `json:"must_have_skills"`     // ทักษะที่ต้องมี (normalize ตาม taxonomy)
+	NiceToHaveSkills   []string `json:"nice_to_have_skills"`  // ทักษะที่มีแล้วได้เปรียบ
+	MinExperienceYears *float64 `json:"min_experience_years"` // ประสบการณ์ขั้นต่ำ (ปี), 0 = ใช้ตาม experience_level; ไม่ส่ง = คงค่าเดิม
+	CustomFields map[string]interface{} `json:"custom_fields"` // ฟิลด์ที่ HR กำหนดเอง (ดู /api/custom-fields?entity=job)
+}
+
+// JobWithFields งานพร้อมค่า custom fields สำหรับส่งกลับ
+type JobWithFields struct {
+	models.JobPosting
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+// withCustomFields แนบค่า custom fields ให้รายการงาน (โหลดครั้งเดียวทั้งหน้า)
+func withCustomFields(jobs []models.JobPosting, hrView bool) []JobWithFields {
+	ids := make([]string, 0, len(jobs))
+	for _, j := range jobs {
+		ids = append(ids, j.ID)
+	}
+	values := loadCustomFields(models.DB, CustomEntityJob, ids, hrView)
+	out := make([]JobWithFields, 0, len(jobs))
+	for _, j := range jobs {
+		cf := values[j.ID]
+		if cf == nil {
+			cf = map[string]interface{}{}
+		}
+		out = append(out, JobWithFields{JobPosting: j, CustomFields: cf})
+	}
+	return out
 }
 
 // ฟังก์ชันสำหรับดึงรายการงานทั้งหมด (GET /api/jobs)
 func ListJobs(c *gin.Context) {
-	status := c.Query("status") // รับ query string status
-	var jobs []models.JobPosting // สร้าง slice สำหรับเก็บผลลัพธ์
-	q := models.DB.Order("posted_date desc") // query เรียงตามวันที่โพสต์
-	if status != "" {
-		q = q.Where("status = ?", status) // ถ้ามี status filter ให้กรอง
-	}
-	if err := q.Find(&jobs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch jobs"}) // error กรณี query ไม่สำเร็จ
+	hrView := c.GetString("user_role") == "hr" || c.GetString("user_role") == "hm" // HR-only fields ให้เห็นเฉพาะ staff
+	f := store.JobFilter{
+		EmploymentType: c.Query("employment_type"),                // กรองตามประเภทการจ้าง
+		Custom:         customMatches(c, CustomEntityJob, hrView), // cf.<key>=value, cf.<key>.min / .max
+	}
+	if status := c.Query("status"); status != "" {
+		f.Statuses = []string{status} // ถ้ามี status filter ให้กรอง
+	}
+	if !hrView {
+		f.ExcludeStatus = "draft" // งานร่างให้เห็นเฉพาะ staff (หน้าเว็บสาธารณะใช้ /api/public/jobs)
+	}
+	if sort := c.Query("sort"); strings.HasPrefix(sort, "cf.") {
+		// sort=cf.<key>&order=asc|desc เรียงตาม custom field (ค่าว่างอยู่ท้ายเสมอ)
+		f.CustomOrder = customFieldOrder(CustomEntityJob, sort, c.Query("order") != "asc", hrView)
+	}
+	jobs, _, err := svc().Jobs.List(f, store.Page{}) // เรียงตามวันที่โพสต์ ล่าสุดก่อน
+	if err != nil {
+		fail(c, err) // error กรณี query ไม่สำเร็จ
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"ok": true, "jobs": jobs}) // ส่ง jobs กลับแบบ JSON
+	respond.OK(c, gin.H{"jobs": withCustomFields(jobs, hrView)}) // ส่ง jobs กลับแบบ JSON
 }
 
 // ฟังก์ชันสำหรับดึงรายละเอียดงานตาม id (GET /api/jobs/:id)
 func GetJob(c *gin.Context) {
 	id := c.Param("id") // รับ id จาก path
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"}) // error ถ้าไม่มี id
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "id"}) // error ถ้าไม่มี id
 		return
 	}
-	var job models.JobPosting
-	if err := models.DB.Where("id = ?", id).First(&job).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"}) // error ถ้าไม่พบงาน
+	hrView := c.GetString("user_role") == "hr" || c.GetString("user_role") == "hm"
+	job, err := svc().Jobs.Get(id, hrView) // งานร่างไม่เปิดให้คนนอกเห็น
+	if err != nil {
+		fail(c, err) // JOB_NOT_FOUND ถ้าไม่พบงาน
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"ok": true, "job": job}) // ส่งข้อมูลงานกลับ
+	respond.OK(c, gin.H{"job": withCustomFields([]models.JobPosting{job}, hrView)[0]}) // ส่งข้อมูลงานกลับ
 }
 
 // ฟังก์ชันสำหรับสร้างงานใหม่ (POST /api/jobs)
@@ -58,7 +105,7 @@ func GetJob(c *gin.Context) {
 func CreateJob(c *gin.Context) {
 	var body CreateJobBody
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"}) // error ถ้า body ไม่ถูกต้อง
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY") // error ถ้า body ไม่ถูกต้อง
 		return
 	}
 	uid, _ := c.Get("user_id") // ดึง user_id จาก context (middleware ใส่ไว้)
@@ -70,6 +117,15 @@ func CreateJob(c *gin.Context) {
 		}
 	}
 
+	// ตรวจ custom fields ก่อนบันทึก (ฟิลด์ required ต้องมีค่า)
+	customWrites, fieldErrs := validateCustomFields(models.DB, CustomEntityJob, body.CustomFields, true, false)
+	if fieldErrs != nil {
+		respond.Error(c, http.StatusBadRequest, "CUSTOM_FIELDS_INVALID", gin.H{"fields": fieldErrs})
+		return
+	}
+
+	tax := loadSkillTaxonomy(models.DB) // ใช้ normalize ทักษะของงาน
+
 	// สร้าง struct JobPosting สำหรับบันทึกลง DB
 	job := models.JobPosting{
 		ID:               uuid.NewString(), // สร้าง id ใหม่
@@ -77,9 +133,12 @@ func CreateJob(c *gin.Context) {
 		Department:       body.Department,
 		Location:         body.Location,
 		ExperienceLevel:  body.ExperienceLevel,
+		EmploymentType:   body.EmploymentType,
 		Description:      body.Description,
 		Requirements:     body.Requirements,
 		Responsibilities: body.Responsibilities,
+		MustHaveSkills:   utils.SkillListJSON(tax.Normalize(body.MustHaveSkills)),
+		NiceToHaveSkills: utils.SkillListJSON(tax.Normalize(body.NiceToHaveSkills)),
 		Status:           body.Status,
 		PostedDate:       time.Now(),
 		ClosingDate:      closing,
@@ -87,11 +146,26 @@ func CreateJob(c *gin.Context) {
 		CreatedAt:        time.Now(),
 	}
 
-	if err := models.DB.Create(&job).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot create job"}) // error ถ้าบันทึกไม่สำเร็จ
+	if body.MinExperienceYears != nil {
+		job.MinExperienceYears = *body.MinExperienceYears
+	}
+	services.PrepareJob(&job) // slug และข้อความสำหรับค้นหาบน job board
+
+	// งานกับค่า custom fields บันทึกพร้อมกันหรือไม่บันทึกเลย
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		return writeCustomFields(tx, job.ID, customWrites, job.CreatedBy)
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR") // error ถ้าบันทึกไม่สำเร็จ
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"ok": true, "job": job}) // ส่ง job ที่สร้างกลับ
+	if err := emitJobEvent(models.DB, WebhookJobCreated, job, ""); err != nil {
+		log.Printf("webhook job.created %s: %v", job.ID, err) // ไม่ให้ webhook ทำให้การสร้างงานล้มเหลว
+	}
+	respond.Created(c, gin.H{"job": withCustomFields([]models.JobPosting{job}, true)[0]}) // ส่ง job ที่สร้างกลับ
 }
 
 // ฟังก์ชันสำหรับแก้ไขงาน (PUT /api/jobs/:id)
@@ -99,58 +173,85 @@ func CreateJob(c *gin.Context) {
 func UpdateJob(c *gin.Context) {
 	id := c.Param("id") // รับ id จาก path
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"}) // error ถ้าไม่มี id
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "id"}) // error ถ้าไม่มี id
 		return
 	}
-	var job models.JobPosting
-	if err := models.DB.Where("id = ?", id).First(&job).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"}) // error ถ้าไม่พบงาน
+	if _, err := svc().Jobs.Get(id, true); err != nil {
+		fail(c, err) // JOB_NOT_FOUND ถ้าไม่พบงาน
 		return
 	}
 
 	var body CreateJobBody
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"}) // error ถ้า body ไม่ถูกต้อง
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY") // error ถ้า body ไม่ถูกต้อง
 		return
 	}
 
-	// อัปเดต field ที่มีข้อมูลใหม่
-	if body.Title != "" {
-		job.Title = body.Title
+	// custom fields ที่ส่งมาเท่านั้นที่ถูกแก้ (null = ลบค่า)
+	customWrites, fieldErrs := validateCustomFields(models.DB, CustomEntityJob, body.CustomFields, false, false)
+	if fieldErrs != nil {
+		respond.Error(c, http.StatusBadRequest, "CUSTOM_FIELDS_INVALID", gin.H{"fields": fieldErrs})
+		return
 	}
-	if body.Department != "" {
-		job.Department = body.Department
+
+	// อัปเดต field ที่มีข้อมูลใหม่ (ค่าว่าง = คงค่าเดิม)
+	ch := services.JobChanges{
+		Title: changed(body.Title), Department: changed(body.Department), Location: changed(body.Location),
+		ExperienceLevel: changed(body.ExperienceLevel), EmploymentType: changed(body.EmploymentType),
+		Description: changed(body.Description), Requirements: changed(body.Requirements),
+		Responsibilities: changed(body.Responsibilities), Status: changed(body.Status),
 	}
-	if body.Location != "" {
-		job.Location = body.Location
+	if body.ClosingDate != "" {
+		if t, err := time.Parse(time.RFC3339, body.ClosingDate); err == nil {
+			ch.ClosingDate = &t
+		}
 	}
-	if body.ExperienceLevel != "" {
-		job.ExperienceLevel = body.ExperienceLevel
+	// ถ้ามีการส่งทักษะมา ให้ normalize แล้วคำนวณคะแนน match ของใบสมัครใหม่
+	if body.MustHaveSkills != nil || body.NiceToHaveSkills != nil {
+		tax := loadSkillTaxonomy(models.DB)
+		if body.MustHaveSkills != nil {
+			ch.MustHaveSkills = changed(utils.SkillListJSON(tax.Normalize(body.MustHaveSkills)))
+		}
+		if body.NiceToHaveSkills != nil {
+			ch.NiceToHaveSkills = changed(utils.SkillListJSON(tax.Normalize(body.NiceToHaveSkills)))
+		}
 	}
-	if body.Description != "" {
-		job.Description = body.Description
+	// ส่ง 0 มา = กลับไปใช้ค่าตาม experience_level
+	if body.MinExperienceYears != nil && *body.MinExperienceYears < 0 {
+		respond.Error(c, http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "min_experience_years"})
+		return
 	}
-	if body.Requirements != "" {
-		job.Requirements = body.Requirements
+	ch.MinExperienceYears = body.MinExperienceYears
+
+	uid, _ := c.Get("user_id")
+	updatedBy, _ := uid.(string)
+	var job, previous models.JobPosting // previous.Status ใช้ตัดสินว่าเป็น job.updated หรือ job.status.<status>
+	err := data().Transaction(func(tx store.Store) error {
+		var err error
+		if job, previous, err = svc().Jobs.On(tx).Update(id, ch); err != nil {
+			return err
+		}
+		return writeCustomFields(dataDB(tx), job.ID, customWrites, updatedBy)
+	})
+	if err != nil {
+		fail(c, err) // error ถ้า save ไม่สำเร็จ
+		return
 	}
-	if body.Responsibilities != "" {
-		job.Responsibilities = body.Responsibilities
+	if ch.Rescore() {
+		_, _ = recomputeMatchScores(models.DB, job.ID) // best-effort
 	}
-	if body.Status != "" {
-		job.Status = body.Status
+	if err := emitJobEvent(models.DB, WebhookJobUpdated, job, previous.Status); err != nil {
+		log.Printf("webhook job.updated %s: %v", job.ID, err)
 	}
-	if body.ClosingDate != "" {
-		if t, err := time.Parse(time.RFC3339, body.ClosingDate); err == nil {
-			job.ClosingDate = t
-		}
-	}
-	job.UpdatedAt = time.Now() // อัปเดตเวลาล่าสุด
+	respond.OK(c, gin.H{"job": withCustomFields([]models.JobPosting{job}, true)[0]}) // ส่ง job ที่อัปเดตกลับ
+}
 
-	if err := models.DB.Save(&job).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot update job"}) // error ถ้า save ไม่สำเร็จ
-		return
+// changed is v as a JobChanges field: an empty string keeps the old value.
+func changed(v string) *string {
+	if v == "" {
+		return nil
 	}
-	c.JSON(http.StatusOK, gin.H{"ok": true, "job": job}) // ส่ง job ที่อัปเดตกลับ
+	return &v
 }
 
 // ฟังก์ชันสำหรับลบงาน (DELETE /api/jobs/:id)
@@ -158,12 +259,26 @@ func UpdateJob(c *gin.Context) {
 func DeleteJob(c *gin.Context) {
 	id := c.Param("id") // รับ id จาก path
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"}) // error ถ้าไม่มี id
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "id"}) // error ถ้าไม่มี id
 		return
 	}
-	if err := models.DB.Where("id = ?", id).Delete(&models.JobPosting{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot delete job"}) // error ถ้าลบไม่สำเร็จ
+	var job models.JobPosting
+	if err := models.DB.Where("id = ?", id).First(&job).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "JOB_NOT_FOUND") // โหลดงานก่อนลบ เพื่อส่งข้อมูลไปกับ webhook
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"ok": true}) // ส่ง ok กลับเมื่อสำเร็จ
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).Delete(&models.JobPosting{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("entity_id = ? AND field_id IN (?)", id, tx.Model(&models.CustomField{}).Select("id").Where("entity = ?", CustomEntityJob)).Delete(&models.CustomFieldValue{}).Error // ลบค่า custom fields ของงาน
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR") // error ถ้าลบไม่สำเร็จ
+		return
+	}
+	if err := emitJobEvent(models.DB, WebhookJobDeleted, job, job.Status); err != nil {
+		log.Printf("webhook job.deleted %s: %v", job.ID, err)
+	}
+	respond.OK(c, gin.H{}) // ส่ง ok กลับเมื่อสำเร็จ
 }
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/services"
+)
+
+// PreferredLanguage is the language userID chose, if any; wired into
+// respond.UserLanguage by main.
+func PreferredLanguage(userID string) (i18n.Lang, bool) {
+	var u models.User
+	if models.DB.Select("id, language").Where("id = ?", userID).Limit(1).Find(&u).RowsAffected == 0 {
+		return "", false
+	}
+	return i18n.Parse(u.Language)
+}
+
+// userLanguage is the language to write stored text in for userID, who is
+// not the one making the request.
+func userLanguage(db *gorm.DB, userID string) i18n.Lang {
+	var u models.User
+	if db.Select("id, language").Where("id = ?", userID).Limit(1).Find(&u).RowsAffected > 0 {
+		if l, ok := i18n.Parse(u.Language); ok {
+			return l
+		}
+	}
+	return i18n.Default
+}
+
+// timelineEntry is a timeline row for app (services.TimelineEntry); readers
+// see the description in their language (localizeTimeline).
+func timelineEntry(appID, status string, at time.Time, desc i18n.Msg) models.ApplicationTimeline {
+	return services.TimelineEntry(appID, status, at, desc)
+}
+
+// localizeTimeline rewrites keyed descriptions in lang.
+func localizeTimeline(lang i18n.Lang, tls []models.ApplicationTimeline) {
+	for i := range tls {
+		if tls[i].DescriptionKey != "" {
+			tls[i].Description = i18n.Decode(tls[i].DescriptionKey, tls[i].DescriptionParams, tls[i].Description).In(lang)
+		}
+	}
+}
+
+// localizeNotifications rewrites keyed titles and messages in lang.
+func localizeNotifications(lang i18n.Lang, ns []models.Notification) {
+	for i := range ns {
+		if ns[i].TitleKey != "" {
+			ns[i].Title = i18n.Decode(ns[i].TitleKey, ns[i].Params, ns[i].Title).In(lang)
+		}
+		if ns[i].MessageKey != "" {
+			ns[i].Message = i18n.Decode(ns[i].MessageKey, ns[i].Params, ns[i].Message).In(lang)
+		}
+	}
+}
+
+// PUT /api/me/language — {"language": "th"|"en"|""}; "" goes back to
+// following the browser's Accept-Language
+func UpdateMyLanguage(c *gin.Context) {
+	var body struct {
+		Language *string `json:"language"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Language == nil {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "language"})
+		return
+	}
+	lang := ""
+	if strings.TrimSpace(*body.Language) != "" {
+		l, ok := i18n.Parse(*body.Language)
+		if !ok {
+			respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "language", "allowed": "en, th"})
+			return
+		}
+		lang = string(l)
+	}
+	uid, _ := c.Get("user_id")
+	if err := models.DB.Model(&models.User{}).Where("id = ?", uid).Update("language", lang).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"language": lang})
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/middleware"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/services"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+)
+
+// RegisterLegacyRoutes serves the API of the retired be_clean service, for
+// the old frontend (fe/): same paths, bodies and response shapes, backed by
+// the same store and rules as /api. main serves it on LEGACY_PORT.
+//
+// be_clean's "applied" status is "submitted" here; jobs show their
+// employment type as "type"; a 1-10 evaluation score is stored as an overall
+// score of score/2.
+func RegisterLegacyRoutes(r gin.IRouter) {
+	health := func(c *gin.Context) { respond.OK(c, gin.H{"status": "ok"}) }
+	r.GET("/health", health)
+	r.GET("/healthz", health)
+
+	auth := middleware.AuthMiddleware()
+	api := r.Group("/api")
+	api.POST("/auth/register", legacyRegister)
+	api.POST("/auth/login", legacyLogin)
+	api.GET("/auth/me", auth, legacyMe)
+	api.GET("/users", auth, middleware.RequireRoles("hr", "hm"), legacyListUsers)
+	api.GET("/jobs", legacyListJobs)
+	api.GET("/jobs/:id", legacyGetJob)
+	api.GET("/mock/jobs", mockFile("mock_jobs.json"))
+	api.GET("/mock/applications", mockFile("mock_applications.json"))
+	api.POST("/applications", auth, legacyApply)
+	api.GET("/applications/my", auth, legacyMyApplications)
+	api.PUT("/applications/:id/status", auth, middleware.RequireRoles("hr"), legacyChangeStatus)
+	api.POST("/applications/:id/notes", auth, middleware.RequireRoles("hr", "hm"), legacyAddNote)
+	api.POST("/applications/:id/evaluations", auth, middleware.RequireRoles("hm"), legacyAddEvaluation)
+}
+
+// legacyStatuses maps be_clean statuses to ours.
+var legacyStatuses = map[string]string{
+	"applied":   "submitted",
+	"screening": "screening",
+	"interview": "interview",
+	"offer":     "offer",
+	"rejected":  "rejected",
+}
+
+func legacyStatus(status string) string {
+	if status == "submitted" {
+		return "applied"
+	}
+	return status
+}
+
+// legacyList reads a requirements/responsibilities column (JSON array or
+// plain text) as the list be_clean clients expect.
+func legacyList(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return []string{}
+	}
+	var list []string
+	if json.Unmarshal([]byte(s), &list) == nil {
+		return list
+	}
+	return []string{s}
+}
+
+func legacyJob(j models.JobPosting) gin.H {
+	return gin.H{
+		"id":               j.ID,
+		"title":            j.Title,
+		"description":      j.Description,
+		"location":         j.Location,
+		"type":             j.EmploymentType,
+		"department":       j.Department,
+		"experienceLevel":  j.ExperienceLevel,
+		"requirements":     legacyList(j.Requirements),
+		"responsibilities": legacyList(j.Responsibilities),
+		"status":           j.Status,
+		"closing_date":     j.ClosingDate,
+		"created_at":       j.PostedDate,
+		"updated_at":       j.UpdatedAt,
+	}
+}
+
+func legacyApplication(a models.Application) gin.H {
+	return gin.H{
+		"id":         a.ID,
+		"user_id":    a.ApplicantID,
+		"job_id":     a.JobID,
+		"resume_url": a.Resume,
+		"status":     legacyStatus(a.Status),
+		"created_at": a.CreatedAt,
+		"updated_at": a.UpdatedAt,
+	}
+}
+
+// currentUser is the signed-in account.
+func currentUser(c *gin.Context) (models.User, *respond.Problem) {
+	u, err := svc().Auth.User(c.GetString("user_id"))
+	if err != nil {
+		p := toProblem(err)
+		if p.Code == "USER_NOT_FOUND" {
+			p.Status = http.StatusUnauthorized // the token outlived its account
+		}
+		return u, p
+	}
+	return u, nil
+}
+
+// POST /api/auth/register {email, password, name} → 201 {token, user}
+func legacyRegister(c *gin.Context) {
+	var body struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,min=6"`
+		Name     string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	auth := svc().Auth
+	u, err := auth.Register(services.Registration{Email: body.Email, Password: body.Password, Name: body.Name, Role: "candidate"})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	detectDuplicates(models.DB, u.ID)
+	token, err := auth.Token(u)
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Created(c, gin.H{"token": token, "user": gin.H{"email": u.Email, "name": u.Name}})
+}
+
+// POST /api/auth/login {email, password} → {token, user}
+func legacyLogin(c *gin.Context) {
+	var body loginBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	u, token, err := svc().Auth.Login(body.Email, body.Password)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond.OK(c, gin.H{"token": token, "user": gin.H{"email": u.Email, "name": u.Name}})
+}
+
+// GET /api/auth/me → {email, name, role}
+func legacyMe(c *gin.Context) {
+	u, p := currentUser(c)
+	if p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	respond.OK(c, gin.H{"email": u.Email, "name": u.Name, "role": u.Role})
+}
+
+// GET /api/users?q=&page=&limit= (HR, HM)
+func legacyListUsers(c *gin.Context) {
+	page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+	users, total, err := data().Users().List(c.Query("q"), store.Page{Offset: offset, Limit: limit})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(users))
+	for _, u := range users {
+		out = append(out, gin.H{"id": u.ID, "email": u.Email, "name": u.Name, "role": u.Role, "created_at": u.CreatedAt, "updated_at": u.UpdatedAt})
+	}
+	respond.Page(c, out, gin.H{"total": total, "page": page, "limit": limit})
+}
+
+// GET /api/jobs?q=&location=&type=&page=&pageSize= (drafts are hidden)
+func legacyListJobs(c *gin.Context) {
+	page, pageSize, offset := utils.ParsePagination(c, 1, 20, 100, "pageSize")
+	f := store.JobFilter{Query: c.Query("q"), Location: c.Query("location"), EmploymentType: c.Query("type"), ExcludeStatus: "draft"}
+	jobs, total, err := svc().Jobs.List(f, store.Page{Offset: offset, Limit: pageSize})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(jobs))
+	for _, j := range jobs {
+		out = append(out, legacyJob(j))
+	}
+	respond.Page(c, out, gin.H{"total": total, "page": page, "pageSize": pageSize})
+}
+
+// GET /api/jobs/:id
+func legacyGetJob(c *gin.Context) {
+	j, err := svc().Jobs.Get(c.Param("id"), false)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond.OK(c, legacyJob(j))
+}
+
+// mockFile serves public/<name>, sample data for frontend development.
+func mockFile(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, err := os.ReadFile(filepath.Join("public", name))
+		if err != nil {
+			respond.Error(c, http.StatusNotFound, "MOCK_DATA_NOT_FOUND")
+			return
+		}
+		c.Data(http.StatusOK, "application/json", raw)
+	}
+}
+
+// POST /api/applications {jobId, resumeUrl} — goes through the same policy,
+// screening and timeline as POST /api/applications on the main API.
+func legacyApply(c *gin.Context) {
+	var body struct {
+		JobID     string `json:"jobId" binding:"required,uuid"`
+		ResumeURL string `json:"resumeUrl" binding:"required,url"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	app, _, p := submitApplication(c, CreateApplicationBody{JobID: body.JobID, ResumeURL: body.ResumeURL})
+	if p != nil {
+		if p.Code == "APPLICATION_ALREADY_ACTIVE" {
+			p.Status = http.StatusConflict // be_clean answered a repeat application with 409
+		}
+		respond.Fail(c, p)
+		return
+	}
+	respond.Created(c, legacyApplication(app))
+}
+
+// GET /api/applications/my
+func legacyMyApplications(c *gin.Context) {
+	apps, err := data().Applications().ForApplicant(c.GetString("user_id"))
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(apps))
+	for _, a := range apps {
+		out = append(out, legacyApplication(a))
+	}
+	respond.OK(c, out)
+}
+
+// PUT /api/applications/:id/status {status, note} (HR)
+func legacyChangeStatus(c *gin.Context) {
+	var body struct {
+		Status string `json:"status" binding:"required"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	status, ok := legacyStatuses[body.Status]
+	if !ok {
+		respond.Error(c, http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "status"})
+		return
+	}
+	change, err := changeStatus(c.Param("id"), status, i18n.Text(body.Note))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond.OK(c, legacyApplication(change.Application))
+}
+
+// POST /api/applications/:id/notes {body} (HR, HM) — a hiring team note
+func legacyAddNote(c *gin.Context) {
+	var body struct {
+		Body string `json:"body" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if _, err := data().Applications().ByID(c.Param("id")); err != nil {
+		respond.Error(c, http.StatusNotFound, "APPLICATION_NOT_FOUND")
+		return
+	}
+	u, p := currentUser(c)
+	if p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	n := models.Note{
+		ID:            uuid.NewString(),
+		ApplicationID: c.Param("id"),
+		Author:        u.Name,
+		CreatedBy:     u.ID,
+		Content:       body.Body,
+		Visibility:    NoteVisibilityHiringTeam,
+		Mentions:      "[]",
+		CreatedAt:     time.Now(),
+	}
+	if err := data().Notes().Create(&n); err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Created(c, gin.H{"id": n.ID, "application_id": n.ApplicationID, "actor": u.Email, "body": n.Content, "created_at": n.CreatedAt})
+}
+
+// POST /api/applications/:id/evaluations {score 1-10, feedback} (HM) —
+// sets the overall score and comments of the application's evaluation,
+// keeping any per-criterion scores.
+func legacyAddEvaluation(c *gin.Context) {
+	var body struct {
+		Score    int    `json:"score" binding:"required,min=1,max=10"`
+		Feedback string `json:"feedback"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	u, p := currentUser(c)
+	if p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	ev, err := svc().Evaluations.Rate(c.Param("id"), u, float32(body.Score)/2, body.Feedback)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond.Created(c, gin.H{"id": ev.ID, "application_id": ev.ApplicationID, "evaluator": u.Email, "score": body.Score, "feedback": ev.Comments, "created_at": ev.EvaluatedAt})
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+)
+
+// MessageTemplateBody request body for message templates
+type MessageTemplateBody struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// notifyUser stores an in-app notification for a user. Title and message
+// are written in the user's language and shown later in the reader's.
+func notifyUser(db *gorm.DB, userID, typ string, title, message i18n.Msg, payload map[string]string) error {
+	if userID == "" {
+		return nil
+	}
+	raw, _ := json.Marshal(payload)
+	lang := userLanguage(db, userID)
+	n := models.Notification{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		Type:       typ,
+		Title:      title.In(lang),
+		Message:    message.In(lang),
+		TitleKey:   title.Key,
+		MessageKey: message.Key,
+		Params:     i18n.M("", title.Params, message.Params).EncodeParams(),
+		Payload:    string(raw),
+		CreatedAt:  time.Now(),
+	}
+	return db.Create(&n).Error
+}
+
+// templateVars are the placeholders available to message templates.
+func templateVars(db *gorm.DB, app models.Application) map[string]string {
+	vars := map[string]string{"status": app.Status, "application_id": app.ID}
+	var u models.User
+	if app.ApplicantID != "" && db.Where("id = ?", app.ApplicantID).Limit(1).Find(&u).RowsAffected > 0 {
+		vars["name"] = u.Name
+		vars["email"] = u.Email
+	}
+	var j models.JobPosting
+	if app.JobID != "" && db.Where("id = ?", app.JobID).Limit(1).Find(&j).RowsAffected > 0 {
+		vars["job_title"] = j.Title
+		vars["department"] = j.Department
+	}
+	return vars
+}
+
+// sendTemplatedMessage renders subject/body for an application and delivers
+// it to the applicant as a notification. Returns the rendered subject.
+func sendTemplatedMessage(db *gorm.DB, app models.Application, subject, body string) (string, error) {
+	vars := templateVars(db, app)
+	s := utils.RenderTemplate(subject, vars)
+	b := utils.RenderTemplate(body, vars)
+	return s, notifyUser(db, app.ApplicantID, "message", i18n.Text(s), i18n.Text(b), map[string]string{"application_id": app.ID, "job_id": app.JobID})
+}
+
+// GET /api/message-templates (HR)
+func ListMessageTemplates(c *gin.Context) {
+	var tpls []models.MessageTemplate
+	if err := models.DB.Order("name asc").Find(&tpls).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"templates": tpls})
+}
+
+// POST /api/message-templates (HR)
+func CreateMessageTemplate(c *gin.Context) {
+	var body MessageTemplateBody
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Name) == "" || strings.TrimSpace(body.Body) == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELDS_REQUIRED", gin.H{"fields": "name, body"})
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	tpl := models.MessageTemplate{
+		ID:        uuid.NewString(),
+		Name:      strings.TrimSpace(body.Name),
+		Subject:   body.Subject,
+		Body:      body.Body,
+		CreatedBy: createdBy,
+	}
+	if err := models.DB.Create(&tpl).Error; err != nil {
+		respond.Error(c, http.StatusConflict, "TEMPLATE_NAME_TAKEN")
+		return
+	}
+	respond.Created(c, gin.H{"template": tpl})
+}
+
+// PUT /api/message-templates/:id (HR)
+func UpdateMessageTemplate(c *gin.Context) {
+	var tpl models.MessageTemplate
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&tpl).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "TEMPLATE_NOT_FOUND")
+		return
+	}
+	var body MessageTemplateBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if strings.TrimSpace(body.Name) != "" {
+		tpl.Name = strings.TrimSpace(body.Name)
+	}
+	if body.Subject != "" {
+		tpl.Subject = body.Subject
+	}
+	if body.Body != "" {
+		tpl.Body = body.Body
+	}
+	if err := models.DB.Save(&tpl).Error; err != nil {
+		respond.Error(c, http.StatusConflict, "TEMPLATE_NAME_TAKEN")
+		return
+	}
+	respond.OK(c, gin.H{"template": tpl})
+}
+
+// DELETE /api/message-templates/:id (HR)
+func DeleteMessageTemplate(c *gin.Context) {
+	if err := models.DB.Where("id = ?", c.Param("id")).Delete(&models.MessageTemplate{}).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{})
+}
@@ -1,46 +1,254 @@
 ﻿package handlers // แพ็กเกจ handlers สำหรับจัดการ API endpoint
 
 import (
-	"net/http"      // สำหรับ HTTP status และ response
-	"time"          // สำหรับจัดการวันที่
+	"encoding/json"  // สำหรับเก็บรายการ mention
+	"mime/multipart" // สำหรับไฟล์แนบ
+	"net/http"       // สำหรับ HTTP status และ response
+	"os"             // สำหรับจัดการไฟล์และโฟลเดอร์
+	"path/filepath"  // สำหรับจัดการ path ของไฟล์
+	"strings"        // สำหรับจัดการข้อความ
+	"time"           // สำหรับจัดการวันที่
 
 	"github.com/gin-gonic/gin" // Gin framework สำหรับสร้าง API
 	"github.com/google/uuid"   // สำหรับสร้าง UUID
 
-	"aats-backend-clean/models" // import models สำหรับเชื่อมต่อ DB
-	"gorm.io/gorm"              // สำหรับ session DB
+	"aats-backend-clean/models"   // import models สำหรับเชื่อมต่อ DB
+	"aats-backend-clean/utils"    // import utils สำหรับ pagination และ mention
+	"gorm.io/gorm"                // สำหรับ session DB
 	glogger "gorm.io/gorm/logger" // สำหรับ silent logger
+	"aats-backend-clean/respond"
+	"aats-backend-clean/i18n"
 )
 
-// โครงสร้างข้อมูลสำหรับรับ request เพิ่มโน้ต
+// ระดับการมองเห็นของโน้ต
+const (
+	NoteVisibilityHR         = "hr"          // เฉพาะ HR
+	NoteVisibilityHiringTeam = "hiring_team" // HR และ hiring manager (ค่าเริ่มต้น)
+	NoteVisibilityPrivate    = "private"     // เฉพาะผู้เขียน
+)
+
+// ขนาดไฟล์แนบสูงสุดต่อไฟล์
+const noteAttachmentMaxBytes = 10 << 20
+
+// โครงสร้างข้อมูลสำหรับรับ request เพิ่มโน้ต (JSON หรือ multipart พร้อมไฟล์ใน field "attachments")
 type NoteBody struct {
-	Content string `json:"content" binding:"required"` // เนื้อหาของโน้ต
-	Author  string `json:"author"`                     // ผู้เขียนโน้ต (ถ้าไม่ใส่จะใช้ชื่อผู้ใช้)
+	Content    string `json:"content" form:"content" binding:"required"` // เนื้อหาของโน้ต ใช้ @email เพื่อ mention
+	Author     string `json:"author" form:"author"`                      // ผู้เขียนโน้ต (ถ้าไม่ใส่จะใช้ชื่อผู้ใช้)
+	Visibility string `json:"visibility" form:"visibility"`              // hr|hiring_team|private
+	ParentID   string `json:"parent_id" form:"parent_id"`                // ตอบกลับโน้ตนี้
+}
+
+// โครงสร้างข้อมูลสำหรับแก้ไขโน้ต (PUT /api/notes/:id)
+type UpdateNoteBody struct {
+	Content    *string `json:"content"`
+	Visibility *string `json:"visibility"` // เปลี่ยนได้เฉพาะโน้ตหลัก การตอบกลับใช้ตามโน้ตหลัก
+}
+
+// validNoteVisibility ตรวจค่าระดับการมองเห็น
+func validNoteVisibility(v string) bool {
+	return v == NoteVisibilityHR || v == NoteVisibilityHiringTeam || v == NoteVisibilityPrivate
+}
+
+// roleSeesNotes บอกว่า role นี้เห็นโน้ตระดับ vis ของคนอื่นหรือไม่
+// ผู้สมัครและพนักงานทั่วไปไม่เห็นโน้ตเลย
+func roleSeesNotes(role interface{}, vis string) bool {
+	if vis == "" {
+		vis = NoteVisibilityHiringTeam // โน้ตเก่าก่อนมีระดับการมองเห็น
+	}
+	switch role {
+	case "hr":
+		return vis != NoteVisibilityPrivate
+	case "hm":
+		return vis == NoteVisibilityHiringTeam
+	}
+	return false
+}
+
+// noteVisibleTo บอกว่าผู้ใช้เห็นโน้ตนี้หรือไม่
+func noteVisibleTo(n models.Note, uid string, role interface{}) bool {
+	return n.CreatedBy == uid || roleSeesNotes(role, n.Visibility)
+}
+
+// visibleNotes จำกัด query ให้เหลือเฉพาะโน้ตที่ผู้ใช้ใน context เห็นได้
+func visibleNotes(c *gin.Context, db *gorm.DB) *gorm.DB {
+	uidv, _ := c.Get("user_id")
+	uid, _ := uidv.(string)
+	role, _ := c.Get("user_role")
+	switch role {
+	case "hr":
+		return db.Where("(COALESCE(visibility, '') <> ? OR created_by = ?)", NoteVisibilityPrivate, uid)
+	case "hm":
+		return db.Where("(COALESCE(visibility, '') IN ? OR created_by = ?)", []string{"", NoteVisibilityHiringTeam}, uid)
+	}
+	return db.Where("1 = 0")
+}
+
+// resolveMentions หา user ที่ถูก @mention และเห็นโน้ตระดับ vis ได้
+// คืน id ที่จะแจ้งเตือน และ email ที่แจ้งไม่ได้ (ไม่พบ หรือไม่มีสิทธิ์เห็นโน้ต)
+func resolveMentions(db *gorm.DB, content, vis, authorID string) ([]string, []string) {
+	emails := utils.ParseMentions(content)
+	ids, skipped := []string{}, []string{}
+	if len(emails) == 0 {
+		return ids, skipped
+	}
+	var users []models.User
+	db.Select("id, email, role").Where("LOWER(email) IN ?", emails).Find(&users)
+	byEmail := map[string]models.User{}
+	for _, u := range users {
+		byEmail[strings.ToLower(u.Email)] = u
+	}
+	for _, e := range emails {
+		u, ok := byEmail[e]
+		switch {
+		case ok && u.ID == authorID:
+		case ok && roleSeesNotes(u.Role, vis):
+			ids = append(ids, u.ID)
+		default:
+			skipped = append(skipped, e)
+		}
+	}
+	return ids, skipped
+}
+
+// notifyMentions แจ้งเตือนผู้ถูก mention
+func notifyMentions(db *gorm.DB, n models.Note, ids []string) {
+	for _, id := range ids {
+		notifyUser(db, id, "mention", i18n.M("notifications.MENTION_TITLE", map[string]any{"author": n.Author}), i18n.Text(clip(n.Content, 200)), map[string]string{"application_id": n.ApplicationID, "note_id": n.ID})
+	}
+}
+
+// saveNoteAttachment บันทึกไฟล์แนบลง uploads/notes (ไม่เปิดเป็น public ต้องดาวน์โหลดผ่าน API)
+func saveNoteAttachment(c *gin.Context, db *gorm.DB, noteID string, file *multipart.FileHeader, uploadedBy string) (models.NoteAttachment, error) {
+	destDir := filepath.Join(".", "uploads", "notes")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return models.NoteAttachment{}, err
+	}
+	att := models.NoteAttachment{
+		ID:          uuid.NewString(),
+		NoteID:      noteID,
+		FileName:    filepath.Base(file.Filename),
+		ContentType: file.Header.Get("Content-Type"),
+		Size:        file.Size,
+		UploadedBy:  uploadedBy,
+	}
+	att.StoredName = att.ID + filepath.Ext(att.FileName)
+	fullpath := filepath.Join(destDir, att.StoredName)
+	if err := c.SaveUploadedFile(file, fullpath); err != nil {
+		return att, err
+	}
+	if err := db.Create(&att).Error; err != nil {
+		os.Remove(fullpath)
+		return att, err
+	}
+	return att, nil
+}
+
+// snapshotNoteRevision เก็บเนื้อหาปัจจุบันของโน้ตไว้ก่อนแก้ไขหรือลบ
+func snapshotNoteRevision(tx *gorm.DB, n models.Note, action, by string) error {
+	var last models.NoteRevision
+	tx.Where("note_id = ?", n.ID).Order("version desc").Limit(1).Find(&last)
+	rev := models.NoteRevision{
+		ID:         uuid.NewString(),
+		NoteID:     n.ID,
+		Version:    last.Version + 1,
+		Action:     action,
+		Content:    n.Content,
+		Visibility: n.Visibility,
+		EditedBy:   by,
+	}
+	return tx.Create(&rev).Error
+}
+
+// noteView คือรูปแบบโน้ตที่ส่งกลับ
+func noteView(n models.Note, atts []models.NoteAttachment) gin.H {
+	var mentions []string
+	_ = json.Unmarshal([]byte(n.Mentions), &mentions)
+	if n.Visibility == "" {
+		n.Visibility = NoteVisibilityHiringTeam
+	}
+	if atts == nil {
+		atts = []models.NoteAttachment{}
+	}
+	return gin.H{
+		"ID": n.ID, "ApplicationID": n.ApplicationID, "ParentID": n.ParentID, "Author": n.Author, "CreatedBy": n.CreatedBy,
+		"Content": n.Content, "Visibility": n.Visibility, "Mentions": mentions, "EditedAt": n.EditedAt, "CreatedAt": n.CreatedAt,
+		"Attachments": atts,
+	}
+}
+
+// noteAttachments โหลดไฟล์แนบของโน้ตหลายรายการ
+func noteAttachments(db *gorm.DB, noteIDs []string) map[string][]models.NoteAttachment {
+	out := map[string][]models.NoteAttachment{}
+	if len(noteIDs) == 0 {
+		return out
+	}
+	var rows []models.NoteAttachment
+	db.Where("note_id IN ?", noteIDs).Order("created_at asc").Find(&rows)
+	for _, a := range rows {
+		out[a.NoteID] = append(out[a.NoteID], a)
+	}
+	return out
 }
 
 // ฟังก์ชันสำหรับเพิ่มโน้ตในใบสมัคร (POST /api/applications/:id/notes)
-// ใช้โดย HR/HM
+// ใช้โดย HR/HM รับ JSON หรือ multipart/form-data (ไฟล์แนบใน field "attachments")
 func CreateNote(c *gin.Context) {
 	appID := c.Param("id") // รับ id ของใบสมัครจาก path
 	var body NoteBody
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"}) // error ถ้า body ไม่ถูกต้อง
+	if err := c.ShouldBind(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY") // error ถ้า body ไม่ถูกต้อง
 		return
 	}
 	var app models.Application
 	if appID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing application id"}) // error ถ้าไม่มี id
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "id"}) // error ถ้าไม่มี id
 		return
 	}
 	if err := models.DB.Where("id = ?", appID).First(&app).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "application not found"}) // error ถ้าไม่พบ application
+		respond.Error(c, http.StatusNotFound, "APPLICATION_NOT_FOUND") // error ถ้าไม่พบ application
 		return
 	}
 	uid, _ := c.Get("user_id") // ดึง user_id จาก context
 	if uid == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"}) // error ถ้าไม่ได้ login
+		respond.Error(c, http.StatusUnauthorized, "UNAUTHENTICATED") // error ถ้าไม่ได้ login
 		return
 	}
+	role, _ := c.Get("user_role")
+
+	// การตอบกลับจะผูกกับโน้ตหลักเสมอ (thread เดียวชั้นเดียว) และใช้ระดับการมองเห็นของโน้ตหลัก
+	visibility := body.Visibility
+	parentID := ""
+	if body.ParentID != "" {
+		var parent models.Note
+		if err := models.DB.Where("id = ? AND application_id = ?", body.ParentID, appID).First(&parent).Error; err != nil || !noteVisibleTo(parent, uid.(string), role) {
+			respond.Error(c, http.StatusNotFound, "PARENT_NOTE_NOT_FOUND")
+			return
+		}
+		parentID = parent.ID
+		if parent.ParentID != "" {
+			parentID = parent.ParentID
+		}
+		visibility = parent.Visibility
+	}
+	if visibility == "" {
+		visibility = NoteVisibilityHiringTeam
+	}
+	if !validNoteVisibility(visibility) {
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "visibility", "allowed": "hr, hiring_team, private"})
+		return
+	}
+
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		files = form.File["attachments"]
+	}
+	for _, f := range files {
+		if f.Size > noteAttachmentMaxBytes {
+			respond.Error(c, http.StatusBadRequest, "ATTACHMENT_TOO_LARGE", gin.H{"file": f.Filename})
+			return
+		}
+	}
+
 	author := body.Author
 	// ถ้าไม่ได้ส่งชื่อผู้เขียนมา ให้ใช้ชื่อผู้ใช้
 	var user models.User
@@ -50,33 +258,271 @@ func CreateNote(c *gin.Context) {
 			author = user.Name
 		}
 	}
+	mentionIDs, skipped := resolveMentions(models.DB, body.Content, visibility, uid.(string))
+	rawMentions, _ := json.Marshal(mentionIDs)
+
 	// สร้าง struct Note สำหรับบันทึกลง DB
 	note := models.Note{
 		ID:            uuid.NewString(),
 		ApplicationID: appID,
+		ParentID:      parentID,
 		Author:        author,
 		CreatedBy:     uid.(string),
 		Content:       body.Content,
+		Visibility:    visibility,
+		Mentions:      string(rawMentions),
 		CreatedAt:     time.Now(),
 	}
-	if err := models.DB.Create(&note).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot create note"}) // error ถ้าบันทึกไม่สำเร็จ
+	var atts []models.NoteAttachment
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		for _, f := range files {
+			att, err := saveNoteAttachment(c, tx, note.ID, f, note.CreatedBy)
+			if err != nil {
+				return err
+			}
+			atts = append(atts, att)
+		}
+		return nil
+	})
+	if err != nil {
+		for _, a := range atts {
+			os.Remove(filepath.Join(".", "uploads", "notes", a.StoredName))
+		}
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR") // error ถ้าบันทึกไม่สำเร็จ
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"ok": true, "note": note}) // ส่งข้อมูลโน้ตกลับ
+	notifyMentions(models.DB, note, mentionIDs)
+	respond.Created(c, gin.H{"note": noteView(note, atts), "mentions_skipped": skipped}) // ส่งข้อมูลโน้ตกลับ
 }
 
-// ฟังก์ชันสำหรับดึงรายการโน้ตของใบสมัคร (GET /api/applications/:id/notes)
+// ฟังก์ชันสำหรับดึงรายการโน้ตของใบสมัคร (GET /api/applications/:id/notes?page=&limit=)
+// แบ่งหน้าตามโน้ตหลัก (ใหม่สุดก่อน) แต่ละโน้ตมี replies เรียงจากเก่าไปใหม่
 func ListNotes(c *gin.Context) {
 	appID := c.Param("id") // รับ id ของใบสมัครจาก path
 	if appID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing application id"}) // error ถ้าไม่มี id
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "id"}) // error ถ้าไม่มี id
 		return
 	}
+	page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+	q := visibleNotes(c, models.DB.Model(&models.Note{})).Where("application_id = ? AND COALESCE(parent_id, '') = ''", appID)
+	var total int64
+	q.Count(&total)
 	var notes []models.Note
-	if err := models.DB.Where("application_id = ?", appID).Order("created_at desc").Find(&notes).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch notes"}) // error ถ้าดึงข้อมูลไม่สำเร็จ
+	if err := q.Order("created_at desc").Offset(offset).Limit(limit).Find(&notes).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR") // error ถ้าดึงข้อมูลไม่สำเร็จ
+		return
+	}
+	ids := make([]string, 0, len(notes))
+	for _, n := range notes {
+		ids = append(ids, n.ID)
+	}
+	var replies []models.Note
+	if len(ids) > 0 {
+		visibleNotes(c, models.DB).Where("parent_id IN ?", ids).Order("created_at asc").Find(&replies)
+	}
+	allIDs := ids
+	for _, r := range replies {
+		allIDs = append(allIDs, r.ID)
+	}
+	atts := noteAttachments(models.DB, allIDs)
+	byParent := map[string][]gin.H{}
+	for _, r := range replies {
+		byParent[r.ParentID] = append(byParent[r.ParentID], noteView(r, atts[r.ID]))
+	}
+	out := make([]gin.H, 0, len(notes))
+	for _, n := range notes {
+		v := noteView(n, atts[n.ID])
+		v["Replies"] = []gin.H{}
+		if r, ok := byParent[n.ID]; ok {
+			v["Replies"] = r
+		}
+		out = append(out, v)
+	}
+	respond.Page(c, gin.H{"notes": out}, gin.H{"page": page, "limit": limit, "total": total}) // ส่งรายการโน้ตกลับ
+}
+
+// loadVisibleNote โหลดโน้ต :id ที่ผู้ใช้เห็นได้
+func loadVisibleNote(c *gin.Context) (models.Note, bool) {
+	var n models.Note
+	uid, _ := c.Get("user_id")
+	role, _ := c.Get("user_role")
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&n).Error; err != nil || !noteVisibleTo(n, uid.(string), role) {
+		respond.Error(c, http.StatusNotFound, "NOTE_NOT_FOUND")
+		return n, false
+	}
+	return n, true
+}
+
+// ฟังก์ชันสำหรับแก้ไขโน้ต (PUT /api/notes/:id) เฉพาะผู้เขียน
+// เนื้อหาเดิมถูกเก็บเป็น revision และแจ้งเตือนเฉพาะคนที่ถูก mention เพิ่ม
+func UpdateNote(c *gin.Context) {
+	var body UpdateNoteBody
+	if err := c.ShouldBindJSON(&body); err != nil || (body.Content == nil && body.Visibility == nil) {
+		respond.Error(c, http.StatusBadRequest, "NOTHING_TO_UPDATE")
+		return
+	}
+	n, ok := loadVisibleNote(c)
+	if !ok {
+		return
+	}
+	uid, _ := c.Get("user_id")
+	if n.CreatedBy != uid.(string) {
+		respond.Error(c, http.StatusForbidden, "NOTE_AUTHOR_ONLY")
+		return
+	}
+	prev := n
+	if body.Content != nil {
+		if strings.TrimSpace(*body.Content) == "" {
+			respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "content"})
+			return
+		}
+		n.Content = *body.Content
+	}
+	if body.Visibility != nil && *body.Visibility != n.Visibility {
+		if n.ParentID != "" {
+			respond.Error(c, http.StatusBadRequest, "NOTE_REPLY_VISIBILITY")
+			return
+		}
+		if !validNoteVisibility(*body.Visibility) {
+			respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "visibility", "allowed": "hr, hiring_team, private"})
+			return
+		}
+		n.Visibility = *body.Visibility
+	}
+
+	var already []string
+	_ = json.Unmarshal([]byte(n.Mentions), &already)
+	mentionIDs, skipped := resolveMentions(models.DB, n.Content, n.Visibility, n.CreatedBy)
+	notified := map[string]bool{}
+	for _, id := range already {
+		notified[id] = true
+	}
+	newMentions := []string{}
+	for _, id := range mentionIDs {
+		if !notified[id] {
+			newMentions = append(newMentions, id)
+		}
+	}
+	rawMentions, _ := json.Marshal(mentionIDs)
+	n.Mentions = string(rawMentions)
+	now := time.Now()
+	n.EditedAt = &now
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := snapshotNoteRevision(tx, prev, "edit", n.CreatedBy); err != nil {
+			return err
+		}
+		if err := tx.Save(&n).Error; err != nil {
+			return err
+		}
+		if n.Visibility != prev.Visibility {
+			return tx.Model(&models.Note{}).Where("parent_id = ?", n.ID).Update("visibility", n.Visibility).Error
+		}
+		return nil
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	notifyMentions(models.DB, n, newMentions)
+	respond.OK(c, gin.H{"note": noteView(n, noteAttachments(models.DB, []string{n.ID})[n.ID]), "mentions_skipped": skipped})
+}
+
+// ฟังก์ชันสำหรับลบโน้ต (DELETE /api/notes/:id) ผู้เขียนหรือ HR
+// ลบแบบ soft delete พร้อม replies และเก็บเนื้อหาไว้ใน revision
+func DeleteNote(c *gin.Context) {
+	n, ok := loadVisibleNote(c)
+	if !ok {
+		return
+	}
+	uid, _ := c.Get("user_id")
+	role, _ := c.Get("user_role")
+	if n.CreatedBy != uid.(string) && role != "hr" {
+		respond.Error(c, http.StatusForbidden, "NOTE_DELETE_FORBIDDEN")
+		return
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		var thread []models.Note
+		tx.Where("id = ? OR parent_id = ?", n.ID, n.ID).Find(&thread)
+		for _, t := range thread {
+			if err := snapshotNoteRevision(tx, t, "delete", uid.(string)); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Note{}).Where("id = ? OR parent_id = ?", n.ID, n.ID).Update("deleted_by", uid.(string)).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? OR parent_id = ?", n.ID, n.ID).Delete(&models.Note{}).Error
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{})
+}
+
+// ฟังก์ชันสำหรับดูประวัติการแก้ไขโน้ต (GET /api/notes/:id/revisions)
+// โน้ตที่ลบแล้วยังดูประวัติได้
+func ListNoteRevisions(c *gin.Context) {
+	var n models.Note
+	uid, _ := c.Get("user_id")
+	role, _ := c.Get("user_role")
+	if err := models.DB.Unscoped().Where("id = ?", c.Param("id")).First(&n).Error; err != nil || !noteVisibleTo(n, uid.(string), role) {
+		respond.Error(c, http.StatusNotFound, "NOTE_NOT_FOUND")
+		return
+	}
+	var revs []models.NoteRevision
+	if err := models.DB.Where("note_id = ?", n.ID).Order("version asc").Find(&revs).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	current := noteView(n, nil)
+	current["Deleted"] = n.DeletedAt.Valid
+	respond.OK(c, gin.H{"note": current, "revisions": revs})
+}
+
+// ฟังก์ชันสำหรับแนบไฟล์เพิ่มในโน้ต (POST /api/notes/:id/attachments) field "file" เฉพาะผู้เขียน
+func AddNoteAttachment(c *gin.Context) {
+	n, ok := loadVisibleNote(c)
+	if !ok {
+		return
+	}
+	uid, _ := c.Get("user_id")
+	if n.CreatedBy != uid.(string) {
+		respond.Error(c, http.StatusForbidden, "NOTE_AUTHOR_ONLY")
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "file"})
+		return
+	}
+	if file.Size > noteAttachmentMaxBytes {
+		respond.Error(c, http.StatusBadRequest, "ATTACHMENT_TOO_LARGE", gin.H{"file": file.Filename})
+		return
+	}
+	att, err := saveNoteAttachment(c, models.DB, n.ID, file, n.CreatedBy)
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Created(c, gin.H{"attachment": att})
+}
+
+// ฟังก์ชันสำหรับดาวน์โหลดไฟล์แนบ (GET /api/notes/:id/attachments/:attachment_id)
+// ตรวจสิทธิ์ตามระดับการมองเห็นของโน้ต
+func DownloadNoteAttachment(c *gin.Context) {
+	n, ok := loadVisibleNote(c)
+	if !ok {
+		return
+	}
+	var att models.NoteAttachment
+	if err := models.DB.Where("id = ? AND note_id = ?", c.Param("attachment_id"), n.ID).First(&att).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "ATTACHMENT_NOT_FOUND")
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"ok": true, "notes": notes}) // ส่งรายการโน้ตกลับ
+	c.FileAttachment(filepath.Join(".", "uploads", "notes", att.StoredName), att.FileName)
 }
@@ -1,15 +1,20 @@
 package handlers // แพ็กเกจ handlers สำหรับจัดการ API endpoint
 
 import (
+    "encoding/json" // สำหรับแปลง payload ของ notification
     "net/http"      // สำหรับ HTTP status และ response
     "sort"          // สำหรับเรียงลำดับ slice
     "strconv"       // สำหรับแปลง string/number
+    "time"          // สำหรับเวลาอ่าน notification
 
     "github.com/gin-gonic/gin" // Gin framework สำหรับสร้าง API
 
     "aats-backend-clean/models" // import models สำหรับเชื่อมต่อ DB
+    "aats-backend-clean/utils"  // import utils สำหรับ pagination
     "gorm.io/gorm"              // สำหรับ session DB
     glogger "gorm.io/gorm/logger" // สำหรับ silent logger
+	"aats-backend-clean/respond"
+	"aats-backend-clean/i18n"
 )
 
 // ฟังก์ชันสำหรับรวม notification (GET /api/notifications/aggregate?user_id=&limit=)
@@ -22,9 +27,11 @@ func AggregateNotifications(c *gin.Context) {
     // โหลดข้อมูล timeline ล่าสุด
     var timelines []models.ApplicationTimeline
     if err := models.DB.Order("date desc").Limit(limit).Find(&timelines).Error; err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch timeline"}) // error ถ้า query ไม่สำเร็จ
+        respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR") // error ถ้า query ไม่สำเร็จ
         return
     }
+    lang := respond.Language(c) // ภาษาของผู้เรียก
+    localizeTimeline(lang, timelines)
 
     // โหลดข้อมูล evaluation ล่าสุด
     var evaluations []models.Evaluation
@@ -55,12 +62,12 @@ func AggregateNotifications(c *gin.Context) {
             continue // ข้ามถ้าไม่ใช่ของ user นี้
         }
         var job models.JobPosting
-        title := "การอัปเดตสถานะการสมัคร"
+        title := i18n.T(lang, "notifications.STATUS_UPDATE_TITLE", nil)
         if app.JobID != "" {
             dbSilent := models.DB.Session(&gorm.Session{Logger: glogger.Default.LogMode(glogger.Silent)})
             _ = dbSilent.Where("id = ?", app.JobID).First(&job).Error
             if job.Title != "" {
-                title = "สถานะใบสมัคร: " + job.Title // ถ้ามี job title ให้แสดงในหัวข้อ
+                title = i18n.T(lang, "notifications.STATUS_UPDATE_JOB_TITLE", map[string]any{"job_title": job.Title}) // ถ้ามี job title ให้แสดงในหัวข้อ
             }
         }
         notifs = append(notifs, Notif{
@@ -88,13 +95,32 @@ func AggregateNotifications(c *gin.Context) {
         notifs = append(notifs, Notif{
             ID:        "eval-" + e.ID,
             Type:      "success",
-            Title:     "ได้รับการประเมิน",
-            Message:   "คะแนนรวม: " + strconv.FormatFloat(float64(e.OverallScore), 'f', 1, 32),
+            Title:     i18n.T(lang, "notifications.EVALUATED_TITLE", nil),
+            Message:   i18n.T(lang, "notifications.EVALUATED", map[string]any{"score": strconv.FormatFloat(float64(e.OverallScore), 'f', 1, 32)}),
             Payload:   map[string]string{"application_id": e.ApplicationID},
             Timestamp: e.EvaluatedAt.Unix(),
         })
     }
 
+    // รวม notification ที่บันทึกไว้ในตาราง notifications (เช่น ข้อความจาก HR)
+    if userID != "" {
+        var stored []models.Notification
+        _ = models.DB.Where("user_id = ?", userID).Order("created_at desc").Limit(limit).Find(&stored).Error
+        localizeNotifications(lang, stored)
+        for _, n := range stored {
+            var payload map[string]string
+            _ = json.Unmarshal([]byte(n.Payload), &payload)
+            notifs = append(notifs, Notif{
+                ID:        "notif-" + n.ID,
+                Type:      n.Type,
+                Title:     n.Title,
+                Message:   n.Message,
+                Payload:   payload,
+                Timestamp: n.CreatedAt.Unix(),
+            })
+        }
+    }
+
     // เรียงลำดับ notification ตามเวลาใหม่สุดไปเก่าสุด
     sort.Slice(notifs, func(i, j int) bool { return notifs[i].Timestamp > notifs[j].Timestamp })
     if len(notifs) > limit {
@@ -102,5 +128,44 @@ func AggregateNotifications(c *gin.Context) {
     }
 
     // ส่ง notification กลับแบบ JSON
-    c.JSON(http.StatusOK, gin.H{"ok": true, "notifications": notifs})
+    respond.OK(c, gin.H{"notifications": notifs})
+}
+
+// ฟังก์ชันสำหรับดึง notification ที่บันทึกไว้ของผู้ใช้ปัจจุบัน (GET /api/notifications?unread=1&page=&limit=)
+func ListMyNotifications(c *gin.Context) {
+    uid, _ := c.Get("user_id") // ดึง user_id จาก context
+    page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+
+    q := models.DB.Model(&models.Notification{}).Where("user_id = ?", uid)
+    if c.Query("unread") == "1" || c.Query("unread") == "true" {
+        q = q.Where("read_at IS NULL") // เฉพาะที่ยังไม่อ่าน
+    }
+    var total int64
+    q.Count(&total)
+
+    var notifs []models.Notification
+    if err := q.Order("created_at desc").Offset(offset).Limit(limit).Find(&notifs).Error; err != nil {
+        respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR") // error ถ้า query ไม่สำเร็จ
+        return
+    }
+    localizeNotifications(respond.Language(c), notifs) // แสดงในภาษาของผู้ใช้
+    respond.Page(c, gin.H{"notifications": notifs}, gin.H{"page": page, "limit": limit, "total": total})
+}
+
+// ฟังก์ชันสำหรับทำเครื่องหมายว่าอ่านแล้ว (POST /api/notifications/:id/read)
+func MarkNotificationRead(c *gin.Context) {
+    uid, _ := c.Get("user_id") // ดึง user_id จาก context
+    now := time.Now()
+    res := models.DB.Model(&models.Notification{}).
+        Where("id = ? AND user_id = ?", c.Param("id"), uid).
+        Update("read_at", &now)
+    if res.Error != nil {
+        respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR") // error ถ้าอัปเดตไม่สำเร็จ
+        return
+    }
+    if res.RowsAffected == 0 {
+        respond.Error(c, http.StatusNotFound, "NOTIFICATION_NOT_FOUND") // ไม่พบหรือไม่ใช่ของผู้ใช้นี้
+        return
+    }
+    respond.OK(c, gin.H{})
 }
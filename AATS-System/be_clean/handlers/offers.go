@@ -0,0 +1,782 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+	"aats-backend-clean/worker"
+)
+
+// OfferBody request body for creating/editing an offer
+type OfferBody struct {
+	Salary      float64  `json:"salary"`
+	Currency    string   `json:"currency"`
+	StartDate   string   `json:"start_date"` // YYYY-MM-DD or RFC3339
+	Benefits    string   `json:"benefits"`
+	ExpiresAt   string   `json:"expires_at"` // YYYY-MM-DD (end of day) or RFC3339
+	TemplateID  string   `json:"template_id"`
+	ApproverIDs []string `json:"approver_ids"` // approval chain, in order
+}
+
+// OfferTemplateBody request body for offer letter templates
+type OfferTemplateBody struct {
+	Name     string `json:"name"`
+	BodyHTML string `json:"body_html"`
+}
+
+// defaultOfferLetter is the catalogue key of the letter used when an offer
+// has no template; its {{placeholders}} are filled by renderOfferLetter.
+const defaultOfferLetter = "letters.OFFER_HTML"
+
+// openOfferStatuses are offers still in play; an application has at most one.
+var openOfferStatuses = []string{"draft", "pending_approval", "approved", "rejected", "sent"}
+
+// offerForRequest loads the offer named by :id.
+func offerForRequest(c *gin.Context) (models.Offer, bool) {
+	var offer models.Offer
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&offer).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "OFFER_NOT_FOUND")
+		return offer, false
+	}
+	return offer, true
+}
+
+func loadOfferApprovals(db *gorm.DB, offerID string) []models.OfferApproval {
+	var approvals []models.OfferApproval
+	db.Where("offer_id = ?", offerID).Order("step asc").Find(&approvals)
+	return approvals
+}
+
+// currentApproval is the first pending step, nil when none is left.
+func currentApproval(approvals []models.OfferApproval) *models.OfferApproval {
+	for i := range approvals {
+		if approvals[i].Status == "pending" {
+			return &approvals[i]
+		}
+	}
+	return nil
+}
+
+// applyOfferBody validates body into offer. Returns a problem on bad input.
+func applyOfferBody(offer *models.Offer, body OfferBody) *respond.Problem {
+	if body.Salary <= 0 {
+		return respond.New(http.StatusBadRequest, "FIELD_NOT_POSITIVE", gin.H{"field": "salary"})
+	}
+	cur := strings.ToUpper(strings.TrimSpace(body.Currency))
+	if cur == "" {
+		cur = "THB"
+	}
+	if len(cur) != 3 {
+		return respond.New(http.StatusBadRequest, "CURRENCY_INVALID")
+	}
+	start, ok := parseDateParam(body.StartDate, false)
+	if !ok {
+		return respond.New(http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "start_date"})
+	}
+	expires, ok := parseDateParam(body.ExpiresAt, true)
+	if !ok {
+		return respond.New(http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "expires_at"})
+	}
+	if _, err := time.Parse("2006-01-02", body.ExpiresAt); err == nil {
+		expires = expires.Add(-time.Second) // 23:59:59 on the given day
+	}
+	if !expires.After(time.Now()) {
+		return respond.New(http.StatusBadRequest, "OFFER_EXPIRES_AT_PASSED")
+	}
+	if body.TemplateID != "" {
+		var n int64
+		models.DB.Model(&models.OfferTemplate{}).Where("id = ?", body.TemplateID).Count(&n)
+		if n == 0 {
+			return respond.New(http.StatusBadRequest, "TEMPLATE_NOT_FOUND")
+		}
+	}
+	offer.Salary, offer.Currency, offer.StartDate, offer.ExpiresAt = body.Salary, cur, start, expires
+	offer.Benefits = strings.TrimSpace(body.Benefits)
+	offer.TemplateID = body.TemplateID
+	return nil
+}
+
+// replaceApprovalChain swaps the offer's approvers for ids (hr/hm users only).
+func replaceApprovalChain(tx *gorm.DB, offerID string, ids []string) error {
+	if err := tx.Where("offer_id = ?", offerID).Delete(&models.OfferApproval{}).Error; err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if err := tx.Create(&models.OfferApproval{ID: uuid.NewString(), OfferID: offerID, Step: i + 1, ApproverID: id, Status: "pending"}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateApprovers checks every approver is an hr or hm user.
+func validateApprovers(ids []string) *respond.Problem {
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return respond.New(http.StatusBadRequest, "APPROVERS_DUPLICATE")
+		}
+		seen[id] = true
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var n int64
+	models.DB.Model(&models.User{}).Where("id IN ? AND role IN ?", ids, []string{"hr", "hm"}).Count(&n)
+	if int(n) != len(ids) {
+		return respond.New(http.StatusBadRequest, "APPROVER_ROLE_INVALID")
+	}
+	return nil
+}
+
+// renderOfferLetter fills the offer's template with application and offer
+// data. Without a template the default letter is written in the candidate's
+// language.
+func renderOfferLetter(db *gorm.DB, offer models.Offer, app models.Application) string {
+	tpl := i18n.T(userLanguage(db, app.ApplicantID), defaultOfferLetter, nil)
+	if offer.TemplateID != "" {
+		var t models.OfferTemplate
+		if db.Where("id = ?", offer.TemplateID).Limit(1).Find(&t).RowsAffected > 0 {
+			tpl = t.BodyHTML
+		}
+	}
+	vars := templateVars(db, app)
+	for k, v := range vars {
+		vars[k] = htmlEscape(v)
+	}
+	vars["salary"] = strconv.FormatFloat(offer.Salary, 'f', 2, 64)
+	vars["currency"] = offer.Currency
+	vars["start_date"] = offer.StartDate.Format("2006-01-02")
+	vars["expires_at"] = offer.ExpiresAt.Format("2006-01-02 15:04")
+	vars["benefits"] = htmlEscape(offer.Benefits)
+	return utils.RenderTemplate(tpl, vars)
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&#34;")
+
+func htmlEscape(s string) string { return htmlEscaper.Replace(s) }
+
+// offerResponse is an offer with its approval chain.
+func offerResponse(offer models.Offer) gin.H {
+	return gin.H{"offer": offer, "approvals": loadOfferApprovals(models.DB, offer.ID)}
+}
+
+// POST /api/applications/:id/offers (HR) — creates a draft
+func CreateOffer(c *gin.Context) {
+	var app models.Application
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&app).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "APPLICATION_NOT_FOUND")
+		return
+	}
+	if app.Status == "hired" || app.Status == "rejected" || app.Status == "withdrawn" {
+		respond.Error(c, http.StatusBadRequest, "APPLICATION_CLOSED", gin.H{"status": app.Status})
+		return
+	}
+	// same rule as moving to the offer stage by hand
+	if p := validateStatusChange(app, "offer"); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	var n int64
+	models.DB.Model(&models.Offer{}).Where("application_id = ? AND status IN ?", app.ID, openOfferStatuses).Count(&n)
+	if n > 0 {
+		respond.Error(c, http.StatusConflict, "OFFER_ALREADY_OPEN")
+		return
+	}
+	var body OfferBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	offer := models.Offer{ID: uuid.NewString(), ApplicationID: app.ID, Status: "draft", CreatedBy: createdBy}
+	if p := applyOfferBody(&offer, body); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if p := validateApprovers(body.ApproverIDs); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	err := data().Transaction(func(st store.Store) error {
+		// checked again under the application's lock: one open offer each
+		cur, err := lockApplication(st, app.ID)
+		if err != nil {
+			return err
+		}
+		if closedStatuses[cur.Status] {
+			return respond.New(http.StatusBadRequest, "APPLICATION_CLOSED", gin.H{"status": cur.Status})
+		}
+		tx := dataDB(st)
+		var n int64
+		if err := tx.Model(&models.Offer{}).Where("application_id = ? AND status IN ?", app.ID, openOfferStatuses).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return respond.New(http.StatusConflict, "OFFER_ALREADY_OPEN")
+		}
+		if err := tx.Create(&offer).Error; err != nil {
+			return err
+		}
+		return replaceApprovalChain(tx, offer.ID, body.ApproverIDs)
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond.Created(c, gin.H{"offer": offer, "approvals": loadOfferApprovals(models.DB, offer.ID)})
+}
+
+// PUT /api/offers/:id (HR) — only drafts and offers sent back by an approver;
+// the approval chain starts over
+func UpdateOffer(c *gin.Context) {
+	offer, ok := offerForRequest(c)
+	if !ok {
+		return
+	}
+	if offer.Status != "draft" && offer.Status != "rejected" {
+		respond.Error(c, http.StatusBadRequest, "OFFER_NOT_EDITABLE")
+		return
+	}
+	var body OfferBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	edited := offer
+	if p := applyOfferBody(&edited, body); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if body.ApproverIDs != nil {
+		if p := validateApprovers(body.ApproverIDs); p != nil {
+			respond.Fail(c, p)
+			return
+		}
+	}
+	err := data().Transaction(func(st store.Store) error {
+		// a withdrawal or a submit may have come first
+		var err error
+		if _, err = lockApplication(st, offer.ApplicationID); err != nil {
+			return err
+		}
+		if offer, err = lockOffer(st, offer.ID); err != nil {
+			return err
+		}
+		if offer.Status != "draft" && offer.Status != "rejected" {
+			return respond.New(http.StatusBadRequest, "OFFER_NOT_EDITABLE")
+		}
+		offer.Salary, offer.Currency, offer.StartDate, offer.ExpiresAt = edited.Salary, edited.Currency, edited.StartDate, edited.ExpiresAt
+		offer.Benefits, offer.TemplateID = edited.Benefits, edited.TemplateID
+		tx := dataDB(st)
+		approvers := body.ApproverIDs
+		if approvers == nil {
+			for _, a := range loadOfferApprovals(tx, offer.ID) {
+				approvers = append(approvers, a.ApproverID)
+			}
+		}
+		offer.Status = "draft"
+		if err := tx.Save(&offer).Error; err != nil {
+			return err
+		}
+		return replaceApprovalChain(tx, offer.ID, approvers)
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	out := offerResponse(offer)
+	respond.OK(c, out)
+}
+
+// POST /api/offers/:id/submit (HR) — starts the approval chain
+func SubmitOffer(c *gin.Context) {
+	offer, ok := offerForRequest(c)
+	if !ok {
+		return
+	}
+	if offer.Status != "draft" {
+		respond.Error(c, http.StatusBadRequest, "OFFER_NOT_DRAFT")
+		return
+	}
+	var approvals []models.OfferApproval
+	err := data().Transaction(func(st store.Store) error {
+		// checked again under the locks: a withdrawal may have come first
+		var err error
+		if _, err = lockApplication(st, offer.ApplicationID); err != nil {
+			return err
+		}
+		if offer, err = lockOffer(st, offer.ID); err != nil {
+			return err
+		}
+		if offer.Status != "draft" {
+			return respond.New(http.StatusBadRequest, "OFFER_NOT_DRAFT")
+		}
+		tx := dataDB(st)
+		approvals = loadOfferApprovals(tx, offer.ID)
+		offer.Status = "pending_approval"
+		if len(approvals) == 0 {
+			offer.Status = "approved" // no chain configured
+		}
+		return tx.Save(&offer).Error
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if next := currentApproval(approvals); next != nil {
+		notifyUser(models.DB, next.ApproverID, "offer_approval", i18n.M("notifications.OFFER_APPROVAL_TITLE"),
+			i18n.M("notifications.OFFER_APPROVAL"), map[string]string{"offer_id": offer.ID, "application_id": offer.ApplicationID})
+	}
+	out := offerResponse(offer)
+	respond.OK(c, out)
+}
+
+// decideOffer records the current approver's decision.
+func decideOffer(c *gin.Context, approve bool) {
+	offer, ok := offerForRequest(c)
+	if !ok {
+		return
+	}
+	if offer.Status != "pending_approval" {
+		respond.Error(c, http.StatusBadRequest, "OFFER_NOT_PENDING_APPROVAL")
+		return
+	}
+	var body struct {
+		Comment string `json:"comment"`
+	}
+	_ = c.ShouldBindJSON(&body)
+	approvals := loadOfferApprovals(models.DB, offer.ID)
+	step := currentApproval(approvals)
+	uid, _ := c.Get("user_id")
+	if step == nil || step.ApproverID != uid {
+		respond.Error(c, http.StatusForbidden, "OFFER_NOT_YOUR_TURN")
+		return
+	}
+	if !approve && strings.TrimSpace(body.Comment) == "" {
+		respond.Error(c, http.StatusBadRequest, "REJECTION_COMMENT_REQUIRED")
+		return
+	}
+
+	now := time.Now()
+	step.Status, step.Comment, step.DecidedAt = "rejected", strings.TrimSpace(body.Comment), &now
+	if approve {
+		step.Status = "approved"
+	}
+	next := currentApproval(approvals)
+	status := offer.Status
+	switch {
+	case !approve:
+		status = "rejected"
+	case next == nil:
+		status = "approved"
+	}
+	err := data().Transaction(func(st store.Store) error {
+		// a withdrawal or another decision may have come first
+		var err error
+		if offer, err = lockOffer(st, offer.ID); err != nil {
+			return err
+		}
+		if offer.Status != "pending_approval" {
+			return respond.New(http.StatusBadRequest, "OFFER_NOT_PENDING_APPROVAL")
+		}
+		tx := dataDB(st)
+		if cur := currentApproval(loadOfferApprovals(tx, offer.ID)); cur == nil || cur.ID != step.ID {
+			return respond.New(http.StatusForbidden, "OFFER_NOT_YOUR_TURN")
+		}
+		offer.Status = status
+		if err := tx.Save(step).Error; err != nil {
+			return err
+		}
+		return tx.Save(&offer).Error
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	payload := map[string]string{"offer_id": offer.ID, "application_id": offer.ApplicationID}
+	switch {
+	case offer.Status == "rejected":
+		notifyUser(models.DB, offer.CreatedBy, "offer_rejected", i18n.M("notifications.OFFER_REJECTED_TITLE"), i18n.Text(step.Comment), payload)
+	case offer.Status == "approved":
+		notifyUser(models.DB, offer.CreatedBy, "offer_approved", i18n.M("notifications.OFFER_APPROVED_TITLE"), i18n.M("notifications.OFFER_APPROVED"), payload)
+	default:
+		notifyUser(models.DB, next.ApproverID, "offer_approval", i18n.M("notifications.OFFER_APPROVAL_TITLE"), i18n.M("notifications.OFFER_APPROVAL"), payload)
+	}
+	out := offerResponse(offer)
+	respond.OK(c, out)
+}
+
+// POST /api/offers/:id/approve (approver)
+func ApproveOffer(c *gin.Context) { decideOffer(c, true) }
+
+// POST /api/offers/:id/reject (approver) — {"comment": "..."} required
+func RejectOffer(c *gin.Context) { decideOffer(c, false) }
+
+// POST /api/offers/:id/send (HR) — freezes the letter, moves the application
+// to offer and tells the candidate
+func SendOffer(c *gin.Context) {
+	offer, ok := offerForRequest(c)
+	if !ok {
+		return
+	}
+	// the checks run under the application's and the offer's locks, against
+	// what concurrent withdrawals and status changes left
+	var app models.Application
+	err := data().Transaction(func(tx store.Store) error {
+		var err error
+		if app, err = lockApplication(tx, offer.ApplicationID); err != nil {
+			return err
+		}
+		if offer, err = lockOffer(tx, offer.ID); err != nil {
+			return err
+		}
+		if offer.Status != "approved" {
+			return respond.New(http.StatusBadRequest, "OFFER_NOT_APPROVED")
+		}
+		now := time.Now()
+		if !offer.ExpiresAt.After(now) {
+			return respond.New(http.StatusBadRequest, "OFFER_EXPIRES_AT_PASSED")
+		}
+		if closedStatuses[app.Status] {
+			return respond.New(http.StatusBadRequest, "APPLICATION_CLOSED", gin.H{"status": app.Status})
+		}
+		if app.Status != "offer" {
+			if err := svc().Applications.On(tx).ValidateStatusChange(app, "offer"); err != nil {
+				return err
+			}
+		}
+		db := dataDB(tx)
+		offer.Status, offer.SentAt = "sent", &now
+		offer.LetterHTML = renderOfferLetter(db, offer, app)
+		if err := db.Save(&offer).Error; err != nil {
+			return err
+		}
+		if app.Status == "offer" {
+			return addTimelineNote(db, app, i18n.M("timeline.OFFER_SENT"))
+		}
+		_, err = applyStatusChange(db, &app, "offer", i18n.M("timeline.OFFER_SENT"))
+		return err
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	notifyUser(models.DB, app.ApplicantID, "offer", i18n.M("notifications.OFFER_RECEIVED_TITLE"),
+		i18n.M("notifications.OFFER_RECEIVED", map[string]any{"expires_at": offer.ExpiresAt.Format("2006-01-02 15:04")}), map[string]string{"offer_id": offer.ID, "application_id": app.ID})
+	out := offerResponse(offer)
+	respond.OK(c, out)
+}
+
+// POST /api/offers/:id/withdraw (HR)
+func WithdrawOffer(c *gin.Context) {
+	offer, ok := offerForRequest(c)
+	if !ok {
+		return
+	}
+	var app models.Application
+	wasSent := false
+	err := data().Transaction(func(tx store.Store) error {
+		// locked and checked again so a concurrent accept or expiry wins cleanly
+		var err error
+		if app, err = lockApplication(tx, offer.ApplicationID); err != nil {
+			return err
+		}
+		if offer, err = lockOffer(tx, offer.ID); err != nil {
+			return err
+		}
+		if offer.Status == "accepted" || offer.Status == "declined" || offer.Status == "withdrawn" {
+			return respond.New(http.StatusBadRequest, "OFFER_CLOSED", gin.H{"status": offer.Status})
+		}
+		db := dataDB(tx)
+		wasSent = offer.Status == "sent"
+		offer.Status = "withdrawn"
+		if err := db.Save(&offer).Error; err != nil {
+			return err
+		}
+		if !wasSent {
+			return nil
+		}
+		return addTimelineNote(db, app, i18n.M("timeline.OFFER_WITHDRAWN"))
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if wasSent {
+		notifyUser(models.DB, app.ApplicantID, "offer", i18n.M("notifications.OFFER_WITHDRAWN_TITLE"), i18n.M("notifications.OFFER_WITHDRAWN"), map[string]string{"offer_id": offer.ID})
+	}
+	respond.OK(c, gin.H{"offer": offer})
+}
+
+// GET /api/applications/:id/offers (HR/HM)
+func ListApplicationOffers(c *gin.Context) {
+	var offers []models.Offer
+	if err := models.DB.Where("application_id = ?", c.Param("id")).Order("created_at desc").Find(&offers).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(offers))
+	for _, o := range offers {
+		out = append(out, offerResponse(o))
+	}
+	respond.OK(c, gin.H{"offers": out})
+}
+
+// candidateOffer loads an offer a candidate may see: their own, once sent.
+func candidateOffer(c *gin.Context, offer models.Offer) (models.Application, bool) {
+	var app models.Application
+	models.DB.Where("id = ?", offer.ApplicationID).Limit(1).Find(&app)
+	uid, _ := c.Get("user_id")
+	if app.ApplicantID == "" || app.ApplicantID != uid || offer.SentAt == nil {
+		respond.Error(c, http.StatusNotFound, "OFFER_NOT_FOUND")
+		return app, false
+	}
+	return app, true
+}
+
+// GET /api/offers/:id — HR/HM see everything; the candidate sees their own
+// offer once it has been sent (without the approval chain)
+func GetOffer(c *gin.Context) {
+	offer, ok := offerForRequest(c)
+	if !ok {
+		return
+	}
+	rv, _ := c.Get("user_role")
+	if applicantOnly(rv) {
+		if _, ok := candidateOffer(c, offer); !ok {
+			return
+		}
+		respond.OK(c, gin.H{"offer": offer})
+		return
+	}
+	out := offerResponse(offer)
+	respond.OK(c, out)
+}
+
+// GET /api/offers/:id/letter?format=html|pdf — the sent letter, or a preview
+// for HR/HM before sending
+func GetOfferLetter(c *gin.Context) {
+	offer, ok := offerForRequest(c)
+	if !ok {
+		return
+	}
+	rv, _ := c.Get("user_role")
+	letter := offer.LetterHTML
+	var app models.Application
+	if applicantOnly(rv) {
+		if app, ok = candidateOffer(c, offer); !ok {
+			return
+		}
+	} else {
+		models.DB.Where("id = ?", offer.ApplicationID).Limit(1).Find(&app)
+		if letter == "" {
+			letter = renderOfferLetter(models.DB, offer, app)
+		}
+	}
+
+	switch c.DefaultQuery("format", "pdf") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(letter))
+	case "pdf":
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", `inline; filename="offer-`+offer.ID+`.pdf"`)
+		c.Status(http.StatusOK)
+		if err := utils.WriteTextPDF(c.Writer, i18n.T(userLanguage(models.DB, app.ApplicantID), "letters.OFFER_PDF_TITLE", nil), utils.HTMLToText(letter)); err != nil {
+			log.Printf("offer letter pdf: %v", err)
+		}
+	default:
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "format", "allowed": "html, pdf"})
+	}
+}
+
+// POST /api/offers/:id/respond (candidate) — {"decision": "accept"|"decline", "reason": "..."}
+// Records time, IP and user agent. Accepting hires the candidate through the
+// normal status change path.
+func RespondToOffer(c *gin.Context) {
+	var body struct {
+		Decision string `json:"decision"`
+		Reason   string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || (body.Decision != "accept" && body.Decision != "decline") {
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "decision", "allowed": "accept, decline"})
+		return
+	}
+	offer, ok := offerForRequest(c)
+	if !ok {
+		return
+	}
+	app, ok := candidateOffer(c, offer)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	err := data().Transaction(func(tx store.Store) error {
+		// locked and checked again so a concurrent expiry, withdrawal or
+		// status change wins cleanly
+		var err error
+		if app, err = lockApplication(tx, app.ID); err != nil {
+			return err
+		}
+		if offer, err = lockOffer(tx, offer.ID); err != nil {
+			return err
+		}
+		if offer.Status != "sent" {
+			if offer.DeclineReason == "expired" {
+				return respond.New(http.StatusGone, "OFFER_EXPIRED")
+			}
+			return respond.New(http.StatusConflict, "OFFER_CLOSED", gin.H{"status": offer.Status})
+		}
+		if !offer.ExpiresAt.After(now) {
+			return respond.New(http.StatusGone, "OFFER_EXPIRED")
+		}
+		db := dataDB(tx)
+		offer.RespondedAt, offer.ResponseIP, offer.ResponseUserAgent = &now, c.ClientIP(), clip(c.Request.UserAgent(), 512)
+		if body.Decision == "accept" {
+			if closedStatuses[app.Status] {
+				return respond.New(http.StatusBadRequest, "APPLICATION_CLOSED", gin.H{"status": app.Status})
+			}
+			if err := svc().Applications.On(tx).ValidateStatusChange(app, "hired"); err != nil {
+				return err
+			}
+			offer.Status = "accepted"
+			if err := db.Save(&offer).Error; err != nil {
+				return err
+			}
+			_, err := applyStatusChange(db, &app, "hired", i18n.M("timeline.OFFER_ACCEPTED"))
+			return err
+		}
+		offer.Status, offer.DeclineReason = "declined", strings.TrimSpace(body.Reason)
+		if err := db.Save(&offer).Error; err != nil {
+			return err
+		}
+		return addTimelineNote(db, app, i18n.M("timeline.OFFER_DECLINED"))
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	title, msg := i18n.M("notifications.OFFER_ACCEPTED_TITLE"), i18n.M("notifications.OFFER_ACCEPTED")
+	if offer.Status == "declined" {
+		title, msg = i18n.M("notifications.OFFER_DECLINED_TITLE"), i18n.M("notifications.OFFER_DECLINED")
+	}
+	notifyUser(models.DB, offer.CreatedBy, "offer_"+offer.Status, title, msg, map[string]string{"offer_id": offer.ID, "application_id": app.ID})
+	respond.OK(c, gin.H{"offer": offer, "application_status": app.Status})
+}
+
+// ExpireOffers auto-declines sent offers whose expiry has passed.
+func ExpireOffers(db *gorm.DB) (int, error) {
+	var offers []models.Offer
+	if err := db.Where("status = ? AND expires_at <= ?", "sent", time.Now()).Find(&offers).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, offer := range offers {
+		now := time.Now()
+		var app models.Application
+		expired, found := false, false
+		// the decline and its timeline note commit together
+		err := db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Model(&models.Offer{}).Where("id = ? AND status = ?", offer.ID, "sent").
+				Updates(map[string]interface{}{"status": "declined", "decline_reason": "expired", "responded_at": now})
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error // answered in the meantime when no row changed
+			}
+			expired = true
+			if found = tx.Where("id = ?", offer.ApplicationID).Limit(1).Find(&app).RowsAffected > 0; !found {
+				return nil
+			}
+			return addTimelineNote(tx, app, i18n.M("timeline.OFFER_EXPIRED"))
+		})
+		if err != nil {
+			return n, err
+		}
+		if !expired {
+			continue
+		}
+		n++
+		if found {
+			notifyUser(db, app.ApplicantID, "offer", i18n.M("notifications.OFFER_EXPIRED_TITLE"), i18n.M("notifications.OFFER_EXPIRED_CANDIDATE"), map[string]string{"offer_id": offer.ID})
+		}
+		notifyUser(db, offer.CreatedBy, "offer_declined", i18n.M("notifications.OFFER_EXPIRED_TITLE"), i18n.M("notifications.OFFER_EXPIRED_HR"), map[string]string{"offer_id": offer.ID, "application_id": offer.ApplicationID})
+	}
+	return n, nil
+}
+
+// StartOfferExpiry runs ExpireOffers every interval.
+func StartOfferExpiry(every time.Duration) {
+	worker.Every(every, func() {
+		if n, err := ExpireOffers(models.DB); err != nil {
+			log.Printf("offer expiry failed: %v", err)
+		} else if n > 0 {
+			log.Printf("offer expiry: %d offer(s) auto-declined", n)
+		}
+	})
+}
+
+// GET /api/offer-templates (HR)
+func ListOfferTemplates(c *gin.Context) {
+	var tpls []models.OfferTemplate
+	if err := models.DB.Order("name asc").Find(&tpls).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"templates": tpls, "default_body_html": i18n.T(respond.Language(c), defaultOfferLetter, nil)})
+}
+
+// POST /api/offer-templates (HR)
+func CreateOfferTemplate(c *gin.Context) {
+	var body OfferTemplateBody
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Name) == "" || strings.TrimSpace(body.BodyHTML) == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELDS_REQUIRED", gin.H{"fields": "name, body_html"})
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	tpl := models.OfferTemplate{ID: uuid.NewString(), Name: strings.TrimSpace(body.Name), BodyHTML: body.BodyHTML, CreatedBy: createdBy}
+	if err := models.DB.Create(&tpl).Error; err != nil {
+		respond.Error(c, http.StatusConflict, "TEMPLATE_NAME_TAKEN")
+		return
+	}
+	respond.Created(c, gin.H{"template": tpl})
+}
+
+// PUT /api/offer-templates/:id (HR) — sent offers keep the letter they were sent with
+func UpdateOfferTemplate(c *gin.Context) {
+	var tpl models.OfferTemplate
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&tpl).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "TEMPLATE_NOT_FOUND")
+		return
+	}
+	var body OfferTemplateBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if strings.TrimSpace(body.Name) != "" {
+		tpl.Name = strings.TrimSpace(body.Name)
+	}
+	if strings.TrimSpace(body.BodyHTML) != "" {
+		tpl.BodyHTML = body.BodyHTML
+	}
+	if err := models.DB.Save(&tpl).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"template": tpl})
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+)
+
+// PolicyBody request body for application policies
+type PolicyBody struct {
+	Scope         string            `json:"scope"`  // global|job
+	JobID         string            `json:"job_id"` // required for scope job
+	Rules         utils.PolicyRules `json:"rules"`
+	EffectiveFrom string            `json:"effective_from"` // YYYY-MM-DD or RFC3339, default now
+	EffectiveTo   string            `json:"effective_to"`   // optional, exclusive
+	Note          string            `json:"note"`
+}
+
+// explainViolations fills each violation's Message in lang.
+func explainViolations(lang i18n.Lang, violations []utils.PolicyViolation) {
+	for i := range violations {
+		violations[i].Message = i18n.T(lang, "errors."+violations[i].Code, violations[i].Params)
+	}
+}
+
+// policyFromBody validates body into p. Returns a problem on bad input.
+func policyFromBody(p *models.ApplicationPolicy, body PolicyBody) *respond.Problem {
+	if body.Scope == "" {
+		body.Scope = utils.PolicyScopeGlobal
+	}
+	switch body.Scope {
+	case utils.PolicyScopeGlobal:
+		body.JobID = ""
+	case utils.PolicyScopeJob:
+		var n int64
+		models.DB.Model(&models.JobPosting{}).Where("id = ?", body.JobID).Count(&n)
+		if n == 0 {
+			return respond.New(http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "job_id"})
+		}
+	default:
+		return respond.New(http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "scope", "allowed": "global, job"})
+	}
+	if field := body.Rules.Validate(); field != "" {
+		return respond.New(http.StatusBadRequest, "FIELD_NEGATIVE", gin.H{"field": field})
+	}
+	from := time.Now()
+	if body.EffectiveFrom != "" {
+		t, ok := parseDateParam(body.EffectiveFrom, false)
+		if !ok {
+			return respond.New(http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "effective_from"})
+		}
+		from = t
+	}
+	var to *time.Time
+	if body.EffectiveTo != "" {
+		t, ok := parseDateParam(body.EffectiveTo, false)
+		if !ok || !t.After(from) {
+			return respond.New(http.StatusBadRequest, "POLICY_WINDOW_INVALID")
+		}
+		to = &t
+	}
+	raw, _ := json.Marshal(body.Rules)
+	p.Scope, p.JobID, p.Rules, p.EffectiveFrom, p.EffectiveTo, p.Note = body.Scope, body.JobID, string(raw), from, to, body.Note
+	return nil
+}
+
+// GET /api/policies?job_id= (HR)
+func ListPolicies(c *gin.Context) {
+	q := models.DB.Order("scope asc, effective_from desc")
+	if jobID := c.Query("job_id"); jobID != "" {
+		q = q.Where("job_id = ?", jobID)
+	}
+	var rows []models.ApplicationPolicy
+	if err := q.Find(&rows).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"policies": rows, "defaults": utils.DefaultPolicy})
+}
+
+// POST /api/policies (HR)
+func CreatePolicy(c *gin.Context) {
+	var body PolicyBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	p := models.ApplicationPolicy{ID: uuid.NewString(), CreatedBy: createdBy}
+	if p := policyFromBody(&p, body); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if err := data().Policies().Create(&p); err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Created(c, gin.H{"policy": p})
+}
+
+// PUT /api/policies/:id (HR)
+func UpdatePolicy(c *gin.Context) {
+	var p models.ApplicationPolicy
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&p).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "POLICY_NOT_FOUND")
+		return
+	}
+	var body PolicyBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if p := policyFromBody(&p, body); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if err := models.DB.Save(&p).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"policy": p})
+}
+
+// DELETE /api/policies/:id (HR)
+func DeletePolicy(c *gin.Context) {
+	res := models.DB.Where("id = ?", c.Param("id")).Delete(&models.ApplicationPolicy{})
+	if res.Error != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	if res.RowsAffected == 0 {
+		respond.Error(c, http.StatusNotFound, "POLICY_NOT_FOUND")
+		return
+	}
+	respond.OK(c, gin.H{})
+}
+
+// GET /api/policies/effective?job_id=&at= (HR) — the resolved rules for a job
+func EffectivePolicy(c *gin.Context) {
+	at := time.Now()
+	if v := c.Query("at"); v != "" {
+		t, ok := parseDateParam(v, false)
+		if !ok {
+			respond.Error(c, http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "at"})
+			return
+		}
+		at = t
+	}
+	cfg, err := svc().Applications.Policy(c.Query("job_id"), at)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond.OK(c, gin.H{"at": at, "policy": cfg})
+}
+
+// GET /api/policies/explain?job_id= — can the caller apply to this job, and
+// if not, which rules block them and from when they can apply again. HR/HM
+// may pass applicant_id to ask on a candidate's behalf.
+func ExplainPolicy(c *gin.Context) {
+	jobID := c.Query("job_id")
+	if jobID == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "job_id"})
+		return
+	}
+	uid, _ := c.Get("user_id")
+	applicantID, _ := uid.(string)
+	rv, _ := c.Get("user_role")
+	if !applicantOnly(rv) && c.Query("applicant_id") != "" {
+		applicantID = c.Query("applicant_id")
+	}
+	cfg, violations, err := svc().Applications.CheckPolicy(applicantID, jobID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if violations == nil {
+		violations = []utils.PolicyViolation{}
+	}
+	explainViolations(respond.Language(c), violations)
+	out := gin.H{"job_id": jobID, "allowed": len(violations) == 0, "violations": violations, "policy": cfg}
+	if len(violations) > 0 {
+		if t, ok := utils.EarliestRetry(violations); ok {
+			out["can_apply_at"] = t
+		}
+	}
+	respond.OK(c, out)
+}
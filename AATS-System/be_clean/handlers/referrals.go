@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+	"aats-backend-clean/worker"
+)
+
+// ReferralBody request body for POST /api/referrals. Sent as JSON, or as
+// multipart/form-data with the resume file in "resume".
+type ReferralBody struct {
+	JobID          string `json:"job_id" form:"job_id"`
+	CandidateName  string `json:"candidate_name" form:"candidate_name"`
+	CandidateEmail string `json:"candidate_email" form:"candidate_email"`
+	CandidatePhone string `json:"candidate_phone" form:"candidate_phone"`
+	ResumeURL      string `json:"resume_url" form:"resume_url"`
+	Relationship   string `json:"relationship" form:"relationship"`
+	Note           string `json:"note" form:"note"`
+}
+
+// referralProbation is how long a referred hire must stay before the bonus
+// becomes eligible (REFERRAL_PROBATION_DAYS, default 90).
+func referralProbation() time.Duration {
+	days := 90
+	if v, err := strconv.Atoi(os.Getenv("REFERRAL_PROBATION_DAYS")); err == nil && v >= 0 {
+		days = v
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// referralStage is what a referrer may see of the candidate's progress:
+// no stages, scores, notes or rejection reasons.
+func referralStage(ref models.Referral, appStatus string) string {
+	if ref.ApplicationID == "" {
+		return "invited"
+	}
+	switch appStatus {
+	case "hired":
+		return "hired"
+	case "rejected":
+		return "not_selected"
+	case "withdrawn":
+		return "withdrawn"
+	}
+	return "in_progress"
+}
+
+// findReferralForApply validates a referral token sent with CreateApplication.
+func findReferralForApply(token, jobID string) (models.Referral, *respond.Problem) {
+	var ref models.Referral
+	if err := models.DB.Where("invite_token = ?", token).First(&ref).Error; err != nil {
+		return ref, respond.New(http.StatusBadRequest, "REFERRAL_INVITE_NOT_FOUND")
+	}
+	if ref.JobID != jobID {
+		return ref, respond.New(http.StatusBadRequest, "REFERRAL_INVITE_OTHER_JOB")
+	}
+	if ref.ApplicationID != "" {
+		return ref, respond.New(http.StatusBadRequest, "REFERRAL_INVITE_USED")
+	}
+	return ref, nil
+}
+
+// bonusForfeitedBySystem marks a bonus forfeited because the candidate left
+// hired during probation; only those come back when they are hired again.
+const bonusForfeitedBySystem = "system"
+
+// syncReferralBonus keeps the bonus state in step with the referred
+// application. Reaching hired starts the probation clock; leaving hired
+// before the bonus became eligible forfeits it. A bonus HR forfeited stays
+// forfeited.
+func syncReferralBonus(db *gorm.DB, app models.Application) error {
+	if app.ReferralID == "" {
+		return nil
+	}
+	var ref models.Referral
+	if db.Where("id = ?", app.ReferralID).Limit(1).Find(&ref).RowsAffected == 0 {
+		return nil
+	}
+	now := time.Now()
+	switch {
+	case app.Status == "hired" && (ref.BonusStatus == "none" || ref.BonusStatus == "" ||
+		ref.BonusStatus == "forfeited" && ref.BonusForfeitedBy == bonusForfeitedBySystem):
+		eligibleAt := now.Add(referralProbation())
+		ref.HiredAt, ref.BonusStatus, ref.BonusEligibleAt, ref.BonusForfeitedBy = &now, "pending", &eligibleAt, ""
+	case app.Status != "hired" && ref.BonusStatus == "pending":
+		ref.BonusStatus, ref.BonusForfeitedBy = "forfeited", bonusForfeitedBySystem
+	default:
+		return nil
+	}
+	return db.Save(&ref).Error
+}
+
+// PromoteReferralBonuses marks pending bonuses eligible once probation has
+// passed and the candidate is still hired, and tells the referrer.
+func PromoteReferralBonuses(db *gorm.DB) (int, error) {
+	var refs []models.Referral
+	if err := db.Where("bonus_status = ? AND bonus_eligible_at <= ?", "pending", time.Now()).Find(&refs).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, ref := range refs {
+		var app models.Application
+		if db.Select("id, status").Where("id = ?", ref.ApplicationID).Limit(1).Find(&app).RowsAffected == 0 || app.Status != "hired" {
+			continue
+		}
+		if err := db.Model(&models.Referral{}).Where("id = ? AND bonus_status = ?", ref.ID, "pending").Update("bonus_status", "eligible").Error; err != nil {
+			return n, err
+		}
+		notifyUser(db, ref.ReferrerID, "referral_bonus", i18n.M("notifications.REFERRAL_BONUS_ELIGIBLE_TITLE"),
+			i18n.M("notifications.REFERRAL_BONUS_ELIGIBLE", map[string]any{"candidate": ref.CandidateName}), map[string]string{"referral_id": ref.ID})
+		n++
+	}
+	return n, nil
+}
+
+// StartReferralBonusChecker runs PromoteReferralBonuses every interval.
+func StartReferralBonusChecker(every time.Duration) {
+	worker.Every(every, func() {
+		if n, err := PromoteReferralBonuses(models.DB); err != nil {
+			log.Printf("referral bonus check failed: %v", err)
+		} else if n > 0 {
+			log.Printf("referral bonus check: %d bonus(es) now eligible", n)
+		}
+	})
+}
+
+// POST /api/referrals (employee, hr, hm)
+func CreateReferral(c *gin.Context) {
+	var body ReferralBody
+	if err := c.ShouldBind(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	body.CandidateEmail = strings.ToLower(strings.TrimSpace(body.CandidateEmail))
+	if body.JobID == "" || strings.TrimSpace(body.CandidateName) == "" || !strings.Contains(body.CandidateEmail, "@") {
+		respond.Error(c, http.StatusBadRequest, "FIELDS_REQUIRED", gin.H{"fields": "job_id, candidate_name, candidate_email"})
+		return
+	}
+	var job models.JobPosting
+	if err := models.DB.Where("id = ?", body.JobID).First(&job).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "JOB_NOT_FOUND")
+		return
+	}
+	if job.Status != "" && job.Status != "active" {
+		respond.Error(c, http.StatusBadRequest, "JOB_NOT_OPEN")
+		return
+	}
+
+	// first referral for a candidate + job wins (the unique index settles
+	// simultaneous submits)
+	var n int64
+	models.DB.Model(&models.Referral{}).Where("job_id = ? AND candidate_email = ?", job.ID, body.CandidateEmail).Count(&n)
+	if n > 0 {
+		respond.Error(c, http.StatusConflict, "REFERRAL_DUPLICATE")
+		return
+	}
+	var existing models.User
+	if models.DB.Where("LOWER(email) = ?", body.CandidateEmail).Limit(1).Find(&existing).RowsAffected > 0 {
+		models.DB.Model(&models.Application{}).Where("applicant_id = ? AND job_id = ? AND status NOT IN ?", existing.ID, job.ID, []string{"rejected", "withdrawn"}).Count(&n)
+		if n > 0 {
+			respond.Error(c, http.StatusConflict, "REFERRAL_CANDIDATE_APPLIED")
+			return
+		}
+	}
+
+	resumeURL := strings.TrimSpace(body.ResumeURL)
+	if fh, err := c.FormFile("resume"); err == nil {
+		if _, resumeURL, err = saveResumeFile(c, fh); err != nil {
+			respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+			return
+		}
+	}
+
+	uid, _ := c.Get("user_id")
+	referrerID, _ := uid.(string)
+	ref := models.Referral{
+		ID:             uuid.NewString(),
+		JobID:          job.ID,
+		ReferrerID:     referrerID,
+		CandidateName:  strings.TrimSpace(body.CandidateName),
+		CandidateEmail: body.CandidateEmail,
+		CandidatePhone: strings.TrimSpace(body.CandidatePhone),
+		ResumeURL:      resumeURL,
+		Relationship:   strings.TrimSpace(body.Relationship),
+		Note:           strings.TrimSpace(body.Note),
+		InviteToken:    strings.ReplaceAll(uuid.NewString(), "-", ""),
+		BonusStatus:    "none",
+	}
+	if err := store.CreateRow(models.DB, &ref); errors.Is(err, store.ErrConflict) {
+		respond.Error(c, http.StatusConflict, "REFERRAL_DUPLICATE")
+		return
+	} else if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+
+	// candidates with an account get the invitation in-app as well
+	invitePath := "/referral/" + ref.InviteToken
+	if existing.ID != "" {
+		referred := map[string]any{"job_title": job.Title}
+		notifyUser(models.DB, existing.ID, "referral_invite", i18n.M("notifications.REFERRED_TITLE", referred),
+			i18n.M("notifications.REFERRED", referred), map[string]string{"job_id": job.ID, "invite_path": invitePath})
+	}
+	respond.Created(c, gin.H{"referral": ref, "invite_path": invitePath})
+}
+
+// GET /api/referrals/invite/:token (public) — what the candidate sees before applying
+func GetReferralInvite(c *gin.Context) {
+	var ref models.Referral
+	if err := models.DB.Where("invite_token = ?", c.Param("token")).First(&ref).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "REFERRAL_INVITE_NOT_FOUND")
+		return
+	}
+	var job models.JobPosting
+	models.DB.Select("id, title, department, location, status").Where("id = ?", ref.JobID).Limit(1).Find(&job)
+	var referrer models.User
+	models.DB.Select("id, name").Where("id = ?", ref.ReferrerID).Limit(1).Find(&referrer)
+	respond.OK(c, gin.H{
+		"job":            gin.H{"id": job.ID, "title": job.Title, "department": job.Department, "location": job.Location, "status": job.Status},
+		"referred_by":    referrer.Name,
+		"candidate_name": ref.CandidateName,
+		"used":           ref.ApplicationID != "",
+		"referral_token": ref.InviteToken,
+	})
+}
+
+// referralStatuses loads the status of the applications behind referrals.
+func referralStatuses(refs []models.Referral) map[string]string {
+	ids := []string{}
+	for _, r := range refs {
+		if r.ApplicationID != "" {
+			ids = append(ids, r.ApplicationID)
+		}
+	}
+	out := map[string]string{}
+	if len(ids) == 0 {
+		return out
+	}
+	var apps []models.Application
+	models.DB.Select("id, status").Where("id IN ?", ids).Find(&apps)
+	for _, a := range apps {
+		out[a.ID] = a.Status
+	}
+	return out
+}
+
+// GET /api/referrals/mine (employee, hr, hm) — privacy-limited view for the referrer
+func ListMyReferrals(c *gin.Context) {
+	uid, _ := c.Get("user_id")
+	var refs []models.Referral
+	if err := models.DB.Where("referrer_id = ?", uid).Order("created_at desc").Find(&refs).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	statuses := referralStatuses(refs)
+	titles := jobTitles(refs)
+	out := make([]gin.H, 0, len(refs))
+	for _, r := range refs {
+		out = append(out, gin.H{
+			"id":                r.ID,
+			"job_id":            r.JobID,
+			"job_title":         titles[r.JobID],
+			"candidate_name":    r.CandidateName,
+			"status":            referralStage(r, statuses[r.ApplicationID]),
+			"bonus_status":      r.BonusStatus,
+			"bonus_eligible_at": r.BonusEligibleAt,
+			"created_at":        r.CreatedAt,
+		})
+	}
+	respond.OK(c, gin.H{"referrals": out})
+}
+
+func jobTitles(refs []models.Referral) map[string]string {
+	ids := []string{}
+	for _, r := range refs {
+		ids = append(ids, r.JobID)
+	}
+	out := map[string]string{}
+	var jobs []models.JobPosting
+	models.DB.Select("id, title").Where("id IN ?", ids).Find(&jobs)
+	for _, j := range jobs {
+		out[j.ID] = j.Title
+	}
+	return out
+}
+
+// GET /api/referrals?job_id=&bonus_status=&referrer_id= (HR) — full detail
+func ListReferrals(c *gin.Context) {
+	page, limit, offset := utils.ParsePagination(c, 1, 20, 200, "limit")
+	q := models.DB.Model(&models.Referral{})
+	for _, f := range []string{"job_id", "bonus_status", "referrer_id"} {
+		if v := c.Query(f); v != "" {
+			q = q.Where(f+" = ?", v)
+		}
+	}
+	var total int64
+	q.Count(&total)
+	var refs []models.Referral
+	if err := q.Order("created_at desc").Offset(offset).Limit(limit).Find(&refs).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	statuses := referralStatuses(refs)
+	titles := jobTitles(refs)
+	out := make([]gin.H, 0, len(refs))
+	for _, r := range refs {
+		out = append(out, gin.H{
+			"referral":           r,
+			"job_title":          titles[r.JobID],
+			"application_status": statuses[r.ApplicationID],
+			"status":             referralStage(r, statuses[r.ApplicationID]),
+		})
+	}
+	respond.Page(c, gin.H{"referrals": out}, gin.H{"page": page, "limit": limit, "total": total})
+}
+
+// PATCH /api/referrals/:id/bonus (HR) — {"status": "paid"|"forfeited"}
+func UpdateReferralBonus(c *gin.Context) {
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || (body.Status != "paid" && body.Status != "forfeited") {
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "status", "allowed": "paid, forfeited"})
+		return
+	}
+	var ref models.Referral
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&ref).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "REFERRAL_NOT_FOUND")
+		return
+	}
+	if body.Status == "paid" && ref.BonusStatus != "eligible" {
+		respond.Error(c, http.StatusBadRequest, "REFERRAL_BONUS_NOT_ELIGIBLE")
+		return
+	}
+	if body.Status == "forfeited" && ref.BonusStatus == "paid" {
+		respond.Error(c, http.StatusBadRequest, "REFERRAL_BONUS_ALREADY_PAID")
+		return
+	}
+	ref.BonusStatus = body.Status
+	if body.Status == "forfeited" {
+		ref.BonusForfeitedBy = c.GetString("user_id")
+	}
+	if err := models.DB.Save(&ref).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"referral": ref})
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+)
+
+// ScreeningQuestionBody one question in PUT /api/jobs/:id/screening-questions.
+// Send the existing id to keep answers filterable by question across edits.
+type ScreeningQuestionBody struct {
+	ID               string          `json:"id"`
+	Prompt           string          `json:"prompt"`
+	Type             string          `json:"type"`
+	Options          []string        `json:"options"`
+	Required         bool            `json:"required"`
+	KnockoutAction   string          `json:"knockout_action"`
+	KnockoutOperator string          `json:"knockout_operator"`
+	KnockoutValue    json.RawMessage `json:"knockout_value"`
+}
+
+// ScreeningAnswerBody one answer sent with CreateApplication
+type ScreeningAnswerBody struct {
+	QuestionID string          `json:"question_id"`
+	Value      json.RawMessage `json:"value"`
+}
+
+// rawToString turns a JSON value into the plain string we store: strings are
+// unquoted, arrays/numbers/bools are kept as their JSON text.
+func rawToString(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+func ruleOf(q models.ScreeningQuestion) utils.ScreeningRule {
+	return utils.ScreeningRule{Action: q.KnockoutAction, Operator: q.KnockoutOperator, Value: q.KnockoutValue}
+}
+
+// screeningQuestionView hides knockout rules from candidates so they cannot
+// tailor answers to pass them.
+func screeningQuestionView(q models.ScreeningQuestion, staff bool) gin.H {
+	var opts []string
+	_ = json.Unmarshal([]byte(q.Options), &opts)
+	if opts == nil {
+		opts = []string{}
+	}
+	v := gin.H{
+		"id":       q.ID,
+		"position": q.Position,
+		"prompt":   q.Prompt,
+		"type":     q.Type,
+		"options":  opts,
+		"required": q.Required,
+	}
+	if staff {
+		v["knockout_action"] = q.KnockoutAction
+		v["knockout_operator"] = q.KnockoutOperator
+		v["knockout_value"] = q.KnockoutValue
+	}
+	return v
+}
+
+func isStaff(c *gin.Context) bool {
+	rv, _ := c.Get("user_role")
+	return rv == "hr" || rv == "hm"
+}
+
+// GET /api/jobs/:id/screening-questions
+func ListScreeningQuestions(c *gin.Context) {
+	var qs []models.ScreeningQuestion
+	if err := models.DB.Where("job_id = ?", c.Param("id")).Order("position asc").Find(&qs).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	staff := isStaff(c)
+	out := make([]gin.H, 0, len(qs))
+	for _, q := range qs {
+		out = append(out, screeningQuestionView(q, staff))
+	}
+	respond.OK(c, gin.H{"questions": out})
+}
+
+// PUT /api/jobs/:id/screening-questions (HR) — replaces the job's question set
+func ReplaceScreeningQuestions(c *gin.Context) {
+	jobID := c.Param("id")
+	var job models.JobPosting
+	if err := models.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "JOB_NOT_FOUND")
+		return
+	}
+	var body struct {
+		Questions []ScreeningQuestionBody `json:"questions"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+
+	qs := make([]models.ScreeningQuestion, 0, len(body.Questions))
+	for i, b := range body.Questions {
+		if strings.TrimSpace(b.Prompt) == "" || !utils.ValidQuestionType(b.Type) {
+			respond.Error(c, http.StatusBadRequest, "SCREENING_QUESTION_INCOMPLETE", gin.H{"question": i + 1})
+			return
+		}
+		if b.Type == utils.QuestionSingleChoice && len(b.Options) < 2 {
+			respond.Error(c, http.StatusBadRequest, "SCREENING_QUESTION_OPTIONS", gin.H{"question": i + 1})
+			return
+		}
+		q := models.ScreeningQuestion{
+			ID:               b.ID,
+			JobID:            jobID,
+			Position:         i + 1,
+			Prompt:           strings.TrimSpace(b.Prompt),
+			Type:             b.Type,
+			Options:          utils.SkillListJSON(b.Options),
+			Required:         b.Required,
+			KnockoutAction:   b.KnockoutAction,
+			KnockoutOperator: b.KnockoutOperator,
+			KnockoutValue:    rawToString(b.KnockoutValue),
+		}
+		// eq/neq compare against the normalized answer, so normalize the rule
+		// value the same way (true -> "yes", "5.0" -> "5")
+		if q.KnockoutOperator == "eq" || q.KnockoutOperator == "neq" {
+			if v, err := utils.NormalizeAnswer(q.Type, q.Options, q.KnockoutValue); err == nil {
+				q.KnockoutValue = v
+			}
+		}
+		if err := utils.ValidateRule(q.Type, ruleOf(q)); err != nil {
+			respond.Error(c, http.StatusBadRequest, "SCREENING_QUESTION_INVALID", gin.H{"question": i + 1, "reason": err.Error()})
+			return
+		}
+		qs = append(qs, q)
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		keep := []string{}
+		for i := range qs {
+			if qs[i].ID != "" {
+				var n int64
+				tx.Model(&models.ScreeningQuestion{}).Where("id = ? AND job_id = ?", qs[i].ID, jobID).Count(&n)
+				if n == 0 {
+					qs[i].ID = "" // unknown id: treat as new question
+				}
+			}
+			if qs[i].ID == "" {
+				qs[i].ID = uuid.NewString()
+			}
+			keep = append(keep, qs[i].ID)
+		}
+		del := tx.Where("job_id = ?", jobID)
+		if len(keep) > 0 {
+			del = del.Where("id NOT IN ?", keep)
+		}
+		if err := del.Delete(&models.ScreeningQuestion{}).Error; err != nil {
+			return err
+		}
+		for i := range qs {
+			if err := tx.Save(&qs[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(qs))
+	for _, q := range qs {
+		out = append(out, screeningQuestionView(q, true))
+	}
+	respond.OK(c, gin.H{"questions": out})
+}
+
+// screeningResult is what CreateApplication needs to persist after validation
+type screeningResult struct {
+	Answers []models.ScreeningAnswer
+	Outcome string   // ""|passed|flagged|rejected
+	Reasons []string // prompts of the questions that fired a rule
+}
+
+// evaluateScreening validates answers against the job's questions and works
+// out the knockout outcome. The returned error message is safe to show.
+func evaluateScreening(jobID string, answers []ScreeningAnswerBody) (screeningResult, *respond.Problem) {
+	var res screeningResult
+	var qs []models.ScreeningQuestion
+	models.DB.Where("job_id = ?", jobID).Order("position asc").Find(&qs)
+	if len(qs) == 0 {
+		return res, nil
+	}
+
+	given := map[string]string{}
+	for _, a := range answers {
+		given[a.QuestionID] = rawToString(a.Value)
+	}
+
+	rejected, flagged := false, false
+	for _, q := range qs {
+		raw, ok := given[q.ID]
+		if !ok || strings.TrimSpace(raw) == "" {
+			if q.Required {
+				return res, respond.New(http.StatusBadRequest, "SCREENING_ANSWER_MISSING", gin.H{"question": q.Prompt})
+			}
+			continue
+		}
+		val, err := utils.NormalizeAnswer(q.Type, q.Options, raw)
+		if err != nil {
+			return res, respond.New(http.StatusBadRequest, "SCREENING_ANSWER_INVALID", gin.H{"question": q.Prompt, "reason": err.Error()})
+		}
+		ans := models.ScreeningAnswer{QuestionID: q.ID, Prompt: q.Prompt, Value: val}
+		if utils.KnockoutTriggered(ruleOf(q), val) {
+			ans.Knockout = q.KnockoutAction
+			res.Reasons = append(res.Reasons, q.Prompt)
+			if q.KnockoutAction == utils.KnockoutReject {
+				rejected = true
+			} else {
+				flagged = true
+			}
+		}
+		res.Answers = append(res.Answers, ans)
+	}
+
+	switch {
+	case rejected:
+		res.Outcome = "rejected"
+	case flagged:
+		res.Outcome = "flagged"
+	default:
+		res.Outcome = "passed"
+	}
+	return res, nil
+}
+
+// recordScreening stores the answers of a freshly created application and
+// applies the knockout outcome (auto-reject or flag) with a timeline entry.
+func recordScreening(tx *gorm.DB, app *models.Application, res screeningResult) error {
+	for i := range res.Answers {
+		res.Answers[i].ID = uuid.NewString()
+		res.Answers[i].ApplicationID = app.ID
+		if err := tx.Create(&res.Answers[i]).Error; err != nil {
+			return err
+		}
+	}
+	if res.Outcome == "" {
+		return nil
+	}
+
+	previous := app.Status
+	updates := map[string]interface{}{"screening_outcome": res.Outcome}
+	app.ScreeningOutcome = res.Outcome
+	var tl *models.ApplicationTimeline
+	switch res.Outcome {
+	case "rejected":
+		app.Status = "rejected"
+		app.UpdatedAt = time.Now()
+		updates["status"] = app.Status
+		updates["updated_at"] = app.UpdatedAt
+		e := timelineEntry(app.ID, "rejected", app.UpdatedAt, i18n.M("timeline.SCREENING_REJECTED", map[string]any{"questions": strings.Join(res.Reasons, "; ")}))
+		tl = &e
+	case "flagged":
+		e := timelineEntry(app.ID, app.Status, time.Now(), i18n.M("timeline.SCREENING_FLAGGED", map[string]any{"questions": strings.Join(res.Reasons, "; ")}))
+		tl = &e
+	}
+	if err := tx.Model(&models.Application{}).Where("id = ?", app.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	if tl != nil {
+		if err := tx.Create(tl).Error; err != nil {
+			return err
+		}
+		return emitTimelineEvent(tx, *app, *tl, previous, false)
+	}
+	return nil
+}
+
+// hideScreening clears what the knockout rules decided about app (its
+// outcome and the action each answer fired) when role is a candidate's, so a
+// response never tells them which answers to change.
+func hideScreening(role string, app *models.Application, answers []models.ScreeningAnswer) {
+	if !applicantOnly(role) {
+		return
+	}
+	app.ScreeningOutcome = ""
+	for i := range answers {
+		answers[i].Knockout = ""
+	}
+}
+
+// loadScreeningAnswers returns the answers of an application in question order.
+func loadScreeningAnswers(appID string) []models.ScreeningAnswer {
+	var answers []models.ScreeningAnswer
+	models.DB.Where("application_id = ?", appID).Find(&answers)
+	var qs []models.ScreeningQuestion
+	ids := make([]string, 0, len(answers))
+	for _, a := range answers {
+		ids = append(ids, a.QuestionID)
+	}
+	pos := map[string]int{}
+	if len(ids) > 0 {
+		models.DB.Where("id IN ?", ids).Find(&qs)
+		for _, q := range qs {
+			pos[q.ID] = q.Position
+		}
+	}
+	sort.SliceStable(answers, func(i, j int) bool { return pos[answers[i].QuestionID] < pos[answers[j].QuestionID] })
+	if answers == nil {
+		answers = []models.ScreeningAnswer{}
+	}
+	return answers
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+)
+
+// closedStatuses are terminal: the candidate can no longer withdraw or edit.
+var closedStatuses = map[string]bool{"hired": true, "rejected": true, "withdrawn": true}
+
+// WithdrawBody request body for POST /api/applications/:id/withdraw
+type WithdrawBody struct {
+	Reason string `json:"reason"`
+}
+
+// EditApplicationBody request body for PATCH /api/applications/:id.
+// Omitted fields are left unchanged; screening_answers replaces all answers.
+type EditApplicationBody struct {
+	CoverLetter      *string               `json:"cover_letter"`
+	ScreeningAnswers []ScreeningAnswerBody `json:"screening_answers"`
+}
+
+// revisionAnswer is how screening answers are stored on a revision.
+type revisionAnswer struct {
+	QuestionID string `json:"question_id"`
+	Prompt     string `json:"prompt"`
+	Value      string `json:"value"`
+}
+
+// ownApplication loads :id and checks it belongs to the calling candidate.
+func ownApplication(c *gin.Context) (models.Application, bool) {
+	var app models.Application
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&app).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "APPLICATION_NOT_FOUND")
+		return app, false
+	}
+	uid, _ := c.Get("user_id")
+	if app.ApplicantID != uid {
+		respond.Error(c, http.StatusForbidden, "FORBIDDEN")
+		return app, false
+	}
+	return app, true
+}
+
+// notifyApplicationOwners tells HR about a candidate action: the HR user who
+// posted the job and the assigned reviewer, if any.
+func notifyApplicationOwners(db *gorm.DB, app models.Application, title, message i18n.Msg) {
+	recipients := map[string]bool{}
+	var job models.JobPosting
+	if db.Select("id, created_by").Where("id = ?", app.JobID).Limit(1).Find(&job).RowsAffected > 0 && job.CreatedBy != "" {
+		recipients[job.CreatedBy] = true
+	}
+	if app.ReviewerID != "" {
+		recipients[app.ReviewerID] = true
+	}
+	for id := range recipients {
+		notifyUser(db, id, "application", title, message, map[string]string{"application_id": app.ID, "job_id": app.JobID})
+	}
+}
+
+// snapshotRevision stores the application's current cover letter and answers
+// as the next version.
+func snapshotRevision(tx *gorm.DB, app models.Application, editedBy string) (models.ApplicationRevision, error) {
+	var answers []models.ScreeningAnswer
+	tx.Where("application_id = ?", app.ID).Find(&answers)
+	out := make([]revisionAnswer, 0, len(answers))
+	for _, a := range answers {
+		out = append(out, revisionAnswer{QuestionID: a.QuestionID, Prompt: a.Prompt, Value: a.Value})
+	}
+	raw, _ := json.Marshal(out)
+	var last models.ApplicationRevision
+	tx.Where("application_id = ?", app.ID).Order("version desc").Limit(1).Find(&last)
+	rev := models.ApplicationRevision{
+		ID:               uuid.NewString(),
+		ApplicationID:    app.ID,
+		Version:          last.Version + 1,
+		CoverLetter:      app.CoverLetter,
+		ScreeningAnswers: string(raw),
+		EditedBy:         editedBy,
+	}
+	return rev, tx.Create(&rev).Error
+}
+
+// POST /api/applications/:id/withdraw (candidate) — {"reason": "..."} optional
+func WithdrawApplication(c *gin.Context) {
+	var body WithdrawBody
+	_ = c.ShouldBindJSON(&body)
+	app, ok := ownApplication(c)
+	if !ok {
+		return
+	}
+	if closedStatuses[app.Status] {
+		respond.Error(c, http.StatusBadRequest, "APPLICATION_CLOSED", gin.H{"status": app.Status})
+		return
+	}
+	desc := i18n.M("timeline.WITHDRAWN")
+	if reason := clip(body.Reason, 1000); reason != "" {
+		desc = i18n.M("timeline.WITHDRAWN_REASON", map[string]any{"reason": reason})
+	}
+
+	var tl models.ApplicationTimeline
+	err := data().Transaction(func(tx store.Store) error {
+		// checked again under the lock: HR may have closed it meanwhile
+		var err error
+		if app, err = lockApplication(tx, app.ID); err != nil {
+			return err
+		}
+		if closedStatuses[app.Status] {
+			return respond.New(http.StatusBadRequest, "APPLICATION_CLOSED", gin.H{"status": app.Status})
+		}
+		db := dataDB(tx)
+		if tl, err = applyStatusChange(db, &app, "withdrawn", desc); err != nil {
+			return err
+		}
+		// an open offer goes with the application, wherever it was in approval
+		return db.Model(&models.Offer{}).Where("application_id = ? AND status IN ?", app.ID, openOfferStatuses).
+			Update("status", "withdrawn").Error
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	notifyApplicationOwners(models.DB, app, i18n.M("notifications.APPLICATION_WITHDRAWN_TITLE"), desc)
+	hideScreening(c.GetString("user_role"), &app, nil)
+	respond.OK(c, gin.H{"application": app, "timeline": tl})
+}
+
+// PATCH /api/applications/:id (candidate) — edit cover letter and screening
+// answers while the application is still submitted. The previous content is
+// kept as a revision.
+func EditApplication(c *gin.Context) {
+	var body EditApplicationBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if body.CoverLetter == nil && body.ScreeningAnswers == nil {
+		respond.Error(c, http.StatusBadRequest, "NOTHING_TO_UPDATE")
+		return
+	}
+	app, ok := ownApplication(c)
+	if !ok {
+		return
+	}
+	if app.Status != "submitted" {
+		respond.Error(c, http.StatusBadRequest, "APPLICATION_NOT_EDITABLE")
+		return
+	}
+	var screening screeningResult
+	if body.ScreeningAnswers != nil {
+		var p *respond.Problem
+		if screening, p = evaluateScreening(app.JobID, body.ScreeningAnswers); p != nil {
+			respond.Fail(c, p)
+			return
+		}
+	}
+
+	uid, _ := c.Get("user_id")
+	editedBy, _ := uid.(string)
+	var rev models.ApplicationRevision
+	err := data().Transaction(func(st store.Store) error {
+		var err error
+		if app, err = lockApplication(st, app.ID); err != nil {
+			return err
+		}
+		if app.Status != "submitted" {
+			return respond.New(http.StatusBadRequest, "APPLICATION_NOT_EDITABLE")
+		}
+		tx := dataDB(st)
+		// the first edit also keeps the application as originally submitted
+		var n int64
+		tx.Model(&models.ApplicationRevision{}).Where("application_id = ?", app.ID).Count(&n)
+		if n == 0 {
+			if _, err := snapshotRevision(tx, app, app.ApplicantID); err != nil {
+				return err
+			}
+		}
+		var coverLetter, answers bool
+		if body.CoverLetter != nil {
+			app.CoverLetter = *body.CoverLetter
+			if err := tx.Model(&models.Application{}).Where("id = ?", app.ID).Update("cover_letter", app.CoverLetter).Error; err != nil {
+				return err
+			}
+			coverLetter = true
+		}
+		if body.ScreeningAnswers != nil {
+			if err := tx.Where("application_id = ?", app.ID).Delete(&models.ScreeningAnswer{}).Error; err != nil {
+				return err
+			}
+			app.ScreeningOutcome = ""
+			if err := tx.Model(&models.Application{}).Where("id = ?", app.ID).Update("screening_outcome", "").Error; err != nil {
+				return err
+			}
+			// knockout rules apply to the new answers as they did on apply
+			if err := recordScreening(tx, &app, screening); err != nil {
+				return err
+			}
+			answers = true
+		}
+		if rev, err = snapshotRevision(tx, app, editedBy); err != nil {
+			return err
+		}
+		version := map[string]any{"version": rev.Version}
+		desc := i18n.M("timeline.CANDIDATE_UPDATED_COVER_LETTER", version)
+		switch {
+		case coverLetter && answers:
+			desc = i18n.M("timeline.CANDIDATE_UPDATED_BOTH", version)
+		case answers:
+			desc = i18n.M("timeline.CANDIDATE_UPDATED_ANSWERS", version)
+		}
+		return addTimelineNote(tx, app, desc)
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	notifyApplicationOwners(models.DB, app, i18n.M("notifications.APPLICATION_UPDATED_TITLE"),
+		i18n.M("notifications.APPLICATION_UPDATED", map[string]any{"version": rev.Version}))
+	saved := loadScreeningAnswers(app.ID)
+	hideScreening(c.GetString("user_role"), &app, saved)
+	respond.OK(c, gin.H{"application": app, "version": rev.Version, "screening": gin.H{"outcome": app.ScreeningOutcome, "answers": saved}})
+}
+
+// GET /api/applications/:id/revisions — the candidate (own) or HR/HM
+func ListApplicationRevisions(c *gin.Context) {
+	rv, _ := c.Get("user_role")
+	if applicantOnly(rv) {
+		if _, ok := ownApplication(c); !ok {
+			return
+		}
+	}
+	var revs []models.ApplicationRevision
+	if err := models.DB.Where("application_id = ?", c.Param("id")).Order("version asc").Find(&revs).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(revs))
+	for _, r := range revs {
+		var answers []revisionAnswer
+		_ = json.Unmarshal([]byte(r.ScreeningAnswers), &answers)
+		out = append(out, gin.H{"version": r.Version, "cover_letter": r.CoverLetter, "screening_answers": answers, "edited_by": r.EditedBy, "created_at": r.CreatedAt})
+	}
+	respond.OK(c, gin.H{"revisions": out})
+}
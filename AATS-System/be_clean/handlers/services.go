@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/respond"
+	"aats-backend-clean/services"
+	"aats-backend-clean/utils"
+)
+
+// Services holds the business rules the handlers call into; main builds
+// them on Store.
+var Services *services.Services
+
+// svc is Services, or services on data() when none were set (tests that
+// only swap models.DB).
+func svc() *services.Services {
+	if Services != nil {
+		return Services
+	}
+	return services.New(data(), os.Getenv("JWT_SECRET"))
+}
+
+// toProblem is a service error as a problem response: rule failures keep
+// their code, anything else is logged and answered as INTERNAL_ERROR.
+func toProblem(err error) *respond.Problem {
+	var p *respond.Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	log.Printf("service error: %v", err)
+	return respond.New(http.StatusInternalServerError, "INTERNAL_ERROR")
+}
+
+// fail writes a service error as a problem response.
+func fail(c *gin.Context, err error) {
+	respond.Fail(c, toProblem(err))
+}
+
+// policyProblem refuses an application for the first violation, listing
+// all of them explained in the caller's language.
+func policyProblem(c *gin.Context, violations []utils.PolicyViolation) *respond.Problem {
+	explainViolations(respond.Language(c), violations)
+	return respond.New(http.StatusBadRequest, violations[0].Code, violations[0].Params, gin.H{"rule": violations[0].Rule, "violations": violations})
+}
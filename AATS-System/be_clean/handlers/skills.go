@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+)
+
+// SkillBody request body for creating/updating a canonical skill
+type SkillBody struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Synonyms []string `json:"synonyms"`
+}
+
+type skillView struct {
+	models.Skill
+	Synonyms []string `json:"synonyms"`
+}
+
+// loadSkillTaxonomy builds the in-memory taxonomy from the skills tables.
+// The tables are small, so it is rebuilt per request instead of cached.
+func loadSkillTaxonomy(db *gorm.DB) *utils.SkillTaxonomy {
+	tax := utils.NewSkillTaxonomy()
+	var skills []models.Skill
+	db.Find(&skills)
+	var syns []models.SkillSynonym
+	db.Find(&syns)
+	bySkill := map[string][]string{}
+	for _, s := range syns {
+		bySkill[s.SkillID] = append(bySkill[s.SkillID], s.Alias)
+	}
+	for _, s := range skills {
+		tax.Add(s.Name, s.Category, bySkill[s.ID]...)
+	}
+	return tax
+}
+
+// normalizeSkillsJSON normalizes a stored skills value (JSON array or CSV)
+// and returns the canonical JSON array.
+func normalizeSkillsJSON(tax *utils.SkillTaxonomy, raw string) string {
+	return utils.SkillListJSON(tax.Normalize(utils.ParseSkillList(raw)))
+}
+
+// applyMatchScore fills NormalizedSkills, MatchScore and MatchBreakdown on app
+// from the job's requirements.
+func applyMatchScore(tax *utils.SkillTaxonomy, app *models.Application, job models.JobPosting) {
+	candidate := tax.Normalize(utils.ParseSkillList(app.Skills))
+	app.NormalizedSkills = utils.SkillListJSON(candidate)
+
+	required := job.MinExperienceYears
+	if required <= 0 {
+		required = utils.DefaultMinYears(job.ExperienceLevel)
+	}
+	b := utils.ComputeMatch(
+		candidate,
+		utils.ExperienceYears(app.Experience),
+		tax.Normalize(utils.ParseSkillList(job.MustHaveSkills)),
+		tax.Normalize(utils.ParseSkillList(job.NiceToHaveSkills)),
+		required,
+	)
+	app.MatchScore = b.Score
+	if raw, err := json.Marshal(b); err == nil {
+		app.MatchBreakdown = string(raw)
+	}
+}
+
+// recomputeMatchScores re-scores every application of a job (or of all jobs
+// when jobID is empty). Returns the number of applications updated.
+func recomputeMatchScores(db *gorm.DB, jobID string) (int, error) {
+	tax := loadSkillTaxonomy(db)
+	q := db.Model(&models.JobPosting{})
+	if jobID != "" {
+		q = q.Where("id = ?", jobID)
+	}
+	var jobs []models.JobPosting
+	if err := q.Find(&jobs).Error; err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, job := range jobs {
+		var apps []models.Application
+		if err := db.Where("job_id = ?", job.ID).Find(&apps).Error; err != nil {
+			return updated, err
+		}
+		for i := range apps {
+			applyMatchScore(tax, &apps[i], job)
+			if err := db.Model(&models.Application{}).Where("id = ?", apps[i].ID).Updates(map[string]interface{}{
+				"normalized_skills": apps[i].NormalizedSkills,
+				"match_score":       apps[i].MatchScore,
+				"match_breakdown":   apps[i].MatchBreakdown,
+			}).Error; err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// replaceSynonyms rewrites the synonym rows of a skill. Aliases that already
+// belong to another skill are rejected.
+func replaceSynonyms(tx *gorm.DB, skillID string, synonyms []string) error {
+	if err := tx.Where("skill_id = ?", skillID).Delete(&models.SkillSynonym{}).Error; err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, s := range synonyms {
+		key := utils.SkillKey(s)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		syn := models.SkillSynonym{ID: uuid.NewString(), SkillID: skillID, Alias: strings.TrimSpace(s), AliasKey: key}
+		if err := tx.Create(&syn).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GET /api/skills?q=&category=
+func ListSkills(c *gin.Context) {
+	q := models.DB.Order("name asc")
+	if cat := c.Query("category"); cat != "" {
+		q = q.Where("category = ?", cat)
+	}
+	var skills []models.Skill
+	if err := q.Find(&skills).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	ids := make([]string, 0, len(skills))
+	for _, s := range skills {
+		ids = append(ids, s.ID)
+	}
+	bySkill := map[string][]string{}
+	if len(ids) > 0 {
+		var syns []models.SkillSynonym
+		models.DB.Where("skill_id IN ?", ids).Order("alias asc").Find(&syns)
+		for _, s := range syns {
+			bySkill[s.SkillID] = append(bySkill[s.SkillID], s.Alias)
+		}
+	}
+
+	// q matches the canonical name or any synonym (key-folded)
+	needle := utils.SkillKey(c.Query("q"))
+	out := make([]skillView, 0, len(skills))
+	for _, s := range skills {
+		if needle != "" {
+			hit := strings.Contains(utils.SkillKey(s.Name), needle)
+			for _, a := range bySkill[s.ID] {
+				hit = hit || strings.Contains(utils.SkillKey(a), needle)
+			}
+			if !hit {
+				continue
+			}
+		}
+		syn := bySkill[s.ID]
+		if syn == nil {
+			syn = []string{}
+		}
+		out = append(out, skillView{Skill: s, Synonyms: syn})
+	}
+	respond.OK(c, gin.H{"skills": out})
+}
+
+// POST /api/skills (HR)
+func CreateSkill(c *gin.Context) {
+	var body SkillBody
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Name) == "" {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	tax := loadSkillTaxonomy(models.DB)
+	if _, known := tax.Canonical(body.Name); known {
+		respond.Error(c, http.StatusConflict, "SKILL_EXISTS")
+		return
+	}
+	skill := models.Skill{ID: uuid.NewString(), Name: strings.TrimSpace(body.Name), Category: body.Category}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&skill).Error; err != nil {
+			return err
+		}
+		return replaceSynonyms(tx, skill.ID, body.Synonyms)
+	})
+	if err != nil {
+		respond.Error(c, http.StatusConflict, "SKILL_EXISTS")
+		return
+	}
+	respond.Created(c, gin.H{"skill": skill})
+}
+
+// PUT /api/skills/:id (HR) — synonyms, when sent, replace the existing list
+func UpdateSkill(c *gin.Context) {
+	id := c.Param("id")
+	var skill models.Skill
+	if err := models.DB.Where("id = ?", id).First(&skill).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "SKILL_NOT_FOUND")
+		return
+	}
+	var body SkillBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if strings.TrimSpace(body.Name) != "" {
+		skill.Name = strings.TrimSpace(body.Name)
+	}
+	if body.Category != "" {
+		skill.Category = body.Category
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&skill).Error; err != nil {
+			return err
+		}
+		if body.Synonyms != nil {
+			return replaceSynonyms(tx, skill.ID, body.Synonyms)
+		}
+		return nil
+	})
+	if err != nil {
+		respond.Error(c, http.StatusConflict, "SKILL_EXISTS")
+		return
+	}
+	respond.OK(c, gin.H{"skill": skill})
+}
+
+// DELETE /api/skills/:id (HR)
+func DeleteSkill(c *gin.Context) {
+	id := c.Param("id")
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("skill_id = ?", id).Delete(&models.SkillSynonym{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Skill{}).Error
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{})
+}
+
+// POST /api/skills/normalize {"skills": ["golang","React.js"]}
+// Lets the frontend show canonical names before submitting.
+func NormalizeSkills(c *gin.Context) {
+	var body struct {
+		Skills []string `json:"skills"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	tax := loadSkillTaxonomy(models.DB)
+	type item struct {
+		Input     string `json:"input"`
+		Canonical string `json:"canonical"`
+		Category  string `json:"category,omitempty"`
+		Known     bool   `json:"known"`
+	}
+	items := make([]item, 0, len(body.Skills))
+	for _, s := range body.Skills {
+		name, known := tax.Canonical(s)
+		items = append(items, item{Input: s, Canonical: name, Category: tax.Category(name), Known: known})
+	}
+	respond.OK(c, gin.H{"skills": tax.Normalize(body.Skills), "items": items})
+}
+
+// POST /api/applications/match/recompute?job_id= (HR)
+// Re-scores applications after the taxonomy or a job's requirements change.
+func RecomputeMatchScores(c *gin.Context) {
+	n, err := recomputeMatchScores(models.DB, c.Query("job_id"))
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"updated": n})
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+)
+
+// Sources used when an application does not match a configured channel.
+const (
+	SourceDirect = "direct" // no attribution at all
+	SourceOther  = "other"  // attribution given but not a known channel
+	// SourceReferral is set for applications made through an employee referral invite
+	SourceReferral = "referral"
+)
+
+var sourceKinds = map[string]bool{"job_board": true, "referral": true, "careers_page": true, "social": true, "agency": true, "event": true, "other": true}
+
+// TrackingBody is the attribution a candidate's browser sends with
+// CreateApplication (taken from the landing URL).
+type TrackingBody struct {
+	Source      string `json:"source"`
+	UTMSource   string `json:"utm_source"`
+	UTMMedium   string `json:"utm_medium"`
+	UTMCampaign string `json:"utm_campaign"`
+	UTMTerm     string `json:"utm_term"`
+	UTMContent  string `json:"utm_content"`
+	Referrer    string `json:"referrer"`
+	ApplyLink   string `json:"apply_link"` // ApplyLink.Token
+}
+
+// SourceChannelBody request body for source channels
+type SourceChannelBody struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Active *bool  `json:"active"`
+}
+
+// ApplyLinkBody request body for POST /api/jobs/:id/apply-links
+type ApplyLinkBody struct {
+	Source      string `json:"source"`
+	UTMSource   string `json:"utm_source"`
+	UTMMedium   string `json:"utm_medium"`
+	UTMCampaign string `json:"utm_campaign"`
+	UTMContent  string `json:"utm_content"`
+	Label       string `json:"label"`
+}
+
+// clip trims s and cuts it to at most n bytes without splitting a character.
+func clip(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func sourceKey(s string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), " ", "_")
+}
+
+// applyTracking resolves the source of a new application. A valid apply link
+// for the same job wins; otherwise source (or utm_source) must name an active
+// channel, anything else is recorded as "other", and no attribution at all
+// as "direct".
+func applyTracking(db *gorm.DB, app *models.Application, t TrackingBody) {
+	app.UTMSource = clip(t.UTMSource, 255)
+	app.UTMMedium = clip(t.UTMMedium, 255)
+	app.UTMCampaign = clip(t.UTMCampaign, 255)
+	app.UTMTerm = clip(t.UTMTerm, 255)
+	app.UTMContent = clip(t.UTMContent, 255)
+	app.Referrer = clip(t.Referrer, 2048)
+
+	if t.ApplyLink != "" {
+		var link models.ApplyLink
+		if db.Where("token = ? AND job_id = ?", t.ApplyLink, app.JobID).Limit(1).Find(&link).RowsAffected > 0 {
+			app.ApplyLinkID = link.ID
+			app.Source = link.Source
+			// the link's own UTM values are authoritative
+			for dst, v := range map[*string]string{&app.UTMSource: link.UTMSource, &app.UTMMedium: link.UTMMedium, &app.UTMCampaign: link.UTMCampaign, &app.UTMContent: link.UTMContent} {
+				if v != "" {
+					*dst = v
+				}
+			}
+			return
+		}
+	}
+
+	for _, candidate := range []string{t.Source, t.UTMSource} {
+		key := sourceKey(candidate)
+		if key == "" {
+			continue
+		}
+		var n int64
+		db.Model(&models.SourceChannel{}).Where("key = ? AND active = ?", key, true).Count(&n)
+		if n > 0 {
+			app.Source = key
+			return
+		}
+	}
+	if t.Source != "" || app.UTMSource != "" || app.Referrer != "" {
+		app.Source = SourceOther
+	} else {
+		app.Source = SourceDirect
+	}
+}
+
+// GET /api/sources (HR) — ?active=true for the active ones only
+func ListSourceChannels(c *gin.Context) {
+	q := models.DB.Order("name asc")
+	if c.Query("active") == "true" {
+		q = q.Where("active = ?", true)
+	}
+	var channels []models.SourceChannel
+	if err := q.Find(&channels).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"sources": channels})
+}
+
+// POST /api/sources (HR)
+func CreateSourceChannel(c *gin.Context) {
+	var body SourceChannelBody
+	if err := c.ShouldBindJSON(&body); err != nil || sourceKey(body.Key) == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "key"})
+		return
+	}
+	key := sourceKey(body.Key)
+	if key == SourceDirect || key == SourceOther || key == SourceReferral {
+		respond.Error(c, http.StatusBadRequest, "SOURCE_KEY_RESERVED")
+		return
+	}
+	if body.Kind == "" {
+		body.Kind = "other"
+	}
+	if !sourceKinds[body.Kind] {
+		respond.Error(c, http.StatusBadRequest, "FIELD_ONE_OF", gin.H{"field": "kind", "allowed": "job_board, referral, careers_page, social, agency, event, other"})
+		return
+	}
+	ch := models.SourceChannel{ID: uuid.NewString(), Key: key, Name: strings.TrimSpace(body.Name), Kind: body.Kind, Active: true}
+	if ch.Name == "" {
+		ch.Name = body.Key
+	}
+	if body.Active != nil {
+		ch.Active = *body.Active
+	}
+	if err := models.DB.Create(&ch).Error; err != nil {
+		respond.Error(c, http.StatusConflict, "SOURCE_KEY_TAKEN")
+		return
+	}
+	respond.Created(c, gin.H{"source": ch})
+}
+
+// PUT /api/sources/:id (HR) — the key is immutable because applications store it
+func UpdateSourceChannel(c *gin.Context) {
+	var ch models.SourceChannel
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&ch).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "SOURCE_NOT_FOUND")
+		return
+	}
+	var body SourceChannelBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if strings.TrimSpace(body.Name) != "" {
+		ch.Name = strings.TrimSpace(body.Name)
+	}
+	if body.Kind != "" {
+		if !sourceKinds[body.Kind] {
+			respond.Error(c, http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "kind"})
+			return
+		}
+		ch.Kind = body.Kind
+	}
+	if body.Active != nil {
+		ch.Active = *body.Active
+	}
+	if err := models.DB.Save(&ch).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"source": ch})
+}
+
+func newLinkToken() string {
+	b := make([]byte, 5)
+	_, _ = rand.Read(b)
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+}
+
+// POST /api/jobs/:id/apply-links (HR)
+func CreateApplyLink(c *gin.Context) {
+	var job models.JobPosting
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "JOB_NOT_FOUND")
+		return
+	}
+	var body ApplyLinkBody
+	if err := c.ShouldBindJSON(&body); err != nil || sourceKey(body.Source) == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "source"})
+		return
+	}
+	var ch models.SourceChannel
+	if err := models.DB.Where("key = ?", sourceKey(body.Source)).First(&ch).Error; err != nil {
+		respond.Error(c, http.StatusBadRequest, "SOURCE_UNKNOWN")
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	link := models.ApplyLink{
+		ID:          uuid.NewString(),
+		Token:       newLinkToken(),
+		JobID:       job.ID,
+		Source:      ch.Key,
+		UTMSource:   clip(body.UTMSource, 255),
+		UTMMedium:   clip(body.UTMMedium, 255),
+		UTMCampaign: clip(body.UTMCampaign, 255),
+		UTMContent:  clip(body.UTMContent, 255),
+		Label:       clip(body.Label, 255),
+		CreatedBy:   createdBy,
+	}
+	if err := models.DB.Create(&link).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Created(c, gin.H{"link": link, "path": "/apply/" + link.Token})
+}
+
+// GET /api/jobs/:id/apply-links (HR) — with clicks, applications and hires per link
+func ListApplyLinks(c *gin.Context) {
+	var links []models.ApplyLink
+	if err := models.DB.Where("job_id = ?", c.Param("id")).Order("created_at desc").Find(&links).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	type stat struct {
+		ApplyLinkID string
+		Apps        int64
+		Hired       int64
+	}
+	var stats []stat
+	models.DB.Model(&models.Application{}).
+		Select("apply_link_id, count(*) as apps, SUM(CASE WHEN status = 'hired' THEN 1 ELSE 0 END) as hired").
+		Where("job_id = ? AND apply_link_id <> ''", c.Param("id")).Group("apply_link_id").Scan(&stats)
+	byLink := map[string]stat{}
+	for _, s := range stats {
+		byLink[s.ApplyLinkID] = s
+	}
+	out := make([]gin.H, 0, len(links))
+	for _, l := range links {
+		out = append(out, gin.H{"link": l, "path": "/apply/" + l.Token, "applications": byLink[l.ID].Apps, "hired": byLink[l.ID].Hired})
+	}
+	respond.OK(c, gin.H{"links": out})
+}
+
+// GET /api/apply-links/:token (public) — counts the click and returns what
+// the careers page needs to send back with the application.
+func ResolveApplyLink(c *gin.Context) {
+	var link models.ApplyLink
+	if err := models.DB.Where("token = ?", c.Param("token")).First(&link).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "APPLY_LINK_NOT_FOUND")
+		return
+	}
+	models.DB.Model(&models.ApplyLink{}).Where("id = ?", link.ID).UpdateColumn("clicks", gorm.Expr("clicks + 1"))
+	var job models.JobPosting
+	models.DB.Select("id, title, status").Where("id = ?", link.JobID).Limit(1).Find(&job)
+	respond.OK(c, gin.H{
+		"job_id":     link.JobID,
+		"job_title":  job.Title,
+		"job_status": job.Status,
+		"apply_link": link.Token,
+		"source":     link.Source,
+	})
+}
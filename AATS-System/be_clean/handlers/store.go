@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+)
+
+// Store is the repository layer the handlers read and write through; main
+// sets it to store.New(models.DB). Handlers not yet moved over still use
+// models.DB directly.
+var Store store.Store
+
+// data is Store, or a store on models.DB when none was set (tests that only
+// swap models.DB).
+func data() store.Store {
+	if Store != nil {
+		return Store
+	}
+	return store.New(models.DB)
+}
+
+// dataDB is the GORM handle of st (the transaction inside
+// data().Transaction) for the handlers' own queries, so they commit or roll
+// back with the repositories' writes. models.DB when st is not backed by
+// GORM (in-memory tests).
+func dataDB(st store.Store) *gorm.DB {
+	if db, ok := store.GormDB(st); ok {
+		return db
+	}
+	return models.DB
+}
+
+// lockApplication re-reads application id in tx holding its lock, so the
+// status checks that follow see what concurrent changes left.
+func lockApplication(tx store.Store, id string) (models.Application, error) {
+	app, err := tx.Applications().Lock(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return app, respond.New(http.StatusNotFound, "APPLICATION_NOT_FOUND")
+	}
+	return app, err
+}
+
+// lockOffer is lockApplication for an offer. Lock the application first
+// when both are needed, so transactions take the locks in the same order.
+func lockOffer(tx store.Store, id string) (models.Offer, error) {
+	var offer models.Offer
+	err := store.LockRow(dataDB(tx), &offer, "offers", id)
+	if errors.Is(err, store.ErrNotFound) {
+		return offer, respond.New(http.StatusNotFound, "OFFER_NOT_FOUND")
+	}
+	return offer, err
+}
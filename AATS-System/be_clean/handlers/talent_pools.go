@@ -0,0 +1,576 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+)
+
+// TalentPoolBody request body for talent pools
+type TalentPoolBody struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// PoolMemberBody request body for POST /api/talent-pools/:id/members.
+// Give candidate_id, or application_id to pool the applicant of an application.
+type PoolMemberBody struct {
+	CandidateID   string   `json:"candidate_id"`
+	ApplicationID string   `json:"application_id"`
+	Tags          []string `json:"tags"`
+}
+
+// PoolInviteBody request body for POST /api/talent-pools/:id/invite
+type PoolInviteBody struct {
+	JobID        string   `json:"job_id"`
+	CandidateIDs []string `json:"candidate_ids"` // empty = every member of the pool
+	Message      string   `json:"message"`
+}
+
+// candidateSkillProfile merges what the candidate's applications say about
+// them: the union of normalized skills and the highest experience.
+func candidateSkillProfile(db *gorm.DB, candidateID string) ([]string, float64) {
+	var apps []models.Application
+	db.Select("id, normalized_skills, experience").Where("applicant_id = ?", candidateID).Find(&apps)
+	seen := map[string]bool{}
+	skills := []string{}
+	years := 0.0
+	for _, a := range apps {
+		var s []string
+		_ = json.Unmarshal([]byte(a.NormalizedSkills), &s)
+		for _, k := range s {
+			if !seen[k] {
+				seen[k] = true
+				skills = append(skills, k)
+			}
+		}
+		if y := utils.ExperienceYears(a.Experience); y > years {
+			years = y
+		}
+	}
+	return skills, years
+}
+
+// refreshPoolProfiles updates the skills/experience snapshot of every pool
+// membership of a candidate (called when they apply again).
+func refreshPoolProfiles(db *gorm.DB, candidateID string) error {
+	var n int64
+	db.Model(&models.TalentPoolMember{}).Where("candidate_id = ?", candidateID).Count(&n)
+	if n == 0 {
+		return nil
+	}
+	skills, years := candidateSkillProfile(db, candidateID)
+	return db.Model(&models.TalentPoolMember{}).Where("candidate_id = ?", candidateID).
+		Updates(map[string]interface{}{"skills": utils.SkillListJSON(skills), "experience_years": years}).Error
+}
+
+// setTalentPoolConsent records a candidate's choice. Withdrawing consent
+// removes them from every pool.
+func setTalentPoolConsent(db *gorm.DB, candidateID string, consent bool) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", candidateID).
+			Updates(map[string]interface{}{"talent_pool_consent": consent, "talent_pool_consent_at": now}).Error; err != nil {
+			return err
+		}
+		if consent {
+			return nil
+		}
+		var ids []string
+		tx.Model(&models.TalentPoolMember{}).Where("candidate_id = ?", candidateID).Pluck("id", &ids)
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Where("member_id IN ?", ids).Delete(&models.TalentPoolTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&models.TalentPoolMember{}).Error
+	})
+}
+
+// replaceMemberTags sets the tags of a pool member.
+func replaceMemberTags(tx *gorm.DB, memberID string, tags []string) error {
+	if err := tx.Where("member_id = ?", memberID).Delete(&models.TalentPoolTag{}).Error; err != nil {
+		return err
+	}
+	for _, t := range normalizeTags(tags) {
+		if err := tx.Create(&models.TalentPoolTag{ID: uuid.NewString(), MemberID: memberID, Tag: t}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poolForRequest loads the pool named by :id.
+func poolForRequest(c *gin.Context) (models.TalentPool, bool) {
+	var pool models.TalentPool
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&pool).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "TALENT_POOL_NOT_FOUND")
+		return pool, false
+	}
+	return pool, true
+}
+
+// GET /api/talent-pools (HR) — with member counts
+func ListTalentPools(c *gin.Context) {
+	var pools []models.TalentPool
+	if err := models.DB.Order("name asc").Find(&pools).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	type count struct {
+		PoolID string
+		N      int64
+	}
+	var counts []count
+	models.DB.Model(&models.TalentPoolMember{}).Select("pool_id, count(*) as n").Group("pool_id").Scan(&counts)
+	byPool := map[string]int64{}
+	for _, c := range counts {
+		byPool[c.PoolID] = c.N
+	}
+	out := make([]gin.H, 0, len(pools))
+	for _, p := range pools {
+		out = append(out, gin.H{"pool": p, "members": byPool[p.ID]})
+	}
+	respond.OK(c, gin.H{"pools": out})
+}
+
+// POST /api/talent-pools (HR)
+func CreateTalentPool(c *gin.Context) {
+	var body TalentPoolBody
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Name) == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "name"})
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	pool := models.TalentPool{ID: uuid.NewString(), Name: strings.TrimSpace(body.Name), Description: strings.TrimSpace(body.Description), CreatedBy: createdBy}
+	if err := models.DB.Create(&pool).Error; err != nil {
+		respond.Error(c, http.StatusConflict, "TALENT_POOL_NAME_TAKEN")
+		return
+	}
+	respond.Created(c, gin.H{"pool": pool})
+}
+
+// PUT /api/talent-pools/:id (HR)
+func UpdateTalentPool(c *gin.Context) {
+	pool, ok := poolForRequest(c)
+	if !ok {
+		return
+	}
+	var body TalentPoolBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if strings.TrimSpace(body.Name) != "" {
+		pool.Name = strings.TrimSpace(body.Name)
+	}
+	pool.Description = strings.TrimSpace(body.Description)
+	if err := models.DB.Save(&pool).Error; err != nil {
+		respond.Error(c, http.StatusConflict, "TALENT_POOL_NAME_TAKEN")
+		return
+	}
+	respond.OK(c, gin.H{"pool": pool})
+}
+
+// DELETE /api/talent-pools/:id (HR) — removes the pool and its memberships,
+// not the candidates
+func DeleteTalentPool(c *gin.Context) {
+	pool, ok := poolForRequest(c)
+	if !ok {
+		return
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		tx.Model(&models.TalentPoolMember{}).Where("pool_id = ?", pool.ID).Pluck("id", &ids)
+		if len(ids) > 0 {
+			if err := tx.Where("member_id IN ?", ids).Delete(&models.TalentPoolTag{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("pool_id = ?", pool.ID).Delete(&models.TalentPoolMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&pool).Error
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{})
+}
+
+// POST /api/talent-pools/:id/members (HR) — the candidate must have consented
+func AddPoolMember(c *gin.Context) {
+	pool, ok := poolForRequest(c)
+	if !ok {
+		return
+	}
+	var body PoolMemberBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if body.ApplicationID != "" {
+		var app models.Application
+		if err := models.DB.Select("id, applicant_id").Where("id = ?", body.ApplicationID).First(&app).Error; err != nil {
+			respond.Error(c, http.StatusNotFound, "APPLICATION_NOT_FOUND")
+			return
+		}
+		body.CandidateID = app.ApplicantID
+	}
+	var cand models.User
+	if body.CandidateID == "" || activeCandidates(models.DB).Where("id = ?", body.CandidateID).Limit(1).Find(&cand).RowsAffected == 0 {
+		respond.Error(c, http.StatusNotFound, "CANDIDATE_NOT_FOUND")
+		return
+	}
+	if !cand.TalentPoolConsent {
+		respond.Error(c, http.StatusConflict, "TALENT_POOL_CONSENT_MISSING")
+		return
+	}
+	var n int64
+	models.DB.Model(&models.TalentPoolMember{}).Where("pool_id = ? AND candidate_id = ?", pool.ID, cand.ID).Count(&n)
+	if n > 0 {
+		respond.Error(c, http.StatusConflict, "POOL_MEMBER_EXISTS")
+		return
+	}
+
+	uid, _ := c.Get("user_id")
+	addedBy, _ := uid.(string)
+	skills, years := candidateSkillProfile(models.DB, cand.ID)
+	member := models.TalentPoolMember{
+		ID:                  uuid.NewString(),
+		PoolID:              pool.ID,
+		CandidateID:         cand.ID,
+		Skills:              utils.SkillListJSON(skills),
+		ExperienceYears:     years,
+		SourceApplicationID: body.ApplicationID,
+		AddedBy:             addedBy,
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		return replaceMemberTags(tx, member.ID, body.Tags)
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Created(c, gin.H{"member": member, "tags": normalizeTags(body.Tags)})
+}
+
+// PUT /api/talent-pools/:id/members/:candidate_id (HR) — {"tags": [...]} replaces the tags
+func UpdatePoolMember(c *gin.Context) {
+	var member models.TalentPoolMember
+	if err := models.DB.Where("pool_id = ? AND candidate_id = ?", c.Param("id"), c.Param("candidate_id")).First(&member).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "POOL_MEMBER_NOT_FOUND")
+		return
+	}
+	var body PoolMemberBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if err := replaceMemberTags(models.DB, member.ID, body.Tags); err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"member": member, "tags": normalizeTags(body.Tags)})
+}
+
+// DELETE /api/talent-pools/:id/members/:candidate_id (HR)
+func RemovePoolMember(c *gin.Context) {
+	var member models.TalentPoolMember
+	if err := models.DB.Where("pool_id = ? AND candidate_id = ?", c.Param("id"), c.Param("candidate_id")).First(&member).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "POOL_MEMBER_NOT_FOUND")
+		return
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("member_id = ?", member.ID).Delete(&models.TalentPoolTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&member).Error
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{})
+}
+
+// GET /api/talent-pools/search?pool_id=&skills=go,react&min_years=3&tag=&q=&page=&limit= (HR)
+// Without pool_id every pool is searched. A candidate in several pools is
+// listed once per pool.
+func SearchTalentPools(c *gin.Context) {
+	page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+	q := models.DB.Model(&models.TalentPoolMember{})
+	// merged-away profiles keep memberships the survivor already had
+	q = q.Where("candidate_id NOT IN (?)", models.DB.Model(&models.User{}).Select("id").Where("COALESCE(merged_into, '') <> ''"))
+	if poolID := c.Query("pool_id"); poolID != "" {
+		q = q.Where("pool_id = ?", poolID)
+	}
+	if v := c.Query("skills"); v != "" {
+		tax := loadSkillTaxonomy(models.DB)
+		for _, s := range tax.Normalize(utils.ParseSkillList(v)) {
+			b, _ := json.Marshal(s)
+			q = q.Where("LOWER(skills) LIKE ?", "%"+strings.ToLower(string(b))+"%")
+		}
+	}
+	if v := c.Query("min_years"); v != "" {
+		years, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			respond.Error(c, http.StatusBadRequest, "FIELD_INVALID", gin.H{"field": "min_years"})
+			return
+		}
+		q = q.Where("experience_years >= ?", years)
+	}
+	if tag := strings.ToLower(strings.TrimSpace(c.Query("tag"))); tag != "" {
+		q = q.Where("id IN (?)", models.DB.Model(&models.TalentPoolTag{}).Select("member_id").Where("tag = ?", tag))
+	}
+	if term := strings.ToLower(strings.TrimSpace(c.Query("q"))); term != "" {
+		q = q.Where("candidate_id IN (?)", models.DB.Model(&models.User{}).Select("id").
+			Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?", "%"+term+"%", "%"+term+"%"))
+	}
+
+	var total int64
+	q.Count(&total)
+	var members []models.TalentPoolMember
+	if err := q.Order("experience_years desc, created_at desc").Offset(offset).Limit(limit).Find(&members).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+
+	memberIDs := make([]string, 0, len(members))
+	candIDs := make([]string, 0, len(members))
+	for _, m := range members {
+		memberIDs = append(memberIDs, m.ID)
+		candIDs = append(candIDs, m.CandidateID)
+	}
+	tags := map[string][]string{}
+	var tagRows []models.TalentPoolTag
+	models.DB.Where("member_id IN ?", memberIDs).Order("tag asc").Find(&tagRows)
+	for _, t := range tagRows {
+		tags[t.MemberID] = append(tags[t.MemberID], t.Tag)
+	}
+	users := map[string]models.User{}
+	var userRows []models.User
+	models.DB.Select("id, name, email, phone, last_contacted_at").Where("id IN ?", candIDs).Find(&userRows)
+	for _, u := range userRows {
+		users[u.ID] = u
+	}
+
+	out := make([]gin.H, 0, len(members))
+	for _, m := range members {
+		var skills []string
+		_ = json.Unmarshal([]byte(m.Skills), &skills)
+		u := users[m.CandidateID]
+		out = append(out, gin.H{
+			"pool_id":           m.PoolID,
+			"candidate_id":      m.CandidateID,
+			"name":              u.Name,
+			"email":             u.Email,
+			"phone":             u.Phone,
+			"skills":            skills,
+			"experience_years":  m.ExperienceYears,
+			"tags":              tags[m.ID],
+			"last_contacted_at": u.LastContactedAt,
+			"added_at":          m.CreatedAt,
+		})
+	}
+	respond.Page(c, gin.H{"members": out}, gin.H{"page": page, "limit": limit, "total": total})
+}
+
+// GET /api/talent-pools/:id/members (HR) — same filters as search, within one pool
+func ListPoolMembers(c *gin.Context) {
+	pool, ok := poolForRequest(c)
+	if !ok {
+		return
+	}
+	q := c.Request.URL.Query()
+	q.Set("pool_id", pool.ID)
+	c.Request.URL.RawQuery = q.Encode()
+	SearchTalentPools(c)
+}
+
+// POST /api/talent-pools/:id/invite (HR) — invites pooled candidates to apply
+// to a job. Candidates the application policy would block, or who already
+// applied, are skipped and reported.
+func InvitePoolToJob(c *gin.Context) {
+	pool, ok := poolForRequest(c)
+	if !ok {
+		return
+	}
+	var body PoolInviteBody
+	if err := c.ShouldBindJSON(&body); err != nil || body.JobID == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "job_id"})
+		return
+	}
+	var job models.JobPosting
+	if err := models.DB.Where("id = ?", body.JobID).First(&job).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "JOB_NOT_FOUND")
+		return
+	}
+	if job.Status != "" && job.Status != "active" {
+		respond.Error(c, http.StatusBadRequest, "JOB_NOT_OPEN")
+		return
+	}
+	q := models.DB.Where("pool_id = ?", pool.ID)
+	if len(body.CandidateIDs) > 0 {
+		q = q.Where("candidate_id IN ?", body.CandidateIDs)
+	}
+	var members []models.TalentPoolMember
+	q.Find(&members)
+
+	uid, _ := c.Get("user_id")
+	invitedBy, _ := uid.(string)
+	invite := map[string]any{"job_title": job.Title}
+	message := i18n.M("notifications.JOB_INVITE", invite)
+	if m := strings.TrimSpace(body.Message); m != "" {
+		message = i18n.Text(m)
+	}
+	now := time.Now()
+	invited := []string{}
+	skipped := []gin.H{}
+	for _, m := range members {
+		var cand models.User
+		if models.DB.Select("id, talent_pool_consent").Where("id = ?", m.CandidateID).Limit(1).Find(&cand).RowsAffected == 0 || !cand.TalentPoolConsent {
+			skipped = append(skipped, gin.H{"candidate_id": m.CandidateID, "reason": "no consent"})
+			continue
+		}
+		_, violations, err := svc().Applications.CheckPolicy(m.CandidateID, job.ID)
+		if err != nil {
+			skipped = append(skipped, gin.H{"candidate_id": m.CandidateID, "reason": "error"})
+			continue
+		}
+		if len(violations) > 0 {
+			skipped = append(skipped, gin.H{"candidate_id": m.CandidateID, "reason": violations[0].Rule})
+			continue
+		}
+		err = models.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&models.TalentPoolInvite{ID: uuid.NewString(), PoolID: pool.ID, CandidateID: m.CandidateID, JobID: job.ID, InvitedBy: invitedBy}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.User{}).Where("id = ?", m.CandidateID).Update("last_contacted_at", now).Error; err != nil {
+				return err
+			}
+			return notifyUser(tx, m.CandidateID, "job_invite", i18n.M("notifications.JOB_INVITE_TITLE", invite), message, map[string]string{"job_id": job.ID})
+		})
+		if err != nil {
+			skipped = append(skipped, gin.H{"candidate_id": m.CandidateID, "reason": "error"})
+			continue
+		}
+		invited = append(invited, m.CandidateID)
+	}
+	respond.OK(c, gin.H{"invited": invited, "skipped": skipped})
+}
+
+// PUT /api/me/talent-pool-consent (candidate) — {"consent": true|false}
+func UpdateTalentPoolConsent(c *gin.Context) {
+	var body struct {
+		Consent *bool `json:"consent"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Consent == nil {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "consent"})
+		return
+	}
+	uid, _ := c.Get("user_id")
+	id, _ := uid.(string)
+	if err := setTalentPoolConsent(models.DB, id, *body.Consent); err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"consent": *body.Consent})
+}
+
+// GET /api/candidates/:id (HR) — CRM view across all applications
+func GetCandidateProfile(c *gin.Context) {
+	var cand models.User
+	if err := models.DB.Where("id = ? AND role = ?", c.Param("id"), "candidate").First(&cand).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "CANDIDATE_NOT_FOUND")
+		return
+	}
+	var apps []struct {
+		models.Application
+		MatchScore float64 `json:"match_score"` // hidden on the model, shown to HR here
+	}
+	models.DB.Model(&models.Application{}).Select("id, job_id, status, submitted_date, match_score").Where("applicant_id = ?", cand.ID).Order("submitted_date desc").Find(&apps)
+	var members []models.TalentPoolMember
+	models.DB.Where("candidate_id = ?", cand.ID).Find(&members)
+	var notes []models.CandidateNote
+	models.DB.Where("candidate_id = ?", cand.ID).Order("created_at desc").Find(&notes)
+	skills, years := candidateSkillProfile(models.DB, cand.ID)
+	respond.OK(c, gin.H{
+		"candidate": gin.H{
+			"id": cand.ID, "name": cand.Name, "email": cand.Email, "phone": cand.Phone,
+			"talent_pool_consent": cand.TalentPoolConsent, "talent_pool_consent_at": cand.TalentPoolConsentAt,
+			"last_contacted_at": cand.LastContactedAt,
+			"custom_fields":     customFieldsOf(models.DB, CustomEntityUser, cand.ID, true),
+		},
+		"skills":           skills,
+		"experience_years": years,
+		"applications":     apps,
+		"pools":            members,
+		"notes":            notes,
+	})
+}
+
+// POST /api/candidates/:id/notes (HR) — {"content": "...", "contacted": true}
+// contacted also moves the last-contact date to now
+func CreateCandidateNote(c *gin.Context) {
+	var body struct {
+		Content   string `json:"content"`
+		Contacted bool   `json:"contacted"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Content) == "" {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "content"})
+		return
+	}
+	var n int64
+	models.DB.Model(&models.User{}).Where("id = ? AND role = ?", c.Param("id"), "candidate").Count(&n)
+	if n == 0 {
+		respond.Error(c, http.StatusNotFound, "CANDIDATE_NOT_FOUND")
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	var author models.User
+	models.DB.Select("id, name").Where("id = ?", createdBy).Limit(1).Find(&author)
+	note := models.CandidateNote{ID: uuid.NewString(), CandidateID: c.Param("id"), Author: author.Name, CreatedBy: createdBy, Content: strings.TrimSpace(body.Content)}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		if body.Contacted {
+			return tx.Model(&models.User{}).Where("id = ?", note.CandidateID).Update("last_contacted_at", time.Now()).Error
+		}
+		return nil
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Created(c, gin.H{"note": note})
+}
+
+// GET /api/candidates/:id/notes (HR)
+func ListCandidateNotes(c *gin.Context) {
+	var notes []models.CandidateNote
+	if err := models.DB.Where("candidate_id = ?", c.Param("id")).Order("created_at desc").Find(&notes).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{"notes": notes})
+}
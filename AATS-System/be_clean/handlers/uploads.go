@@ -1,6 +1,7 @@
 ﻿package handlers // แพ็กเกจ handlers สำหรับจัดการ API endpoint
 
 import (
+	"mime/multipart" // สำหรับไฟล์ที่อัปโหลด
 	"net/http"      // สำหรับ HTTP status และ response
 	"os"            // สำหรับจัดการไฟล์และโฟลเดอร์
 	"path/filepath" // สำหรับจัดการ path ของไฟล์
@@ -8,22 +9,16 @@ import (
 
 	"github.com/gin-gonic/gin" // Gin framework สำหรับสร้าง API
 	"github.com/google/uuid"   // สำหรับสร้าง UUID
+	"aats-backend-clean/respond"
 )
 
-// ฟังก์ชันสำหรับอัปโหลดไฟล์ resume (POST /api/uploads/resume)
-// รับไฟล์แบบ multipart/form-data โดยใช้ field name "file"
-func UploadResume(c *gin.Context) {
-	file, err := c.FormFile("file") // รับไฟล์จาก request
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"}) // error ถ้าไม่มีไฟล์
-		return
-	}
-
+// saveResumeFile บันทึกไฟล์ resume ลง uploads/resumes และคืน URL สาธารณะ
+// ใช้ร่วมกันระหว่าง UploadResume และการส่ง referral
+func saveResumeFile(c *gin.Context, file *multipart.FileHeader) (string, string, error) {
 	// สร้างโฟลเดอร์ปลายทางสำหรับเก็บไฟล์ ถ้ายังไม่มี
 	destDir := filepath.Join(".", "uploads", "resumes")
 	if err := os.MkdirAll(destDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot create upload dir"}) // error ถ้าสร้างโฟลเดอร์ไม่สำเร็จ
-		return
+		return "", "", err
 	}
 
 	// สร้างชื่อไฟล์ใหม่โดยใช้ UUID เพื่อป้องกันชื่อซ้ำ
@@ -32,19 +27,33 @@ func UploadResume(c *gin.Context) {
 
 	// บันทึกไฟล์ลงโฟลเดอร์ปลายทาง
 	if err := c.SaveUploadedFile(file, fullpath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot save file"}) // error ถ้าบันทึกไฟล์ไม่สำเร็จ
-		return
+		return "", "", err
 	}
 
 	// สร้าง URL สำหรับเข้าถึงไฟล์ที่อัปโหลด
-	publicURL := "/uploads/resumes/" + filename
+	return filename, "/uploads/resumes/" + filename, nil
+}
+
+// ฟังก์ชันสำหรับอัปโหลดไฟล์ resume (POST /api/uploads/resume)
+// รับไฟล์แบบ multipart/form-data โดยใช้ field name "file"
+func UploadResume(c *gin.Context) {
+	file, err := c.FormFile("file") // รับไฟล์จาก request
+	if err != nil {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "file"}) // error ถ้าไม่มีไฟล์
+		return
+	}
+
+	filename, publicURL, err := saveResumeFile(c, file)
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR") // error ถ้าบันทึกไม่สำเร็จ
+		return
+	}
 
 	// ส่งข้อมูลไฟล์ที่อัปโหลดกลับ
-	c.JSON(http.StatusCreated, gin.H{
-		"ok":       true,
+	respond.Created(c, gin.H{
 		"filename": filename,
-		"url":      publicURL,
-		"size":     file.Size,
+		"url": publicURL,
+		"size": file.Size,
 		"uploaded": time.Now(),
 	})
 }
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+)
+
+// UserByEmail is the id and role of the account with email; merged
+// profiles don't count. Wired into middleware.UserByEmail by main so tokens
+// of the old be_clean service keep working.
+func UserByEmail(email string) (string, string, bool) {
+	u, err := data().Users().ByEmail(email)
+	if err != nil || u.MergedInto != "" {
+		return "", "", false
+	}
+	return u.ID, u.Role, true
+}
+
+// userView is an account as shown to staff (never the password hash).
+func userView(u models.User) gin.H {
+	return gin.H{"id": u.ID, "email": u.Email, "name": u.Name, "role": u.Role, "phone": u.Phone, "created_at": u.CreatedAt}
+}
+
+// GET /api/users?q=&page=&limit= — accounts whose email or name contains q,
+// newest first (HR)
+func ListUsers(c *gin.Context) {
+	page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+	users, total, err := data().Users().List(c.Query("q"), store.Page{Offset: offset, Limit: limit})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	views := make([]gin.H, 0, len(users))
+	for _, u := range users {
+		views = append(views, userView(u))
+	}
+	respond.Page(c, gin.H{"users": views}, gin.H{"page": page, "limit": limit, "total": total})
+}
@@ -0,0 +1,591 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/utils"
+	"aats-backend-clean/worker"
+)
+
+// Webhook event types. Status changes carry the new status in the type
+// (application.status.hired, job.status.closed) so subscribers can pick
+// exactly the transitions they care about, or all of them with
+// "application.status.*".
+const (
+	WebhookApplicationCreated = "application.created"
+	WebhookApplicationStatus  = "application.status." // + status
+	WebhookApplicationNote    = "application.note"    // timeline entry without a status change
+	WebhookJobCreated         = "job.created"
+	WebhookJobUpdated         = "job.updated"
+	WebhookJobStatus          = "job.status." // + status
+	WebhookJobDeleted         = "job.deleted"
+	WebhookPing               = "ping" // sent by POST /api/webhooks/:id/ping only
+)
+
+// webhookEventTypes is what GET /api/webhooks/event-types lists.
+var webhookEventTypes = []string{
+	WebhookApplicationCreated, WebhookApplicationStatus + "<status>", WebhookApplicationNote,
+	WebhookJobCreated, WebhookJobUpdated, WebhookJobStatus + "<status>", WebhookJobDeleted, WebhookPing,
+}
+
+var webhookPatternRe = regexp.MustCompile(`^(\*|[a-z_]+(\.[a-z_]+)*(\.\*)?)$`)
+
+// webhookClient sends deliveries; receivers get 10 seconds to answer.
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookResponseMax is how much of a receiver's response is logged.
+const webhookResponseMax = 2048
+
+// WebhookBody request body for webhook subscriptions.
+type WebhookBody struct {
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	Events       []string `json:"events"`
+	Active       *bool    `json:"active"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+// ---- emitting ----
+
+// createWebhookEvent stores an event with the payload subscribers receive.
+func createWebhookEvent(db *gorm.DB, eventType string, data interface{}) (models.WebhookEvent, error) {
+	ev := models.WebhookEvent{ID: uuid.NewString(), Type: eventType, CreatedAt: time.Now()}
+	payload, err := json.Marshal(gin.H{"id": ev.ID, "type": eventType, "created_at": ev.CreatedAt, "data": data})
+	if err != nil {
+		return ev, err
+	}
+	ev.Payload = string(payload)
+	return ev, db.Create(&ev).Error
+}
+
+// queueWebhookDelivery puts one event on the delivery queue of one subscription.
+func queueWebhookDelivery(db *gorm.DB, subID string, ev models.WebhookEvent, replayOf string) (models.WebhookDelivery, error) {
+	d := models.WebhookDelivery{
+		ID: uuid.NewString(), SubscriptionID: subID, EventID: ev.ID, EventType: ev.Type,
+		State: "pending", NextAttemptAt: time.Now(), ReplayOf: replayOf,
+	}
+	return d, db.Create(&d).Error
+}
+
+// subscriptionWants reports whether a subscription's patterns select an event type.
+func subscriptionWants(sub models.WebhookSubscription, eventType string) bool {
+	var patterns []string
+	_ = json.Unmarshal([]byte(sub.Events), &patterns)
+	for _, p := range patterns {
+		if utils.WebhookEventMatches(p, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// emitWebhookEvent records an event and queues it for every active
+// subscription that wants it. Pass the transaction that makes the change:
+// the event is then only delivered if the change commits.
+func emitWebhookEvent(db *gorm.DB, eventType string, data interface{}) error {
+	var subs []models.WebhookSubscription
+	if err := db.Where("active = ?", true).Find(&subs).Error; err != nil {
+		return err
+	}
+	wanted := subs[:0]
+	for _, s := range subs {
+		if subscriptionWants(s, eventType) {
+			wanted = append(wanted, s)
+		}
+	}
+	if len(wanted) == 0 {
+		return nil // nobody listens; no need to keep the event
+	}
+	ev, err := createWebhookEvent(db, eventType, data)
+	if err != nil {
+		return err
+	}
+	for _, s := range wanted {
+		if _, err := queueWebhookDelivery(db, s.ID, ev, ""); err != nil {
+			return err
+		}
+	}
+	wakeWebhookDispatcher()
+	return nil
+}
+
+// emitTimelineEvent turns a new timeline entry into a webhook event:
+// application.created for the first entry, application.status.<status>
+// when the status moved, application.note otherwise.
+func emitTimelineEvent(db *gorm.DB, app models.Application, tl models.ApplicationTimeline, previousStatus string, created bool) error {
+	eventType := WebhookApplicationNote
+	if created {
+		eventType = WebhookApplicationCreated
+	} else if tl.Status != previousStatus {
+		eventType = WebhookApplicationStatus + tl.Status
+	}
+	var applicant models.User
+	db.Select("id, name, email").Where("id = ?", app.ApplicantID).Limit(1).Find(&applicant)
+	var job models.JobPosting
+	db.Select("id, title, department").Where("id = ?", app.JobID).Limit(1).Find(&job)
+	return emitWebhookEvent(db, eventType, gin.H{
+		"application": gin.H{
+			"id": app.ID, "job_id": app.JobID, "applicant_id": app.ApplicantID, "status": app.Status,
+			"submitted_date": app.SubmittedDate, "source": app.Source,
+		},
+		"applicant":       gin.H{"id": applicant.ID, "name": applicant.Name, "email": applicant.Email},
+		"job":             gin.H{"id": job.ID, "title": job.Title, "department": job.Department},
+		"timeline":        gin.H{"id": tl.ID, "status": tl.Status, "date": tl.Date, "description": tl.Description},
+		"previous_status": previousStatus,
+	})
+}
+
+// emitJobEvent sends job.created / job.deleted as given; an update becomes
+// job.status.<status> when the status changed and job.updated otherwise.
+func emitJobEvent(db *gorm.DB, eventType string, job models.JobPosting, previousStatus string) error {
+	if eventType == WebhookJobUpdated && job.Status != previousStatus {
+		eventType = WebhookJobStatus + job.Status
+	}
+	return emitWebhookEvent(db, eventType, gin.H{
+		"job": gin.H{
+			"id": job.ID, "slug": job.Slug, "title": job.Title, "department": job.Department, "location": job.Location,
+			"experience_level": job.ExperienceLevel, "status": job.Status, "posted_date": job.PostedDate,
+			"closing_date": job.ClosingDate, "url": careersJobURL(job.Slug),
+		},
+		"previous_status": previousStatus,
+	})
+}
+
+// ---- delivering ----
+
+var (
+	webhookPool *worker.Pool
+	webhookWake = make(chan struct{}, 1)
+)
+
+// StartWebhookDispatcher starts the delivery workers and checks the queue
+// every interval (and right after events are emitted). Deliveries left
+// "sending" by a previous process are retried. Call after the DB is connected.
+func StartWebhookDispatcher(every time.Duration, workers int) {
+	models.DB.Model(&models.WebhookDelivery{}).Where("state = ?", "sending").Update("state", "pending")
+	webhookPool = worker.NewPool(workers, 256, deliverWebhook)
+	worker.Every(every, DispatchDueWebhooks)
+	go func() {
+		for range webhookWake {
+			time.Sleep(200 * time.Millisecond) // let the emitting transaction commit
+			DispatchDueWebhooks()
+		}
+	}()
+}
+
+func wakeWebhookDispatcher() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// DispatchDueWebhooks claims deliveries whose next attempt is due and hands
+// them to the workers; without a running dispatcher (tests, tools) it
+// delivers them in place.
+func DispatchDueWebhooks() {
+	var due []models.WebhookDelivery
+	models.DB.Select("id").Where("state = ? AND next_attempt_at <= ?", "pending", time.Now()).
+		Order("next_attempt_at asc").Limit(200).Find(&due)
+	for _, d := range due {
+		// claim: only one dispatcher moves a delivery out of pending
+		res := models.DB.Model(&models.WebhookDelivery{}).Where("id = ? AND state = ?", d.ID, "pending").Update("state", "sending")
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
+		}
+		if webhookPool == nil {
+			deliverWebhook(d.ID)
+		} else if !webhookPool.Enqueue(d.ID) {
+			models.DB.Model(&models.WebhookDelivery{}).Where("id = ?", d.ID).Update("state", "pending")
+		}
+	}
+}
+
+// deliverWebhook makes one attempt at a delivery, logs it and schedules a
+// retry with exponential backoff on failure.
+func deliverWebhook(id string) {
+	var d models.WebhookDelivery
+	if err := models.DB.Where("id = ?", id).First(&d).Error; err != nil {
+		return
+	}
+	var sub models.WebhookSubscription
+	var ev models.WebhookEvent
+	if models.DB.Where("id = ?", d.SubscriptionID).First(&sub).Error != nil || !sub.Active {
+		models.DB.Model(&d).Updates(map[string]interface{}{"state": "failed", "error": "subscription removed or inactive"})
+		return
+	}
+	if err := models.DB.Where("id = ?", d.EventID).First(&ev).Error; err != nil {
+		models.DB.Model(&d).Updates(map[string]interface{}{"state": "failed", "error": "event not found"})
+		return
+	}
+
+	now := time.Now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+	attempt := models.WebhookAttempt{ID: uuid.NewString(), DeliveryID: d.ID, Attempt: d.Attempts}
+	status, respBody, err := postWebhook(sub, d, ev, now)
+	attempt.DurationMs = time.Since(now).Milliseconds()
+	attempt.StatusCode, attempt.ResponseBody = status, respBody
+	d.ResponseStatus, d.Error = status, ""
+	if err != nil {
+		attempt.Error, d.Error = err.Error(), err.Error()
+	}
+	models.DB.Create(&attempt)
+
+	subUpdates := map[string]interface{}{}
+	switch {
+	case err == nil:
+		d.State = "succeeded"
+		subUpdates["failure_count"], subUpdates["last_success_at"] = 0, now
+	case d.Attempts >= utils.WebhookMaxAttempts:
+		d.State = "failed"
+		subUpdates["failure_count"], subUpdates["last_failure_at"] = gorm.Expr("failure_count + 1"), now
+	default:
+		d.State = "pending"
+		d.NextAttemptAt = now.Add(utils.WebhookBackoff(d.Attempts))
+		subUpdates["failure_count"], subUpdates["last_failure_at"] = gorm.Expr("failure_count + 1"), now
+	}
+	models.DB.Save(&d)
+	models.DB.Model(&models.WebhookSubscription{}).Where("id = ?", sub.ID).UpdateColumns(subUpdates)
+}
+
+// postWebhook sends the signed request. Any 2xx answer is success.
+func postWebhook(sub models.WebhookSubscription, d models.WebhookDelivery, ev models.WebhookEvent, now time.Time) (int, string, error) {
+	body := []byte(ev.Payload)
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AATS-Webhooks/1.0")
+	req.Header.Set(utils.WebhookSignatureHeader, utils.SignWebhook(sub.Secret, now, body))
+	req.Header.Set(utils.WebhookEventHeader, ev.Type)
+	req.Header.Set(utils.WebhookDeliveryHeader, d.ID)
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMax))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(raw), errors.New("receiver answered " + resp.Status)
+	}
+	return resp.StatusCode, string(raw), nil
+}
+
+// ---- subscriptions API ----
+
+// webhookView is how a subscription is returned; the secret only appears
+// when it is created or rotated.
+func webhookView(s models.WebhookSubscription) gin.H {
+	events := []string{}
+	_ = json.Unmarshal([]byte(s.Events), &events)
+	return gin.H{
+		"id": s.ID, "name": s.Name, "url": s.URL, "events": events, "active": s.Active,
+		"failure_count": s.FailureCount, "last_success_at": s.LastSuccessAt, "last_failure_at": s.LastFailureAt,
+		"created_at": s.CreatedAt, "updated_at": s.UpdatedAt,
+	}
+}
+
+// webhookFromBody validates body into s. Returns a problem on bad input.
+func webhookFromBody(s *models.WebhookSubscription, body WebhookBody, creating bool) *respond.Problem {
+	if body.URL != "" || creating {
+		u, err := url.Parse(strings.TrimSpace(body.URL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return respond.New(http.StatusBadRequest, "WEBHOOK_URL_INVALID")
+		}
+		s.URL = u.String()
+	}
+	if name := strings.TrimSpace(body.Name); name != "" {
+		s.Name = name
+	} else if creating {
+		s.Name = s.URL
+	}
+	if body.Events != nil || creating {
+		if len(body.Events) == 0 {
+			return respond.New(http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "events"})
+		}
+		for _, p := range body.Events {
+			if !webhookPatternRe.MatchString(p) {
+				return respond.New(http.StatusBadRequest, "WEBHOOK_EVENT_PATTERN_INVALID", gin.H{"pattern": p})
+			}
+		}
+		raw, _ := json.Marshal(body.Events)
+		s.Events = string(raw)
+	}
+	if body.Active != nil {
+		s.Active = *body.Active
+	}
+	return nil
+}
+
+// GET /api/webhooks/event-types (HR)
+func ListWebhookEventTypes(c *gin.Context) {
+	respond.OK(c, gin.H{"event_types": webhookEventTypes})
+}
+
+// GET /api/webhooks (HR)
+func ListWebhooks(c *gin.Context) {
+	var subs []models.WebhookSubscription
+	if err := models.DB.Order("created_at asc").Find(&subs).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	out := make([]gin.H, 0, len(subs))
+	for _, s := range subs {
+		out = append(out, webhookView(s))
+	}
+	respond.OK(c, gin.H{"webhooks": out})
+}
+
+// POST /api/webhooks (HR) — {"name","url","events":["application.status.hired","job.*"]}
+func CreateWebhook(c *gin.Context) {
+	var body WebhookBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	uid, _ := c.Get("user_id")
+	createdBy, _ := uid.(string)
+	s := models.WebhookSubscription{ID: uuid.NewString(), Active: true, Secret: utils.NewWebhookSecret(), CreatedBy: createdBy}
+	if p := webhookFromBody(&s, body, true); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if err := models.DB.Create(&s).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	view := webhookView(s)
+	view["secret"] = s.Secret
+	respond.Created(c, gin.H{"webhook": view})
+}
+
+// loadWebhook finds the :id subscription or answers 404.
+func loadWebhook(c *gin.Context) (models.WebhookSubscription, bool) {
+	var s models.WebhookSubscription
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&s).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "WEBHOOK_NOT_FOUND")
+		return s, false
+	}
+	return s, true
+}
+
+// GET /api/webhooks/:id (HR)
+func GetWebhook(c *gin.Context) {
+	s, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	counts := gin.H{}
+	for _, st := range []string{"pending", "sending", "succeeded", "failed"} {
+		var n int64
+		models.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ? AND state = ?", s.ID, st).Count(&n)
+		counts[st] = n
+	}
+	respond.OK(c, gin.H{"webhook": webhookView(s), "deliveries": counts})
+}
+
+// PUT /api/webhooks/:id (HR) — rotate_secret returns a new secret
+func UpdateWebhook(c *gin.Context) {
+	s, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	var body WebhookBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if p := webhookFromBody(&s, body, false); p != nil {
+		respond.Fail(c, p)
+		return
+	}
+	if body.RotateSecret {
+		s.Secret = utils.NewWebhookSecret()
+	}
+	if err := models.DB.Save(&s).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	view := webhookView(s)
+	if body.RotateSecret {
+		view["secret"] = s.Secret
+	}
+	respond.OK(c, gin.H{"webhook": view})
+}
+
+// DELETE /api/webhooks/:id (HR) — queued deliveries are dropped, the log is kept
+func DeleteWebhook(c *gin.Context) {
+	s, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WebhookDelivery{}).Where("subscription_id = ? AND state IN ?", s.ID, []string{"pending", "sending"}).
+			Updates(map[string]interface{}{"state": "failed", "error": "subscription deleted"}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&s).Error
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.OK(c, gin.H{})
+}
+
+// POST /api/webhooks/:id/ping (HR) — queue a ping event to this subscription only
+func PingWebhook(c *gin.Context) {
+	s, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	var d models.WebhookDelivery
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		ev, err := createWebhookEvent(tx, WebhookPing, gin.H{"webhook_id": s.ID})
+		if err != nil {
+			return err
+		}
+		d, err = queueWebhookDelivery(tx, s.ID, ev, "")
+		return err
+	})
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	wakeWebhookDispatcher()
+	respond.Accepted(c, gin.H{"delivery": d})
+}
+
+// ---- delivery log and replay ----
+
+// GET /api/webhooks/:id/deliveries?state=&event_type=&page=&limit= (HR)
+func ListWebhookDeliveries(c *gin.Context) {
+	s, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+	q := models.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", s.ID)
+	if st := c.Query("state"); st != "" {
+		q = q.Where("state = ?", st)
+	}
+	if et := c.Query("event_type"); et != "" {
+		q = q.Where("event_type = ?", et)
+	}
+	var total int64
+	q.Count(&total)
+	var deliveries []models.WebhookDelivery
+	if err := q.Order("created_at desc").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	respond.Page(c, gin.H{"deliveries": deliveries}, gin.H{"page": page, "limit": limit, "total": total})
+}
+
+// GET /api/webhook-deliveries/:id (HR) — the delivery, its payload and every attempt
+func GetWebhookDelivery(c *gin.Context) {
+	var d models.WebhookDelivery
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&d).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "WEBHOOK_DELIVERY_NOT_FOUND")
+		return
+	}
+	var ev models.WebhookEvent
+	models.DB.Where("id = ?", d.EventID).Limit(1).Find(&ev)
+	var attempts []models.WebhookAttempt
+	models.DB.Where("delivery_id = ?", d.ID).Order("attempt asc").Find(&attempts)
+	respond.OK(c, gin.H{"delivery": d, "payload": json.RawMessage(ev.Payload), "attempts": attempts})
+}
+
+// POST /api/webhook-deliveries/:id/replay (HR) — send the same event again
+// as a new delivery (same event id, so receivers can de-duplicate)
+func ReplayWebhookDelivery(c *gin.Context) {
+	var orig models.WebhookDelivery
+	if err := models.DB.Where("id = ?", c.Param("id")).First(&orig).Error; err != nil {
+		respond.Error(c, http.StatusNotFound, "WEBHOOK_DELIVERY_NOT_FOUND")
+		return
+	}
+	var ev models.WebhookEvent
+	if err := models.DB.Where("id = ?", orig.EventID).First(&ev).Error; err != nil {
+		respond.Error(c, http.StatusGone, "WEBHOOK_EVENT_GONE")
+		return
+	}
+	d, err := queueWebhookDelivery(models.DB, orig.SubscriptionID, ev, orig.ID)
+	if err != nil {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	wakeWebhookDispatcher()
+	respond.Accepted(c, gin.H{"delivery": d})
+}
+
+// POST /api/webhooks/:id/replay (HR) — {"since","until","failed_only"}:
+// re-send the events of a time window this subscription wants, e.g. after
+// the receiver was down. failed_only limits it to events whose delivery
+// to this subscription failed.
+func ReplayWebhookEvents(c *gin.Context) {
+	s, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Since      string `json:"since"`
+		Until      string `json:"until"`
+		FailedOnly bool   `json:"failed_only"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respond.Error(c, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	since, ok := parseDateParam(body.Since, false)
+	if !ok {
+		respond.Error(c, http.StatusBadRequest, "FIELD_REQUIRED", gin.H{"field": "since"})
+		return
+	}
+	q := models.DB.Where("created_at >= ?", since)
+	if until, ok := parseDateParam(body.Until, true); ok {
+		q = q.Where("created_at < ?", until)
+	}
+	if body.FailedOnly {
+		q = q.Where("id IN (?)", models.DB.Model(&models.WebhookDelivery{}).Select("event_id").
+			Where("subscription_id = ? AND state = ?", s.ID, "failed"))
+	}
+	var events []models.WebhookEvent
+	q.Where("type <> ?", WebhookPing).Order("created_at asc").Limit(5000).Find(&events)
+	queued := 0
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		for _, ev := range events {
+			if !subscriptionWants(s, ev.Type) {
+				continue
+			}
+			if _, err := queueWebhookDelivery(tx, s.ID, ev, ""); err != nil {
+				return err
+			}
+			queued++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("webhook replay %s: %v", s.ID, err)
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		return
+	}
+	wakeWebhookDispatcher()
+	respond.Accepted(c, gin.H{"queued": queued})
+}
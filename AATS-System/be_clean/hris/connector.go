@@ -0,0 +1,118 @@
+// Package hris hands hired candidates over to the HR information system as
+// new-hire records. A Connector does the transport; the queue, retries and
+// sync status live in the database (see models.HRISHandoff).
+package hris
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// NewHire is the record sent to the HR system when a candidate is hired.
+type NewHire struct {
+	ApplicationID string    `json:"application_id"` // stable reference; send it again and the HR system should update, not duplicate
+	CandidateID   string    `json:"candidate_id"`
+	FullName      string    `json:"full_name"`
+	FirstName     string    `json:"first_name"`
+	LastName      string    `json:"last_name"`
+	Email         string    `json:"email"`
+	Phone         string    `json:"phone"`
+	JobID         string    `json:"job_id"`
+	JobTitle      string    `json:"job_title"`
+	Department    string    `json:"department"`
+	Location      string    `json:"location"`
+	OfferID       string    `json:"offer_id,omitempty"`
+	Salary        float64   `json:"salary,omitempty"` // per month
+	Currency      string    `json:"currency,omitempty"`
+	Benefits      string    `json:"benefits,omitempty"`
+	StartDate     string    `json:"start_date,omitempty"` // YYYY-MM-DD, from the accepted offer
+	HiredAt       time.Time `json:"hired_at"`
+	Source        string    `json:"source,omitempty"`
+}
+
+// SplitName fills FirstName and LastName from FullName: the first word and
+// the rest (Thai and Western names alike are written given name first).
+func (h *NewHire) SplitName() {
+	parts := strings.Fields(h.FullName)
+	if len(parts) == 0 {
+		return
+	}
+	h.FirstName = parts[0]
+	h.LastName = strings.Join(parts[1:], " ")
+}
+
+// Result is what the HR system answered.
+type Result struct {
+	ExternalID string // the HR system's id for the new hire, if it returns one
+}
+
+// Connector sends new-hire records to an HR system.
+type Connector interface {
+	Name() string
+	Send(ctx context.Context, h NewHire) (Result, error)
+}
+
+// PermanentError marks a failure retrying cannot fix (rejected data,
+// bad credentials); the handoff fails at once instead of backing off.
+type PermanentError struct{ Err error }
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent wraps err as a PermanentError.
+func Permanent(err error) error { return &PermanentError{Err: err} }
+
+// IsPermanent reports whether err (or anything it wraps) is permanent.
+func IsPermanent(err error) bool {
+	var p *PermanentError
+	return errors.As(err, &p)
+}
+
+// Retry policy: attempt n waits BaseBackoff * 2^(n-1), capped at
+// MaxBackoff; after MaxAttempts the handoff fails and waits for HR.
+const (
+	MaxAttempts = 6
+	BaseBackoff = time.Minute
+	MaxBackoff  = 6 * time.Hour
+)
+
+// Backoff is how long to wait after the given (1-based) failed attempt.
+func Backoff(attempt int) time.Duration {
+	d := BaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return d
+}
+
+// FromEnv builds the connector configured by HRIS_CONNECTOR:
+//
+//	http  POST JSON to HRIS_URL (HRIS_TOKEN is sent as a bearer token)
+//	csv   write one file per new hire into HRIS_CSV_DIR
+//
+// Unset means no HRIS: nil, nil.
+func FromEnv() (Connector, error) {
+	switch kind := strings.ToLower(strings.TrimSpace(os.Getenv("HRIS_CONNECTOR"))); kind {
+	case "":
+		return nil, nil
+	case "http":
+		if os.Getenv("HRIS_URL") == "" {
+			return nil, errors.New("HRIS_CONNECTOR=http needs HRIS_URL")
+		}
+		return NewHTTPConnector(os.Getenv("HRIS_URL"), os.Getenv("HRIS_TOKEN")), nil
+	case "csv":
+		if os.Getenv("HRIS_CSV_DIR") == "" {
+			return nil, errors.New("HRIS_CONNECTOR=csv needs HRIS_CSV_DIR")
+		}
+		return &CSVConnector{Dir: os.Getenv("HRIS_CSV_DIR")}, nil
+	default:
+		return nil, fmt.Errorf("unknown HRIS_CONNECTOR %q (want http or csv)", kind)
+	}
+}
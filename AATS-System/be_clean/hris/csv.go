@@ -0,0 +1,73 @@
+package hris
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// CSVColumns is the header of a new-hire CSV file.
+var CSVColumns = []string{
+	"application_id", "candidate_id", "first_name", "last_name", "full_name", "email", "phone",
+	"job_id", "job_title", "department", "location",
+	"offer_id", "salary", "currency", "benefits", "start_date", "hired_at", "source",
+}
+
+// CSVConnector drops one CSV file per new hire into a directory the HR
+// system (or an SFTP sync) picks up. The file is named after the
+// application, so a retry replaces it instead of adding a duplicate, and
+// is renamed into place so a reader never sees it half written.
+type CSVConnector struct {
+	Dir string
+}
+
+func (c *CSVConnector) Name() string { return "csv" }
+
+// CSVFileName is the file a new hire is written to.
+func CSVFileName(h NewHire) string {
+	return "new_hire_" + h.ApplicationID + ".csv"
+}
+
+// CSVRow is a new hire in CSVColumns order.
+func CSVRow(h NewHire) []string {
+	salary := ""
+	if h.Salary != 0 {
+		salary = strconv.FormatFloat(h.Salary, 'f', 2, 64)
+	}
+	return []string{
+		h.ApplicationID, h.CandidateID, h.FirstName, h.LastName, h.FullName, h.Email, h.Phone,
+		h.JobID, h.JobTitle, h.Department, h.Location,
+		h.OfferID, salary, h.Currency, h.Benefits, h.StartDate, h.HiredAt.UTC().Format("2006-01-02T15:04:05Z"), h.Source,
+	}
+}
+
+func (c *CSVConnector) Send(_ context.Context, h NewHire) (Result, error) {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return Result{}, err
+	}
+	name := CSVFileName(h)
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-"+name+"-*")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	tmp.Write([]byte("\xEF\xBB\xBF")) // BOM so Excel reads Thai names correctly
+	w := csv.NewWriter(tmp)
+	w.Write(CSVColumns)
+	w.Write(CSVRow(h))
+	w.Flush()
+	if err := w.Error(); err != nil {
+		tmp.Close()
+		return Result{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return Result{}, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.Dir, name)); err != nil {
+		return Result{}, err
+	}
+	return Result{ExternalID: name}, nil
+}
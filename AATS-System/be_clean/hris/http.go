@@ -0,0 +1,75 @@
+package hris
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPConnector POSTs each new hire as JSON to one endpoint. Any 2xx is
+// success; a JSON answer with "id" or "employee_id" is kept as the
+// external id. 4xx answers other than 408 and 429 are permanent failures.
+type HTTPConnector struct {
+	URL    string
+	Token  string // sent as "Authorization: Bearer <token>" when set
+	Client *http.Client
+}
+
+// NewHTTPConnector returns a connector with a 30 second timeout.
+func NewHTTPConnector(url, token string) *HTTPConnector {
+	return &HTTPConnector{URL: url, Token: token, Client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (c *HTTPConnector) Name() string { return "http" }
+
+func (c *HTTPConnector) Send(ctx context.Context, h NewHire) (Result, error) {
+	body, err := json.Marshal(h)
+	if err != nil {
+		return Result{}, Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "new-hire-"+h.ApplicationID)
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := fmt.Errorf("HRIS answered %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return Result{}, Permanent(err)
+		}
+		return Result{}, err
+	}
+	var answer struct {
+		ID         interface{} `json:"id"`
+		EmployeeID interface{} `json:"employee_id"`
+	}
+	_ = json.Unmarshal(raw, &answer)
+	res := Result{}
+	for _, v := range []interface{}{answer.EmployeeID, answer.ID} {
+		if v != nil {
+			res.ExternalID = fmt.Sprint(v)
+			break
+		}
+	}
+	return res, nil
+}
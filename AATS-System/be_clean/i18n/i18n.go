@@ -0,0 +1,227 @@
+// Package i18n holds the text the server shows to people, in English and
+// Thai, and picks the language for a request. The catalogue lives in
+// locales/<lang>.json, grouped by area ("errors", ...) and keyed by a
+// stable name; lookups use "area.KEY".
+//
+// Messages may refer to parameters as {name}; times are written as
+// YYYY-MM-DD. Text that is stored and read later (notifications, timeline
+// entries) is kept as a Msg, so each reader sees it in their own language.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lang is a supported language tag.
+type Lang string
+
+const (
+	English Lang = "en"
+	Thai    Lang = "th"
+)
+
+// Default is used when the client names no supported language.
+var Default = English
+
+// Supported lists the languages with a catalogue, default first.
+var Supported = []Lang{English, Thai}
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var catalogue = map[Lang]map[string]string{}
+
+func init() {
+	for _, lang := range Supported {
+		raw, err := locales.ReadFile("locales/" + string(lang) + ".json")
+		if err != nil {
+			panic("i18n: " + err.Error())
+		}
+		var areas map[string]map[string]string
+		if err := json.Unmarshal(raw, &areas); err != nil {
+			panic("i18n: locales/" + string(lang) + ".json: " + err.Error())
+		}
+		flat := map[string]string{}
+		for area, msgs := range areas {
+			for k, v := range msgs {
+				flat[area+"."+k] = v
+			}
+		}
+		catalogue[lang] = flat
+	}
+}
+
+// Parse maps a language tag ("th", "th-TH", "EN_us") to a supported
+// language.
+func Parse(tag string) (Lang, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	primary, _, _ = strings.Cut(primary, "_")
+	for _, l := range Supported {
+		if primary == string(l) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate picks the supported language the client prefers most from an
+// Accept-Language header ("th-TH,th;q=0.9,en;q=0.8"), or Default.
+func Negotiate(header string) Lang {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if l, ok := Parse(tag); ok && q > bestQ {
+			best, bestQ = l, q
+		}
+	}
+	return best
+}
+
+// T returns the message for key in lang with {name} placeholders filled
+// from params. A key missing in lang falls back to English, then to the
+// key itself.
+func T(lang Lang, key string, params map[string]any) string {
+	msg, ok := catalogue[lang][key]
+	if !ok {
+		if msg, ok = catalogue[English][key]; !ok {
+			return key
+		}
+	}
+	if len(params) == 0 || !strings.Contains(msg, "{") {
+		return msg
+	}
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(msg, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(msg[open:], '}')
+		if end < 0 {
+			break
+		}
+		name := msg[open+1 : open+end]
+		b.WriteString(msg[:open])
+		if v, ok := params[name]; ok {
+			b.WriteString(format(v))
+		} else {
+			b.WriteString(msg[open : open+end+1])
+		}
+		msg = msg[open+end+1:]
+	}
+	b.WriteString(msg)
+	return b.String()
+}
+
+// Has reports whether key is in the English catalogue.
+func Has(key string) bool {
+	_, ok := catalogue[English][key]
+	return ok
+}
+
+// Keys lists the keys of lang's catalogue, sorted.
+func Keys(lang Lang) []string {
+	keys := make([]string, 0, len(catalogue[lang]))
+	for k := range catalogue[lang] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Msg is text shown later, in the reader's language: a catalogue key and
+// its params. Text people wrote (comments, rendered templates) has no key
+// and is shown as is.
+type Msg struct {
+	Key    string
+	Params map[string]any
+	Text   string
+}
+
+// M is the message for key, with params merged in order.
+func M(key string, params ...map[string]any) Msg {
+	m := Msg{Key: key}
+	for _, ps := range params {
+		for k, v := range ps {
+			if m.Params == nil {
+				m.Params = map[string]any{}
+			}
+			m.Params[k] = v
+		}
+	}
+	return m
+}
+
+// Text is a message that is not translated.
+func Text(s string) Msg { return Msg{Text: s} }
+
+// In renders m in lang.
+func (m Msg) In(lang Lang) string {
+	if m.Key == "" {
+		return m.Text
+	}
+	return T(lang, m.Key, m.Params)
+}
+
+// EncodeParams is m's params as a JSON object for storage, "" when none.
+func (m Msg) EncodeParams() string {
+	if len(m.Params) == 0 {
+		return ""
+	}
+	raw, _ := json.Marshal(m.Params)
+	return string(raw)
+}
+
+// Decode rebuilds a stored message from its key, EncodeParams output and
+// the text saved with it (used when key is empty). Times come back as
+// time.Time.
+func Decode(key, params, text string) Msg {
+	m := Msg{Key: key, Text: text}
+	if key == "" || params == "" {
+		return m
+	}
+	if json.Unmarshal([]byte(params), &m.Params) != nil {
+		return m
+	}
+	for k, v := range m.Params {
+		if s, ok := v.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				m.Params[k] = t
+			}
+		}
+	}
+	return m
+}
+
+func format(v any) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format("2006-01-02")
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	case []string:
+		return strings.Join(t, ", ")
+	case []any: // a list read back by Decode
+		parts := make([]string, len(t))
+		for i, e := range t {
+			parts[i] = format(e)
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(v)
+}
@@ -1,33 +1,78 @@
-﻿package main
+package main
 
 import (
 "log"
 "os"
+"time"
 
 "github.com/gin-gonic/gin"
 "github.com/joho/godotenv"
 
 "aats-backend-clean/middleware"
 "aats-backend-clean/handlers"
+"aats-backend-clean/hris"
 "aats-backend-clean/models"
+"aats-backend-clean/openapi"
+"aats-backend-clean/respond"
+"aats-backend-clean/services"
+"aats-backend-clean/store"
+"aats-backend-clean/utils"
 )
 
 func main() {
 _ = godotenv.Load(".env")
 
-models.ConnectDatabase() // เชื่อม Postgres ตาม DATABASE_URL
+models.ConnectDatabase() // เชื่อม Postgres ตาม DATABASE_URL (ไม่มีก็ใช้ SQLite ที่ SQLITE_PATH)
+handlers.Store = store.New(models.DB) // repository layer between handlers and the database
+handlers.Services = services.New(handlers.Store, os.Getenv("JWT_SECRET")) // business rules the handlers call into
+middleware.UserByEmail = handlers.UserByEmail // accept tokens issued by the old be_clean service
+if err := handlers.BackfillJobBoard(models.DB); err != nil { // slugs and search text of jobs saved before the job board
+log.Printf("job board backfill: %v", err)
+}
+handlers.StartBulkRunner(2) // background workers for bulk actions
+handlers.StartAnalyticsRefresher(5 * time.Minute) // incremental refresh of analytics aggregates
+handlers.StartReferralBonusChecker(time.Hour) // pending referral bonuses → eligible after probation
+handlers.StartOfferExpiry(time.Minute) // auto-decline sent offers past their expiry
+handlers.StartWebhookDispatcher(15*time.Second, 4) // deliver queued webhooks, retrying with backoff
+hrisConn, err := hris.FromEnv() // HRIS_CONNECTOR=http|csv, see hris.FromEnv
+if err != nil {
+log.Fatal("HRIS config: ", err)
+}
+handlers.StartHRISHandoff(time.Minute, hrisConn) // send hired candidates to the HR system
+if path := os.Getenv("PDF_FONT"); path != "" {
+font, err := utils.LoadFont(path)
+if err != nil {
+log.Fatal("PDF_FONT: ", err)
+}
+utils.PDFFont = font // offer letter PDFs embed it (e.g. Sarabun for Thai)
+}
+
+respond.UserLanguage = handlers.PreferredLanguage // messages follow the user's chosen language
+
+spec, err := openapi.Load()
+if err != nil {
+log.Fatal("openapi spec: ", err)
+}
 
-r := gin.Default()
+r := gin.New()
+r.Use(gin.Logger(), middleware.Recovery())
 r.Use(middleware.CORS())
+r.NoRoute(middleware.NotFound)
+// reject JSON bodies that don't match openapi/openapi.json (OPENAPI_VALIDATION=enforce|report|off)
+r.Use(middleware.ValidateRequest(spec, os.Getenv("OPENAPI_VALIDATION")))
 
 // health
 r.GET("/health", func(c *gin.Context) {
-c.JSON(200, gin.H{"status": "ok"})
+respond.OK(c, gin.H{"status": "ok"})
 })
 
 // API v1
 api := r.Group("/api")
 
+// API description and docs (regenerate with: go run ./cmd/openapi)
+api.GET("/openapi.json", handlers.OpenAPISpec)
+api.GET("/docs", handlers.APIDocs)
+
 // dev utilities
 api.POST("/dev/seed", handlers.SeedDev)
 api.POST("/dev/seed_more", handlers.SeedMore)
@@ -40,34 +85,212 @@ auth.POST("/login", handlers.Login)
 // protected me
 auth.GET("/me", middleware.AuthMiddleware(), handlers.Me)
 
+// users (HR)
+api.GET("/users", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListUsers)
+
 // jobs
 jobs := api.Group("/jobs")
-jobs.GET("", handlers.ListJobs)
-jobs.GET("/:id", handlers.GetJob)
+// optional auth: staff also see HR-only custom fields
+jobs.GET("", middleware.OptionalAuth(), handlers.ListJobs)
+jobs.GET("/:id", middleware.OptionalAuth(), handlers.GetJob)
 // create / update / delete require auth
 jobs.POST("", middleware.AuthMiddleware(), handlers.CreateJob)
 jobs.PUT("/:id", middleware.AuthMiddleware(), handlers.UpdateJob)
 jobs.DELETE("/:id", middleware.AuthMiddleware(), handlers.DeleteJob)
+// screening questions (staff see knockout rules)
+jobs.GET("/:id/screening-questions", middleware.OptionalAuth(), handlers.ListScreeningQuestions)
+jobs.PUT("/:id/screening-questions", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ReplaceScreeningQuestions)
+// trackable apply links (HR); resolving a link is public
+jobs.GET("/:id/apply-links", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListApplyLinks)
+jobs.POST("/:id/apply-links", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateApplyLink)
+api.GET("/apply-links/:token", handlers.ResolveApplyLink)
+
+// public job board: published jobs only, for the careers site
+api.GET("/public/jobs", handlers.PublicListJobs)
+api.GET("/public/jobs/:slug", handlers.PublicGetJob)
+api.GET("/public/job-facets", handlers.PublicJobFacets)
+// syndication feeds (conditional GET via ETag)
+api.GET("/feeds/jobs.rss", handlers.JobsRSSFeed)
+api.GET("/feeds/jobs.atom", handlers.JobsAtomFeed)
+api.GET("/feeds/boards/:board", handlers.JobBoardFeed)
+// per-board feed layout and field mapping (HR)
+api.GET("/feed-boards", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListFeedBoards)
+api.POST("/feed-boards", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateFeedBoard)
+api.PUT("/feed-boards/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateFeedBoard)
+api.DELETE("/feed-boards/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.DeleteFeedBoard)
+api.GET("/webhooks", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListWebhooks)
+api.POST("/webhooks", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateWebhook)
+api.GET("/webhooks/event-types", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListWebhookEventTypes)
+api.GET("/webhooks/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.GetWebhook)
+api.PUT("/webhooks/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateWebhook)
+api.DELETE("/webhooks/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.DeleteWebhook)
+api.POST("/webhooks/:id/ping", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.PingWebhook)
+api.GET("/webhooks/:id/deliveries", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListWebhookDeliveries)
+api.POST("/webhooks/:id/replay", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ReplayWebhookEvents)
+api.GET("/webhook-deliveries/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.GetWebhookDelivery)
+api.POST("/webhook-deliveries/:id/replay", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ReplayWebhookDelivery)
+api.GET("/hris/handoffs", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListHRISHandoffs)
+api.GET("/hris/handoffs/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.GetHRISHandoff)
+api.POST("/hris/handoffs/:id/retry", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.RetryHRISHandoff)
+
+// candidate source channels (HR)
+api.GET("/sources", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListSourceChannels)
+api.POST("/sources", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateSourceChannel)
+api.PUT("/sources/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateSourceChannel)
+
+// application policy: rules for who may apply when, and why not
+api.GET("/policies", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListPolicies)
+api.POST("/policies", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreatePolicy)
+api.GET("/policies/effective", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.EffectivePolicy)
+api.GET("/policies/explain", middleware.AuthMiddleware(), handlers.ExplainPolicy)
+api.PUT("/policies/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdatePolicy)
+api.DELETE("/policies/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.DeletePolicy)
+
+// Employee referrals
+api.POST("/referrals", middleware.AuthMiddleware(), middleware.RequireRoles("employee", "hr", "hm"), handlers.CreateReferral)
+api.GET("/referrals/mine", middleware.AuthMiddleware(), middleware.RequireRoles("employee", "hr", "hm"), handlers.ListMyReferrals)
+api.GET("/referrals/invite/:token", handlers.GetReferralInvite)
+api.GET("/referrals", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListReferrals)
+api.PATCH("/referrals/:id/bonus", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateReferralBonus)
+
+// Talent pools and candidate CRM
+api.GET("/talent-pools", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListTalentPools)
+api.POST("/talent-pools", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateTalentPool)
+api.GET("/talent-pools/search", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.SearchTalentPools)
+api.PUT("/talent-pools/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateTalentPool)
+api.DELETE("/talent-pools/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.DeleteTalentPool)
+api.GET("/talent-pools/:id/members", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListPoolMembers)
+api.POST("/talent-pools/:id/members", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.AddPoolMember)
+api.PUT("/talent-pools/:id/members/:candidate_id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdatePoolMember)
+api.DELETE("/talent-pools/:id/members/:candidate_id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.RemovePoolMember)
+api.POST("/talent-pools/:id/invite", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.InvitePoolToJob)
+api.PUT("/me/language", middleware.AuthMiddleware(), handlers.UpdateMyLanguage)
+api.PUT("/me/talent-pool-consent", middleware.AuthMiddleware(), middleware.RequireRoles("candidate"), handlers.UpdateTalentPoolConsent)
+api.GET("/candidates/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.GetCandidateProfile)
+api.GET("/candidates/:id/notes", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListCandidateNotes)
+api.POST("/candidates/:id/notes", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateCandidateNote)
+
+// Duplicate candidates and profile merges
+api.GET("/duplicates", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListDuplicates)
+api.POST("/duplicates/scan", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ScanDuplicates)
+api.POST("/duplicates/:id/dismiss", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.DismissDuplicate)
+api.GET("/candidates/:id/duplicates", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CandidateDuplicates)
+api.GET("/candidate-merges", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListCandidateMerges)
+api.POST("/candidate-merges", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.MergeCandidates)
+api.POST("/candidate-merges/:id/undo", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UndoCandidateMerge)
+
+// custom fields on applications, jobs and users (definitions are HR-managed)
+api.GET("/custom-fields", middleware.OptionalAuth(), handlers.ListCustomFields)
+api.POST("/custom-fields", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateCustomField)
+api.PUT("/custom-fields/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateCustomField)
+api.DELETE("/custom-fields/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.DeleteCustomField)
+api.GET("/custom-values/:entity/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.GetCustomValues)
+api.PUT("/custom-values/:entity/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateCustomValues)
+// tag autocomplete
+api.GET("/tags", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.AutocompleteTags)
+
+// skills taxonomy (read is public; changes are HR only)
+skills := api.Group("/skills")
+skills.GET("", handlers.ListSkills)
+skills.POST("/normalize", handlers.NormalizeSkills)
+skills.POST("", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateSkill)
+skills.PUT("/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateSkill)
+skills.DELETE("/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.DeleteSkill)
 
 // applications
 api.POST("/applications", middleware.AuthMiddleware(), handlers.CreateApplication)
 api.GET("/applications", middleware.AuthMiddleware(), handlers.ListApplications)
+api.GET("/applications/export", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.ExportApplications)
 api.GET("/applications/:id", middleware.AuthMiddleware(), handlers.GetApplication)
 api.PATCH("/applications/:id/status", middleware.AuthMiddleware(), handlers.UpdateApplicationStatus)
+api.PATCH("/applications/:id", middleware.AuthMiddleware(), middleware.RequireRoles("candidate"), handlers.EditApplication)
+api.POST("/applications/:id/withdraw", middleware.AuthMiddleware(), middleware.RequireRoles("candidate"), handlers.WithdrawApplication)
+api.GET("/applications/:id/revisions", middleware.AuthMiddleware(), handlers.ListApplicationRevisions)
+api.POST("/applications/match/recompute", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.RecomputeMatchScores)
+
+// bulk actions (HR) — processed in the background, poll /api/bulk-jobs/:id
+api.POST("/applications/bulk/status", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.BulkUpdateStatus)
+api.POST("/applications/bulk/tags", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.BulkTag)
+api.POST("/applications/bulk/assign", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.BulkAssignReviewer)
+api.POST("/applications/bulk/message", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.BulkMessage)
+api.GET("/bulk-jobs", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListBulkJobs)
+api.GET("/bulk-jobs/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.GetBulkJob)
+api.GET("/bulk-jobs/:id/items", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListBulkJobItems)
+
+// CSV import (HR) — dry_run=true returns the validation report only
+api.GET("/import/fields", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ImportFields)
+api.POST("/import/jobs", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ImportJobs)
+api.POST("/import/applications", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ImportApplications)
+
+// analytics (HR) — served from aggregates refreshed in the background
+analytics := api.Group("/analytics", middleware.AuthMiddleware(), middleware.RequireRoles("hr"))
+analytics.GET("/funnel", handlers.AnalyticsFunnel)
+analytics.GET("/time-in-stage", handlers.AnalyticsTimeInStage)
+analytics.GET("/rejections", handlers.AnalyticsRejections)
+analytics.GET("/evaluations", handlers.AnalyticsEvaluations)
+analytics.GET("/trends", handlers.AnalyticsTrends)
+analytics.POST("/refresh", handlers.RefreshAnalyticsNow)
+
+// message templates (HR)
+api.GET("/message-templates", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListMessageTemplates)
+api.POST("/message-templates", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateMessageTemplate)
+api.PUT("/message-templates/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateMessageTemplate)
+api.DELETE("/message-templates/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.DeleteMessageTemplate)
+
+// offers: HR drafts, approvers sign off in order, HR sends, the candidate accepts/declines
+api.POST("/applications/:id/offers", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateOffer)
+api.GET("/applications/:id/offers", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.ListApplicationOffers)
+api.GET("/offers/:id", middleware.AuthMiddleware(), handlers.GetOffer)
+api.GET("/offers/:id/letter", middleware.AuthMiddleware(), handlers.GetOfferLetter)
+api.PUT("/offers/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateOffer)
+api.POST("/offers/:id/submit", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.SubmitOffer)
+api.POST("/offers/:id/approve", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.ApproveOffer)
+api.POST("/offers/:id/reject", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.RejectOffer)
+api.POST("/offers/:id/send", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.SendOffer)
+api.POST("/offers/:id/withdraw", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.WithdrawOffer)
+api.POST("/offers/:id/respond", middleware.AuthMiddleware(), middleware.RequireRoles("candidate"), handlers.RespondToOffer)
+api.GET("/offer-templates", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.ListOfferTemplates)
+api.POST("/offer-templates", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.CreateOfferTemplate)
+api.PUT("/offer-templates/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr"), handlers.UpdateOfferTemplate)
 
 // notes & evaluations
-api.POST("/applications/:id/notes", middleware.AuthMiddleware(), handlers.CreateNote)
-api.GET("/applications/:id/notes", middleware.AuthMiddleware(), handlers.ListNotes)
+api.POST("/applications/:id/notes", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.CreateNote)
+api.GET("/applications/:id/notes", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.ListNotes)
+api.PUT("/notes/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.UpdateNote)
+api.DELETE("/notes/:id", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.DeleteNote)
+api.GET("/notes/:id/revisions", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.ListNoteRevisions)
+api.POST("/notes/:id/attachments", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.AddNoteAttachment)
+api.GET("/notes/:id/attachments/:attachment_id", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), handlers.DownloadNoteAttachment)
 
 api.POST("/applications/:id/evaluation", middleware.AuthMiddleware(), handlers.CreateEvaluation)
 api.GET("/applications/:id/evaluation", middleware.AuthMiddleware(), handlers.GetEvaluation)
 
 // notifications (aggregate derived notifications)
 api.GET("/notifications/aggregate", middleware.AuthMiddleware(), handlers.AggregateNotifications)
+// stored in-app notifications of the current user
+api.GET("/notifications", middleware.AuthMiddleware(), handlers.ListMyNotifications)
+api.POST("/notifications/:id/read", middleware.AuthMiddleware(), handlers.MarkNotificationRead)
 
 // uploads
 api.POST("/uploads/resume", middleware.AuthMiddleware(), handlers.UploadResume)
 
+// legacy be_clean API for the old frontend (LEGACY_PORT, default 8081, "off" disables)
+if legacyPort := os.Getenv("LEGACY_PORT"); legacyPort != "off" {
+if legacyPort == "" {
+legacyPort = "8081"
+}
+legacy := gin.New()
+legacy.Use(gin.Logger(), middleware.Recovery(), middleware.CORS())
+legacy.NoRoute(middleware.NotFound)
+handlers.RegisterLegacyRoutes(legacy)
+go func() {
+log.Println(" Legacy API listening on :" + legacyPort)
+if err := legacy.Run(":" + legacyPort); err != nil {
+log.Printf("legacy API stopped: %v", err)
+}
+}()
+}
+
 // listen
 port := os.Getenv("PORT")
 if port == "" {
@@ -5,45 +5,64 @@ import (
 	"os"
 	"strings"
 
+	"aats-backend-clean/respond"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// UserByEmail finds the account of a token that only carries an email
+// claim (issued by the old be_clean service); main wires it to the users
+// table. Nil rejects such tokens.
+var UserByEmail func(email string) (id, role string, ok bool)
+
+// tokenUser is the user id and role a token's claims stand for.
+func tokenUser(claims jwt.MapClaims) (sub, role string) {
+	sub, _ = claims["sub"].(string)
+	role, _ = claims["role"].(string)
+	if sub == "" && UserByEmail != nil {
+		if email, _ := claims["email"].(string); email != "" {
+			if id, r, ok := UserByEmail(email); ok {
+				return id, r
+			}
+		}
+	}
+	return sub, role
+}
+
 // AuthMiddleware validate Bearer token and set user_id & role in context
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+			respond.Error(c, http.StatusUnauthorized, "AUTH_HEADER_MISSING")
 			return
 		}
 		parts := strings.SplitN(auth, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+			respond.Error(c, http.StatusUnauthorized, "AUTH_HEADER_INVALID")
 			return
 		}
 		tokenStr := parts[1]
 		secret := os.Getenv("JWT_SECRET")
 		if secret == "" {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "JWT_SECRET not set"})
+			respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
 			return
 		}
 		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
 			return []byte(secret), nil
 		})
 		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			respond.Error(c, http.StatusUnauthorized, "TOKEN_INVALID")
 			return
 		}
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			respond.Error(c, http.StatusUnauthorized, "TOKEN_INVALID")
 			return
 		}
-		sub, _ := claims["sub"].(string)
-		role, _ := claims["role"].(string)
+		sub, role := tokenUser(claims)
 		if sub == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
+			respond.Error(c, http.StatusUnauthorized, "TOKEN_INVALID")
 			return
 		}
 
@@ -53,3 +72,29 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalAuth sets user_id & user_role when a valid Bearer token is present
+// and otherwise lets the request through anonymously. Used on public routes
+// that show more to staff (e.g. screening rules).
+func OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		secret := os.Getenv("JWT_SECRET")
+		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" || secret == "" {
+			c.Next()
+			return
+		}
+		token, err := jwt.Parse(parts[1], func(t *jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		})
+		if err == nil && token.Valid {
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				if sub, role := tokenUser(claims); sub != "" {
+					c.Set("user_id", sub)
+					c.Set("user_role", role)
+				}
+			}
+		}
+		c.Next()
+	}
+}
@@ -1,11 +1,14 @@
 package middleware
 
 import (
+	"net/http"
 	"time"
 	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/respond"
 )
 
 func CORS() gin.HandlerFunc {
@@ -13,8 +16,8 @@ func CORS() gin.HandlerFunc {
 		// ระบุ origins ที่อนุญาตแบบชัดเจนสำหรับการพัฒนา
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Authorization", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Authorization", "Content-Type", "Accept-Language"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Language"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 		// เพิ่มฟังก์ชันให้ยอมรับ localhost ในพอร์ตอื่นๆ ได้ด้วย (สะดวกตอนรัน dev server บนพอร์ตต่างกัน)
@@ -31,6 +34,16 @@ func CORS() gin.HandlerFunc {
 	}
 	return cors.New(cfg)
 }
-func JSONErr(c *gin.Context, code int, msg string) {
-	c.AbortWithStatusJSON(code, gin.H{"error": msg})
+
+// Recovery turns a panic in a handler into a 500 problem response; gin
+// logs the panic and stack.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, err any) {
+		respond.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+	})
+}
+
+// NotFound answers unknown routes with a problem response.
+func NotFound(c *gin.Context) {
+	respond.Error(c, http.StatusNotFound, "ROUTE_NOT_FOUND")
 }
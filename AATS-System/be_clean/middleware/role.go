@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 
+	"aats-backend-clean/respond"
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,16 +16,16 @@ func RequireRoles(allowed ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		rv, exists := c.Get("user_role")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+			respond.Error(c, http.StatusUnauthorized, "UNAUTHENTICATED")
 			return
 		}
 		role, ok := rv.(string)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid role"})
+			respond.Error(c, http.StatusUnauthorized, "TOKEN_INVALID")
 			return
 		}
 		if !roleSet[role] {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			respond.Error(c, http.StatusForbidden, "FORBIDDEN")
 			return
 		}
 		c.Next()
@@ -0,0 +1,27 @@
+-- Migration: Create skills taxonomy tables and match score columns
+CREATE TABLE IF NOT EXISTS skills (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    category VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_skills_category ON skills(category);
+
+CREATE TABLE IF NOT EXISTS skill_synonyms (
+    id VARCHAR(36) PRIMARY KEY,
+    skill_id VARCHAR(36),
+    alias VARCHAR(255) NOT NULL,
+    alias_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_skill_synonyms_skill_id ON skill_synonyms(skill_id);
+
+ALTER TABLE job_postings ADD COLUMN IF NOT EXISTS must_have_skills TEXT;
+ALTER TABLE job_postings ADD COLUMN IF NOT EXISTS nice_to_have_skills TEXT;
+ALTER TABLE job_postings ADD COLUMN IF NOT EXISTS min_experience_years NUMERIC DEFAULT 0;
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS normalized_skills TEXT;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS match_score NUMERIC DEFAULT 0;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS match_breakdown TEXT;
+CREATE INDEX IF NOT EXISTS idx_applications_match_score ON applications(match_score);
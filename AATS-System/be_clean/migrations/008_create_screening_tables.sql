@@ -0,0 +1,31 @@
+-- Migration: Create screening question/answer tables
+CREATE TABLE IF NOT EXISTS screening_questions (
+    id VARCHAR(36) PRIMARY KEY,
+    job_id VARCHAR(36),
+    position INTEGER DEFAULT 0,
+    prompt TEXT NOT NULL,
+    type VARCHAR(50),
+    options TEXT,
+    required BOOLEAN DEFAULT FALSE,
+    knockout_action VARCHAR(20),
+    knockout_operator VARCHAR(20),
+    knockout_value TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_screening_questions_job_id ON screening_questions(job_id);
+
+CREATE TABLE IF NOT EXISTS screening_answers (
+    id VARCHAR(36) PRIMARY KEY,
+    application_id VARCHAR(36),
+    question_id VARCHAR(36),
+    prompt TEXT,
+    value TEXT,
+    knockout VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_screening_answers_application_id ON screening_answers(application_id);
+CREATE INDEX IF NOT EXISTS idx_screening_answers_question_id ON screening_answers(question_id);
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS screening_outcome VARCHAR(20);
+CREATE INDEX IF NOT EXISTS idx_applications_screening_outcome ON applications(screening_outcome);
@@ -0,0 +1,67 @@
+-- Migration: Create tables for bulk actions, tags, message templates and notifications
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS reviewer_id VARCHAR(36);
+CREATE INDEX IF NOT EXISTS idx_applications_reviewer_id ON applications(reviewer_id);
+
+CREATE TABLE IF NOT EXISTS application_tags (
+    id VARCHAR(36) PRIMARY KEY,
+    application_id VARCHAR(36),
+    tag VARCHAR(100),
+    created_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_application_tag ON application_tags(application_id, tag);
+CREATE INDEX IF NOT EXISTS idx_application_tags_tag ON application_tags(tag);
+
+CREATE TABLE IF NOT EXISTS message_templates (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    subject TEXT,
+    body TEXT,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36),
+    type VARCHAR(50),
+    title TEXT,
+    message TEXT,
+    payload TEXT,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications(created_at);
+
+CREATE TABLE IF NOT EXISTS bulk_jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    action VARCHAR(50),
+    params TEXT,
+    state VARCHAR(50),
+    total INTEGER DEFAULT 0,
+    processed INTEGER DEFAULT 0,
+    succeeded INTEGER DEFAULT 0,
+    failed INTEGER DEFAULT 0,
+    error TEXT,
+    created_by VARCHAR(36),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_state ON bulk_jobs(state);
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_created_by ON bulk_jobs(created_by);
+
+CREATE TABLE IF NOT EXISTS bulk_job_items (
+    id VARCHAR(36) PRIMARY KEY,
+    bulk_job_id VARCHAR(36),
+    position INTEGER,
+    application_id VARCHAR(36),
+    state VARCHAR(20),
+    error TEXT,
+    processed_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_bulk_job_items_bulk_job_id ON bulk_job_items(bulk_job_id);
+CREATE INDEX IF NOT EXISTS idx_bulk_job_items_state ON bulk_job_items(state);
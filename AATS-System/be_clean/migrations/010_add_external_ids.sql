@@ -0,0 +1,6 @@
+-- Migration: External ids for CSV import upserts
+ALTER TABLE job_postings ADD COLUMN IF NOT EXISTS external_id VARCHAR(100);
+CREATE INDEX IF NOT EXISTS idx_job_postings_external_id ON job_postings(external_id);
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS external_id VARCHAR(100);
+CREATE INDEX IF NOT EXISTS idx_applications_external_id ON applications(external_id);
@@ -0,0 +1,41 @@
+-- Migration: Materialised analytics aggregates (refreshed incrementally by the API)
+CREATE TABLE IF NOT EXISTS analytics_application_facts (
+    application_id VARCHAR(36) PRIMARY KEY,
+    job_id VARCHAR(36),
+    department VARCHAR(255),
+    submitted_date TIMESTAMP,
+    status VARCHAR(50),
+    stage_reached INTEGER DEFAULT 0,
+    first_response_hours DOUBLE PRECISION,
+    time_to_hire_hours DOUBLE PRECISION,
+    hired_at TIMESTAMP,
+    rejected_at TIMESTAMP,
+    rejected_from VARCHAR(50),
+    rejection_reason TEXT,
+    has_evaluation BOOLEAN DEFAULT FALSE,
+    technical_skills INTEGER DEFAULT 0,
+    communication INTEGER DEFAULT 0,
+    problem_solving INTEGER DEFAULT 0,
+    cultural_fit INTEGER DEFAULT 0,
+    overall_score DOUBLE PRECISION DEFAULT 0,
+    refreshed_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_analytics_application_facts_job_id ON analytics_application_facts(job_id);
+CREATE INDEX IF NOT EXISTS idx_analytics_application_facts_department ON analytics_application_facts(department);
+CREATE INDEX IF NOT EXISTS idx_analytics_application_facts_submitted_date ON analytics_application_facts(submitted_date);
+
+CREATE TABLE IF NOT EXISTS analytics_stage_durations (
+    application_id VARCHAR(36),
+    seq INTEGER,
+    stage VARCHAR(50),
+    entered_at TIMESTAMP,
+    hours DOUBLE PRECISION,
+    PRIMARY KEY (application_id, seq)
+);
+CREATE INDEX IF NOT EXISTS idx_analytics_stage_durations_stage ON analytics_stage_durations(stage);
+
+CREATE TABLE IF NOT EXISTS analytics_watermarks (
+    name VARCHAR(50) PRIMARY KEY,
+    watermark TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
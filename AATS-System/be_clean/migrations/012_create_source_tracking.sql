@@ -0,0 +1,42 @@
+-- Migration: Candidate source tracking (channels, apply links, UTM on applications)
+CREATE TABLE IF NOT EXISTS source_channels (
+    id VARCHAR(36) PRIMARY KEY,
+    key VARCHAR(100) NOT NULL UNIQUE,
+    name VARCHAR(255),
+    kind VARCHAR(50),
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS apply_links (
+    id VARCHAR(36) PRIMARY KEY,
+    token VARCHAR(32) NOT NULL UNIQUE,
+    job_id VARCHAR(36),
+    source VARCHAR(100),
+    utm_source VARCHAR(255),
+    utm_medium VARCHAR(255),
+    utm_campaign VARCHAR(255),
+    utm_content VARCHAR(255),
+    label VARCHAR(255),
+    clicks INTEGER DEFAULT 0,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_apply_links_job_id ON apply_links(job_id);
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS source VARCHAR(100);
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS utm_source VARCHAR(255);
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS utm_medium VARCHAR(255);
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS utm_campaign VARCHAR(255);
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS utm_term VARCHAR(255);
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS utm_content VARCHAR(255);
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS referrer TEXT;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS apply_link_id VARCHAR(36);
+CREATE INDEX IF NOT EXISTS idx_applications_source ON applications(source);
+CREATE INDEX IF NOT EXISTS idx_applications_utm_campaign ON applications(utm_campaign);
+CREATE INDEX IF NOT EXISTS idx_applications_apply_link_id ON applications(apply_link_id);
+
+-- source is also an analytics dimension
+ALTER TABLE analytics_application_facts ADD COLUMN IF NOT EXISTS source VARCHAR(100);
+CREATE INDEX IF NOT EXISTS idx_analytics_application_facts_source ON analytics_application_facts(source);
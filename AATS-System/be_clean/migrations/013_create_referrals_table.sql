@@ -0,0 +1,27 @@
+-- Migration: Employee referrals
+CREATE TABLE IF NOT EXISTS referrals (
+    id VARCHAR(36) PRIMARY KEY,
+    job_id VARCHAR(36),
+    referrer_id VARCHAR(36),
+    candidate_name VARCHAR(255),
+    candidate_email VARCHAR(255),
+    candidate_phone VARCHAR(50),
+    resume_url TEXT,
+    relationship VARCHAR(255),
+    note TEXT,
+    invite_token VARCHAR(64) UNIQUE,
+    application_id VARCHAR(36),
+    hired_at TIMESTAMP,
+    bonus_status VARCHAR(20) DEFAULT 'none',
+    bonus_eligible_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_referrals_job_id ON referrals(job_id);
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer_id ON referrals(referrer_id);
+CREATE INDEX IF NOT EXISTS idx_referrals_candidate_email ON referrals(candidate_email);
+CREATE INDEX IF NOT EXISTS idx_referrals_application_id ON referrals(application_id);
+CREATE INDEX IF NOT EXISTS idx_referrals_bonus_status ON referrals(bonus_status);
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS referral_id VARCHAR(36);
+CREATE INDEX IF NOT EXISTS idx_applications_referral_id ON applications(referral_id);
@@ -0,0 +1,46 @@
+-- Migration: Offers, approval chain and letter templates
+CREATE TABLE IF NOT EXISTS offers (
+    id VARCHAR(36) PRIMARY KEY,
+    application_id VARCHAR(36) NOT NULL,
+    salary NUMERIC(14,2),
+    currency VARCHAR(3),
+    start_date TIMESTAMP,
+    benefits TEXT,
+    expires_at TIMESTAMP,
+    status VARCHAR(20) DEFAULT 'draft',
+    template_id VARCHAR(36),
+    letter_html TEXT,
+    sent_at TIMESTAMP,
+    responded_at TIMESTAMP,
+    response_ip VARCHAR(64),
+    response_user_agent TEXT,
+    decline_reason TEXT,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_offers_application_id ON offers(application_id);
+CREATE INDEX IF NOT EXISTS idx_offers_status ON offers(status);
+CREATE INDEX IF NOT EXISTS idx_offers_expires_at ON offers(expires_at);
+
+CREATE TABLE IF NOT EXISTS offer_approvals (
+    id VARCHAR(36) PRIMARY KEY,
+    offer_id VARCHAR(36),
+    step INTEGER,
+    approver_id VARCHAR(36),
+    status VARCHAR(20) DEFAULT 'pending',
+    comment TEXT,
+    decided_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_offer_approvals_offer_id ON offer_approvals(offer_id);
+CREATE INDEX IF NOT EXISTS idx_offer_approvals_approver_id ON offer_approvals(approver_id);
+
+CREATE TABLE IF NOT EXISTS offer_templates (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    body_html TEXT,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
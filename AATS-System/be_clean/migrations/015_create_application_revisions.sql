@@ -0,0 +1,11 @@
+-- Migration: Versioned candidate edits of submitted applications
+CREATE TABLE IF NOT EXISTS application_revisions (
+    id VARCHAR(36) PRIMARY KEY,
+    application_id VARCHAR(36),
+    version INTEGER,
+    cover_letter TEXT,
+    screening_answers TEXT,
+    edited_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_application_revision ON application_revisions(application_id, version);
@@ -0,0 +1,16 @@
+-- Migration: Configurable application policy (global or per job, with effective dates)
+CREATE TABLE IF NOT EXISTS application_policies (
+    id VARCHAR(36) PRIMARY KEY,
+    scope VARCHAR(20) NOT NULL DEFAULT 'global',
+    job_id VARCHAR(36),
+    rules TEXT,
+    effective_from TIMESTAMP NOT NULL,
+    effective_to TIMESTAMP,
+    note TEXT,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_application_policies_scope ON application_policies(scope);
+CREATE INDEX IF NOT EXISTS idx_application_policies_job_id ON application_policies(job_id);
+CREATE INDEX IF NOT EXISTS idx_application_policies_effective_from ON application_policies(effective_from);
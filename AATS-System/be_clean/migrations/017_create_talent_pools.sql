@@ -0,0 +1,59 @@
+-- Migration: Talent pools and candidate CRM
+ALTER TABLE users ADD COLUMN IF NOT EXISTS talent_pool_consent BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS talent_pool_consent_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_contacted_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS talent_pools (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS talent_pool_members (
+    id VARCHAR(36) PRIMARY KEY,
+    pool_id VARCHAR(36),
+    candidate_id VARCHAR(36),
+    skills TEXT,
+    experience_years NUMERIC(5,2) DEFAULT 0,
+    source_application_id VARCHAR(36),
+    added_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pool_member ON talent_pool_members(pool_id, candidate_id);
+CREATE INDEX IF NOT EXISTS idx_talent_pool_members_candidate_id ON talent_pool_members(candidate_id);
+CREATE INDEX IF NOT EXISTS idx_talent_pool_members_experience_years ON talent_pool_members(experience_years);
+
+CREATE TABLE IF NOT EXISTS talent_pool_tags (
+    id VARCHAR(36) PRIMARY KEY,
+    member_id VARCHAR(36),
+    tag VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pool_member_tag ON talent_pool_tags(member_id, tag);
+CREATE INDEX IF NOT EXISTS idx_talent_pool_tags_tag ON talent_pool_tags(tag);
+
+CREATE TABLE IF NOT EXISTS candidate_notes (
+    id VARCHAR(36) PRIMARY KEY,
+    candidate_id VARCHAR(36),
+    author VARCHAR(255),
+    created_by VARCHAR(36),
+    content TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_candidate_notes_candidate_id ON candidate_notes(candidate_id);
+
+CREATE TABLE IF NOT EXISTS talent_pool_invites (
+    id VARCHAR(36) PRIMARY KEY,
+    pool_id VARCHAR(36),
+    candidate_id VARCHAR(36),
+    job_id VARCHAR(36),
+    invited_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_talent_pool_invites_pool_id ON talent_pool_invites(pool_id);
+CREATE INDEX IF NOT EXISTS idx_talent_pool_invites_candidate_id ON talent_pool_invites(candidate_id);
+CREATE INDEX IF NOT EXISTS idx_talent_pool_invites_job_id ON talent_pool_invites(job_id);
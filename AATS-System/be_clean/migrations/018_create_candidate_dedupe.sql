@@ -0,0 +1,40 @@
+-- Migration: Duplicate candidate detection and profile merge
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_key VARCHAR(32);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS merged_into VARCHAR(36);
+CREATE INDEX IF NOT EXISTS idx_users_phone_key ON users(phone_key);
+CREATE INDEX IF NOT EXISTS idx_users_merged_into ON users(merged_into);
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS resume_hash VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_applications_resume_hash ON applications(resume_hash);
+
+CREATE TABLE IF NOT EXISTS duplicate_candidates (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36),
+    other_user_id VARCHAR(36),
+    score NUMERIC(4,3) DEFAULT 0,
+    reasons TEXT,
+    status VARCHAR(20) DEFAULT 'open',
+    reviewed_by VARCHAR(36),
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_duplicate_pair ON duplicate_candidates(user_id, other_user_id);
+CREATE INDEX IF NOT EXISTS idx_duplicate_candidates_score ON duplicate_candidates(score);
+CREATE INDEX IF NOT EXISTS idx_duplicate_candidates_status ON duplicate_candidates(status);
+
+CREATE TABLE IF NOT EXISTS candidate_merges (
+    id VARCHAR(36) PRIMARY KEY,
+    survivor_id VARCHAR(36),
+    merged_id VARCHAR(36),
+    duplicate_id VARCHAR(36),
+    moved TEXT,
+    status VARCHAR(20) DEFAULT 'merged',
+    merged_by VARCHAR(36),
+    undone_by VARCHAR(36),
+    undone_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_candidate_merges_survivor_id ON candidate_merges(survivor_id);
+CREATE INDEX IF NOT EXISTS idx_candidate_merges_merged_id ON candidate_merges(merged_id);
+CREATE INDEX IF NOT EXISTS idx_candidate_merges_status ON candidate_merges(status);
@@ -0,0 +1,36 @@
+-- Migration: Rich notes (visibility, replies, mentions, edit history, attachments)
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS parent_id VARCHAR(36);
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) DEFAULT 'hiring_team';
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS mentions TEXT;
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP;
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(36);
+ALTER TABLE notes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+UPDATE notes SET visibility = 'hiring_team' WHERE visibility IS NULL OR visibility = '';
+CREATE INDEX IF NOT EXISTS idx_notes_application_id ON notes(application_id);
+CREATE INDEX IF NOT EXISTS idx_notes_parent_id ON notes(parent_id);
+CREATE INDEX IF NOT EXISTS idx_notes_visibility ON notes(visibility);
+CREATE INDEX IF NOT EXISTS idx_notes_deleted_at ON notes(deleted_at);
+
+CREATE TABLE IF NOT EXISTS note_revisions (
+    id VARCHAR(36) PRIMARY KEY,
+    note_id VARCHAR(36),
+    version INT NOT NULL,
+    action VARCHAR(20),
+    content TEXT,
+    visibility VARCHAR(20),
+    edited_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_note_revision ON note_revisions(note_id, version);
+
+CREATE TABLE IF NOT EXISTS note_attachments (
+    id VARCHAR(36) PRIMARY KEY,
+    note_id VARCHAR(36),
+    file_name VARCHAR(255),
+    stored_name VARCHAR(255),
+    content_type VARCHAR(255),
+    size BIGINT DEFAULT 0,
+    uploaded_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_note_attachments_note_id ON note_attachments(note_id);
@@ -0,0 +1,33 @@
+-- Migration: Custom fields on applications, jobs and users
+CREATE TABLE IF NOT EXISTS custom_fields (
+    id VARCHAR(36) PRIMARY KEY,
+    entity VARCHAR(20) NOT NULL,
+    key VARCHAR(64) NOT NULL,
+    label VARCHAR(255),
+    type VARCHAR(20) NOT NULL,
+    options TEXT,
+    required BOOLEAN DEFAULT FALSE,
+    hr_only BOOLEAN DEFAULT FALSE,
+    position INT DEFAULT 0,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_field_key ON custom_fields(entity, key);
+
+CREATE TABLE IF NOT EXISTS custom_field_values (
+    id VARCHAR(36) PRIMARY KEY,
+    field_id VARCHAR(36) NOT NULL,
+    entity_id VARCHAR(36) NOT NULL,
+    value_text TEXT,
+    value_number DOUBLE PRECISION,
+    value_date TIMESTAMP,
+    updated_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_value ON custom_field_values(field_id, entity_id);
+CREATE INDEX IF NOT EXISTS idx_custom_field_values_entity_id ON custom_field_values(entity_id);
+CREATE INDEX IF NOT EXISTS idx_custom_field_values_value_text ON custom_field_values(value_text);
+CREATE INDEX IF NOT EXISTS idx_custom_field_values_value_number ON custom_field_values(value_number);
+CREATE INDEX IF NOT EXISTS idx_custom_field_values_value_date ON custom_field_values(value_date);
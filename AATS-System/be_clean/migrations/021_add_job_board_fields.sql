@@ -0,0 +1,11 @@
+-- Migration: Public job board (slugs and search text on job postings)
+ALTER TABLE job_postings ADD COLUMN IF NOT EXISTS slug VARCHAR(120);
+ALTER TABLE job_postings ADD COLUMN IF NOT EXISTS search_text TEXT;
+-- existing jobs get their slug when the server starts; empty slugs
+-- are left out of the unique index until then
+CREATE UNIQUE INDEX IF NOT EXISTS idx_job_postings_slug ON job_postings(slug) WHERE slug <> '';
+CREATE INDEX IF NOT EXISTS idx_job_postings_status_posted ON job_postings(status, posted_date DESC, id DESC);
+-- trigram index so the substring search stays fast (works for Thai text,
+-- which has no spaces for to_tsvector to split on)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_job_postings_search_text ON job_postings USING gin (search_text gin_trgm_ops);
@@ -0,0 +1,15 @@
+-- Migration: Job syndication feeds (per-board XML field mapping)
+CREATE TABLE IF NOT EXISTS job_feed_boards (
+    id VARCHAR(36) PRIMARY KEY,
+    slug VARCHAR(64) NOT NULL,
+    name VARCHAR(255),
+    root_element VARCHAR(64),
+    item_element VARCHAR(64),
+    fields TEXT,
+    departments TEXT,
+    enabled BOOLEAN DEFAULT TRUE,
+    created_by VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_job_feed_boards_slug ON job_feed_boards(slug);
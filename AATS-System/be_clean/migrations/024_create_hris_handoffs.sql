@@ -0,0 +1,17 @@
+-- Migration: HRIS new-hire handoffs (queue and sync status)
+CREATE TABLE IF NOT EXISTS hris_handoffs (
+    id VARCHAR(36) PRIMARY KEY,
+    application_id VARCHAR(36) NOT NULL,
+    connector VARCHAR(20),
+    state VARCHAR(20) DEFAULT 'pending',
+    next_attempt_at TIMESTAMP,
+    attempts INT DEFAULT 0,
+    last_error TEXT,
+    external_id VARCHAR(255),
+    payload TEXT,
+    synced_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_hris_handoffs_application_id ON hris_handoffs(application_id);
+CREATE INDEX IF NOT EXISTS idx_hris_handoff_due ON hris_handoffs(state, next_attempt_at);
@@ -0,0 +1,9 @@
+-- Migration: message keys for stored text, so it can be shown in the reader's language
+ALTER TABLE users ADD COLUMN IF NOT EXISTS language VARCHAR(8);
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS title_key VARCHAR(100);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS message_key VARCHAR(100);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS params TEXT;
+
+ALTER TABLE application_timelines ADD COLUMN IF NOT EXISTS description_key VARCHAR(100);
+ALTER TABLE application_timelines ADD COLUMN IF NOT EXISTS description_params TEXT;
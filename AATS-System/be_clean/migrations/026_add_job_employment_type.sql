@@ -0,0 +1,3 @@
+-- Migration: employment type on job postings (filterable on the job list)
+ALTER TABLE job_postings ADD COLUMN IF NOT EXISTS employment_type VARCHAR(20);
+CREATE INDEX IF NOT EXISTS idx_job_postings_employment_type ON job_postings(employment_type);
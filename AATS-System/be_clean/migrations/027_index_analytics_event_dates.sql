@@ -0,0 +1,3 @@
+-- Migration: index the hire and rejection dates of analytics facts (trends filter and bucket by them)
+CREATE INDEX IF NOT EXISTS idx_analytics_application_facts_hired_at ON analytics_application_facts(hired_at);
+CREATE INDEX IF NOT EXISTS idx_analytics_application_facts_rejected_at ON analytics_application_facts(rejected_at);
@@ -0,0 +1,3 @@
+-- Migration: who forfeited a referral bonus ('system' when the candidate left hired, else the HR user).
+-- Bonuses forfeited before this column existed are treated as HR decisions and stay forfeited.
+ALTER TABLE referrals ADD COLUMN IF NOT EXISTS bonus_forfeited_by VARCHAR(36);
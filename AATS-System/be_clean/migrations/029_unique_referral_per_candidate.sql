@@ -0,0 +1,3 @@
+-- Migration: one referral per candidate and job, so simultaneous submits cannot both get through.
+-- Emails are saved lower-cased; the index lower-cases too for rows written before that.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referrals_job_candidate ON referrals(job_id, LOWER(candidate_email));
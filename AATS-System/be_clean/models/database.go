@@ -4,36 +4,88 @@ import (
 	"log"
 	"os"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
+// ConnectDatabase opens DATABASE_URL (Postgres), or the SQLite file at
+// SQLITE_PATH (default aats.db) when it is not set, and migrates it.
 func ConnectDatabase() {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		log.Fatal(" DATABASE_URL not found. โปรดตั้งค่าในไฟล์ .env หรือ Environment Variable")
+	var dialector gorm.Dialector
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		dialector = postgres.Open(dsn)
+	} else {
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "aats.db"
+		}
+		log.Println(" DATABASE_URL not set, using SQLite at", path)
+		dialector = sqlite.Open(path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		log.Fatal(" ไม่สามารถเชื่อมต่อฐานข้อมูลได้:", err)
 	}
 
-	if err := db.AutoMigrate(
+	if err := Migrate(db); err != nil {
+		log.Fatal(" AutoMigrate ล้มเหลว:", err)
+	}
+
+	DB = db
+	log.Println(" " + db.Dialector.Name() + " connected & migrated successfully")
+}
+
+// Migrate creates or updates the tables of every model.
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(
 		&User{},
 		&JobPosting{},
 		&Application{},
 		&ApplicationTimeline{},
 		&Evaluation{},
 		&Note{},
-	); err != nil {
-		log.Fatal(" AutoMigrate ล้มเหลว:", err)
-	}
-
-	DB = db
-	log.Println(" PostgreSQL connected & migrated successfully")
+		&Skill{},
+		&SkillSynonym{},
+		&ScreeningQuestion{},
+		&ScreeningAnswer{},
+		&ApplicationTag{},
+		&MessageTemplate{},
+		&Notification{},
+		&BulkJob{},
+		&BulkJobItem{},
+		&AnalyticsApplicationFact{},
+		&AnalyticsStageDuration{},
+		&AnalyticsWatermark{},
+		&SourceChannel{},
+		&ApplyLink{},
+		&Referral{},
+		&Offer{},
+		&OfferApproval{},
+		&OfferTemplate{},
+		&ApplicationRevision{},
+		&ApplicationPolicy{},
+		&TalentPool{},
+		&TalentPoolMember{},
+		&TalentPoolTag{},
+		&CandidateNote{},
+		&TalentPoolInvite{},
+		&DuplicateCandidate{},
+		&CandidateMerge{},
+		&NoteRevision{},
+		&NoteAttachment{},
+		&CustomField{},
+		&CustomFieldValue{},
+		&JobFeedBoard{},
+		&WebhookSubscription{},
+		&WebhookEvent{},
+		&WebhookDelivery{},
+		&WebhookAttempt{},
+		&HRISHandoff{},
+	)
 }
 
 func GetDB() *gorm.DB {
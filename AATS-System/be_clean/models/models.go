@@ -1,69 +1,116 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // ==== USER ====
 type User struct {
-	ID         string     `gorm:"primaryKey"`
-	Email      string     `gorm:"uniqueIndex;not null"`
-	Password   string     `gorm:"not null"`
-	Role       string     `gorm:"not null"` // candidate | hr | hm
+	ID         string `gorm:"primaryKey"`
+	Email      string `gorm:"uniqueIndex;not null"`
+	Password   string `gorm:"not null"`
+	Role       string `gorm:"not null"` // candidate | hr | hm | employee
 	Name       string
 	Phone      string
+	PhoneKey   string `gorm:"index"` // utils.NormalizePhone(Phone), for duplicate detection
+	MergedInto string `gorm:"index"` // set when this profile was merged into another User.ID
+	Language   string // preferred language for messages (i18n.Lang), "" = follow Accept-Language
 	Department *string
 	Position   *string
-	CreatedAt  time.Time  `gorm:"autoCreateTime"`
-	UpdatedAt  time.Time  `gorm:"autoUpdateTime"`
+	// talent pool / CRM (candidates)
+	TalentPoolConsent   bool       // candidate agreed to be kept in talent pools
+	TalentPoolConsentAt *time.Time // when consent was last given or withdrawn
+	LastContactedAt     *time.Time // last time a recruiter reached out
+	CreatedAt           time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime"`
 }
 
 // ==== JOB_POSTING ====
 type JobPosting struct {
-	ID               string    `gorm:"primaryKey"`
-	Title            string    `gorm:"not null"`
-	Department       string
-	Location         string
-	ExperienceLevel  string
-	Description      string
-	Requirements     string    // JSON string (array)
-	Responsibilities string    // JSON string (array)
-	Status           string
-	PostedDate       time.Time
-	ClosingDate      time.Time
-	CreatedBy        string    `gorm:"index"` // FK → User.ID (logical)
-	CreatedAt        time.Time `gorm:"autoCreateTime"`
-	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
+	ID                 string `gorm:"primaryKey"`
+	ExternalID         string `gorm:"index"` // id in the source system (CSV import upsert key)
+	Title              string `gorm:"not null"`
+	Slug               string `gorm:"index"` // public URL segment, see utils.JobSlug
+	Department         string
+	Location           string
+	ExperienceLevel    string
+	EmploymentType     string `gorm:"index"` // full-time|part-time|contract|internship, "" = not stated
+	Description        string
+	Requirements       string  // JSON string (array)
+	Responsibilities   string  // JSON string (array)
+	MustHaveSkills     string  // JSON string (array of canonical skill names)
+	NiceToHaveSkills   string  // JSON string (array of canonical skill names)
+	MinExperienceYears float64 // 0 = derive from ExperienceLevel
+	Status             string
+	PostedDate         time.Time
+	ClosingDate        time.Time
+	SearchText         string    `json:"-"`     // lower-cased title, description, skills… for job board search
+	CreatedBy          string    `gorm:"index"` // FK → User.ID (logical)
+	CreatedAt          time.Time `gorm:"autoCreateTime"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime"`
 }
 
 // ==== APPLICATION ====
 type Application struct {
-	ID            string    `gorm:"primaryKey"`
-	JobID         string    `gorm:"index"`     // FK → JobPosting.ID (logical)
-	ApplicantID   string    `gorm:"index"`     // FK → User.ID (logical)
-	Resume        string
-	CoverLetter   string
-	Education     string    // JSON string (object)
-	Experience    string    // JSON string (object)
-	Skills        string    // JSON string (array)
-	Status        string    // submitted|screening|interview|offer|rejected|hired
-	SubmittedDate time.Time
-	CreatedAt     time.Time `gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+	ID               string `gorm:"primaryKey"`
+	ExternalID       string `gorm:"index"` // id in the source system (CSV import upsert key)
+	JobID            string `gorm:"index"` // FK → JobPosting.ID (logical)
+	ApplicantID      string `gorm:"index"` // FK → User.ID (logical)
+	Resume           string
+	ResumeHash       string `gorm:"index"` // SHA-256 of the uploaded resume file
+	CoverLetter      string
+	Education        string  // JSON string (object)
+	Experience       string  // JSON string (object)
+	Skills           string  // JSON string (array)
+	NormalizedSkills string  // JSON string (array of canonical skill names)
+	MatchScore       float64 `gorm:"index" json:"-"` // 0-100, see utils.ComputeMatch; staff only
+	MatchBreakdown   string  `json:"-"`              // JSON string (utils.MatchBreakdown); staff only
+	ScreeningOutcome string  `gorm:"index"`          // ""|passed|flagged|rejected (knockout screening)
+	ReviewerID       string  `gorm:"index"`          // FK → User.ID (logical), assigned reviewer
+	Source           string  `gorm:"index"`          // SourceChannel.Key, "direct" or "other"
+	UTMSource        string
+	UTMMedium        string
+	UTMCampaign      string `gorm:"index"`
+	UTMTerm          string
+	UTMContent       string
+	Referrer         string
+	ApplyLinkID      string `gorm:"index"` // FK → ApplyLink.ID (logical)
+	ReferralID       string `gorm:"index"` // FK → Referral.ID (logical)
+	Status           string // submitted|screening|interview|offer|rejected|hired|withdrawn
+	SubmittedDate    time.Time
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
 }
 
 // ==== APPLICATION_TIMELINE ====
 type ApplicationTimeline struct {
-	ID            string    `gorm:"primaryKey"`
-	ApplicationID string    `gorm:"index"` // FK → Application.ID (logical)
-	Status        string
-	Date          time.Time
-	Description   string
-	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	ID                string `gorm:"primaryKey"`
+	ApplicationID     string `gorm:"index"` // FK → Application.ID (logical)
+	Status            string
+	Date              time.Time
+	Description       string    // in the default language; see DescriptionKey
+	DescriptionKey    string    `json:",omitempty"` // i18n key, empty for text people wrote
+	DescriptionParams string    `json:"-"`          // JSON params for DescriptionKey
+	CreatedAt         time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== APPLICATION_REVISION (candidate edits while still submitted) ====
+type ApplicationRevision struct {
+	ID               string `gorm:"primaryKey"`
+	ApplicationID    string `gorm:"uniqueIndex:idx_application_revision"` // FK → Application.ID (logical)
+	Version          int    `gorm:"uniqueIndex:idx_application_revision"` // 1 = as submitted
+	CoverLetter      string
+	ScreeningAnswers string    // JSON string (array of {question_id, prompt, value})
+	EditedBy         string    // user id
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
 }
 
 // ==== EVALUATION (1:1 กับ Application) ====
 type Evaluation struct {
-	ID              string    `gorm:"primaryKey"`
-	ApplicationID   string    `gorm:"uniqueIndex"` // enforce 1:1
+	ID              string `gorm:"primaryKey"`
+	ApplicationID   string `gorm:"uniqueIndex"` // enforce 1:1
 	EvaluatorID     string
 	EvaluatorName   string
 	TechnicalSkills int
@@ -79,10 +126,489 @@ type Evaluation struct {
 
 // ==== NOTE ====
 type Note struct {
-	ID            string    `gorm:"primaryKey"`
-	ApplicationID string    `gorm:"index"`
+	ID            string `gorm:"primaryKey"`
+	ApplicationID string `gorm:"index"`
+	ParentID      string `gorm:"index"` // FK → Note.ID (logical), set on replies
 	Author        string
 	CreatedBy     string // user id
 	Content       string
+	Visibility    string         `gorm:"index"` // hr|hiring_team|private; replies follow their parent
+	Mentions      string         // JSON string (array of mentioned user ids)
+	EditedAt      *time.Time     // last edit, nil if never edited
+	DeletedBy     string         // user id
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime"`
+}
+
+// ==== NOTE_REVISION (previous content of an edited or deleted note) ====
+type NoteRevision struct {
+	ID         string `gorm:"primaryKey"`
+	NoteID     string `gorm:"uniqueIndex:idx_note_revision"` // FK → Note.ID (logical)
+	Version    int    `gorm:"uniqueIndex:idx_note_revision"` // 1 = as first written
+	Action     string // edit|delete
+	Content    string
+	Visibility string
+	EditedBy   string    // user id
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== NOTE_ATTACHMENT (file attached to a note, served through the API) ====
+type NoteAttachment struct {
+	ID          string `gorm:"primaryKey"`
+	NoteID      string `gorm:"index"` // FK → Note.ID (logical)
+	FileName    string // original name
+	StoredName  string // name under uploads/notes
+	ContentType string
+	Size        int64
+	UploadedBy  string    // user id
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== SKILL (canonical skills taxonomy) ====
+type Skill struct {
+	ID        string    `gorm:"primaryKey"`
+	Name      string    `gorm:"uniqueIndex;not null"` // canonical display name e.g. "Go"
+	Category  string    `gorm:"index"`                // e.g. language, framework, soft
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== SKILL_SYNONYM ====
+type SkillSynonym struct {
+	ID        string    `gorm:"primaryKey"`
+	SkillID   string    `gorm:"index"`                // FK → Skill.ID (logical)
+	Alias     string    `gorm:"not null"`             // as entered e.g. "Golang"
+	AliasKey  string    `gorm:"uniqueIndex;not null"` // utils.SkillKey(Alias)
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== SCREENING_QUESTION (per job, answered on apply) ====
+type ScreeningQuestion struct {
+	ID               string `gorm:"primaryKey"`
+	JobID            string `gorm:"index"` // FK → JobPosting.ID (logical)
+	Position         int
+	Prompt           string `gorm:"not null"`
+	Type             string // yes_no|single_choice|numeric|text
+	Options          string // JSON string (array) for single_choice
+	Required         bool
+	KnockoutAction   string // ""|reject|flag
+	KnockoutOperator string // see utils.ScreeningRule
+	KnockoutValue    string
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== SCREENING_ANSWER ====
+type ScreeningAnswer struct {
+	ID            string    `gorm:"primaryKey"`
+	ApplicationID string    `gorm:"index"` // FK → Application.ID (logical)
+	QuestionID    string    `gorm:"index"` // FK → ScreeningQuestion.ID (logical)
+	Prompt        string    // copy of the question at answer time
+	Value         string    `gorm:"index"` // normalized answer
+	Knockout      string    // action fired by this answer: ""|reject|flag
 	CreatedAt     time.Time `gorm:"autoCreateTime"`
 }
+
+// ==== APPLICATION_TAG ====
+type ApplicationTag struct {
+	ID            string    `gorm:"primaryKey"`
+	ApplicationID string    `gorm:"uniqueIndex:idx_application_tag"` // FK → Application.ID (logical)
+	Tag           string    `gorm:"uniqueIndex:idx_application_tag;index"`
+	CreatedBy     string    // user id
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== MESSAGE_TEMPLATE ====
+type MessageTemplate struct {
+	ID        string    `gorm:"primaryKey"`
+	Name      string    `gorm:"uniqueIndex;not null"`
+	Subject   string    // supports {{name}}, {{job_title}}, {{status}}
+	Body      string    // same placeholders as Subject
+	CreatedBy string    // user id
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== NOTIFICATION (stored, per user) ====
+type Notification struct {
+	ID         string `gorm:"primaryKey"`
+	UserID     string `gorm:"index"` // FK → User.ID (logical), recipient
+	Type       string // info|success|warning|message
+	Title      string // in the recipient's language at the time; see TitleKey
+	Message    string
+	TitleKey   string `json:",omitempty"` // i18n keys, empty for text people wrote
+	MessageKey string `json:",omitempty"`
+	Params     string `json:"-"` // JSON params for both keys
+	Payload    string // JSON string (object)
+	ReadAt     *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime;index"`
+}
+
+// ==== BULK_JOB (background bulk action on applications) ====
+type BulkJob struct {
+	ID         string `gorm:"primaryKey"`
+	Action     string // status|tag|assign|message
+	Params     string // JSON string (action parameters)
+	State      string `gorm:"index"` // queued|running|completed|completed_with_errors|failed
+	Total      int
+	Processed  int
+	Succeeded  int
+	Failed     int
+	Error      string // set when the whole job failed
+	CreatedBy  string `gorm:"index"` // user id
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== BULK_JOB_ITEM (per-application result) ====
+type BulkJobItem struct {
+	ID            string `gorm:"primaryKey"`
+	BulkJobID     string `gorm:"index"` // FK → BulkJob.ID (logical)
+	Position      int
+	ApplicationID string
+	State         string `gorm:"index"` // pending|succeeded|failed
+	Error         string
+	ProcessedAt   *time.Time
+}
+
+// ==== ANALYTICS (materialised aggregates, refreshed by handlers.RefreshAnalytics) ====
+// One row per application with everything the analytics endpoints need, so
+// reports never have to walk application_timelines.
+type AnalyticsApplicationFact struct {
+	ApplicationID      string    `gorm:"primaryKey"`
+	JobID              string    `gorm:"index"`
+	Department         string    `gorm:"index"`
+	Source             string    `gorm:"index"`
+	SubmittedDate      time.Time `gorm:"index"`
+	Status             string
+	StageReached       int // index in utils.FunnelStages (0 submitted .. 4 hired)
+	FirstResponseHours *float64
+	TimeToHireHours    *float64
+	HiredAt            *time.Time `gorm:"index"`
+	RejectedAt         *time.Time `gorm:"index"`
+	RejectedFrom       string     // stage the candidate was in when rejected
+	RejectionReason    string     // description of the rejecting timeline entry
+	HasEvaluation      bool
+	TechnicalSkills    int
+	Communication      int
+	ProblemSolving     int
+	CulturalFit        int
+	OverallScore       float64
+	RefreshedAt        time.Time
+}
+
+// Completed stays in a status, used for time-in-stage percentiles.
+type AnalyticsStageDuration struct {
+	ApplicationID string `gorm:"primaryKey"`
+	Seq           int    `gorm:"primaryKey"`
+	Stage         string `gorm:"index"`
+	EnteredAt     time.Time
+	Hours         float64
+}
+
+// Watermark of the last incremental refresh.
+type AnalyticsWatermark struct {
+	Name      string `gorm:"primaryKey"`
+	Watermark time.Time
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== SOURCE_CHANNEL (where candidates come from, configurable by HR) ====
+type SourceChannel struct {
+	ID        string    `gorm:"primaryKey"`
+	Key       string    `gorm:"uniqueIndex;not null"` // stored on Application.Source e.g. "linkedin"
+	Name      string    // display name
+	Kind      string    // job_board|referral|careers_page|social|agency|event|other
+	Active    bool      `gorm:"default:true"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== APPLY_LINK (trackable per-job apply link) ====
+type ApplyLink struct {
+	ID          string `gorm:"primaryKey"`
+	Token       string `gorm:"uniqueIndex;not null"` // short code used in the public URL
+	JobID       string `gorm:"index"`                // FK → JobPosting.ID (logical)
+	Source      string // SourceChannel.Key
+	UTMSource   string
+	UTMMedium   string
+	UTMCampaign string
+	UTMContent  string
+	Label       string
+	Clicks      int
+	CreatedBy   string
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== REFERRAL (employee referral of a candidate for a job) ====
+type Referral struct {
+	ID               string `gorm:"primaryKey"`
+	JobID            string `gorm:"uniqueIndex:idx_referrals_job_candidate"` // FK → JobPosting.ID (logical)
+	ReferrerID       string `gorm:"index"`                                   // FK → User.ID (logical), the employee
+	CandidateName    string
+	CandidateEmail   string `gorm:"uniqueIndex:idx_referrals_job_candidate;index"` // lower-cased; one referral per candidate and job
+	CandidatePhone   string
+	ResumeURL        string
+	Relationship     string // how the referrer knows the candidate
+	Note             string
+	InviteToken      string     `gorm:"uniqueIndex"` // candidate applies through /referral/<token>
+	ApplicationID    string     `gorm:"index"`       // set when the candidate applies
+	HiredAt          *time.Time // first time the application reached hired
+	BonusStatus      string     `gorm:"index"` // none|pending|eligible|paid|forfeited
+	BonusEligibleAt  *time.Time // HiredAt + probation
+	BonusForfeitedBy string     // "system" when the candidate left hired, else the HR user
+	CreatedAt        time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime"`
+}
+
+// ==== OFFER (job offer made on an application) ====
+type Offer struct {
+	ID                string  `gorm:"primaryKey"`
+	ApplicationID     string  `gorm:"index;not null"` // FK → Application.ID (logical)
+	Salary            float64 // per month
+	Currency          string  // ISO 4217, e.g. THB
+	StartDate         time.Time
+	Benefits          string
+	ExpiresAt         time.Time `gorm:"index"`
+	Status            string    `gorm:"index"` // draft|pending_approval|approved|rejected|sent|accepted|declined|withdrawn
+	TemplateID        string    // OfferTemplate.ID, empty = built-in letter
+	LetterHTML        string    // rendered when sent; the copy the candidate signs off on
+	SentAt            *time.Time
+	RespondedAt       *time.Time // accept/decline time (or auto-decline on expiry)
+	ResponseIP        string
+	ResponseUserAgent string
+	DeclineReason     string    // free text from the candidate, "expired" for auto-decline
+	CreatedBy         string    // user id (HR)
+	CreatedAt         time.Time `gorm:"autoCreateTime"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== OFFER APPROVAL (one step of an offer's approval chain) ====
+type OfferApproval struct {
+	ID         string `gorm:"primaryKey"`
+	OfferID    string `gorm:"index"` // FK → Offer.ID (logical)
+	Step       int    // approvals happen in Step order
+	ApproverID string `gorm:"index"` // FK → User.ID (logical)
+	Status     string // pending|approved|rejected
+	Comment    string
+	DecidedAt  *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== OFFER TEMPLATE (HTML letter with {{placeholders}}) ====
+type OfferTemplate struct {
+	ID        string    `gorm:"primaryKey"`
+	Name      string    `gorm:"uniqueIndex;not null"`
+	BodyHTML  string    // supports {{name}}, {{job_title}}, {{salary}}, {{currency}}, {{start_date}}, {{benefits}}, {{expires_at}}
+	CreatedBy string    // user id
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== APPLICATION_POLICY (apply rules, tenant-wide or per job, with effective dates) ====
+type ApplicationPolicy struct {
+	ID            string     `gorm:"primaryKey"`
+	Scope         string     `gorm:"index"` // global|job
+	JobID         string     `gorm:"index"` // FK → JobPosting.ID (logical), set when Scope = job
+	Rules         string     // JSON utils.PolicyRules; omitted rules inherit
+	EffectiveFrom time.Time  `gorm:"index"`
+	EffectiveTo   *time.Time // exclusive, nil = open-ended
+	Note          string
+	CreatedBy     string    // user id
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== TALENT_POOL (named list of candidates kept beyond one application) ====
+type TalentPool struct {
+	ID          string `gorm:"primaryKey"`
+	Name        string `gorm:"uniqueIndex;not null"`
+	Description string
+	CreatedBy   string    // user id
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== TALENT_POOL_MEMBER ====
+type TalentPoolMember struct {
+	ID                  string    `gorm:"primaryKey"`
+	PoolID              string    `gorm:"uniqueIndex:idx_pool_member"`       // FK → TalentPool.ID (logical)
+	CandidateID         string    `gorm:"uniqueIndex:idx_pool_member;index"` // FK → User.ID (logical)
+	Skills              string    // JSON string (array of canonical skills from all applications)
+	ExperienceYears     float64   `gorm:"index"` // highest across applications
+	SourceApplicationID string    // application the candidate was pooled from, if any
+	AddedBy             string    // user id
+	CreatedAt           time.Time `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== TALENT_POOL_TAG ====
+type TalentPoolTag struct {
+	ID        string    `gorm:"primaryKey"`
+	MemberID  string    `gorm:"uniqueIndex:idx_pool_member_tag"` // FK → TalentPoolMember.ID (logical)
+	Tag       string    `gorm:"uniqueIndex:idx_pool_member_tag;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== CANDIDATE_NOTE (HR note on a candidate, not tied to one application) ====
+type CandidateNote struct {
+	ID          string `gorm:"primaryKey"`
+	CandidateID string `gorm:"index"` // FK → User.ID (logical)
+	Author      string
+	CreatedBy   string // user id
+	Content     string
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== TALENT_POOL_INVITE (pooled candidate invited to apply to a job) ====
+type TalentPoolInvite struct {
+	ID          string    `gorm:"primaryKey"`
+	PoolID      string    `gorm:"index"`
+	CandidateID string    `gorm:"index"`
+	JobID       string    `gorm:"index"`
+	InvitedBy   string    // user id
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== DUPLICATE_CANDIDATE (pair of profiles that may be the same person) ====
+type DuplicateCandidate struct {
+	ID          string  `gorm:"primaryKey"`
+	UserID      string  `gorm:"uniqueIndex:idx_duplicate_pair"` // FK → User.ID (logical), lower id of the pair
+	OtherUserID string  `gorm:"uniqueIndex:idx_duplicate_pair"` // FK → User.ID (logical)
+	Score       float64 `gorm:"index"`                          // 0-1, see utils.ScoreDuplicate
+	Reasons     string  // JSON string (array: phone|resume|name)
+	Status      string  `gorm:"index"` // open|dismissed|merged
+	ReviewedBy  string  // user id
+	ReviewedAt  *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== CANDIDATE_MERGE (audit trail of a profile merge, used to undo it) ====
+type CandidateMerge struct {
+	ID          string `gorm:"primaryKey"`
+	SurvivorID  string `gorm:"index"` // FK → User.ID (logical), the profile kept
+	MergedID    string `gorm:"index"` // FK → User.ID (logical), the profile folded in
+	DuplicateID string // FK → DuplicateCandidate.ID (logical), optional
+	Moved       string // JSON string (object: table → ids moved to the survivor)
+	Status      string `gorm:"index"` // merged|undone
+	MergedBy    string // user id
+	UndoneBy    string // user id
+	UndoneAt    *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== CUSTOM_FIELD (admin-defined field on applications, jobs or users) ====
+type CustomField struct {
+	ID        string `gorm:"primaryKey"`
+	Entity    string `gorm:"uniqueIndex:idx_custom_field_key"` // application|job|user
+	Key       string `gorm:"uniqueIndex:idx_custom_field_key"` // used in API bodies and filters (cf.<key>)
+	Label     string
+	Type      string // text|number|date|enum|multi_select
+	Options   string // JSON string (array of allowed values for enum/multi_select)
+	Required  bool
+	HROnly    bool // hidden from candidates and not writable by them
+	Position  int  // display order
+	CreatedBy string
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== CUSTOM_FIELD_VALUE (one field's value on one record) ====
+type CustomFieldValue struct {
+	ID          string     `gorm:"primaryKey"`
+	FieldID     string     `gorm:"uniqueIndex:idx_custom_value"`       // FK → CustomField.ID (logical)
+	EntityID    string     `gorm:"uniqueIndex:idx_custom_value;index"` // Application.ID, JobPosting.ID or User.ID
+	ValueText   string     `gorm:"index"`                              // see utils.CustomValue
+	ValueNumber *float64   `gorm:"index"`
+	ValueDate   *time.Time `gorm:"index"`
+	UpdatedBy   string     // user id
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime"`
+}
+
+// ==== JOB_FEED_BOARD (an external job board fed from /api/feeds/boards/:slug) ====
+type JobFeedBoard struct {
+	ID          string `gorm:"primaryKey"`
+	Slug        string `gorm:"uniqueIndex"` // feed URL segment
+	Name        string
+	RootElement string // default "source"
+	ItemElement string // default "job"
+	Fields      string // JSON string (array of utils.FeedField, in output order)
+	Departments string // JSON string (array); empty = every department
+	Enabled     bool
+	CreatedBy   string
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== WEBHOOK_SUBSCRIPTION (outbound webhook endpoint) ====
+type WebhookSubscription struct {
+	ID            string `gorm:"primaryKey"`
+	Name          string
+	URL           string
+	Secret        string `json:"-"` // HMAC-SHA256 signing key
+	Events        string // JSON string (array of event patterns: "job.created", "application.status.*", "*")
+	Active        bool   `gorm:"index"`
+	FailureCount  int    // consecutive failed deliveries
+	LastSuccessAt *time.Time
+	LastFailureAt *time.Time
+	CreatedBy     string
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== WEBHOOK_EVENT (everything that happened, kept for replay) ====
+type WebhookEvent struct {
+	ID        string    `gorm:"primaryKey"`
+	Type      string    `gorm:"index"`
+	Payload   string    // JSON string (the body sent to subscribers)
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+// ==== WEBHOOK_DELIVERY (one event to one subscription; the persisted queue) ====
+type WebhookDelivery struct {
+	ID             string `gorm:"primaryKey"`
+	SubscriptionID string `gorm:"index"` // FK → WebhookSubscription.ID (logical)
+	EventID        string `gorm:"index"` // FK → WebhookEvent.ID (logical)
+	EventType      string
+	State          string    `gorm:"index:idx_webhook_delivery_due"` // pending|sending|succeeded|failed
+	NextAttemptAt  time.Time `gorm:"index:idx_webhook_delivery_due"`
+	Attempts       int
+	LastAttemptAt  *time.Time
+	ResponseStatus int
+	Error          string
+	ReplayOf       string    // delivery this one replays, if any
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+// ==== WEBHOOK_ATTEMPT (log of each HTTP call made for a delivery) ====
+type WebhookAttempt struct {
+	ID           string `gorm:"primaryKey"`
+	DeliveryID   string `gorm:"index"` // FK → WebhookDelivery.ID (logical)
+	Attempt      int
+	StatusCode   int
+	Error        string
+	ResponseBody string // first 2 KB
+	DurationMs   int64
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// ==== HRIS_HANDOFF (a hired candidate sent to the HR system as a new hire) ====
+type HRISHandoff struct {
+	ID            string    `gorm:"primaryKey"`
+	ApplicationID string    `gorm:"index"` // FK → Application.ID (logical)
+	Connector     string    // hris.Connector name at the time of queueing: http|csv
+	State         string    `gorm:"index:idx_hris_handoff_due"` // pending|sending|succeeded|failed
+	NextAttemptAt time.Time `gorm:"index:idx_hris_handoff_due"`
+	Attempts      int
+	LastError     string
+	ExternalID    string     // id the HR system gave the new hire (file name for csv)
+	Payload       string     // JSON string (the hris.NewHire last sent)
+	SyncedAt      *time.Time // when the HR system accepted it
+	CreatedAt     time.Time  `gorm:"autoCreateTime"` // = hired at
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,1318 @@
+package openapi
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sourcePackages are read for the handlers, the services they call and
+// the types they bind and return.
+var sourcePackages = []string{"handlers", "services", "utils", "models"}
+
+var routeMethods = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+// statusCodes are the net/http constants handlers answer with.
+var statusCodes = map[string]int{
+	"StatusOK": 200, "StatusCreated": 201, "StatusAccepted": 202, "StatusNoContent": 204,
+	"StatusMovedPermanently": 301, "StatusFound": 302, "StatusSeeOther": 303, "StatusNotModified": 304,
+	"StatusTemporaryRedirect": 307, "StatusBadRequest": 400, "StatusUnauthorized": 401, "StatusForbidden": 403,
+	"StatusNotFound": 404, "StatusMethodNotAllowed": 405, "StatusConflict": 409, "StatusGone": 410,
+	"StatusRequestEntityTooLarge": 413, "StatusUnsupportedMediaType": 415, "StatusUnprocessableEntity": 422,
+	"StatusTooManyRequests": 429, "StatusInternalServerError": 500, "StatusNotImplemented": 501,
+	"StatusBadGateway": 502, "StatusServiceUnavailable": 503,
+}
+
+const (
+	problemRef  = "#/components/schemas/Problem"
+	problemType = "application/problem+json"
+)
+
+type generator struct {
+	funcs   map[string]*ast.FuncDecl // "handlers.CreateJob", "services.JobService.Get"
+	types   map[string]*ast.TypeSpec // "models.User"
+	schemas map[string]*Schema       // components by name
+	named   map[string]string        // "models.User" → component name
+}
+
+// route is one registration found in main.go.
+type route struct {
+	method, path string // path as registered with gin
+	handler      string // "handlers.CreateJob"; empty for an inline func
+	inline       *ast.FuncLit
+	auth         string // required|optional|""
+	roles        []string
+}
+
+type routeGroup struct {
+	prefix string
+	auth   string
+	roles  []string
+}
+
+// analysis is what a handler (and the helpers it passes the context to)
+// reads from the request and writes back.
+type analysis struct {
+	query     map[string]*Schema
+	form      map[string]*Schema
+	body      *Schema
+	bodyForm  bool // bound with ShouldBind: JSON or form data
+	bodyOpt   bool // the bind error is ignored, so an empty body is fine
+	discarded map[*ast.CallExpr]bool
+	writerCT  string
+	responses map[string]*Response
+	visited   map[string]bool
+}
+
+// Generate builds the document from the sources under root (the module
+// directory): every route registered in main.go and, per route, the body
+// its handler binds, the query and form fields it reads and the responses
+// it writes.
+func Generate(root string) (*Document, error) {
+	g := &generator{funcs: map[string]*ast.FuncDecl{}, types: map[string]*ast.TypeSpec{}, schemas: map[string]*Schema{}, named: map[string]string{}}
+	fset := token.NewFileSet()
+	for _, pkg := range sourcePackages {
+		files, err := parseDir(fset, filepath.Join(root, pkg))
+		if err != nil {
+			return nil, err
+		}
+		g.index(pkg, files)
+	}
+	mainFiles, err := parseDir(fset, root)
+	if err != nil {
+		return nil, err
+	}
+	routes := g.routes(mainFiles)
+	if len(routes) == 0 {
+		return nil, errors.New("no routes found in " + filepath.Join(root, "main.go"))
+	}
+
+	g.schemas["Problem"] = &Schema{
+		Type:        "object",
+		Description: "RFC 7807 problem details; params of the message are added as extra members",
+		Properties: map[string]*Schema{
+			"type":     {Type: "string"},
+			"title":    {Type: "string"},
+			"status":   {Type: "integer"},
+			"code":     {Type: "string", Description: "stable error code"},
+			"detail":   {Type: "string", Description: "message in the negotiated language"},
+			"instance": {Type: "string"},
+			"fields":   {Type: "object", Description: "problem per field, for validation errors", AdditionalProperties: &Additional{Schema: &Schema{Type: "string"}}},
+		},
+		Required: []string{"code", "detail", "status", "title", "type"},
+	}
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   "AATS API",
+			Version: "1.0.0",
+			Description: "Generated from main.go and the handlers by `go run ./cmd/openapi`; do not edit by hand. " +
+				"Request bodies are validated against this document.",
+		},
+		Servers: []Server{{URL: "/"}},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:         g.schemas,
+			SecuritySchemes: map[string]SecurityScheme{"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"}},
+		},
+	}
+	for _, rt := range routes {
+		p := RoutePath(rt.path)
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = PathItem{}
+		}
+		doc.Paths[p][strings.ToLower(rt.method)] = g.operation(rt)
+	}
+	return doc, nil
+}
+
+// parseDir parses the non-test Go files of a directory, skipping files
+// excluded with //go:build ignore.
+func parseDir(fset *token.FileSet, dir string) ([]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		ignored := false
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				if c.Pos() < f.Package && strings.HasPrefix(c.Text, "//go:build ignore") {
+					ignored = true
+				}
+			}
+		}
+		if !ignored {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+func (g *generator) index(pkg string, files []*ast.File) {
+	for _, f := range files {
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					g.funcs[pkg+"."+d.Name.Name] = d
+				} else if recv := typeName(d.Recv.List[0].Type, pkg); recv != "" {
+					g.funcs[recv+"."+d.Name.Name] = d
+				}
+			case *ast.GenDecl:
+				for _, s := range d.Specs {
+					if ts, ok := s.(*ast.TypeSpec); ok {
+						g.types[pkg+"."+ts.Name.Name] = ts
+					}
+				}
+			}
+		}
+	}
+}
+
+// routes walks main.go in order, following r.Group(...) prefixes and the
+// middleware attached to groups and routes.
+func (g *generator) routes(files []*ast.File) []route {
+	var out []route
+	groups := map[string]routeGroup{}
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+					return true
+				}
+				name, ok := n.Lhs[0].(*ast.Ident)
+				call, ok2 := n.Rhs[0].(*ast.CallExpr)
+				if !ok || !ok2 {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				recv, _ := sel.X.(*ast.Ident)
+				switch {
+				case recv != nil && recv.Name == "gin" && (sel.Sel.Name == "Default" || sel.Sel.Name == "New"):
+					groups[name.Name] = routeGroup{}
+				case recv != nil && sel.Sel.Name == "Group" && len(call.Args) > 0:
+					parent := groups[recv.Name]
+					prefix, _ := stringLit(call.Args[0], nil)
+					grp := routeGroup{prefix: parent.prefix + prefix, auth: parent.auth, roles: parent.roles}
+					applyMiddleware(&grp.auth, &grp.roles, call.Args[1:])
+					groups[name.Name] = grp
+				}
+			case *ast.CallExpr:
+				sel, ok := n.Fun.(*ast.SelectorExpr)
+				if !ok || !routeMethods[sel.Sel.Name] || len(n.Args) < 2 {
+					return true
+				}
+				recv, ok := sel.X.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				grp, ok := groups[recv.Name]
+				if !ok {
+					return true
+				}
+				path, ok := stringLit(n.Args[0], nil)
+				if !ok {
+					return true
+				}
+				rt := route{method: sel.Sel.Name, path: grp.prefix + path, auth: grp.auth, roles: grp.roles}
+				applyMiddleware(&rt.auth, &rt.roles, n.Args[1:len(n.Args)-1])
+				switch h := n.Args[len(n.Args)-1].(type) {
+				case *ast.SelectorExpr:
+					if pkg, ok := h.X.(*ast.Ident); ok {
+						rt.handler = pkg.Name + "." + h.Sel.Name
+					}
+				case *ast.FuncLit:
+					rt.inline = h
+				}
+				out = append(out, rt)
+			}
+			return true
+		})
+	}
+	return out
+}
+
+func applyMiddleware(auth *string, roles *[]string, args []ast.Expr) {
+	for _, a := range args {
+		call, ok := a.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		switch sel.Sel.Name {
+		case "AuthMiddleware":
+			*auth = "required"
+		case "OptionalAuth":
+			*auth = "optional"
+		case "RequireRoles":
+			var rs []string
+			for _, r := range call.Args {
+				if s, ok := stringLit(r, nil); ok {
+					rs = append(rs, s)
+				}
+			}
+			*roles = rs
+		}
+	}
+}
+
+// RoutePath converts a gin route ("/api/jobs/:id") to its spec path
+// ("/api/jobs/{id}").
+func RoutePath(route string) string {
+	segs := strings.Split(route, "/")
+	for i, s := range segs {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segs[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+func (g *generator) operation(rt route) *Operation {
+	an := &analysis{query: map[string]*Schema{}, form: map[string]*Schema{}, responses: map[string]*Response{}, visited: map[string]bool{}}
+	op := &Operation{Responses: an.responses}
+	var doc string
+	if fn := g.funcs[rt.handler]; fn != nil {
+		an.visited[rt.handler] = true
+		pkg := rt.handler[:strings.Index(rt.handler, ".")]
+		g.analyze(fn.Body, ctxParam(fn.Type), pkg, nil, an)
+		op.OperationID = fn.Name.Name
+		doc = fn.Doc.Text()
+	} else if rt.inline != nil {
+		g.analyze(rt.inline.Body, ctxParam(rt.inline.Type), "main", nil, an)
+	}
+	if op.OperationID == "" {
+		op.OperationID = inlineOperationID(rt.method, rt.path)
+	}
+	op.Summary, op.Description = summarize(doc, op.OperationID)
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(rt.path, "/"), "api/")
+	op.Tags = []string{strings.SplitN(rest, "/", 2)[0]}
+
+	for _, seg := range strings.Split(rt.path, "/") {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			op.Parameters = append(op.Parameters, Parameter{Name: seg[1:], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	for _, name := range sortedKeys(an.query) {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "query", Schema: an.query[name]})
+	}
+
+	content := map[string]MediaType{}
+	if an.body != nil {
+		content["application/json"] = MediaType{Schema: an.body}
+		if an.bodyForm {
+			content["multipart/form-data"] = MediaType{Schema: an.body}
+		}
+	}
+	if len(an.form) > 0 {
+		form := &Schema{Type: "object", Properties: an.form}
+		if an.bodyForm && an.body != nil {
+			form = &Schema{Type: "object", Properties: map[string]*Schema{}}
+			for k, v := range an.form {
+				form.Properties[k] = v
+			}
+			if b := g.resolve(an.body); b != nil {
+				for k, v := range b.Properties {
+					form.Properties[k] = v
+				}
+			}
+		}
+		content["multipart/form-data"] = MediaType{Schema: form}
+	}
+	if len(content) > 0 {
+		op.RequestBody = &RequestBody{Content: content, Required: an.body != nil && !an.bodyForm && !an.bodyOpt}
+	}
+
+	switch rt.auth {
+	case "required":
+		op.Security = []map[string][]string{{"bearerAuth": {}}}
+		an.add(http.StatusUnauthorized, problemType, &Schema{Ref: problemRef})
+	case "optional":
+		op.Security = []map[string][]string{{}, {"bearerAuth": {}}}
+	}
+	if len(rt.roles) > 0 {
+		op.Roles = rt.roles
+		an.add(http.StatusForbidden, problemType, &Schema{Ref: problemRef})
+	}
+	if an.writerCT != "" && !an.hasSuccess() {
+		an.add(http.StatusOK, an.writerCT, &Schema{Type: "string", Format: "binary"})
+	}
+	if len(an.responses) == 0 {
+		an.responses["default"] = &Response{Description: "Response"}
+	}
+	return op
+}
+
+// ctxParam is the name of the *gin.Context parameter, if any.
+func ctxParam(ft *ast.FuncType) string {
+	for _, f := range ft.Params.List {
+		star, ok := f.Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		if sel, ok := star.X.(*ast.SelectorExpr); ok && sel.Sel.Name == "Context" && len(f.Names) > 0 {
+			if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "gin" {
+				return f.Names[0].Name
+			}
+		}
+	}
+	return ""
+}
+
+// analyze records what body reads from and writes to the context named
+// ctx, following helpers the context is passed to. env holds string
+// literals passed to a helper's parameters (e.g. the limit parameter name
+// of utils.ParsePagination).
+func (g *generator) analyze(body *ast.BlockStmt, ctx, pkg string, env map[string]string, an *analysis) {
+	if body == nil || ctx == "" {
+		return
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch st := n.(type) {
+		case *ast.ExprStmt: // c.ShouldBindJSON(&body)
+			if call, ok := st.X.(*ast.CallExpr); ok {
+				an.discard(call)
+			}
+		case *ast.AssignStmt: // _ = c.ShouldBindJSON(&body)
+			if len(st.Lhs) == 1 && len(st.Rhs) == 1 && isIdent(st.Lhs[0], "_") {
+				if call, ok := st.Rhs[0].(*ast.CallExpr); ok {
+					an.discard(call)
+				}
+			}
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && isIdent(sel.X, ctx) {
+			g.contextCall(sel.Sel.Name, call, body, pkg, env, an)
+		} else if ok && isIdent(sel.X, "respond") {
+			g.respondCall(sel.Sel.Name, call, body, pkg, an)
+			return true
+		}
+		withCtx := false
+		for i, arg := range call.Args {
+			if isIdent(arg, ctx) {
+				g.follow(call, i, pkg, env, an)
+				withCtx = true
+			}
+		}
+		if fn, recv := g.method(call, pkg); fn != nil {
+			g.problems(fn, recv, an)
+		} else if fn, fnPkg := g.callee(call, pkg); fn != nil && !withCtx && fnPkg == pkg && returnsError(fn) {
+			g.builtProblems(fn, fnPkg, an)
+		}
+		return true
+	})
+}
+
+// method resolves a call on a value whose type the generator can work out
+// (svc().Applications.Submit) to the method's declaration and its
+// receiver type ("services.ApplicationService").
+func (g *generator) method(call *ast.CallExpr, pkg string) (*ast.FuncDecl, string) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, ""
+	}
+	recv := g.exprType(sel.X, pkg)
+	if recv == "" {
+		return nil, ""
+	}
+	return g.funcs[recv+"."+sel.Sel.Name], recv
+}
+
+// exprType is the named type of a call result or of a field reached from
+// one, "" when unknown.
+func (g *generator) exprType(e ast.Expr, pkg string) string {
+	switch e := e.(type) {
+	case *ast.CallExpr:
+		fn, fnPkg := g.callee(e, pkg)
+		if m, recv := g.method(e, pkg); m != nil { // svc().Applications.On(tx)
+			fn, fnPkg = m, recv[:strings.Index(recv, ".")]
+		}
+		if fn != nil && fn.Type.Results != nil && len(fn.Type.Results.List) == 1 {
+			return typeName(fn.Type.Results.List[0].Type, fnPkg)
+		}
+	case *ast.SelectorExpr:
+		base := g.exprType(e.X, pkg)
+		if base == "" {
+			return ""
+		}
+		st, basePkg := g.structOf(ast.NewIdent(base[strings.Index(base, ".")+1:]), base[:strings.Index(base, ".")])
+		if st == nil {
+			return ""
+		}
+		for _, f := range st.Fields.List {
+			for _, n := range f.Names {
+				if n.Name == e.Sel.Name {
+					return typeName(f.Type, basePkg)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// callee is the declaration of a plain or package-qualified function call.
+func (g *generator) callee(call *ast.CallExpr, pkg string) (*ast.FuncDecl, string) {
+	switch f := call.Fun.(type) {
+	case *ast.Ident:
+		return g.funcs[pkg+"."+f.Name], pkg
+	case *ast.SelectorExpr:
+		if p, ok := f.X.(*ast.Ident); ok {
+			return g.funcs[p.Name+"."+f.Sel.Name], p.Name
+		}
+	}
+	return nil, ""
+}
+
+// typeName qualifies a (pointer to a) named type: "services.JobService".
+func typeName(t ast.Expr, pkg string) string {
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	switch t := t.(type) {
+	case *ast.Ident:
+		return pkg + "." + t.Name
+	case *ast.SelectorExpr:
+		if p, ok := t.X.(*ast.Ident); ok {
+			return p.Name + "." + t.Sel.Name
+		}
+	}
+	return ""
+}
+
+// problems records the problems a service method returns, including those
+// of the methods it calls on its receiver.
+func (g *generator) problems(fn *ast.FuncDecl, recv string, an *analysis) {
+	key := recv + "." + fn.Name.Name
+	if an.visited[key] || fn.Body == nil {
+		return
+	}
+	an.visited[key] = true
+	self := ""
+	if names := fn.Recv.List[0].Names; len(names) > 0 {
+		self = names[0].Name
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			if isIdent(sel.X, "respond") && (sel.Sel.Name == "New" || sel.Sel.Name == "Error") {
+				g.respondCall(sel.Sel.Name, call, fn.Body, "", an)
+			} else if self != "" && g.onReceiver(sel.X, self, recv, fn.Body) {
+				if m := g.funcs[recv+"."+sel.Sel.Name]; m != nil {
+					g.problems(m, recv, an)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// onReceiver reports whether x is the receiver self of type recv or a
+// copy of it from one of its methods: s.On(tx), or txs := s.On(tx).
+func (g *generator) onReceiver(x ast.Expr, self, recv string, body *ast.BlockStmt) bool {
+	sameType := func(e ast.Expr) bool {
+		call, ok := e.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || !isIdent(sel.X, self) {
+			return false
+		}
+		m := g.funcs[recv+"."+sel.Sel.Name]
+		return m != nil && m.Type.Results != nil && len(m.Type.Results.List) == 1 &&
+			typeName(m.Type.Results.List[0].Type, recv[:strings.Index(recv, ".")]) == recv
+	}
+	if isIdent(x, self) || sameType(x) {
+		return true
+	}
+	id, ok := x.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if as, ok := n.(*ast.AssignStmt); ok && as.Tok == token.DEFINE && len(as.Lhs) == 1 && len(as.Rhs) == 1 &&
+			isIdent(as.Lhs[0], id.Name) && sameType(as.Rhs[0]) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func (an *analysis) discard(call *ast.CallExpr) {
+	if an.discarded == nil {
+		an.discarded = map[*ast.CallExpr]bool{}
+	}
+	an.discarded[call] = true
+}
+
+func (g *generator) follow(call *ast.CallExpr, argIdx int, pkg string, env map[string]string, an *analysis) {
+	key, calleePkg := "", pkg
+	switch f := call.Fun.(type) {
+	case *ast.Ident:
+		key = pkg + "." + f.Name
+	case *ast.SelectorExpr:
+		if p, ok := f.X.(*ast.Ident); ok {
+			key, calleePkg = p.Name+"."+f.Sel.Name, p.Name
+		}
+	}
+	fn := g.funcs[key]
+	if fn == nil || an.visited[key] {
+		return
+	}
+	an.visited[key] = true
+	var params []string
+	for _, f := range fn.Type.Params.List {
+		for _, n := range f.Names {
+			params = append(params, n.Name)
+		}
+	}
+	if argIdx >= len(params) {
+		return
+	}
+	calleeEnv := map[string]string{}
+	for i, a := range call.Args {
+		if s, ok := stringLit(a, env); ok && i < len(params) {
+			calleeEnv[params[i]] = s
+		}
+	}
+	g.analyze(fn.Body, params[argIdx], calleePkg, calleeEnv, an)
+}
+
+func (g *generator) contextCall(name string, call *ast.CallExpr, body *ast.BlockStmt, pkg string, env map[string]string, an *analysis) {
+	arg := func(i int) (string, bool) {
+		if i >= len(call.Args) {
+			return "", false
+		}
+		return stringLit(call.Args[i], env)
+	}
+	switch name {
+	case "Query", "DefaultQuery", "GetQuery":
+		if k, ok := arg(0); ok {
+			an.query[k] = &Schema{Type: "string"}
+		}
+	case "QueryArray", "GetQueryArray":
+		if k, ok := arg(0); ok {
+			an.query[k] = &Schema{Type: "array", Items: &Schema{Type: "string"}}
+		}
+	case "PostForm", "DefaultPostForm", "GetPostForm":
+		if k, ok := arg(0); ok {
+			an.form[k] = &Schema{Type: "string"}
+		}
+	case "FormFile":
+		if k, ok := arg(0); ok {
+			an.form[k] = &Schema{Type: "string", Format: "binary"}
+		}
+	case "ShouldBindJSON", "BindJSON", "ShouldBind", "Bind":
+		if len(call.Args) == 0 {
+			return
+		}
+		target := call.Args[0]
+		if u, ok := target.(*ast.UnaryExpr); ok {
+			target = u.X
+		}
+		if id, ok := target.(*ast.Ident); ok {
+			if t := localType(body, id.Name); t != nil {
+				an.body = g.typeSchema(t, pkg)
+				an.bodyForm = name == "ShouldBind" || name == "Bind"
+				an.bodyOpt = an.discarded[call]
+			}
+		}
+	case "JSON", "IndentedJSON", "PureJSON", "AbortWithStatusJSON":
+		if len(call.Args) == 2 {
+			code := statusCode(call.Args[0])
+			an.add(code, "application/json", g.valueSchema(call.Args[1], body, pkg))
+		}
+	case "Data":
+		if len(call.Args) == 3 {
+			ct, ok := arg(1)
+			if !ok {
+				ct = "application/octet-stream"
+			}
+			an.add(statusCode(call.Args[0]), ct, &Schema{Type: "string"})
+		}
+	case "String":
+		if len(call.Args) > 0 {
+			an.add(statusCode(call.Args[0]), "text/plain", &Schema{Type: "string"})
+		}
+	case "Status", "AbortWithStatus", "Redirect":
+		if len(call.Args) > 0 {
+			an.add(statusCode(call.Args[0]), "", nil)
+		}
+	case "File", "FileAttachment":
+		an.add(http.StatusOK, "application/octet-stream", &Schema{Type: "string", Format: "binary"})
+	case "Header":
+		if k, ok := arg(0); ok && strings.EqualFold(k, "Content-Type") {
+			if v, ok := arg(1); ok {
+				an.writerCT = strings.TrimSpace(strings.SplitN(v, ";", 2)[0])
+			}
+		}
+	}
+}
+
+// respondCall records what a respond helper writes: {"data", "meta"} for
+// success, a Problem for errors. Fail takes a problem built elsewhere, so its
+// status is unknown.
+func (g *generator) respondCall(name string, call *ast.CallExpr, body *ast.BlockStmt, pkg string, an *analysis) {
+	envelope := func(code int, keys ...string) {
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}, Required: []string{"data"}}
+		for i, k := range keys {
+			if i+1 < len(call.Args) {
+				s.Properties[k] = g.valueSchema(call.Args[i+1], body, pkg)
+			}
+		}
+		an.add(code, "application/json", s)
+	}
+	switch name {
+	case "OK":
+		envelope(http.StatusOK, "data")
+	case "Created":
+		envelope(http.StatusCreated, "data")
+	case "Accepted":
+		envelope(http.StatusAccepted, "data")
+	case "Page":
+		envelope(http.StatusOK, "data", "meta")
+	case "Error":
+		if len(call.Args) > 1 {
+			an.add(statusCode(call.Args[1]), problemType, &Schema{Ref: problemRef})
+		}
+	case "New":
+		if len(call.Args) > 0 {
+			an.add(statusCode(call.Args[0]), problemType, &Schema{Ref: problemRef})
+		}
+	case "Fail":
+		an.add(0, problemType, &Schema{Ref: problemRef})
+		// a problem built by a helper: respond.Fail(c, toProblem(err))
+		if len(call.Args) > 1 {
+			if built, ok := call.Args[1].(*ast.CallExpr); ok {
+				if fn, fnPkg := g.callee(built, pkg); fn != nil {
+					g.builtProblems(fn, fnPkg, an)
+				}
+			}
+		}
+	}
+}
+
+// builtProblems records the problems a helper builds with respond.New, and
+// those of the service methods and package helpers it calls (changeStatus
+// returning what ApplicationService.ChangeStatus refused).
+func (g *generator) builtProblems(fn *ast.FuncDecl, pkg string, an *analysis) {
+	key := pkg + "." + fn.Name.Name
+	if an.visited[key] || fn.Body == nil {
+		return
+	}
+	an.visited[key] = true
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && isIdent(sel.X, "respond") && sel.Sel.Name == "New" {
+			g.respondCall("New", call, fn.Body, "", an)
+		} else if m, recv := g.method(call, pkg); m != nil {
+			g.problems(m, recv, an)
+		} else if h, hPkg := g.callee(call, pkg); h != nil && hPkg == pkg && returnsError(h) {
+			g.builtProblems(h, hPkg, an)
+		}
+		return true
+	})
+}
+
+// returnsError reports whether fn's last result is an error or a problem,
+// so a refusal it builds reaches the caller.
+func returnsError(fn *ast.FuncDecl) bool {
+	if fn.Type.Results == nil {
+		return false
+	}
+	last := fn.Type.Results.List[len(fn.Type.Results.List)-1].Type
+	return isIdent(last, "error") || typeName(last, "") == "respond.Problem"
+}
+
+func (an *analysis) hasSuccess() bool {
+	for k := range an.responses {
+		if strings.HasPrefix(k, "2") {
+			return true
+		}
+	}
+	return false
+}
+
+// add records a response; the same status written in several places
+// merges into one schema.
+func (an *analysis) add(code int, contentType string, s *Schema) {
+	key, desc := "default", "Error"
+	if code != 0 {
+		key, desc = strconv.Itoa(code), http.StatusText(code)
+	}
+	resp := an.responses[key]
+	if resp == nil {
+		resp = &Response{Description: desc}
+		an.responses[key] = resp
+	}
+	if contentType == "" || s == nil {
+		return
+	}
+	if resp.Content == nil {
+		resp.Content = map[string]MediaType{}
+	}
+	if cur, ok := resp.Content[contentType]; ok {
+		resp.Content[contentType] = MediaType{Schema: mergeSchemas(cur.Schema, s)}
+	} else {
+		resp.Content[contentType] = MediaType{Schema: s}
+	}
+}
+
+// mergeSchemas joins two object schemas written for the same status: all
+// properties, required only where both agree.
+func mergeSchemas(a, b *Schema) *Schema {
+	if a.Ref != "" || b.Ref != "" || a.Type != "object" || b.Type != "object" || a.Properties == nil || b.Properties == nil {
+		return a
+	}
+	out := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for k, v := range a.Properties {
+		out.Properties[k] = v
+	}
+	for k, v := range b.Properties {
+		if cur, ok := out.Properties[k]; !ok || (cur.Type == "" && cur.Ref == "") {
+			out.Properties[k] = v
+		}
+	}
+	inB := map[string]bool{}
+	for _, r := range b.Required {
+		inB[r] = true
+	}
+	for _, r := range a.Required {
+		if inB[r] {
+			out.Required = append(out.Required, r)
+		}
+	}
+	return out
+}
+
+func statusCode(e ast.Expr) int {
+	switch e := e.(type) {
+	case *ast.SelectorExpr:
+		return statusCodes[e.Sel.Name]
+	case *ast.BasicLit:
+		n, _ := strconv.Atoi(e.Value)
+		return n
+	}
+	return 0
+}
+
+// valueSchema describes a value passed to c.JSON: gin.H literals become
+// objects with their keys, typed values their type.
+func (g *generator) valueSchema(e ast.Expr, body *ast.BlockStmt, pkg string) *Schema {
+	switch e := e.(type) {
+	case *ast.CompositeLit:
+		if isGinH(e.Type) {
+			return g.ginHSchema(e, body, pkg, "")
+		}
+		if e.Type != nil {
+			return g.typeSchema(e.Type, pkg)
+		}
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.STRING:
+			return &Schema{Type: "string"}
+		case token.INT:
+			return &Schema{Type: "integer"}
+		case token.FLOAT:
+			return &Schema{Type: "number"}
+		}
+	case *ast.Ident:
+		switch e.Name {
+		case "true", "false":
+			return &Schema{Type: "boolean"}
+		case "nil":
+			return &Schema{Nullable: true}
+		}
+		if lit := localGinH(body, e.Name); lit != nil {
+			return g.ginHSchema(lit, body, pkg, e.Name)
+		}
+		if t := localType(body, e.Name); t != nil {
+			return g.typeSchema(t, pkg)
+		}
+		if t, tPkg := g.resultType(body, e.Name, pkg); t != nil {
+			return g.typeSchema(t, tPkg)
+		}
+	case *ast.UnaryExpr:
+		return g.valueSchema(e.X, body, pkg)
+	case *ast.IndexExpr:
+		if s := g.valueSchema(e.X, body, pkg); s.Items != nil {
+			return s.Items
+		}
+	case *ast.CallExpr:
+		// a helper's declared result type
+		var fn *ast.FuncDecl
+		fnPkg := pkg
+		switch f := e.Fun.(type) {
+		case *ast.Ident:
+			fn = g.funcs[pkg+"."+f.Name]
+		case *ast.SelectorExpr:
+			if p, ok := f.X.(*ast.Ident); ok {
+				fn, fnPkg = g.funcs[p.Name+"."+f.Sel.Name], p.Name
+			}
+		}
+		if fn != nil && fn.Type.Results != nil && len(fn.Type.Results.List) == 1 && len(fn.Type.Results.List[0].Names) <= 1 {
+			return g.typeSchema(fn.Type.Results.List[0].Type, fnPkg)
+		}
+	}
+	return &Schema{}
+}
+
+// ginHSchema describes a gin.H literal. When the literal is the local
+// variable name, keys set later (`name["key"] = v`) are added as optional;
+// a key that is not a string literal leaves the object open.
+func (g *generator) ginHSchema(lit *ast.CompositeLit, body *ast.BlockStmt, pkg, name string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, el := range lit.Elts {
+		kv, ok := el.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		k, ok := stringLit(kv.Key, nil)
+		if !ok {
+			continue
+		}
+		s.Properties[k] = g.valueSchema(kv.Value, body, pkg)
+		s.Required = append(s.Required, k)
+	}
+	if name != "" {
+		ast.Inspect(body, func(n ast.Node) bool {
+			as, ok := n.(*ast.AssignStmt)
+			if !ok || as.Tok != token.ASSIGN || len(as.Lhs) != len(as.Rhs) {
+				return true
+			}
+			for i, l := range as.Lhs {
+				ix, ok := l.(*ast.IndexExpr)
+				if !ok || !isIdent(ix.X, name) {
+					continue
+				}
+				if k, ok := stringLit(ix.Index, nil); !ok {
+					s.AdditionalProperties = &Additional{}
+				} else if s.Properties[k] == nil {
+					s.Properties[k] = g.valueSchema(as.Rhs[i], body, pkg)
+				}
+			}
+			return true
+		})
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// localGinH is the gin.H literal a local variable is declared with
+// (`out := gin.H{...}`), nil otherwise.
+func localGinH(body *ast.BlockStmt, name string) *ast.CompositeLit {
+	var found *ast.CompositeLit
+	ast.Inspect(body, func(n ast.Node) bool {
+		as, ok := n.(*ast.AssignStmt)
+		if found != nil || !ok || as.Tok != token.DEFINE || len(as.Lhs) != len(as.Rhs) {
+			return found == nil
+		}
+		for i, l := range as.Lhs {
+			if lit, ok := as.Rhs[i].(*ast.CompositeLit); ok && isIdent(l, name) && isGinH(lit.Type) {
+				found = lit
+			}
+		}
+		return true
+	})
+	return found
+}
+
+func isGinH(t ast.Expr) bool {
+	sel, ok := t.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "H" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "gin"
+}
+
+// localType finds the declared type of a local variable: `var x T`,
+// `x := T{...}`, `x := &T{...}` or `x := make(T, ...)`.
+func localType(body *ast.BlockStmt, name string) ast.Expr {
+	var found ast.Expr
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			for _, id := range n.Names {
+				if id.Name == name && n.Type != nil {
+					found = n.Type
+				}
+			}
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, l := range n.Lhs {
+				if !isIdent(l, name) {
+					continue
+				}
+				rhs := n.Rhs[i]
+				if u, ok := rhs.(*ast.UnaryExpr); ok && u.Op == token.AND {
+					rhs = u.X
+				}
+				switch r := rhs.(type) {
+				case *ast.CompositeLit:
+					found = r.Type
+				case *ast.CallExpr:
+					if isIdent(r.Fun, "make") && len(r.Args) > 0 {
+						found = r.Args[0]
+					}
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// resultType is the declared type of a local variable assigned from a call
+// (`ev, created, err := svc().Evaluations.Submit(...)`), and the package
+// the type expression belongs to.
+func (g *generator) resultType(body *ast.BlockStmt, name, pkg string) (ast.Expr, string) {
+	var found ast.Expr
+	foundPkg := ""
+	ast.Inspect(body, func(n ast.Node) bool {
+		as, ok := n.(*ast.AssignStmt)
+		if found != nil || !ok || as.Tok != token.DEFINE || len(as.Rhs) != 1 {
+			return found == nil
+		}
+		call, ok := as.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		fn, fnPkg := g.callee(call, pkg)
+		if m, recv := g.method(call, pkg); m != nil {
+			fn, fnPkg = m, recv[:strings.Index(recv, ".")]
+		}
+		if fn == nil || fn.Type.Results == nil {
+			return true
+		}
+		var results []ast.Expr
+		for _, r := range fn.Type.Results.List {
+			for range max(1, len(r.Names)) {
+				results = append(results, r.Type)
+			}
+		}
+		for i, l := range as.Lhs {
+			if isIdent(l, name) && i < len(results) {
+				found, foundPkg = results[i], fnPkg
+			}
+		}
+		return true
+	})
+	return found, foundPkg
+}
+
+// typeSchema describes a Go type expression declared in pkg.
+func (g *generator) typeSchema(t ast.Expr, pkg string) *Schema {
+	switch t := t.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return &Schema{Type: "string"}
+		case "bool":
+			return &Schema{Type: "boolean"}
+		case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32":
+			return &Schema{Type: "integer"}
+		case "int64", "uint64":
+			return &Schema{Type: "integer", Format: "int64"}
+		case "float32", "float64":
+			return &Schema{Type: "number"}
+		case "any", "error":
+			return &Schema{}
+		}
+		return g.namedSchema(pkg, t.Name)
+	case *ast.SelectorExpr:
+		p, _ := t.X.(*ast.Ident)
+		if p == nil {
+			return &Schema{}
+		}
+		switch p.Name + "." + t.Sel.Name {
+		case "time.Time":
+			return &Schema{Type: "string", Format: "date-time"}
+		case "gorm.DeletedAt":
+			return &Schema{Type: "string", Format: "date-time", Nullable: true}
+		case "gin.H":
+			return &Schema{Type: "object", AdditionalProperties: &Additional{}}
+		}
+		if _, ok := g.types[p.Name+"."+t.Sel.Name]; ok {
+			return g.namedSchema(p.Name, t.Sel.Name)
+		}
+		return &Schema{}
+	case *ast.StarExpr:
+		s := g.typeSchema(t.X, pkg)
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case *ast.ArrayType:
+		if isIdent(t.Elt, "byte") {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elt, pkg)}
+	case *ast.MapType:
+		return &Schema{Type: "object", AdditionalProperties: &Additional{Schema: g.typeSchema(t.Value, pkg)}}
+	case *ast.StructType:
+		return g.structSchema(t, pkg)
+	}
+	return &Schema{}
+}
+
+// namedSchema references the component for a named struct type (adding
+// it on first use); other named types are described inline.
+func (g *generator) namedSchema(pkg, name string) *Schema {
+	key := pkg + "." + name
+	if comp, ok := g.named[key]; ok {
+		return &Schema{Ref: "#/components/schemas/" + comp}
+	}
+	ts := g.types[key]
+	if ts == nil {
+		return &Schema{}
+	}
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		return g.typeSchema(ts.Type, pkg)
+	}
+	comp := name
+	if _, taken := g.schemas[comp]; taken {
+		comp = exportName(pkg) + name
+	}
+	g.named[key] = comp
+	g.schemas[comp] = &Schema{} // placeholder for self references
+	*g.schemas[comp] = *g.structSchema(st, pkg)
+	return &Schema{Ref: "#/components/schemas/" + comp}
+}
+
+// structSchema describes a struct the way encoding/json sees it: json
+// tags name the properties, embedded structs are flattened, and gin's
+// binding:"required" marks required properties.
+func (g *generator) structSchema(st *ast.StructType, pkg string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: &Additional{Closed: true}}
+	for _, f := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if f.Tag != nil {
+			if raw, err := strconv.Unquote(f.Tag.Value); err == nil {
+				tag = reflect.StructTag(raw)
+			}
+		}
+		jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+		required := strings.Contains(tag.Get("binding"), "required")
+		if len(f.Names) == 0 {
+			if jsonName == "" {
+				if inner, innerPkg := g.structOf(f.Type, pkg); inner != nil {
+					sub := g.structSchema(inner, innerPkg)
+					for k, v := range sub.Properties {
+						s.Properties[k] = v
+					}
+					s.Required = append(s.Required, sub.Required...)
+				}
+				continue
+			}
+		}
+		names := f.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(exportName(jsonName))}
+		}
+		for _, n := range names {
+			if !n.IsExported() {
+				continue
+			}
+			prop := jsonName
+			if prop == "" {
+				prop = n.Name
+			}
+			ps := g.typeSchema(f.Type, pkg)
+			if f.Comment != nil && ps.Ref == "" {
+				ps.Description = strings.TrimSpace(f.Comment.Text())
+			}
+			s.Properties[prop] = ps
+			if required {
+				s.Required = append(s.Required, prop)
+			}
+		}
+	}
+	return s
+}
+
+// structOf resolves an embedded field's type to its struct declaration.
+func (g *generator) structOf(t ast.Expr, pkg string) (*ast.StructType, string) {
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	key := ""
+	switch t := t.(type) {
+	case *ast.Ident:
+		key = pkg + "." + t.Name
+	case *ast.SelectorExpr:
+		if p, ok := t.X.(*ast.Ident); ok {
+			key, pkg = p.Name+"."+t.Sel.Name, p.Name
+		}
+	}
+	if ts := g.types[key]; ts != nil {
+		if st, ok := ts.Type.(*ast.StructType); ok {
+			return st, pkg
+		}
+	}
+	return nil, ""
+}
+
+// resolve follows a component reference.
+func (g *generator) resolve(s *Schema) *Schema {
+	if s != nil && s.Ref != "" {
+		return g.schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+var (
+	routePrefixRe = regexp.MustCompile(`^(GET|POST|PUT|PATCH|DELETE)\s+\S+\s*`)
+	routeNoteRe   = regexp.MustCompile(`\s*\((GET|POST|PUT|PATCH|DELETE)\s+[^)]*\)`)
+	rolesNoteRe   = regexp.MustCompile(`^\([^)]*\)\s*`)
+)
+
+// summarize takes the summary from the first line of a handler's doc
+// comment, without the "METHOD /path (roles)" prefix most handlers start
+// with; the whole comment is the description.
+func summarize(doc, operationID string) (string, string) {
+	doc = strings.TrimSpace(doc)
+	// The first sentence of the first paragraph, minus the route line.
+	first, _, _ := strings.Cut(doc, "\n\n")
+	first = strings.Join(strings.Fields(first), " ")
+	first = routePrefixRe.ReplaceAllString(first, "")
+	first = routeNoteRe.ReplaceAllString(rolesNoteRe.ReplaceAllString(first, ""), "")
+	first = strings.TrimSpace(strings.TrimLeft(first, "—–-: "))
+	if i := strings.IndexAny(first, ";:"); i > 0 {
+		first = first[:i]
+	}
+	if i := strings.Index(first, ". "); i > 0 {
+		first = first[:i]
+	}
+	if i := strings.LastIndex(first, "("); i > 0 && !strings.Contains(first[i:], ")") {
+		first = strings.TrimSpace(first[:i])
+	}
+	first = strings.TrimSuffix(first, ".")
+	if len(strings.Fields(first)) < 2 || strings.HasPrefix(first, "{") {
+		first = humanize(operationID)
+	} else {
+		r := []rune(first)
+		r[0] = unicode.ToUpper(r[0])
+		first = string(r)
+	}
+	return first, doc
+}
+
+// humanize turns "ListWebhookDeliveries" into "List webhook deliveries".
+func humanize(id string) string {
+	var b strings.Builder
+	for i, r := range id {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte(' ')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// inlineOperationID names a route whose handler is a func literal.
+func inlineOperationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.Trim(seg, ":*{}")
+		if seg != "" && seg != "api" {
+			id += exportName(seg)
+		}
+	}
+	return id
+}
+
+func exportName(s string) string {
+	var b strings.Builder
+	up := true
+	for _, r := range s {
+		if r == '-' || r == '_' || r == '.' {
+			up = true
+			continue
+		}
+		if up {
+			r = unicode.ToUpper(r)
+			up = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// stringLit reads a string literal, or a parameter bound to one in env.
+func stringLit(e ast.Expr, env map[string]string) (string, bool) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			s, err := strconv.Unquote(e.Value)
+			return s, err == nil
+		}
+	case *ast.Ident:
+		s, ok := env[e.Name]
+		return s, ok
+	}
+	return "", false
+}
+
+func isIdent(e ast.Expr, name string) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+func sortedKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,152 @@
+// Package openapi holds the OpenAPI 3 description of the API. Generate
+// builds it from the route table in main.go and the handler sources;
+// openapi.json next to this file is the committed result, served at
+// /api/openapi.json and used to validate incoming requests.
+//
+// Regenerate after changing routes or request bodies:
+//
+//	go run ./cmd/openapi
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sync"
+)
+
+// Document is an OpenAPI 3.0 document (the parts this API uses).
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps a lower-case HTTP method to its operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Roles       []string              `json:"x-roles,omitempty"` // roles allowed by RequireRoles; empty = any signed-in user
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // path|query
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Additional        `json:"additionalProperties,omitempty"`
+}
+
+// Additional is an object's additionalProperties: a schema, or false for
+// a closed object (request bodies bound to Go structs).
+type Additional struct {
+	Closed bool
+	Schema *Schema
+}
+
+func (a Additional) MarshalJSON() ([]byte, error) {
+	if a.Closed {
+		return []byte("false"), nil
+	}
+	if a.Schema == nil {
+		return []byte("true"), nil
+	}
+	return json.Marshal(a.Schema)
+}
+
+func (a *Additional) UnmarshalJSON(raw []byte) error {
+	switch string(raw) {
+	case "false":
+		a.Closed = true
+		return nil
+	case "true":
+		return nil
+	}
+	a.Schema = &Schema{}
+	return json.Unmarshal(raw, a.Schema)
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Marshal renders a document the way openapi.json is stored.
+func Marshal(d *Document) ([]byte, error) {
+	raw, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(raw, '\n'), nil
+}
+
+//go:embed openapi.json
+var specJSON []byte
+
+// JSON is the committed spec, as served at /api/openapi.json.
+func JSON() []byte { return specJSON }
+
+var (
+	loadOnce sync.Once
+	loaded   *Document
+	loadErr  error
+)
+
+// Load parses the committed spec once.
+func Load() (*Document, error) {
+	loadOnce.Do(func() {
+		loaded = &Document{}
+		loadErr = json.Unmarshal(specJSON, loaded)
+	})
+	return loaded, loadErr
+}
@@ -0,0 +1,159 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Operation finds the operation for a gin route ("/api/jobs/:id") and
+// method, or nil when the spec does not describe it.
+func (d *Document) Operation(method, route string) *Operation {
+	item, ok := d.Paths[RoutePath(route)]
+	if !ok {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+// ValidateJSON checks a JSON request body against the operation's
+// application/json request schema. Problems are keyed by field path
+// ("screening_answers[0].answer"); a problem with the body as a whole is
+// keyed "body". A nil map means the body is acceptable, including when the
+// operation declares no JSON body.
+func (d *Document) ValidateJSON(op *Operation, body []byte) map[string]string {
+	if op == nil || op.RequestBody == nil {
+		return nil
+	}
+	mt, ok := op.RequestBody.Content["application/json"]
+	if !ok || mt.Schema == nil {
+		return nil
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return map[string]string{"body": "is required"}
+		}
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return map[string]string{"body": "invalid JSON"}
+	}
+	errs := map[string]string{}
+	d.check(mt.Schema, v, "", errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (d *Document) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+func (d *Document) check(s *Schema, v any, path string, errs map[string]string) {
+	s = d.resolve(s)
+	if s == nil || v == nil { // null decodes to a zero value everywhere in the handlers
+		return
+	}
+	key := path
+	if key == "" {
+		key = "body"
+	}
+	switch s.Type {
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			errs[key] = "must be a string"
+			return
+		}
+		if s.Format == "date-time" && str != "" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				errs[key] = "must be an RFC 3339 date-time"
+			}
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			errs[key] = "must be an integer"
+			return
+		}
+		if _, err := n.Int64(); err != nil {
+			errs[key] = "must be an integer"
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			errs[key] = "must be a number"
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			errs[key] = "must be a boolean"
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			errs[key] = "must be an array"
+			return
+		}
+		for i, item := range arr {
+			d.check(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			errs[key] = "must be an object"
+			return
+		}
+		d.checkObject(s, obj, path, errs)
+	}
+}
+
+func (d *Document) checkObject(s *Schema, obj map[string]any, path string, errs map[string]string) {
+	join := func(k string) string {
+		if path == "" {
+			return k
+		}
+		return path + "." + k
+	}
+	// encoding/json matches keys case-insensitively, so "Title" still
+	// binds to title.
+	has := func(k string) bool {
+		for key := range obj {
+			if strings.EqualFold(key, k) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, k := range s.Required {
+		if !has(k) {
+			errs[join(k)] = "is required"
+		}
+	}
+	for k, v := range obj {
+		prop, ok := s.Properties[k]
+		if !ok {
+			for name, p := range s.Properties {
+				if strings.EqualFold(name, k) {
+					prop, ok = p, true
+					break
+				}
+			}
+		}
+		switch {
+		case ok:
+			d.check(prop, v, join(k), errs)
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Closed:
+			errs[join(k)] = "unknown field"
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			d.check(s.AdditionalProperties.Schema, v, join(k), errs)
+		}
+	}
+}
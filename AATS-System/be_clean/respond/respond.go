@@ -0,0 +1,124 @@
+// Package respond writes every API response in one shape.
+//
+// Success bodies are {"data": ...}, with "meta" added for paged lists
+// (page, limit, total, next_cursor). Errors are RFC 7807 problem details
+// served as application/problem+json:
+//
+//	{
+//	  "type": "urn:aats:problem:APPLICATION_COOLDOWN_ACTIVE",
+//	  "title": "Bad Request",
+//	  "status": 400,
+//	  "code": "APPLICATION_COOLDOWN_ACTIVE",
+//	  "detail": "You can apply for this job again from 2026-03-01",
+//	  "instance": "/api/applications",
+//	  "allowed_at": "2026-03-01T09:00:00Z"
+//	}
+//
+// code is stable and meant for programs; detail is the message for people,
+// in the caller's language (see Language). Any params passed with the error
+// are added as extra members.
+package respond
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/i18n"
+)
+
+// ProblemContentType is the media type of error responses.
+const ProblemContentType = "application/problem+json"
+
+// TypePrefix prefixes the code to form the problem "type" URI.
+const TypePrefix = "urn:aats:problem:"
+
+// OK writes 200 {"data": data}.
+func OK(c *gin.Context, data any) {
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// Created writes 201 {"data": data}.
+func Created(c *gin.Context, data any) {
+	c.JSON(http.StatusCreated, gin.H{"data": data})
+}
+
+// Accepted writes 202 {"data": data}, for work that continues in the
+// background.
+func Accepted(c *gin.Context, data any) {
+	c.JSON(http.StatusAccepted, gin.H{"data": data})
+}
+
+// Page writes 200 {"data": data, "meta": meta} for one page of a list.
+func Page(c *gin.Context, data any, meta gin.H) {
+	c.JSON(http.StatusOK, gin.H{"data": data, "meta": meta})
+}
+
+// Problem is an error on its way to the client: an HTTP status, a stable
+// code and the values its message refers to.
+type Problem struct {
+	Status int
+	Code   string
+	Params gin.H
+}
+
+// New builds a problem; params are merged in order.
+func New(status int, code string, params ...gin.H) *Problem {
+	p := &Problem{Status: status, Code: code}
+	for _, ps := range params {
+		for k, v := range ps {
+			if p.Params == nil {
+				p.Params = gin.H{}
+			}
+			p.Params[k] = v
+		}
+	}
+	return p
+}
+
+func (p *Problem) Error() string { return p.Code }
+
+// Error writes a problem response and aborts the chain.
+func Error(c *gin.Context, status int, code string, params ...gin.H) {
+	Fail(c, New(status, code, params...))
+}
+
+// Fail writes p as a problem response and aborts the chain.
+func Fail(c *gin.Context, p *Problem) {
+	lang := Language(c)
+	body := gin.H{}
+	for k, v := range p.Params {
+		body[k] = v
+	}
+	body["type"] = TypePrefix + p.Code
+	body["title"] = http.StatusText(p.Status)
+	body["status"] = p.Status
+	body["code"] = p.Code
+	body["detail"] = i18n.T(lang, "errors."+p.Code, p.Params)
+	body["instance"] = c.Request.URL.Path
+	c.Header("Content-Type", ProblemContentType)
+	c.Header("Content-Language", string(lang))
+	c.AbortWithStatusJSON(p.Status, body)
+}
+
+// UserLanguage looks up the language a signed-in user chose, if any; main
+// wires it to the users table.
+var UserLanguage func(userID string) (i18n.Lang, bool)
+
+const languageKey = "respond.language"
+
+// Language is the language to answer the request in: the signed-in user's
+// preference, else the best match for Accept-Language.
+func Language(c *gin.Context) i18n.Lang {
+	if v, ok := c.Get(languageKey); ok {
+		return v.(i18n.Lang)
+	}
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	if uid := c.GetString("user_id"); uid != "" && UserLanguage != nil {
+		if l, ok := UserLanguage(uid); ok {
+			lang = l
+		}
+	}
+	c.Set(languageKey, lang)
+	return lang
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+)
+
+// ApplicationService submits applications under the application policy and
+// moves them through their statuses, keeping the timeline in step.
+type ApplicationService struct {
+	store store.Store
+	// Now is the clock policies are evaluated and history is dated with.
+	Now func() time.Time
+}
+
+func NewApplicationService(st store.Store) *ApplicationService {
+	return &ApplicationService{store: st, Now: time.Now}
+}
+
+// On is the service working on st (e.g. a transaction) with the same clock.
+func (s *ApplicationService) On(st store.Store) *ApplicationService {
+	return &ApplicationService{store: st, Now: s.Now}
+}
+
+// PolicyError is returned when the application policy stops an applicant
+// applying. Violations is never empty.
+type PolicyError struct {
+	Violations []utils.PolicyViolation
+}
+
+func (e *PolicyError) Error() string { return e.Violations[0].Code }
+
+// TimelineEntry is a timeline row for appID; Description is kept in the
+// default language and shown to readers in theirs.
+func TimelineEntry(appID, status string, at time.Time, desc i18n.Msg) models.ApplicationTimeline {
+	return models.ApplicationTimeline{
+		ID:                uuid.NewString(),
+		ApplicationID:     appID,
+		Status:            status,
+		Date:              at,
+		Description:       desc.In(i18n.Default),
+		DescriptionKey:    desc.Key,
+		DescriptionParams: desc.EncodeParams(),
+	}
+}
+
+// Get is the application with id; APPLICATION_NOT_FOUND when there is none.
+func (s *ApplicationService) Get(id string) (models.Application, error) {
+	app, err := s.store.Applications().ByID(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return app, respond.New(http.StatusNotFound, "APPLICATION_NOT_FOUND")
+	}
+	return app, err
+}
+
+// Policy is the policy in force for jobID at `at`. Layers whose rules do not
+// parse are skipped.
+func (s *ApplicationService) Policy(jobID string, at time.Time) (utils.PolicyConfig, error) {
+	rows, err := s.store.Policies().ForJob(jobID)
+	if err != nil {
+		return utils.DefaultPolicy, err
+	}
+	layers := make([]utils.PolicyLayer, 0, len(rows))
+	for _, r := range rows {
+		var rules utils.PolicyRules
+		if json.Unmarshal([]byte(r.Rules), &rules) != nil {
+			continue
+		}
+		layers = append(layers, utils.PolicyLayer{Scope: r.Scope, Rules: rules, EffectiveFrom: r.EffectiveFrom, EffectiveTo: r.EffectiveTo})
+	}
+	return utils.ResolvePolicy(utils.DefaultPolicy, layers, at), nil
+}
+
+// priorApplications loads an applicant's applications with the dates the
+// policy needs: when each was rejected or hired, and whether an interview
+// happened before the rejection.
+func (s *ApplicationService) priorApplications(applicantID string) ([]utils.PriorApplication, error) {
+	apps, err := s.store.Applications().ForApplicant(applicantID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]utils.PriorApplication, 0, len(apps))
+	for _, a := range apps {
+		p := utils.PriorApplication{ID: a.ID, JobID: a.JobID, Status: a.Status, SubmittedAt: a.SubmittedDate, ClosedAt: a.UpdatedAt}
+		if a.Status == "rejected" || a.Status == "hired" {
+			tls, err := s.store.Timelines().ForApplication(a.ID)
+			if err != nil {
+				return nil, err
+			}
+			p.ClosedAt, p.InterviewedBeforeRejection = Closure(a, tls)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// Closure is when app reached its status according to its timeline (newest
+// first), its last update when no entry says, and for a rejection whether
+// an interview came before it.
+func Closure(app models.Application, timeline []models.ApplicationTimeline) (at time.Time, interviewed bool) {
+	at = app.UpdatedAt
+	for _, tl := range timeline {
+		if tl.Status == app.Status {
+			at = tl.Date
+			break
+		}
+	}
+	for _, tl := range timeline {
+		if app.Status == "rejected" && tl.Status == "interview" && tl.Date.Before(at) {
+			interviewed = true
+		}
+	}
+	return at, interviewed
+}
+
+// List is one page of the applications matching f.
+func (s *ApplicationService) List(f store.ApplicationFilter, p store.Page) ([]models.Application, error) {
+	return s.store.Applications().List(f, p)
+}
+
+// Count is the number of applications matching f.
+func (s *ApplicationService) Count(f store.ApplicationFilter) (int64, error) {
+	return s.store.Applications().Count(f)
+}
+
+// Each calls fn with every application matching f, for exports.
+func (s *ApplicationService) Each(f store.ApplicationFilter, fn func(models.Application) error) error {
+	return s.store.Applications().Each(f, fn)
+}
+
+// View is application id as viewerID sees it: candidates (ownOnly) only
+// see their own, anyone else's is FORBIDDEN.
+func (s *ApplicationService) View(id, viewerID string, ownOnly bool) (models.Application, error) {
+	app, err := s.Get(id)
+	if err == nil && ownOnly && app.ApplicantID != viewerID {
+		return app, respond.New(http.StatusForbidden, "FORBIDDEN")
+	}
+	return app, err
+}
+
+// Related is what a batch of applications refers to, by id.
+type Related struct {
+	Jobs        map[string]models.JobPosting
+	Applicants  map[string]models.User
+	Evaluations map[string]models.Evaluation            // by application id
+	Timelines   map[string][]models.ApplicationTimeline // by application id, newest first
+}
+
+// Related loads the jobs, applicants, evaluations and timelines of apps with
+// one query each.
+func (s *ApplicationService) Related(apps []models.Application) (Related, error) {
+	r := Related{
+		Jobs:        map[string]models.JobPosting{},
+		Applicants:  map[string]models.User{},
+		Evaluations: map[string]models.Evaluation{},
+		Timelines:   map[string][]models.ApplicationTimeline{},
+	}
+	var appIDs, jobIDs, userIDs []string
+	for _, a := range apps {
+		appIDs = append(appIDs, a.ID)
+		jobIDs = append(jobIDs, a.JobID)
+		userIDs = append(userIDs, a.ApplicantID)
+	}
+	jobs, err := s.store.Jobs().ByIDs(jobIDs)
+	if err != nil {
+		return r, err
+	}
+	for _, j := range jobs {
+		r.Jobs[j.ID] = j
+	}
+	users, err := s.store.Users().ByIDs(userIDs)
+	if err != nil {
+		return r, err
+	}
+	for _, u := range users {
+		r.Applicants[u.ID] = u
+	}
+	evals, err := s.store.Evaluations().ForApplications(appIDs)
+	if err != nil {
+		return r, err
+	}
+	for _, e := range evals {
+		r.Evaluations[e.ApplicationID] = e
+	}
+	tls, err := s.store.Timelines().ForApplications(appIDs)
+	if err != nil {
+		return r, err
+	}
+	for _, tl := range tls {
+		r.Timelines[tl.ApplicationID] = append(r.Timelines[tl.ApplicationID], tl)
+	}
+	return r, nil
+}
+
+// CheckPolicy evaluates the policy for applicantID applying to jobID now.
+// No violations means the application is allowed.
+func (s *ApplicationService) CheckPolicy(applicantID, jobID string) (utils.PolicyConfig, []utils.PolicyViolation, error) {
+	now := s.Now()
+	cfg, err := s.Policy(jobID, now)
+	if err != nil {
+		return cfg, nil, err
+	}
+	prior, err := s.priorApplications(applicantID)
+	if err != nil {
+		return cfg, nil, err
+	}
+	return cfg, utils.EvaluateApplyPolicy(cfg, jobID, prior, now), nil
+}
+
+// Submit saves app as a new submitted application with its first timeline
+// entry, unless the policy stops the applicant (*PolicyError). The caller
+// fills in everything else about app.
+//
+// The policy check and the writes are one transaction holding the
+// applicant's lock, so concurrent submits by one applicant are checked one
+// after the other and cannot get past the caps together.
+func (s *ApplicationService) Submit(app *models.Application) (models.ApplicationTimeline, error) {
+	var tl models.ApplicationTimeline
+	err := s.store.Transaction(func(tx store.Store) error {
+		var err error
+		tl, err = s.On(tx).submit(app)
+		return err
+	})
+	return tl, err
+}
+
+func (s *ApplicationService) submit(app *models.Application) (models.ApplicationTimeline, error) {
+	// applicants without an account (imported) have no row to lock; nobody
+	// can submit as them concurrently
+	if _, err := s.store.Users().Lock(app.ApplicantID); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return models.ApplicationTimeline{}, err
+	}
+	_, violations, err := s.CheckPolicy(app.ApplicantID, app.JobID)
+	if err != nil {
+		return models.ApplicationTimeline{}, err
+	}
+	if len(violations) > 0 {
+		return models.ApplicationTimeline{}, &PolicyError{Violations: violations}
+	}
+	now := s.Now()
+	if app.ID == "" {
+		app.ID = uuid.NewString()
+	}
+	app.Status = "submitted"
+	app.SubmittedDate, app.CreatedAt = now, now
+	tl := TimelineEntry(app.ID, "submitted", now, i18n.M("timeline.APPLICATION_SUBMITTED"))
+	if err := s.store.Applications().Create(app); err != nil {
+		return tl, err
+	}
+	return tl, s.store.Timelines().Append(&tl)
+}
+
+// ValidateStatusChange holds the rules every status change made by staff
+// must pass (single, bulk, offers). Returns nil when the change is allowed.
+func (s *ApplicationService) ValidateStatusChange(app models.Application, newStatus string) error {
+	// withdrawing is the candidate's decision (POST /applications/:id/withdraw)
+	if newStatus == "withdrawn" {
+		return respond.New(http.StatusBadRequest, "WITHDRAW_CANDIDATE_ONLY")
+	}
+	// and final: staff cannot move an application the candidate withdrew
+	if app.Status == "withdrawn" {
+		return respond.New(http.StatusBadRequest, "APPLICATION_CLOSED", gin.H{"status": app.Status})
+	}
+	if newStatus != "hired" && newStatus != "offer" {
+		return nil
+	}
+	// offers and hires need the hiring manager's evaluation first
+	ev, err := s.store.Evaluations().ForApplication(app.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		return respond.New(http.StatusBadRequest, "EVALUATION_REQUIRED", gin.H{"status": newStatus})
+	}
+	if err != nil {
+		return err
+	}
+	if ev.EvaluatorID != "" {
+		if evaluator, err := s.store.Users().ByID(ev.EvaluatorID); err == nil && evaluator.Role != "hm" {
+			return respond.New(http.StatusBadRequest, "EVALUATION_NOT_BY_HM", gin.H{"status": newStatus})
+		}
+	}
+	return nil
+}
+
+// SetStatus saves app in newStatus and appends the matching timeline entry,
+// both or neither. It does not validate the change; see
+// ValidateStatusChange.
+func (s *ApplicationService) SetStatus(app *models.Application, newStatus string, desc i18n.Msg) (models.ApplicationTimeline, error) {
+	app.Status = newStatus
+	app.UpdatedAt = s.Now()
+	tl := TimelineEntry(app.ID, newStatus, app.UpdatedAt, desc)
+	return tl, s.store.Transaction(func(tx store.Store) error {
+		if err := tx.Applications().Save(app); err != nil {
+			return err
+		}
+		return tx.Timelines().Append(&tl)
+	})
+}
+
+// StatusChange is the outcome of ChangeStatus.
+type StatusChange struct {
+	Application models.Application
+	Previous    string
+	Timeline    models.ApplicationTimeline
+}
+
+// ChangeStatus validates and applies a staff status change of application
+// id in one transaction holding the application's lock, so concurrent
+// changes apply one after the other and each is checked against the status
+// the previous one left.
+func (s *ApplicationService) ChangeStatus(id, newStatus string, desc i18n.Msg) (StatusChange, error) {
+	var out StatusChange
+	err := s.store.Transaction(func(tx store.Store) error {
+		app, err := tx.Applications().Lock(id)
+		if errors.Is(err, store.ErrNotFound) {
+			return respond.New(http.StatusNotFound, "APPLICATION_NOT_FOUND")
+		}
+		if err != nil {
+			return err
+		}
+		out = StatusChange{Previous: app.Status}
+		txs := s.On(tx)
+		if err := txs.ValidateStatusChange(app, newStatus); err != nil {
+			return err
+		}
+		out.Timeline, err = txs.SetStatus(&app, newStatus, desc)
+		out.Application = app
+		return err
+	})
+	return out, err
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+)
+
+// TokenTTL is how long an access token stays valid.
+const TokenTTL = 24 * time.Hour
+
+// AuthService registers accounts, checks passwords and issues tokens.
+type AuthService struct {
+	users  store.UserRepo
+	secret string
+	// Now is the clock tokens are dated with.
+	Now func() time.Time
+}
+
+// NewAuthService signs tokens with secret; an empty secret makes Token fail.
+func NewAuthService(users store.UserRepo, secret string) *AuthService {
+	return &AuthService{users: users, secret: secret, Now: time.Now}
+}
+
+// Registration is a new account.
+type Registration struct {
+	Email    string
+	Password string
+	Name     string
+	Role     string
+	Phone    string
+}
+
+// Register creates the account; EMAIL_TAKEN when the email is in use.
+func (s *AuthService) Register(r Registration) (models.User, error) {
+	if _, err := s.users.ByEmail(r.Email); err == nil {
+		return models.User{}, respond.New(http.StatusConflict, "EMAIL_TAKEN")
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return models.User{}, err
+	}
+	hash, err := utils.HashPassword(r.Password)
+	if err != nil {
+		return models.User{}, err
+	}
+	u := models.User{
+		ID:       uuid.NewString(),
+		Email:    r.Email,
+		Password: hash,
+		Role:     r.Role,
+		Name:     r.Name,
+		Phone:    r.Phone,
+		PhoneKey: utils.NormalizePhone(r.Phone),
+	}
+	if err := s.users.Create(&u); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			// registered at the same moment with the same email
+			return u, respond.New(http.StatusConflict, "EMAIL_TAKEN")
+		}
+		return u, err
+	}
+	return u, nil
+}
+
+// Login checks the password and returns the account with a fresh token.
+// Merged accounts cannot sign in.
+func (s *AuthService) Login(email, password string) (models.User, string, error) {
+	u, err := s.users.ByEmail(email)
+	if errors.Is(err, store.ErrNotFound) {
+		return u, "", respond.New(http.StatusUnauthorized, "INVALID_CREDENTIALS")
+	}
+	if err != nil {
+		return u, "", err
+	}
+	if !utils.CheckPasswordHash(u.Password, password) {
+		return u, "", respond.New(http.StatusUnauthorized, "INVALID_CREDENTIALS")
+	}
+	if u.MergedInto != "" {
+		return u, "", respond.New(http.StatusForbidden, "ACCOUNT_MERGED")
+	}
+	token, err := s.Token(u)
+	return u, token, err
+}
+
+// User is the account with id; USER_NOT_FOUND when there is none.
+func (s *AuthService) User(id string) (models.User, error) {
+	u, err := s.users.ByID(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return u, respond.New(http.StatusNotFound, "USER_NOT_FOUND")
+	}
+	return u, err
+}
+
+// Token is a signed access token for u. The email claim is kept for the
+// clients of the legacy API, which identify users by it.
+func (s *AuthService) Token(u models.User) (string, error) {
+	if s.secret == "" {
+		return "", errors.New("JWT_SECRET not set")
+	}
+	now := s.Now()
+	claims := jwt.MapClaims{
+		"sub":   u.ID,
+		"role":  u.Role,
+		"email": u.Email,
+		"exp":   now.Add(TokenTTL).Unix(),
+		"iat":   now.Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secret))
+}
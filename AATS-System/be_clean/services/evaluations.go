@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+)
+
+// EvaluationService records the hiring manager's evaluation of an
+// application (one per application).
+type EvaluationService struct {
+	apps  store.ApplicationRepo
+	evals store.EvaluationRepo
+	// Now is the clock evaluations are dated with.
+	Now func() time.Time
+}
+
+func NewEvaluationService(apps store.ApplicationRepo, evals store.EvaluationRepo) *EvaluationService {
+	return &EvaluationService{apps: apps, evals: evals, Now: time.Now}
+}
+
+// EvaluationOpen reports whether applications in status can be evaluated
+// (interview onwards).
+func EvaluationOpen(status string) bool {
+	return status == "interview" || status == "offer" || status == "hired"
+}
+
+// Scores is an evaluation as the evaluator fills it in.
+type Scores struct {
+	TechnicalSkills int
+	Communication   int
+	ProblemSolving  int
+	CulturalFit     int
+	Strengths       string
+	Weaknesses      string
+	Comments        string
+	// OverallScore defaults to the mean of the four criteria.
+	OverallScore float32
+}
+
+// openApplication loads appID and checks it can be evaluated.
+func (s *EvaluationService) openApplication(appID string) (models.Application, error) {
+	app, err := s.apps.ByID(appID)
+	if errors.Is(err, store.ErrNotFound) {
+		return app, respond.New(http.StatusNotFound, "APPLICATION_NOT_FOUND")
+	}
+	if err != nil {
+		return app, err
+	}
+	if !EvaluationOpen(app.Status) {
+		return app, respond.New(http.StatusBadRequest, "EVALUATION_TOO_EARLY")
+	}
+	return app, nil
+}
+
+// Submit creates or replaces the evaluation of appID; created reports which
+// happened.
+func (s *EvaluationService) Submit(appID, evaluatorID string, sc Scores) (ev models.Evaluation, created bool, err error) {
+	if _, err := s.openApplication(appID); err != nil {
+		return ev, false, err
+	}
+	overall := sc.OverallScore
+	if overall == 0 {
+		overall = float32(sc.TechnicalSkills+sc.Communication+sc.ProblemSolving+sc.CulturalFit) / 4.0
+	}
+	ev = models.Evaluation{
+		ID:              uuid.NewString(),
+		ApplicationID:   appID,
+		EvaluatorID:     evaluatorID,
+		TechnicalSkills: sc.TechnicalSkills,
+		Communication:   sc.Communication,
+		ProblemSolving:  sc.ProblemSolving,
+		CulturalFit:     sc.CulturalFit,
+		OverallScore:    overall,
+		Strengths:       sc.Strengths,
+		Weaknesses:      sc.Weaknesses,
+		Comments:        sc.Comments,
+		EvaluatedAt:     s.Now(),
+	}
+	created, err = s.evals.Upsert(&ev)
+	return ev, created, err
+}
+
+// Rate sets only the overall score and comments of appID's evaluation,
+// keeping any per-criterion scores (the legacy API's single score).
+func (s *EvaluationService) Rate(appID string, evaluator models.User, overall float32, comments string) (models.Evaluation, error) {
+	if _, err := s.openApplication(appID); err != nil {
+		return models.Evaluation{}, err
+	}
+	ev, err := s.evals.ForApplication(appID)
+	if errors.Is(err, store.ErrNotFound) {
+		ev = models.Evaluation{ID: uuid.NewString(), ApplicationID: appID}
+	} else if err != nil {
+		return ev, err
+	}
+	ev.EvaluatorID, ev.EvaluatorName = evaluator.ID, evaluator.Name
+	ev.OverallScore = overall
+	ev.Comments = comments
+	ev.EvaluatedAt = s.Now()
+	_, err = s.evals.Upsert(&ev)
+	return ev, err
+}
+
+// Get is the evaluation of appID; EVALUATION_NOT_FOUND when there is none.
+func (s *EvaluationService) Get(appID string) (models.Evaluation, error) {
+	ev, err := s.evals.ForApplication(appID)
+	if errors.Is(err, store.ErrNotFound) {
+		return ev, respond.New(http.StatusNotFound, "EVALUATION_NOT_FOUND")
+	}
+	return ev, err
+}
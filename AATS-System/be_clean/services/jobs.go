@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+)
+
+// JobService reads and edits job postings.
+type JobService struct {
+	store store.Store
+	// Now is the clock updates are stamped with.
+	Now func() time.Time
+}
+
+func NewJobService(st store.Store) *JobService {
+	return &JobService{store: st, Now: time.Now}
+}
+
+// On is the service working on st (e.g. a transaction) with the same clock.
+func (s *JobService) On(st store.Store) *JobService {
+	return &JobService{store: st, Now: s.Now}
+}
+
+// Get is the job with id. Drafts are JOB_NOT_FOUND unless withDrafts (staff
+// views, and applying, which checks the job exists but not its status).
+func (s *JobService) Get(id string, withDrafts bool) (models.JobPosting, error) {
+	j, err := s.store.Jobs().ByID(id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && j.Status == "draft" && !withDrafts) {
+		return j, respond.New(http.StatusNotFound, "JOB_NOT_FOUND")
+	}
+	return j, err
+}
+
+// List is one page of the jobs matching f and the total number of matches.
+func (s *JobService) List(f store.JobFilter, p store.Page) ([]models.JobPosting, int64, error) {
+	return s.store.Jobs().List(f, p)
+}
+
+// JobChanges are the fields an update sets; nil fields keep their value.
+// Skill lists are JSON arrays, already normalized.
+type JobChanges struct {
+	Title, Department, Location, ExperienceLevel, EmploymentType *string
+	Description, Requirements, Responsibilities, Status          *string
+	MustHaveSkills, NiceToHaveSkills                             *string
+	MinExperienceYears                                           *float64
+	ClosingDate                                                  *time.Time
+}
+
+// Rescore reports whether the changes affect the match scores of the job's
+// applications.
+func (c JobChanges) Rescore() bool {
+	return c.MustHaveSkills != nil || c.NiceToHaveSkills != nil || c.MinExperienceYears != nil
+}
+
+// Update applies ch to job id and saves it. previous is the job as it was.
+func (s *JobService) Update(id string, ch JobChanges) (job, previous models.JobPosting, err error) {
+	if previous, err = s.Get(id, true); err != nil {
+		return previous, previous, err
+	}
+	job = previous
+	for dst, v := range map[*string]*string{
+		&job.Title: ch.Title, &job.Department: ch.Department, &job.Location: ch.Location,
+		&job.ExperienceLevel: ch.ExperienceLevel, &job.EmploymentType: ch.EmploymentType,
+		&job.Description: ch.Description, &job.Requirements: ch.Requirements, &job.Responsibilities: ch.Responsibilities,
+		&job.Status: ch.Status, &job.MustHaveSkills: ch.MustHaveSkills, &job.NiceToHaveSkills: ch.NiceToHaveSkills,
+	} {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	if ch.MinExperienceYears != nil {
+		job.MinExperienceYears = *ch.MinExperienceYears
+	}
+	if ch.ClosingDate != nil {
+		job.ClosingDate = *ch.ClosingDate
+	}
+	job.UpdatedAt = s.Now()
+	PrepareJob(&job) // the slug stays, the search text follows the new content
+	return job, previous, s.store.Jobs().Save(&job)
+}
+
+// PrepareJob fills the derived job board fields: the slug once, and the
+// search text on every save.
+func PrepareJob(job *models.JobPosting) {
+	if job.Slug == "" {
+		job.Slug = utils.JobSlug(job.Title, job.ID)
+	}
+	job.SearchText = utils.SearchText(
+		job.Title, job.Department, job.Location, job.ExperienceLevel, job.Description,
+		strings.Join(utils.ParseSkillList(job.Requirements), " "),
+		strings.Join(utils.ParseSkillList(job.Responsibilities), " "),
+		strings.Join(utils.ParseSkillList(job.MustHaveSkills), " "),
+		strings.Join(utils.ParseSkillList(job.NiceToHaveSkills), " "),
+	)
+}
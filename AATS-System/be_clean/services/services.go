@@ -0,0 +1,32 @@
+// Package services holds the business rules of the core hiring flow:
+// accounts, jobs, applications with their policy and status history, and
+// evaluations. Services work on store repositories only, so they run the
+// same against Postgres, SQLite or the in-memory store in tests; handlers
+// translate HTTP to service calls and back.
+//
+// Rule failures are returned as *respond.Problem (a stable code plus the
+// values its message refers to) and policy refusals as *PolicyError. Any
+// other error is a storage failure.
+package services
+
+import (
+	"aats-backend-clean/store"
+)
+
+// Services is the set main wires into the handlers.
+type Services struct {
+	Auth         *AuthService
+	Jobs         *JobService
+	Applications *ApplicationService
+	Evaluations  *EvaluationService
+}
+
+// New builds every service on st; secret signs the access tokens.
+func New(st store.Store, secret string) *Services {
+	return &Services{
+		Auth:         NewAuthService(st.Users(), secret),
+		Jobs:         NewJobService(st),
+		Applications: NewApplicationService(st),
+		Evaluations:  NewEvaluationService(st.Applications(), st.Evaluations()),
+	}
+}
@@ -0,0 +1,488 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/utils"
+)
+
+// dialect holds what differs between databases.
+type dialect interface {
+	name() string
+	// containsAny is a WHERE clause matching rows where any of cols contains
+	// the pattern bound to every "?", ignoring case.
+	containsAny(cols ...string) string
+	// uniqueViolation reports whether err is a unique constraint failure.
+	uniqueViolation(err error) bool
+	// forUpdate takes the write lock on row id of table for the rest of the
+	// transaction tx and returns the query to read it with.
+	forUpdate(tx *gorm.DB, table, id string) (*gorm.DB, error)
+	// retryable reports whether err means the transaction lost a race with
+	// another and may succeed when run again.
+	retryable(err error) bool
+}
+
+// New is the Store backed by db, in db's dialect (postgres or sqlite).
+func New(db *gorm.DB) Store {
+	var d dialect = postgres{}
+	if db.Dialector.Name() == "sqlite" {
+		d = sqlite{}
+	}
+	return &gormStore{db: db, d: d}
+}
+
+type gormStore struct {
+	db *gorm.DB
+	d  dialect
+}
+
+// GormDB is the GORM handle s works on (inside Transaction, the
+// transaction), for queries that have no repository yet; ok is false when s
+// is not backed by GORM.
+func GormDB(s Store) (db *gorm.DB, ok bool) {
+	g, ok := s.(*gormStore)
+	if !ok {
+		return nil, false
+	}
+	return g.db, true
+}
+
+func (s *gormStore) Users() UserRepo               { return userRepo{s} }
+func (s *gormStore) Jobs() JobRepo                 { return jobRepo{s} }
+func (s *gormStore) Applications() ApplicationRepo { return applicationRepo{s} }
+func (s *gormStore) Timelines() TimelineRepo       { return timelineRepo{s} }
+func (s *gormStore) Notes() NoteRepo               { return noteRepo{s} }
+func (s *gormStore) Evaluations() EvaluationRepo   { return evaluationRepo{s} }
+func (s *gormStore) Policies() PolicyRepo          { return policyRepo{s} }
+func (s *gormStore) Dialect() string               { return s.d.name() }
+
+// inTransaction reports whether db is bound to an open transaction (the way
+// GORM decides to nest with a savepoint).
+func inTransaction(db *gorm.DB) bool {
+	committer, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok && committer != nil
+}
+
+func (s *gormStore) Transaction(fn func(Store) error) error {
+	run := func(tx *gorm.DB) error { return fn(&gormStore{db: tx, d: s.d}) }
+	if inTransaction(s.db) {
+		return s.db.Transaction(run)
+	}
+	for attempt := 1; ; attempt++ {
+		err := s.db.Transaction(run)
+		if err == nil || attempt == MaxAttempts || !s.d.retryable(err) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt*attempt) * 10 * time.Millisecond)
+	}
+}
+
+// first loads one row into dst, mapping "no rows" to ErrNotFound.
+func (s *gormStore) first(dst interface{}, query string, args ...interface{}) error {
+	return firstOn(s.db, dst, query, args...)
+}
+
+// lock is first by id, holding the row's write lock when in a transaction.
+func (s *gormStore) lock(dst interface{}, table, id string) error {
+	if !inTransaction(s.db) {
+		return s.first(dst, "id = ?", id)
+	}
+	q, err := s.d.forUpdate(s.db, table, id)
+	if err != nil {
+		return err
+	}
+	return firstOn(q, dst, "id = ?", id)
+}
+
+// LockRow reads row id of table into dst holding the row's write lock until
+// db's transaction ends, the way the repositories' Lock do, for tables that
+// have no repository yet (offers). ErrNotFound when there is no such row;
+// outside a transaction it only reads.
+func LockRow(db *gorm.DB, dst interface{}, table, id string) error {
+	return New(db).(*gormStore).lock(dst, table, id)
+}
+
+// CreateRow inserts v the way the repositories' Create do, for tables that
+// have no repository yet (referrals). ErrConflict when v breaks a unique
+// constraint.
+func CreateRow(db *gorm.DB, v interface{}) error {
+	return New(db).(*gormStore).create(v)
+}
+
+func firstOn(db *gorm.DB, dst interface{}, query string, args ...interface{}) error {
+	res := db.Where(query, args...).Limit(1).Find(dst)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// create inserts v, mapping unique violations to ErrConflict.
+func (s *gormStore) create(v interface{}) error {
+	if err := s.db.Create(v).Error; err != nil {
+		if s.d.uniqueViolation(err) || errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func paginate(q *gorm.DB, p Page) *gorm.DB {
+	if p.Offset > 0 {
+		q = q.Offset(p.Offset)
+	}
+	if p.Limit > 0 {
+		q = q.Limit(p.Limit)
+	}
+	return q
+}
+
+// containsPattern escapes LIKE wildcards in q.
+func containsPattern(q string) string {
+	q = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q)
+	return "%" + q + "%"
+}
+
+// subquery is a fresh query on s's connection, for IN (...) filters.
+func (s *gormStore) subquery(model interface{}) *gorm.DB {
+	return s.db.Session(&gorm.Session{NewDB: true}).Model(model)
+}
+
+// whereCustom narrows tx (rows of table) to those matching every m.
+func (s *gormStore) whereCustom(tx *gorm.DB, ms []CustomMatch) *gorm.DB {
+	for _, m := range ms {
+		sub := s.subquery(&models.CustomFieldValue{}).Select("entity_id").Where("field_id = ?", m.FieldID)
+		switch m.Op {
+		case CustomEquals:
+			sub = sub.Where("value_text = ?", m.Text)
+		case CustomEqualFold:
+			sub = sub.Where("LOWER(value_text) = LOWER(?)", m.Text)
+		case CustomAnyOf:
+			sub = sub.Where("LOWER(value_text) IN ?", m.AnyOf)
+		case CustomContains:
+			sub = sub.Where("value_text LIKE ? ESCAPE '\\'", containsPattern(m.Text))
+		case CustomMinNumber:
+			sub = sub.Where("value_number >= ?", m.Number)
+		case CustomMaxNumber:
+			sub = sub.Where("value_number <= ?", m.Number)
+		case CustomMinDate:
+			sub = sub.Where("value_date >= ?", m.Date)
+		case CustomMaxDate:
+			sub = sub.Where("value_date <= ?", m.Date)
+		default:
+			tx = tx.Where("1 = 0")
+			continue
+		}
+		tx = tx.Where("id IN (?)", sub)
+	}
+	return tx
+}
+
+// customOrder is the ORDER BY for o on rows of table, followed by then.
+func customOrder(table string, o CustomOrder, then string) clause.OrderBy {
+	col := "value_text"
+	switch o.Type {
+	case utils.FieldNumber:
+		col = "value_number"
+	case utils.FieldDate:
+		col = "value_date"
+	}
+	dir := "ASC"
+	if o.Desc {
+		dir = "DESC"
+	}
+	sql := "(SELECT cfv." + col + " FROM custom_field_values cfv WHERE cfv.field_id = ? AND cfv.entity_id = " +
+		table + ".id) " + dir + " NULLS LAST, " + then
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: []interface{}{o.FieldID}, WithoutParentheses: true}}
+}
+
+type userRepo struct{ s *gormStore }
+
+func (r userRepo) Create(u *models.User) error { return r.s.create(u) }
+
+func (r userRepo) ByID(id string) (models.User, error) {
+	var u models.User
+	return u, r.s.first(&u, "id = ?", id)
+}
+
+func (r userRepo) Lock(id string) (models.User, error) {
+	var u models.User
+	return u, r.s.lock(&u, "users", id)
+}
+
+func (r userRepo) ByIDs(ids []string) ([]models.User, error) {
+	users := []models.User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	return users, r.s.db.Where("id IN ?", ids).Find(&users).Error
+}
+
+func (r userRepo) ByEmail(email string) (models.User, error) {
+	var u models.User
+	return u, r.s.first(&u, "email = ?", email)
+}
+
+func (r userRepo) List(q string, p Page) ([]models.User, int64, error) {
+	tx := r.s.db.Model(&models.User{})
+	if q != "" {
+		like := containsPattern(q)
+		tx = tx.Where(r.s.d.containsAny("email", "name"), like, like)
+	}
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	users := []models.User{}
+	err := paginate(tx.Order("created_at desc, id"), p).Find(&users).Error
+	return users, total, err
+}
+
+type jobRepo struct{ s *gormStore }
+
+func (r jobRepo) Create(j *models.JobPosting) error { return r.s.create(j) }
+
+func (r jobRepo) ByID(id string) (models.JobPosting, error) {
+	var j models.JobPosting
+	return j, r.s.first(&j, "id = ?", id)
+}
+
+func (r jobRepo) ByIDs(ids []string) ([]models.JobPosting, error) {
+	jobs := []models.JobPosting{}
+	if len(ids) == 0 {
+		return jobs, nil
+	}
+	return jobs, r.s.db.Where("id IN ?", ids).Find(&jobs).Error
+}
+
+func (r jobRepo) Save(j *models.JobPosting) error { return r.s.db.Save(j).Error }
+
+func (r jobRepo) List(f JobFilter, p Page) ([]models.JobPosting, int64, error) {
+	tx := r.s.db.Model(&models.JobPosting{})
+	if f.Query != "" {
+		like := containsPattern(f.Query)
+		tx = tx.Where(r.s.d.containsAny("title", "description"), like, like)
+	}
+	if f.Location != "" {
+		tx = tx.Where("location = ?", f.Location)
+	}
+	if f.EmploymentType != "" {
+		tx = tx.Where("employment_type = ?", f.EmploymentType)
+	}
+	if len(f.Statuses) > 0 {
+		tx = tx.Where("status IN ?", f.Statuses)
+	}
+	if f.ExcludeStatus != "" {
+		tx = tx.Where("status <> ?", f.ExcludeStatus)
+	}
+	tx = r.s.whereCustom(tx, f.Custom)
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var order interface{} = "posted_date desc, created_at desc, id"
+	if f.CustomOrder != nil {
+		order = customOrder("job_postings", *f.CustomOrder, "posted_date desc, created_at desc, id")
+	}
+	jobs := []models.JobPosting{}
+	err := paginate(tx.Order(order), p).Find(&jobs).Error
+	return jobs, total, err
+}
+
+type applicationRepo struct{ s *gormStore }
+
+func (r applicationRepo) Create(a *models.Application) error { return r.s.create(a) }
+
+func (r applicationRepo) ByID(id string) (models.Application, error) {
+	var a models.Application
+	return a, r.s.first(&a, "id = ?", id)
+}
+
+func (r applicationRepo) Lock(id string) (models.Application, error) {
+	var a models.Application
+	return a, r.s.lock(&a, "applications", id)
+}
+
+func (r applicationRepo) ForApplicant(applicantID string) ([]models.Application, error) {
+	apps := []models.Application{}
+	err := r.s.db.Where("applicant_id = ?", applicantID).Order("created_at desc, id").Find(&apps).Error
+	return apps, err
+}
+
+func (r applicationRepo) Save(a *models.Application) error { return r.s.db.Save(a).Error }
+
+// briefColumns are the columns ApplicationFilter.Brief loads.
+const briefColumns = "id, job_id, applicant_id, status, submitted_date, updated_at, match_score, match_breakdown, screening_outcome, reviewer_id, source, utm_campaign"
+
+// where narrows the applications to f.
+func (r applicationRepo) where(f ApplicationFilter) *gorm.DB {
+	tx := r.s.db.Model(&models.Application{})
+	for _, eq := range [][2]string{
+		{"applicant_id", f.ApplicantID}, {"job_id", f.JobID}, {"status", f.Status}, {"screening_outcome", f.ScreeningOutcome},
+		{"reviewer_id", f.ReviewerID}, {"source", f.Source}, {"utm_campaign", f.UTMCampaign},
+	} {
+		if eq[1] != "" {
+			tx = tx.Where(eq[0]+" = ?", eq[1])
+		}
+	}
+	if !f.SubmittedFrom.IsZero() {
+		tx = tx.Where("submitted_date >= ?", f.SubmittedFrom)
+	}
+	if !f.SubmittedBefore.IsZero() {
+		tx = tx.Where("submitted_date < ?", f.SubmittedBefore)
+	}
+	if f.QuestionID != "" {
+		sub := r.s.subquery(&models.ScreeningAnswer{}).Select("application_id").Where("question_id = ?", f.QuestionID)
+		if f.Answer != "" {
+			sub = sub.Where("LOWER(value) = LOWER(?)", f.Answer)
+		}
+		tx = tx.Where("id IN (?)", sub)
+	}
+	if f.Tag != "" {
+		tx = tx.Where("id IN (?)", r.s.subquery(&models.ApplicationTag{}).Select("application_id").Where("tag = ?", f.Tag))
+	}
+	if f.Query != "" {
+		like := containsPattern(f.Query)
+		tx = tx.Where(r.s.d.containsAny("cover_letter", "education", "experience", "skills"), like, like, like, like)
+	}
+	return r.s.whereCustom(tx, f.Custom)
+}
+
+// ordered is where(f) in f's order, with only the brief columns if asked.
+func (r applicationRepo) ordered(f ApplicationFilter) *gorm.DB {
+	tx := r.where(f)
+	if f.Brief {
+		tx = tx.Select(briefColumns)
+	}
+	if f.CustomOrder != nil {
+		return tx.Order(customOrder("applications", *f.CustomOrder, "submitted_date desc, id"))
+	}
+	col := map[string]string{"match_score": "match_score", "updated_at": "updated_at"}[f.SortBy]
+	if col == "" {
+		col = "submitted_date"
+	}
+	dir := " desc"
+	if f.Asc {
+		dir = " asc"
+	}
+	if col != "submitted_date" {
+		return tx.Order(col + dir + ", submitted_date desc, id")
+	}
+	return tx.Order(col + dir + ", id")
+}
+
+func (r applicationRepo) List(f ApplicationFilter, p Page) ([]models.Application, error) {
+	apps := []models.Application{}
+	return apps, paginate(r.ordered(f), p).Find(&apps).Error
+}
+
+func (r applicationRepo) Count(f ApplicationFilter) (int64, error) {
+	var n int64
+	return n, r.where(f).Count(&n).Error
+}
+
+func (r applicationRepo) Each(f ApplicationFilter, fn func(models.Application) error) error {
+	rows, err := r.ordered(f).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a models.Application
+		if err := r.s.db.ScanRows(rows, &a); err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+type timelineRepo struct{ s *gormStore }
+
+func (r timelineRepo) Append(tl *models.ApplicationTimeline) error { return r.s.create(tl) }
+
+func (r timelineRepo) ForApplication(appID string) ([]models.ApplicationTimeline, error) {
+	return r.ForApplications([]string{appID})
+}
+
+func (r timelineRepo) ForApplications(appIDs []string) ([]models.ApplicationTimeline, error) {
+	tls := []models.ApplicationTimeline{}
+	if len(appIDs) == 0 {
+		return tls, nil
+	}
+	err := r.s.db.Where("application_id IN ?", appIDs).Order("date desc, created_at desc").Find(&tls).Error
+	return tls, err
+}
+
+type noteRepo struct{ s *gormStore }
+
+func (r noteRepo) Create(n *models.Note) error { return r.s.create(n) }
+
+func (r noteRepo) ForApplication(appID string) ([]models.Note, error) {
+	notes := []models.Note{}
+	err := r.s.db.Where("application_id = ?", appID).Order("created_at desc, id").Find(&notes).Error
+	return notes, err
+}
+
+type evaluationRepo struct{ s *gormStore }
+
+func (r evaluationRepo) ForApplication(appID string) (models.Evaluation, error) {
+	var e models.Evaluation
+	return e, r.s.first(&e, "application_id = ?", appID)
+}
+
+func (r evaluationRepo) ForApplications(appIDs []string) ([]models.Evaluation, error) {
+	evals := []models.Evaluation{}
+	if len(appIDs) == 0 {
+		return evals, nil
+	}
+	return evals, r.s.db.Where("application_id IN ?", appIDs).Find(&evals).Error
+}
+
+func (r evaluationRepo) Upsert(e *models.Evaluation) (bool, error) {
+	existing, err := r.ForApplication(e.ApplicationID)
+	if errors.Is(err, ErrNotFound) {
+		return true, r.s.create(e)
+	}
+	if err != nil {
+		return false, err
+	}
+	existing.EvaluatorID = e.EvaluatorID
+	existing.EvaluatorName = e.EvaluatorName
+	existing.TechnicalSkills = e.TechnicalSkills
+	existing.Communication = e.Communication
+	existing.ProblemSolving = e.ProblemSolving
+	existing.CulturalFit = e.CulturalFit
+	existing.OverallScore = e.OverallScore
+	existing.Strengths = e.Strengths
+	existing.Weaknesses = e.Weaknesses
+	existing.Comments = e.Comments
+	existing.EvaluatedAt = e.EvaluatedAt
+	if err := r.s.db.Save(&existing).Error; err != nil {
+		return false, err
+	}
+	*e = existing
+	return false, nil
+}
+
+type policyRepo struct{ s *gormStore }
+
+func (r policyRepo) Create(p *models.ApplicationPolicy) error { return r.s.create(p) }
+
+func (r policyRepo) ForJob(jobID string) ([]models.ApplicationPolicy, error) {
+	rows := []models.ApplicationPolicy{}
+	err := r.s.db.Where("scope = ? OR (scope = ? AND job_id = ?)", utils.PolicyScopeGlobal, utils.PolicyScopeJob, jobID).Find(&rows).Error
+	return rows, err
+}
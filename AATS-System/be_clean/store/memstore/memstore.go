@@ -0,0 +1,438 @@
+// Package memstore is a store.Store kept in memory, for unit tests of the
+// services that should not need a database. It passes the same conformance
+// suite (store/storetest) as the GORM store, so code tested against it
+// behaves the same on Postgres and SQLite.
+//
+// Rows are copied in and out; callers never share memory with the store.
+package memstore
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/store"
+	"aats-backend-clean/utils"
+)
+
+// Store is an empty in-memory store, safe for concurrent use.
+// Transactions run one at a time; rolling one back restores every table,
+// so writes made outside transactions while one runs may be lost with it.
+type Store struct {
+	txMu sync.Mutex // held by the running transaction
+	mu   sync.Mutex // guards tables
+	tables
+}
+
+type tables struct {
+	users    map[string]models.User
+	jobs     map[string]models.JobPosting
+	apps     map[string]models.Application
+	tls      map[string]models.ApplicationTimeline
+	notes    map[string]models.Note
+	evals    map[string]models.Evaluation
+	policies map[string]models.ApplicationPolicy
+}
+
+func (t tables) clone() tables {
+	return tables{
+		users:    maps.Clone(t.users),
+		jobs:     maps.Clone(t.jobs),
+		apps:     maps.Clone(t.apps),
+		tls:      maps.Clone(t.tls),
+		notes:    maps.Clone(t.notes),
+		evals:    maps.Clone(t.evals),
+		policies: maps.Clone(t.policies),
+	}
+}
+
+func New() *Store {
+	return &Store{tables: tables{
+		users:    map[string]models.User{},
+		jobs:     map[string]models.JobPosting{},
+		apps:     map[string]models.Application{},
+		tls:      map[string]models.ApplicationTimeline{},
+		notes:    map[string]models.Note{},
+		evals:    map[string]models.Evaluation{},
+		policies: map[string]models.ApplicationPolicy{},
+	}}
+}
+
+var _ store.Store = (*Store)(nil)
+
+var _ store.Store = tx{}
+
+func (s *Store) Transaction(fn func(store.Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	return s.run(fn)
+}
+
+// tx is the Store inside a transaction; its transactions nest.
+type tx struct{ *Store }
+
+func (t tx) Transaction(fn func(store.Store) error) error { return t.run(fn) }
+
+// run calls fn, restoring the tables when it fails.
+func (s *Store) run(fn func(store.Store) error) error {
+	s.mu.Lock()
+	saved := s.tables.clone()
+	s.mu.Unlock()
+	if err := fn(tx{s}); err != nil {
+		s.mu.Lock()
+		s.tables = saved
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (s *Store) Users() store.UserRepo               { return userRepo{s} }
+func (s *Store) Jobs() store.JobRepo                 { return jobRepo{s} }
+func (s *Store) Applications() store.ApplicationRepo { return applicationRepo{s} }
+func (s *Store) Timelines() store.TimelineRepo       { return timelineRepo{s} }
+func (s *Store) Notes() store.NoteRepo               { return noteRepo{s} }
+func (s *Store) Evaluations() store.EvaluationRepo   { return evaluationRepo{s} }
+func (s *Store) Policies() store.PolicyRepo          { return policyRepo{s} }
+func (s *Store) Dialect() string                     { return "memory" }
+
+// stamp sets the timestamps the database would fill on insert.
+func stamp(created, updated *time.Time) {
+	now := time.Now()
+	if created != nil && created.IsZero() {
+		*created = now
+	}
+	if updated != nil && updated.IsZero() {
+		*updated = now
+	}
+}
+
+// insert adds v under id; ErrConflict when id is taken.
+func insert[T any](s *Store, m map[string]T, id string, v T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := m[id]; ok {
+		return store.ErrConflict
+	}
+	m[id] = v
+	return nil
+}
+
+// find returns the first row matching ok, or ErrNotFound.
+func find[T any](s *Store, m map[string]T, ok func(T) bool) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range m {
+		if ok(v) {
+			return v, nil
+		}
+	}
+	var zero T
+	return zero, store.ErrNotFound
+}
+
+// filter returns the rows matching ok sorted by less; never nil.
+func filter[T any](s *Store, m map[string]T, ok func(T) bool, less func(a, b T) bool) []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []T{}
+	for _, v := range m {
+		if ok(v) {
+			out = append(out, v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return less(out[i], out[j]) })
+	return out
+}
+
+func paginate[T any](rows []T, p store.Page) []T {
+	if p.Offset >= len(rows) {
+		return []T{}
+	}
+	rows = rows[p.Offset:]
+	if p.Limit > 0 && p.Limit < len(rows) {
+		rows = rows[:p.Limit]
+	}
+	return rows
+}
+
+// contains reports whether any of fields contains q, ignoring case.
+func contains(q string, fields ...string) bool {
+	q = strings.ToLower(q)
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// newer orders by a desc, then id.
+func newer(a, b time.Time, aID, bID string) bool {
+	if !a.Equal(b) {
+		return a.After(b)
+	}
+	return aID < bID
+}
+
+type userRepo struct{ s *Store }
+
+func (r userRepo) Create(u *models.User) error {
+	stamp(&u.CreatedAt, &u.UpdatedAt)
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, other := range r.s.users {
+		if other.ID == u.ID || other.Email == u.Email {
+			return store.ErrConflict
+		}
+	}
+	r.s.users[u.ID] = *u
+	return nil
+}
+
+func (r userRepo) ByID(id string) (models.User, error) {
+	return find(r.s, r.s.users, func(u models.User) bool { return u.ID == id })
+}
+
+// Lock is ByID: transactions already run one at a time.
+func (r userRepo) Lock(id string) (models.User, error) { return r.ByID(id) }
+
+func (r userRepo) ByIDs(ids []string) ([]models.User, error) {
+	return filter(r.s, r.s.users,
+		func(u models.User) bool { return slices.Contains(ids, u.ID) },
+		func(a, b models.User) bool { return a.ID < b.ID }), nil
+}
+
+func (r userRepo) ByEmail(email string) (models.User, error) {
+	return find(r.s, r.s.users, func(u models.User) bool { return u.Email == email })
+}
+
+func (r userRepo) List(q string, p store.Page) ([]models.User, int64, error) {
+	users := filter(r.s, r.s.users,
+		func(u models.User) bool { return q == "" || contains(q, u.Email, u.Name) },
+		func(a, b models.User) bool { return newer(a.CreatedAt, b.CreatedAt, a.ID, b.ID) })
+	return paginate(users, p), int64(len(users)), nil
+}
+
+type jobRepo struct{ s *Store }
+
+func (r jobRepo) Create(j *models.JobPosting) error {
+	stamp(&j.CreatedAt, &j.UpdatedAt)
+	return insert(r.s, r.s.jobs, j.ID, *j)
+}
+
+func (r jobRepo) ByID(id string) (models.JobPosting, error) {
+	return find(r.s, r.s.jobs, func(j models.JobPosting) bool { return j.ID == id })
+}
+
+func (r jobRepo) ByIDs(ids []string) ([]models.JobPosting, error) {
+	return filter(r.s, r.s.jobs,
+		func(j models.JobPosting) bool { return slices.Contains(ids, j.ID) },
+		func(a, b models.JobPosting) bool { return a.ID < b.ID }), nil
+}
+
+func (r jobRepo) Save(j *models.JobPosting) error {
+	j.UpdatedAt = time.Now()
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.jobs[j.ID] = *j
+	return nil
+}
+
+// The store keeps no custom field values, so a custom filter matches nothing
+// and a custom order leaves every record in the usual order.
+
+func (r jobRepo) List(f store.JobFilter, p store.Page) ([]models.JobPosting, int64, error) {
+	match := func(j models.JobPosting) bool {
+		switch {
+		case len(f.Custom) > 0,
+			f.Query != "" && !contains(f.Query, j.Title, j.Description),
+			f.Location != "" && j.Location != f.Location,
+			f.EmploymentType != "" && j.EmploymentType != f.EmploymentType,
+			len(f.Statuses) > 0 && !slices.Contains(f.Statuses, j.Status),
+			f.ExcludeStatus != "" && j.Status == f.ExcludeStatus:
+			return false
+		}
+		return true
+	}
+	jobs := filter(r.s, r.s.jobs, match, func(a, b models.JobPosting) bool {
+		if !a.PostedDate.Equal(b.PostedDate) {
+			return a.PostedDate.After(b.PostedDate)
+		}
+		return newer(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+	})
+	return paginate(jobs, p), int64(len(jobs)), nil
+}
+
+type applicationRepo struct{ s *Store }
+
+func (r applicationRepo) Create(a *models.Application) error {
+	stamp(&a.CreatedAt, &a.UpdatedAt)
+	return insert(r.s, r.s.apps, a.ID, *a)
+}
+
+func (r applicationRepo) ByID(id string) (models.Application, error) {
+	return find(r.s, r.s.apps, func(a models.Application) bool { return a.ID == id })
+}
+
+func (r applicationRepo) Lock(id string) (models.Application, error) { return r.ByID(id) }
+
+func (r applicationRepo) ForApplicant(applicantID string) ([]models.Application, error) {
+	return filter(r.s, r.s.apps,
+		func(a models.Application) bool { return a.ApplicantID == applicantID },
+		func(a, b models.Application) bool { return newer(a.CreatedAt, b.CreatedAt, a.ID, b.ID) }), nil
+}
+
+func (r applicationRepo) Save(a *models.Application) error {
+	a.UpdatedAt = time.Now()
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.apps[a.ID] = *a
+	return nil
+}
+
+// ordered is the applications matching f in f's order. The store keeps no
+// screening answers or tags either, so filters on them match nothing.
+func (r applicationRepo) ordered(f store.ApplicationFilter) []models.Application {
+	match := func(a models.Application) bool {
+		switch {
+		case len(f.Custom) > 0, f.QuestionID != "", f.Tag != "",
+			f.ApplicantID != "" && a.ApplicantID != f.ApplicantID,
+			f.JobID != "" && a.JobID != f.JobID,
+			f.Status != "" && a.Status != f.Status,
+			f.ScreeningOutcome != "" && a.ScreeningOutcome != f.ScreeningOutcome,
+			f.ReviewerID != "" && a.ReviewerID != f.ReviewerID,
+			f.Source != "" && a.Source != f.Source,
+			f.UTMCampaign != "" && a.UTMCampaign != f.UTMCampaign,
+			!f.SubmittedFrom.IsZero() && a.SubmittedDate.Before(f.SubmittedFrom),
+			!f.SubmittedBefore.IsZero() && !a.SubmittedDate.Before(f.SubmittedBefore),
+			f.Query != "" && !contains(f.Query, a.CoverLetter, a.Education, a.Experience, a.Skills):
+			return false
+		}
+		return true
+	}
+	key := func(a models.Application) float64 { return float64(a.SubmittedDate.UnixNano()) }
+	if f.CustomOrder == nil {
+		switch f.SortBy {
+		case "match_score":
+			key = func(a models.Application) float64 { return a.MatchScore }
+		case "updated_at":
+			key = func(a models.Application) float64 { return float64(a.UpdatedAt.UnixNano()) }
+		}
+	}
+	asc := f.Asc && f.CustomOrder == nil
+	apps := filter(r.s, r.s.apps, match, func(a, b models.Application) bool {
+		if ka, kb := key(a), key(b); ka != kb {
+			return ka < kb == asc
+		}
+		return newer(a.SubmittedDate, b.SubmittedDate, a.ID, b.ID)
+	})
+	if f.Brief {
+		for i, a := range apps {
+			apps[i] = models.Application{
+				ID: a.ID, JobID: a.JobID, ApplicantID: a.ApplicantID, Status: a.Status, SubmittedDate: a.SubmittedDate,
+				UpdatedAt: a.UpdatedAt, MatchScore: a.MatchScore, MatchBreakdown: a.MatchBreakdown,
+				ScreeningOutcome: a.ScreeningOutcome, ReviewerID: a.ReviewerID, Source: a.Source, UTMCampaign: a.UTMCampaign,
+			}
+		}
+	}
+	return apps
+}
+
+func (r applicationRepo) List(f store.ApplicationFilter, p store.Page) ([]models.Application, error) {
+	return paginate(r.ordered(f), p), nil
+}
+
+func (r applicationRepo) Count(f store.ApplicationFilter) (int64, error) {
+	return int64(len(r.ordered(f))), nil
+}
+
+func (r applicationRepo) Each(f store.ApplicationFilter, fn func(models.Application) error) error {
+	for _, a := range r.ordered(f) {
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type timelineRepo struct{ s *Store }
+
+func (r timelineRepo) Append(tl *models.ApplicationTimeline) error {
+	stamp(&tl.CreatedAt, nil)
+	return insert(r.s, r.s.tls, tl.ID, *tl)
+}
+
+func (r timelineRepo) ForApplication(appID string) ([]models.ApplicationTimeline, error) {
+	return r.ForApplications([]string{appID})
+}
+
+func (r timelineRepo) ForApplications(appIDs []string) ([]models.ApplicationTimeline, error) {
+	return filter(r.s, r.s.tls,
+		func(tl models.ApplicationTimeline) bool { return slices.Contains(appIDs, tl.ApplicationID) },
+		func(a, b models.ApplicationTimeline) bool {
+			if !a.Date.Equal(b.Date) {
+				return a.Date.After(b.Date)
+			}
+			return newer(a.CreatedAt, b.CreatedAt, a.ID, b.ID)
+		}), nil
+}
+
+type noteRepo struct{ s *Store }
+
+func (r noteRepo) Create(n *models.Note) error {
+	stamp(&n.CreatedAt, nil)
+	return insert(r.s, r.s.notes, n.ID, *n)
+}
+
+func (r noteRepo) ForApplication(appID string) ([]models.Note, error) {
+	return filter(r.s, r.s.notes,
+		func(n models.Note) bool { return n.ApplicationID == appID },
+		func(a, b models.Note) bool { return newer(a.CreatedAt, b.CreatedAt, a.ID, b.ID) }), nil
+}
+
+type evaluationRepo struct{ s *Store }
+
+func (r evaluationRepo) ForApplication(appID string) (models.Evaluation, error) {
+	return find(r.s, r.s.evals, func(e models.Evaluation) bool { return e.ApplicationID == appID })
+}
+
+func (r evaluationRepo) ForApplications(appIDs []string) ([]models.Evaluation, error) {
+	return filter(r.s, r.s.evals,
+		func(e models.Evaluation) bool { return slices.Contains(appIDs, e.ApplicationID) },
+		func(a, b models.Evaluation) bool { return a.ID < b.ID }), nil
+}
+
+func (r evaluationRepo) Upsert(e *models.Evaluation) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, existing := range r.s.evals {
+		if existing.ApplicationID == e.ApplicationID {
+			e.ID = id
+			r.s.evals[id] = *e
+			return false, nil
+		}
+	}
+	stamp(&e.EvaluatedAt, nil)
+	r.s.evals[e.ID] = *e
+	return true, nil
+}
+
+type policyRepo struct{ s *Store }
+
+func (r policyRepo) Create(p *models.ApplicationPolicy) error {
+	stamp(&p.CreatedAt, &p.UpdatedAt)
+	return insert(r.s, r.s.policies, p.ID, *p)
+}
+
+func (r policyRepo) ForJob(jobID string) ([]models.ApplicationPolicy, error) {
+	return filter(r.s, r.s.policies,
+		func(p models.ApplicationPolicy) bool {
+			return p.Scope == utils.PolicyScopeGlobal || (p.Scope == utils.PolicyScopeJob && p.JobID == jobID)
+		},
+		func(a, b models.ApplicationPolicy) bool { return a.ID < b.ID }), nil
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postgres struct{}
+
+func (postgres) name() string { return "postgres" }
+
+func (postgres) containsAny(cols ...string) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = c + " ILIKE ?"
+	}
+	return "(" + strings.Join(parts, " OR ") + ")"
+}
+
+func (postgres) uniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (postgres) forUpdate(tx *gorm.DB, table, id string) (*gorm.DB, error) {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}), nil
+}
+
+// retryable: serialization_failure and deadlock_detected.
+func (postgres) retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// sqlite is the fallback used when no DATABASE_URL is set. LIKE is
+// case-insensitive for ASCII only, so both sides are lowered.
+type sqlite struct{}
+
+func (sqlite) name() string { return "sqlite" }
+
+func (sqlite) containsAny(cols ...string) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = "LOWER(" + c + ") LIKE LOWER(?) ESCAPE '\\'"
+	}
+	return "(" + strings.Join(parts, " OR ") + ")"
+}
+
+func (sqlite) uniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// forUpdate: SQLite has no row locks; a write takes the database's write
+// lock (waiting up to busy_timeout), which serializes the transactions
+// that lock anything.
+func (sqlite) forUpdate(tx *gorm.DB, table, id string) (*gorm.DB, error) {
+	return tx, tx.Exec("UPDATE "+table+" SET id = id WHERE id = ?", id).Error
+}
+
+// retryable: SQLITE_BUSY (a writer holds the lock past busy_timeout, or
+// two readers both tried to write) and SQLITE_LOCKED (shared-cache
+// databases).
+func (sqlite) retryable(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked") || strings.Contains(msg, "SQLITE_BUSY")
+}
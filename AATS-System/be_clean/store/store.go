@@ -0,0 +1,223 @@
+// Package store is the data access layer between the handlers and the
+// database. Handlers ask a Store for users, jobs, applications and their
+// history instead of building GORM queries themselves, so the same code runs
+// on Postgres and on the SQLite fallback.
+//
+// New picks the dialect from the connection; both dialects must pass the
+// conformance suite in store/storetest.
+package store
+
+import (
+	"errors"
+	"time"
+
+	"aats-backend-clean/models"
+)
+
+var (
+	// ErrNotFound is returned when no row matches.
+	ErrNotFound = errors.New("store: not found")
+	// ErrConflict is returned when a write breaks a unique constraint
+	// (e.g. a second account with the same email).
+	ErrConflict = errors.New("store: conflict")
+)
+
+// MaxAttempts is how many times Transaction runs fn before giving up on a
+// race with concurrent transactions.
+const MaxAttempts = 5
+
+// Store hands out the repositories. Every repository of one Store works on
+// the same connection.
+type Store interface {
+	// Transaction runs fn with a Store whose repositories all work in one
+	// transaction, committed when fn returns nil and rolled back otherwise.
+	// A top-level transaction that loses a race with another (deadlock,
+	// serialization failure, busy SQLite database) runs fn again, up to
+	// MaxAttempts times, so fn must have no effects outside its Store.
+	// Called on a transaction's Store it nests: only fn's own writes are
+	// rolled back.
+	Transaction(fn func(tx Store) error) error
+
+	Users() UserRepo
+	Jobs() JobRepo
+	Applications() ApplicationRepo
+	Timelines() TimelineRepo
+	Notes() NoteRepo
+	Evaluations() EvaluationRepo
+	Policies() PolicyRepo
+	// Dialect is "postgres" or "sqlite".
+	Dialect() string
+}
+
+// Page selects a slice of a list; Limit 0 means no limit.
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// UserRepo stores accounts.
+type UserRepo interface {
+	// Create inserts u; ErrConflict when the email is taken.
+	Create(u *models.User) error
+	ByID(id string) (models.User, error)
+	// Lock is ByID holding the user's write lock until the transaction
+	// ends; transactions locking the same user run one after the other.
+	// The lock guards everything kept per user (e.g. their applications
+	// under the application policy). Outside a transaction it is ByID.
+	Lock(id string) (models.User, error)
+	// ByEmail matches the email exactly.
+	ByEmail(email string) (models.User, error)
+	// ByIDs loads the users with the given ids, in any order; ids without a
+	// user are skipped.
+	ByIDs(ids []string) ([]models.User, error)
+	// List returns users whose email or name contains q (any case), newest
+	// first, and the total number of matches.
+	List(q string, p Page) ([]models.User, int64, error)
+}
+
+// CustomOp is how a CustomMatch compares a custom field's value.
+type CustomOp int
+
+const (
+	CustomNone      CustomOp = iota // matches nothing (unknown or hidden field, invalid value)
+	CustomEquals                    // the stored text is Text
+	CustomEqualFold                 // the stored text is Text, any case
+	CustomAnyOf                     // the stored text is one of AnyOf, any case
+	CustomContains                  // the stored text contains Text (a multi_select option)
+	CustomMinNumber                 // the number is at least Number
+	CustomMaxNumber                 // the number is at most Number
+	CustomMinDate                   // the date is Date or later
+	CustomMaxDate                   // the date is Date or earlier
+)
+
+// CustomMatch narrows a list to records whose custom field FieldID holds a
+// matching value. Callers resolve the field and parse the value; see
+// utils.CustomValue for how values are stored.
+type CustomMatch struct {
+	FieldID string
+	Op      CustomOp
+	Text    string
+	AnyOf   []string
+	Number  float64
+	Date    time.Time
+}
+
+// CustomOrder sorts a list by a custom field, records without a value last
+// either way. Type is the field's type (utils.FieldNumber, ...).
+type CustomOrder struct {
+	FieldID string
+	Type    string
+	Desc    bool
+}
+
+// JobFilter narrows a job list. Empty fields match everything.
+type JobFilter struct {
+	Query          string   // contained in the title or description, any case
+	Location       string   // exact
+	EmploymentType string   // exact
+	Statuses       []string // any of
+	ExcludeStatus  string   // e.g. "draft" for the public list
+	Custom         []CustomMatch
+	// CustomOrder sorts by a custom field before the usual order.
+	CustomOrder *CustomOrder
+}
+
+// ApplicationFilter narrows and orders an application list. Empty fields
+// match everything.
+type ApplicationFilter struct {
+	ApplicantID      string
+	JobID            string
+	Status           string
+	SubmittedFrom    time.Time // inclusive
+	SubmittedBefore  time.Time // exclusive
+	ScreeningOutcome string
+	QuestionID       string // answered this screening question
+	Answer           string // ... with this answer, any case
+	Tag              string
+	ReviewerID       string
+	Source           string
+	UTMCampaign      string
+	Query            string // contained in the cover letter, education, experience or skills, any case
+	Custom           []CustomMatch
+
+	// SortBy is "submitted_date" (the default), "match_score" or
+	// "updated_at"; ties go newest first. CustomOrder sorts by a custom
+	// field instead, newest first among equal values.
+	SortBy      string
+	Asc         bool
+	CustomOrder *CustomOrder
+	// Brief loads only what lists show, leaving the texts (cover letter,
+	// resume, education, experience, skills...) empty.
+	Brief bool
+}
+
+// JobRepo stores job postings.
+type JobRepo interface {
+	Create(j *models.JobPosting) error
+	ByID(id string) (models.JobPosting, error)
+	// ByIDs loads the jobs with the given ids, in any order; ids without a
+	// job are skipped.
+	ByIDs(ids []string) ([]models.JobPosting, error)
+	// List returns matching jobs, most recently posted first, and the total
+	// number of matches.
+	List(f JobFilter, p Page) ([]models.JobPosting, int64, error)
+	// Save writes every field of j.
+	Save(j *models.JobPosting) error
+}
+
+// ApplicationRepo stores applications.
+type ApplicationRepo interface {
+	Create(a *models.Application) error
+	ByID(id string) (models.Application, error)
+	// Lock is ByID holding the application's write lock until the
+	// transaction ends (see UserRepo.Lock).
+	Lock(id string) (models.Application, error)
+	// ForApplicant lists a candidate's applications, newest first.
+	ForApplicant(applicantID string) ([]models.Application, error)
+	// List returns the page of applications matching f, in f's order.
+	List(f ApplicationFilter, p Page) ([]models.Application, error)
+	// Count is the number of applications matching f.
+	Count(f ApplicationFilter) (int64, error)
+	// Each calls fn with every application matching f, in f's order,
+	// without holding them all in memory; an error from fn stops it.
+	Each(f ApplicationFilter, fn func(models.Application) error) error
+	// Save writes every field of a.
+	Save(a *models.Application) error
+}
+
+// TimelineRepo stores the history of applications.
+type TimelineRepo interface {
+	Append(tl *models.ApplicationTimeline) error
+	// ForApplication lists an application's entries, newest first.
+	ForApplication(appID string) ([]models.ApplicationTimeline, error)
+	// ForApplications lists the entries of several applications, each
+	// application's newest first.
+	ForApplications(appIDs []string) ([]models.ApplicationTimeline, error)
+}
+
+// NoteRepo stores notes on applications.
+type NoteRepo interface {
+	Create(n *models.Note) error
+	// ForApplication lists an application's notes, newest first.
+	ForApplication(appID string) ([]models.Note, error)
+}
+
+// EvaluationRepo stores the evaluation of an application (one per
+// application).
+type EvaluationRepo interface {
+	ForApplication(appID string) (models.Evaluation, error)
+	// ForApplications loads the evaluations of several applications, in any
+	// order.
+	ForApplications(appIDs []string) ([]models.Evaluation, error)
+	// Upsert creates e, or replaces the scores of the application's existing
+	// evaluation and loads it into e. created reports which happened.
+	Upsert(e *models.Evaluation) (created bool, err error)
+}
+
+// PolicyRepo stores the application policy layers.
+type PolicyRepo interface {
+	Create(p *models.ApplicationPolicy) error
+	// ForJob lists the global layers and those scoped to jobID, in any
+	// order and whatever their effective window.
+	ForJob(jobID string) ([]models.ApplicationPolicy, error)
+}
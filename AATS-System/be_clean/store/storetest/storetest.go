@@ -0,0 +1,458 @@
+// Package storetest is the conformance suite every store.Store must pass.
+// Each backend calls Run with a function that opens an empty, migrated
+// store.
+package storetest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/store"
+)
+
+// Run runs the suite; open is called once per subtest.
+func Run(t *testing.T, open func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s store.Store)
+	}{
+		{"Users", testUsers},
+		{"UserList", testUserList},
+		{"Jobs", testJobs},
+		{"Applications", testApplications},
+		{"ApplicationList", testApplicationList},
+		{"Timelines", testTimelines},
+		{"Notes", testNotes},
+		{"Evaluations", testEvaluations},
+		{"Policies", testPolicies},
+		{"Transaction", testTransaction},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) { tc.fn(t, open(t)) })
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func user(email, name string) *models.User {
+	return &models.User{ID: uuid.NewString(), Email: email, Password: "x", Role: "candidate", Name: name}
+}
+
+func testUsers(t *testing.T, s store.Store) {
+	u := user("somchai@example.com", "สมชาย ใจดี")
+	must(t, s.Users().Create(u))
+
+	got, err := s.Users().ByEmail("somchai@example.com")
+	must(t, err)
+	if got.ID != u.ID || got.Name != u.Name {
+		t.Errorf("ByEmail = %+v", got)
+	}
+	if got, err = s.Users().ByID(u.ID); err != nil || got.Email != u.Email {
+		t.Errorf("ByID = %+v, %v", got, err)
+	}
+	if _, err := s.Users().ByEmail("nobody@example.com"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("missing email: err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Users().ByID(uuid.NewString()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("missing id: err = %v, want ErrNotFound", err)
+	}
+	if err := s.Users().Create(user("somchai@example.com", "again")); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("duplicate email: err = %v, want ErrConflict", err)
+	}
+	if got, err := s.Users().ByIDs([]string{u.ID, uuid.NewString()}); err != nil || len(got) != 1 || got[0].ID != u.ID {
+		t.Errorf("ByIDs = %+v, %v", got, err)
+	}
+	if got, err := s.Users().ByIDs(nil); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("ByIDs(nil) = %v, %v; want an empty list", got, err)
+	}
+}
+
+func testUserList(t *testing.T, s store.Store) {
+	for _, u := range []*models.User{
+		user("anna@example.com", "Anna Lee"),
+		user("bob@example.com", "Bob Smith"),
+		user("carl@corp.example", "Carl ANNAN"),
+		user("d_x@example.com", "Percent 100%"),
+	} {
+		must(t, s.Users().Create(u))
+	}
+
+	all, total, err := s.Users().List("", store.Page{})
+	must(t, err)
+	if total != 4 || len(all) != 4 {
+		t.Errorf("List all: %d rows, total %d", len(all), total)
+	}
+
+	got, total, err := s.Users().List("ANN", store.Page{})
+	must(t, err)
+	if total != 2 || len(got) != 2 {
+		t.Errorf("List(ANN): %d rows, total %d, want 2 (case-insensitive, name or email)", len(got), total)
+	}
+
+	page, total, err := s.Users().List("example", store.Page{Offset: 1, Limit: 2})
+	must(t, err)
+	if total != 4 || len(page) != 2 {
+		t.Errorf("paged: %d rows, total %d, want 2 of 4", len(page), total)
+	}
+
+	// LIKE wildcards in the query are matched literally
+	if _, total, _ := s.Users().List("%", store.Page{}); total != 1 {
+		t.Errorf("List(%%): total %d, want 1", total)
+	}
+	if _, total, _ := s.Users().List("d_x", store.Page{}); total != 1 {
+		t.Errorf("List(d_x): total %d, want 1", total)
+	}
+}
+
+func testJobs(t *testing.T, s store.Store) {
+	base := time.Date(2026, 9, 1, 9, 0, 0, 0, time.UTC)
+	jobs := []models.JobPosting{
+		{Title: "Go Developer", Location: "Bangkok", EmploymentType: "full-time", Status: "active", Description: "Backend services"},
+		{Title: "Frontend Developer", Location: "Chiang Mai", EmploymentType: "contract", Status: "active", Description: "React"},
+		{Title: "นักพัฒนา Go", Location: "Bangkok", EmploymentType: "full-time", Status: "closed", Description: "golang"},
+		{Title: "Draft role", Location: "Bangkok", Status: "draft"},
+	}
+	for i := range jobs {
+		jobs[i].ID = uuid.NewString()
+		jobs[i].PostedDate = base.AddDate(0, 0, i)
+		must(t, s.Jobs().Create(&jobs[i]))
+	}
+
+	got, err := s.Jobs().ByID(jobs[0].ID)
+	must(t, err)
+	if got.Title != "Go Developer" || got.EmploymentType != "full-time" {
+		t.Errorf("ByID = %+v", got)
+	}
+	if _, err := s.Jobs().ByID(uuid.NewString()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("missing job: err = %v", err)
+	}
+
+	cases := []struct {
+		f    store.JobFilter
+		want []string // titles, most recently posted first
+	}{
+		{store.JobFilter{ExcludeStatus: "draft"}, []string{"นักพัฒนา Go", "Frontend Developer", "Go Developer"}},
+		{store.JobFilter{Query: "go"}, []string{"นักพัฒนา Go", "Go Developer"}},
+		{store.JobFilter{Query: "BACKEND"}, []string{"Go Developer"}},
+		{store.JobFilter{Location: "Bangkok", EmploymentType: "full-time"}, []string{"นักพัฒนา Go", "Go Developer"}},
+		{store.JobFilter{Statuses: []string{"active"}}, []string{"Frontend Developer", "Go Developer"}},
+		{store.JobFilter{Query: "นักพัฒนา"}, []string{"นักพัฒนา Go"}},
+	}
+	for _, tc := range cases {
+		list, total, err := s.Jobs().List(tc.f, store.Page{})
+		must(t, err)
+		if int(total) != len(tc.want) || len(list) != len(tc.want) {
+			t.Errorf("%+v: %d rows, total %d, want %v", tc.f, len(list), total, tc.want)
+			continue
+		}
+		for i := range list {
+			if list[i].Title != tc.want[i] {
+				t.Errorf("%+v: row %d = %q, want %q", tc.f, i, list[i].Title, tc.want[i])
+			}
+		}
+	}
+
+	page, total, err := s.Jobs().List(store.JobFilter{}, store.Page{Offset: 3, Limit: 2})
+	must(t, err)
+	if total != 4 || len(page) != 1 || page[0].Title != "Go Developer" {
+		t.Errorf("last page: %d rows, total %d", len(page), total)
+	}
+
+	// a store without custom values matches none and orders as usual
+	if _, total, _ := s.Jobs().List(store.JobFilter{Custom: []store.CustomMatch{{FieldID: "f", Op: store.CustomEquals, Text: "x"}}}, store.Page{}); total != 0 {
+		t.Errorf("custom filter: total %d, want 0", total)
+	}
+	if list, _, _ := s.Jobs().List(store.JobFilter{CustomOrder: &store.CustomOrder{FieldID: "f", Desc: true}}, store.Page{}); len(list) != 4 || list[0].Title != "Draft role" {
+		t.Errorf("custom order: %+v", list)
+	}
+
+	got.Title, got.Status = "Senior Go Developer", "closed"
+	must(t, s.Jobs().Save(&got))
+	byIDs, err := s.Jobs().ByIDs([]string{got.ID, jobs[1].ID, uuid.NewString()})
+	must(t, err)
+	if len(byIDs) != 2 {
+		t.Fatalf("ByIDs: %d jobs, want 2", len(byIDs))
+	}
+	for _, j := range byIDs {
+		if j.ID == got.ID && (j.Title != "Senior Go Developer" || j.Status != "closed" || j.Location != "Bangkok") {
+			t.Errorf("after Save: %+v", j)
+		}
+	}
+}
+
+func testApplications(t *testing.T, s store.Store) {
+	applicant, other := uuid.NewString(), uuid.NewString()
+	first := &models.Application{ID: uuid.NewString(), JobID: "j1", ApplicantID: applicant, Status: "submitted"}
+	must(t, s.Applications().Create(first))
+	time.Sleep(10 * time.Millisecond) // created_at orders the list
+	second := &models.Application{ID: uuid.NewString(), JobID: "j2", ApplicantID: applicant, Status: "submitted"}
+	must(t, s.Applications().Create(second))
+	must(t, s.Applications().Create(&models.Application{ID: uuid.NewString(), JobID: "j1", ApplicantID: other, Status: "submitted"}))
+
+	mine, err := s.Applications().ForApplicant(applicant)
+	must(t, err)
+	if len(mine) != 2 || mine[0].ID != second.ID || mine[1].ID != first.ID {
+		t.Errorf("ForApplicant: %+v", mine)
+	}
+	if none, err := s.Applications().ForApplicant(uuid.NewString()); err != nil || none == nil || len(none) != 0 {
+		t.Errorf("ForApplicant(nobody) = %v, %v; want an empty list", none, err)
+	}
+
+	first.Status = "interview"
+	must(t, s.Applications().Save(first))
+	got, err := s.Applications().ByID(first.ID)
+	must(t, err)
+	if got.Status != "interview" || got.ApplicantID != applicant {
+		t.Errorf("after Save: %+v", got)
+	}
+	if _, err := s.Applications().ByID(uuid.NewString()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("missing application: err = %v", err)
+	}
+	if err := s.Applications().Create(&models.Application{ID: first.ID}); !errors.Is(err, store.ErrConflict) {
+		t.Errorf("duplicate id: err = %v, want ErrConflict", err)
+	}
+}
+
+func testApplicationList(t *testing.T, s store.Store) {
+	day := time.Date(2026, 9, 1, 9, 0, 0, 0, time.UTC)
+	apps := []*models.Application{
+		{JobID: "j1", ApplicantID: "ann", Status: "submitted", CoverLetter: "I write Go", MatchScore: 50, Source: "linkedin"},
+		{JobID: "j1", ApplicantID: "bob", Status: "interview", Education: "BSc (100% scholarship)", MatchScore: 90},
+		{JobID: "j2", ApplicantID: "ann", Status: "submitted", Skills: `["golang"]`, MatchScore: 70, ReviewerID: "hr1"},
+		{JobID: "j2", ApplicantID: "cat", Status: "rejected", CoverLetter: "Python", MatchScore: 90, UTMCampaign: "fair"},
+	}
+	ids := make([]string, len(apps)) // newest first
+	for i, a := range apps {
+		a.ID, a.SubmittedDate, a.ScreeningOutcome = uuid.NewString(), day.AddDate(0, 0, i), "passed"
+		must(t, s.Applications().Create(a))
+		ids[len(apps)-1-i] = a.ID
+	}
+	a, b, c, d := ids[3], ids[2], ids[1], ids[0]
+
+	cases := []struct {
+		name string
+		f    store.ApplicationFilter
+		want []string
+	}{
+		{"all", store.ApplicationFilter{}, []string{d, c, b, a}},
+		{"applicant", store.ApplicationFilter{ApplicantID: "ann"}, []string{c, a}},
+		{"job and status", store.ApplicationFilter{JobID: "j2", Status: "submitted"}, []string{c}},
+		{"submitted range", store.ApplicationFilter{SubmittedFrom: day.AddDate(0, 0, 1), SubmittedBefore: day.AddDate(0, 0, 3)}, []string{c, b}},
+		{"reviewer", store.ApplicationFilter{ReviewerID: "hr1"}, []string{c}},
+		{"source and screening", store.ApplicationFilter{Source: "linkedin", ScreeningOutcome: "passed"}, []string{a}},
+		{"campaign", store.ApplicationFilter{UTMCampaign: "fair"}, []string{d}},
+		{"query any field, any case", store.ApplicationFilter{Query: "GO"}, []string{c, a}},
+		{"query wildcard is literal", store.ApplicationFilter{Query: "100%"}, []string{b}},
+		{"match score desc, newest first", store.ApplicationFilter{SortBy: "match_score"}, []string{d, b, c, a}},
+		{"match score asc", store.ApplicationFilter{SortBy: "match_score", Asc: true}, []string{a, c, d, b}},
+		{"oldest first", store.ApplicationFilter{Asc: true}, []string{a, b, c, d}},
+		{"custom order without values", store.ApplicationFilter{CustomOrder: &store.CustomOrder{FieldID: "f"}}, []string{d, c, b, a}},
+		{"custom filter without values", store.ApplicationFilter{Custom: []store.CustomMatch{{FieldID: "f", Op: store.CustomAnyOf, AnyOf: []string{"x"}}}}, nil},
+		{"tag nobody has", store.ApplicationFilter{Tag: "urgent"}, nil},
+	}
+	for _, tc := range cases {
+		list, err := s.Applications().List(tc.f, store.Page{})
+		must(t, err)
+		n, err := s.Applications().Count(tc.f)
+		must(t, err)
+		var got, each []string
+		for _, a := range list {
+			got = append(got, a.ID)
+		}
+		must(t, s.Applications().Each(tc.f, func(a models.Application) error { each = append(each, a.ID); return nil }))
+		if !slices.Equal(got, tc.want) || !slices.Equal(each, tc.want) || n != int64(len(tc.want)) {
+			t.Errorf("%s: List %v, Each %v, Count %d; want %v", tc.name, got, each, n, tc.want)
+		}
+	}
+
+	page, err := s.Applications().List(store.ApplicationFilter{Brief: true}, store.Page{Offset: 1, Limit: 2})
+	must(t, err)
+	if len(page) != 2 || page[0].ID != c || page[1].ID != b {
+		t.Fatalf("page 2: %+v", page)
+	}
+	if page[0].Skills != "" || page[1].Education != "" || page[0].ReviewerID != "hr1" || page[1].MatchScore != 90 || page[1].Status != "interview" {
+		t.Errorf("brief rows: %+v", page)
+	}
+
+	stop := errors.New("stop")
+	seen := 0
+	if err := s.Applications().Each(store.ApplicationFilter{}, func(models.Application) error { seen++; return stop }); !errors.Is(err, stop) || seen != 1 {
+		t.Errorf("Each after fn fails: %v, %d calls", err, seen)
+	}
+}
+
+func testTimelines(t *testing.T, s store.Store) {
+	app := uuid.NewString()
+	day := time.Date(2026, 9, 1, 9, 0, 0, 0, time.UTC)
+	for i, st := range []string{"submitted", "screening", "interview"} {
+		must(t, s.Timelines().Append(&models.ApplicationTimeline{
+			ID: uuid.NewString(), ApplicationID: app, Status: st, Date: day.AddDate(0, 0, i), Description: st,
+		}))
+	}
+	must(t, s.Timelines().Append(&models.ApplicationTimeline{ID: uuid.NewString(), ApplicationID: uuid.NewString(), Status: "submitted", Date: day}))
+
+	tls, err := s.Timelines().ForApplication(app)
+	must(t, err)
+	if len(tls) != 3 || tls[0].Status != "interview" || tls[2].Status != "submitted" {
+		t.Errorf("ForApplication: %+v", tls)
+	}
+
+	other := uuid.NewString()
+	must(t, s.Timelines().Append(&models.ApplicationTimeline{ID: uuid.NewString(), ApplicationID: other, Status: "hired", Date: day.AddDate(0, 0, 5)}))
+	tls, err = s.Timelines().ForApplications([]string{app, other})
+	must(t, err)
+	if len(tls) != 4 || tls[0].Status != "hired" || tls[1].Status != "interview" {
+		t.Errorf("ForApplications: %+v", tls)
+	}
+}
+
+func testNotes(t *testing.T, s store.Store) {
+	app := uuid.NewString()
+	must(t, s.Notes().Create(&models.Note{ID: uuid.NewString(), ApplicationID: app, Content: "first", Visibility: "hr"}))
+	time.Sleep(10 * time.Millisecond)
+	must(t, s.Notes().Create(&models.Note{ID: uuid.NewString(), ApplicationID: app, Content: "second", Visibility: "hr"}))
+
+	notes, err := s.Notes().ForApplication(app)
+	must(t, err)
+	if len(notes) != 2 || notes[0].Content != "second" {
+		t.Errorf("ForApplication: %+v", notes)
+	}
+}
+
+func testEvaluations(t *testing.T, s store.Store) {
+	app := uuid.NewString()
+	if _, err := s.Evaluations().ForApplication(app); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("no evaluation yet: err = %v", err)
+	}
+
+	e := &models.Evaluation{ID: uuid.NewString(), ApplicationID: app, EvaluatorID: "hm1", OverallScore: 3, Comments: "ok"}
+	created, err := s.Evaluations().Upsert(e)
+	must(t, err)
+	if !created {
+		t.Error("first Upsert should create")
+	}
+
+	again := &models.Evaluation{ID: uuid.NewString(), ApplicationID: app, EvaluatorID: "hm2", OverallScore: 4.5, Comments: "strong"}
+	created, err = s.Evaluations().Upsert(again)
+	must(t, err)
+	if created {
+		t.Error("second Upsert should update")
+	}
+	if again.ID != e.ID {
+		t.Errorf("Upsert loaded id %s, want the existing %s", again.ID, e.ID)
+	}
+
+	got, err := s.Evaluations().ForApplication(app)
+	must(t, err)
+	if got.ID != e.ID || got.EvaluatorID != "hm2" || got.OverallScore != 4.5 || got.Comments != "strong" {
+		t.Errorf("after update: %+v", got)
+	}
+
+	if evals, err := s.Evaluations().ForApplications([]string{app, uuid.NewString()}); err != nil || len(evals) != 1 || evals[0].ID != e.ID {
+		t.Errorf("ForApplications = %+v, %v", evals, err)
+	}
+}
+
+func testPolicies(t *testing.T, s store.Store) {
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range []*models.ApplicationPolicy{
+		{Scope: "global", Rules: `{"max_active_applications":3}`},
+		{Scope: "job", JobID: "j1", Rules: `{"hired_cooldown_months":1}`},
+		{Scope: "job", JobID: "j2", Rules: `{"hired_cooldown_months":2}`},
+	} {
+		p.ID, p.EffectiveFrom = uuid.NewString(), from
+		must(t, s.Policies().Create(p))
+	}
+
+	rows, err := s.Policies().ForJob("j1")
+	must(t, err)
+	if len(rows) != 2 {
+		t.Fatalf("ForJob(j1): %d layers, want global + j1", len(rows))
+	}
+	for _, r := range rows {
+		if r.Scope == "job" && r.JobID != "j1" {
+			t.Errorf("ForJob(j1) returned a layer of %s", r.JobID)
+		}
+	}
+	if rows, _ := s.Policies().ForJob("j3"); len(rows) != 1 || rows[0].Scope != "global" {
+		t.Errorf("ForJob(j3) = %+v, want only the global layer", rows)
+	}
+}
+
+func testTransaction(t *testing.T, s store.Store) {
+	u := user("tx@example.com", "Tx")
+	must(t, s.Users().Create(u))
+	app := func() *models.Application {
+		return &models.Application{ID: uuid.NewString(), JobID: "j1", ApplicantID: u.ID, Status: "submitted"}
+	}
+	count := func() int {
+		apps, err := s.Applications().ForApplicant(u.ID)
+		must(t, err)
+		return len(apps)
+	}
+
+	// committed: both rows stay
+	kept := app()
+	must(t, s.Transaction(func(tx store.Store) error {
+		if got, err := tx.Users().Lock(u.ID); err != nil || got.Email != u.Email {
+			t.Errorf("Lock(user) = %+v, %v", got, err)
+		}
+		if err := tx.Applications().Create(kept); err != nil {
+			return err
+		}
+		return tx.Timelines().Append(&models.ApplicationTimeline{ID: uuid.NewString(), ApplicationID: kept.ID, Status: "submitted", Date: time.Now()})
+	}))
+	if tls, _ := s.Timelines().ForApplication(kept.ID); count() != 1 || len(tls) != 1 {
+		t.Errorf("after commit: %d applications, %d timeline entries", count(), len(tls))
+	}
+
+	// failed: nothing stays, and fn's error comes back
+	boom := errors.New("boom")
+	dropped := app()
+	err := s.Transaction(func(tx store.Store) error {
+		must(t, tx.Applications().Create(dropped))
+		must(t, tx.Timelines().Append(&models.ApplicationTimeline{ID: uuid.NewString(), ApplicationID: dropped.ID, Status: "submitted", Date: time.Now()}))
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("Transaction = %v, want fn's error", err)
+	}
+	if tls, _ := s.Timelines().ForApplication(dropped.ID); count() != 1 || len(tls) != 0 {
+		t.Errorf("after rollback: %d applications, %d timeline entries", count(), len(tls))
+	}
+
+	// nested: a failed inner transaction drops only its own writes
+	outer, inner := app(), app()
+	must(t, s.Transaction(func(tx store.Store) error {
+		if got, err := tx.Applications().Lock(kept.ID); err != nil || got.ID != kept.ID {
+			t.Errorf("Lock(application) = %+v, %v", got, err)
+		}
+		if _, err := tx.Applications().Lock(uuid.NewString()); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Lock(missing) err = %v, want ErrNotFound", err)
+		}
+		must(t, tx.Applications().Create(outer))
+		if err := tx.Transaction(func(tx store.Store) error {
+			must(t, tx.Applications().Create(inner))
+			return boom
+		}); !errors.Is(err, boom) {
+			t.Errorf("nested Transaction = %v, want fn's error", err)
+		}
+		return nil
+	}))
+	if _, err := s.Applications().ByID(outer.ID); err != nil {
+		t.Errorf("outer write: %v", err)
+	}
+	if _, err := s.Applications().ByID(inner.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("rolled back inner write: err = %v", err)
+	}
+}
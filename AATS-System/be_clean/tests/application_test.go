@@ -1,14 +0,0 @@
-package models
-
-import (
-	"testing"
-)
-
-func TestApplicationStatus(t *testing.T) {
-	app := Application{
-		Status: "submitted",
-	}
-	if app.Status != "submitted" {
-		t.Errorf("Status should be submitted")
-	}
-}
@@ -0,0 +1,530 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/services"
+	"aats-backend-clean/store"
+	"aats-backend-clean/store/memstore"
+)
+
+// openSQLiteFile is a migrated SQLite database in a file of its own.
+// Concurrent transactions on it wait for each other's locks (busy_timeout);
+// on a shared-cache in-memory database they would be refused instead.
+func openSQLiteFile(t *testing.T) *gorm.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "aats.db")
+	db, err := gorm.Open(sqlite.Open("file:"+path+"?_pragma=busy_timeout(5000)"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := models.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// concurrentStores runs fn on an empty store of every kind: a SQLite file,
+// memory, and Postgres when TEST_DATABASE_URL is set.
+func concurrentStores(t *testing.T, fn func(t *testing.T, st store.Store)) {
+	t.Run("sqlite", func(t *testing.T) { fn(t, store.New(openSQLiteFile(t))) })
+	t.Run("memory", func(t *testing.T) { fn(t, memstore.New()) })
+	t.Run("postgres", func(t *testing.T) {
+		db := openPostgres(t)
+		emptyPostgres(t, db)
+		fn(t, store.New(db))
+	})
+}
+
+// race runs fn(0) … fn(n-1) at once and returns their errors by index.
+func race(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = fn(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+// codeCounts tallies errs by code, "" for successes.
+func codeCounts(errs []error) map[string]int {
+	n := map[string]int{}
+	for _, err := range errs {
+		n[code(err)]++
+	}
+	return n
+}
+
+// slowReads is a store whose application reads take a few milliseconds,
+// so racing transactions overlap between checking and writing.
+type slowReads struct{ store.Store }
+
+func (s slowReads) Transaction(fn func(tx store.Store) error) error {
+	return s.Store.Transaction(func(tx store.Store) error { return fn(slowReads{tx}) })
+}
+
+func (s slowReads) Applications() store.ApplicationRepo {
+	return slowApplications{s.Store.Applications()}
+}
+
+type slowApplications struct{ store.ApplicationRepo }
+
+const readDelay = 3 * time.Millisecond
+
+func (r slowApplications) ByID(id string) (models.Application, error) {
+	defer time.Sleep(readDelay)
+	return r.ApplicationRepo.ByID(id)
+}
+
+func (r slowApplications) Lock(id string) (models.Application, error) {
+	defer time.Sleep(readDelay)
+	return r.ApplicationRepo.Lock(id)
+}
+
+func (r slowApplications) ForApplicant(applicantID string) ([]models.Application, error) {
+	defer time.Sleep(readDelay)
+	return r.ApplicationRepo.ForApplicant(applicantID)
+}
+
+func candidate(t *testing.T, st store.Store, id string) {
+	t.Helper()
+	if err := st.Users().Create(&models.User{ID: id, Email: id + "@example.com", Name: id, Role: "candidate"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrentSubmitsHoldActiveCap(t *testing.T) {
+	concurrentStores(t, func(t *testing.T, st store.Store) {
+		candidate(t, st, "ann")
+		apps := services.NewApplicationService(slowReads{st})
+
+		errs := race(20, func(i int) error {
+			_, err := apps.Submit(&models.Application{ApplicantID: "ann", JobID: fmt.Sprintf("job-%02d", i)})
+			return err
+		})
+		if got := codeCounts(errs); got[""] != 5 || got["APPLICATION_LIMIT_REACHED"] != 15 {
+			t.Errorf("20 concurrent submits: %v, want 5 saved and 15 APPLICATION_LIMIT_REACHED", got)
+		}
+		saved, err := st.Applications().ForApplicant("ann")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(saved) != 5 {
+			t.Errorf("%d applications saved, want 5", len(saved))
+		}
+		for _, app := range saved {
+			if tls, _ := st.Timelines().ForApplication(app.ID); len(tls) != 1 {
+				t.Errorf("%s has %d timeline entries, want 1", app.ID, len(tls))
+			}
+		}
+	})
+}
+
+func TestConcurrentSubmitsToOneJob(t *testing.T) {
+	concurrentStores(t, func(t *testing.T, st store.Store) {
+		candidate(t, st, "ann")
+		apps := services.NewApplicationService(slowReads{st})
+
+		errs := race(10, func(int) error {
+			_, err := apps.Submit(&models.Application{ApplicantID: "ann", JobID: "job-a"})
+			return err
+		})
+		if got := codeCounts(errs); got[""] != 1 || got["APPLICATION_ALREADY_ACTIVE"] != 9 {
+			t.Errorf("10 concurrent submits to one job: %v, want 1 saved and 9 APPLICATION_ALREADY_ACTIVE", got)
+		}
+	})
+}
+
+func TestConcurrentStatusChangesKeepHistory(t *testing.T) {
+	concurrentStores(t, func(t *testing.T, st store.Store) {
+		candidate(t, st, "ann")
+		apps := services.NewApplicationService(slowReads{st})
+		app := models.Application{ApplicantID: "ann", JobID: "job-a"}
+		if _, err := apps.Submit(&app); err != nil {
+			t.Fatal(err)
+		}
+
+		statuses := []string{"screening", "interview", "reviewing"}
+		errs := race(12, func(i int) error {
+			_, err := apps.ChangeStatus(app.ID, statuses[i%len(statuses)], i18n.Msg{})
+			return err
+		})
+		if got := codeCounts(errs); got[""] != 12 {
+			t.Fatalf("12 concurrent status changes: %v", got)
+		}
+		tls, err := st.Timelines().ForApplication(app.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cur, err := st.Applications().ByID(app.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tls) != 13 || tls[0].Status != cur.Status {
+			t.Errorf("%d timeline entries (want 13), newest %q, application %q", len(tls), tls[0].Status, cur.Status)
+		}
+	})
+}
+
+// failingTimelines is a store whose timeline appends fail, in and out of
+// transactions.
+type failingTimelines struct{ store.Store }
+
+func (s failingTimelines) Transaction(fn func(tx store.Store) error) error {
+	return s.Store.Transaction(func(tx store.Store) error { return fn(failingTimelines{tx}) })
+}
+
+func (s failingTimelines) Timelines() store.TimelineRepo {
+	return failingTimelineRepo{s.Store.Timelines()}
+}
+
+type failingTimelineRepo struct{ store.TimelineRepo }
+
+var errAppend = errors.New("append failed")
+
+func (failingTimelineRepo) Append(*models.ApplicationTimeline) error { return errAppend }
+
+func TestFailedTimelineRollsBackApplication(t *testing.T) {
+	concurrentStores(t, func(t *testing.T, st store.Store) {
+		candidate(t, st, "ann")
+		apps := services.NewApplicationService(st)
+		app := models.Application{ApplicantID: "ann", JobID: "job-a"}
+		if _, err := apps.Submit(&app); err != nil {
+			t.Fatal(err)
+		}
+
+		broken := services.NewApplicationService(failingTimelines{st})
+		if _, err := broken.Submit(&models.Application{ApplicantID: "ann", JobID: "job-b"}); !errors.Is(err, errAppend) {
+			t.Errorf("submit: %v", err)
+		}
+		if saved, _ := st.Applications().ForApplicant("ann"); len(saved) != 1 {
+			t.Errorf("%d applications saved, want only the first", len(saved))
+		}
+		if _, err := broken.ChangeStatus(app.ID, "screening", i18n.Msg{}); !errors.Is(err, errAppend) {
+			t.Errorf("status change: %v", err)
+		}
+		if cur, _ := st.Applications().ByID(app.ID); cur.Status != "submitted" {
+			t.Errorf("status %q after the failed change, want submitted", cur.Status)
+		}
+	})
+}
+
+// concurrentDBs runs fn on an empty database of every kind the handlers
+// run on: a SQLite file, and Postgres when TEST_DATABASE_URL is set.
+func concurrentDBs(t *testing.T, fn func(t *testing.T, db *gorm.DB)) {
+	t.Run("sqlite", func(t *testing.T) { fn(t, openSQLiteFile(t)) })
+	t.Run("postgres", func(t *testing.T) {
+		db := openPostgres(t)
+		emptyPostgres(t, db)
+		fn(t, db)
+	})
+}
+
+// send is a request for goroutines (no t): the status and the problem code,
+// "" on success.
+func (e *apiEnv) send(method, path, token, body string) (int, string) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+	e.r.ServeHTTP(w, req)
+	var out struct {
+		Code string `json:"code"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	return w.Code, out.Code
+}
+
+// slowQueries makes db's reads of tables take a few milliseconds, like
+// slowReads for the handlers' own queries.
+func slowQueries(t *testing.T, db *gorm.DB, tables ...string) {
+	t.Helper()
+	err := db.Callback().Query().After("gorm:query").Register("tests:slow", func(tx *gorm.DB) {
+		if slices.Contains(tables, tx.Statement.Table) {
+			time.Sleep(readDelay)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// outcome is a send result as "<status> <code>".
+func outcome(status int, code string) string { return fmt.Sprintf("%d %s", status, code) }
+
+// TestConcurrentApplicationsOverHTTP submits through the handler, which
+// also writes the screening answers, custom fields and pool profile in the
+// same transaction.
+func TestConcurrentApplicationsOverHTTP(t *testing.T) {
+	concurrentDBs(t, func(t *testing.T, db *gorm.DB) {
+		e := newAPIEnvOn(t, db)
+		ann := e.user("ann", "candidate")
+		jobs := e.jobs(12)
+
+		codes := make([]string, len(jobs))
+		race(len(jobs), func(i int) error {
+			codes[i] = outcome(e.send("POST", "/api/applications", ann, `{"job_id":"`+jobs[i]+`"}`))
+			return nil
+		})
+		created := 0
+		for _, c := range codes {
+			switch c {
+			case "201 ":
+				created++
+			case "400 APPLICATION_LIMIT_REACHED":
+			default:
+				t.Errorf("unexpected response %s", c)
+			}
+		}
+		if created != 5 {
+			t.Errorf("%d applications created, want 5", created)
+		}
+		var n, tls int64
+		models.DB.Model(&models.Application{}).Where("applicant_id = ?", "ann").Count(&n)
+		models.DB.Model(&models.ApplicationTimeline{}).Count(&tls)
+		if n != 5 || tls != 5 {
+			t.Errorf("%d applications and %d timeline entries saved, want 5 each", n, tls)
+		}
+	})
+}
+
+// rounds is how often each two-request race is run; one round rarely
+// interleaves.
+const rounds = 8
+
+// offerStage is an application of a new candidate at the offer stage, with
+// the hiring manager's evaluation, and (when sent) a sent offer: the
+// candidate's token, the application and the offer.
+func (e *apiEnv) offerStage(name, job string, sent bool) (token, appID, offerID string) {
+	e.t.Helper()
+	token = e.user(name, "candidate")
+	appID = e.mustApply(token, job)
+	ev := models.Evaluation{ID: "ev-" + name, ApplicationID: appID, EvaluatorID: "hm1", TechnicalSkills: 4, Communication: 4, ProblemSolving: 4, CulturalFit: 4, OverallScore: 4}
+	if err := models.DB.Create(&ev).Error; err != nil {
+		e.t.Fatal(err)
+	}
+	e.mustSetStatus(appID, "interview")
+	if !sent {
+		return token, appID, ""
+	}
+	e.mustSetStatus(appID, "offer")
+	now := time.Now()
+	offer := models.Offer{ID: "offer-" + name, ApplicationID: appID, Status: "sent", SentAt: &now, ExpiresAt: now.Add(24 * time.Hour), CreatedBy: "hr1"}
+	if err := models.DB.Create(&offer).Error; err != nil {
+		e.t.Fatal(err)
+	}
+	return token, appID, offer.ID
+}
+
+// history checks the application ended in want, with want in its timeline
+// and lost (the change that was refused) not.
+func (e *apiEnv) history(appID, want, lost string) {
+	e.t.Helper()
+	var app models.Application
+	models.DB.Where("id = ?", appID).First(&app)
+	var wants, losts int64
+	models.DB.Model(&models.ApplicationTimeline{}).Where("application_id = ? AND status = ?", appID, want).Count(&wants)
+	models.DB.Model(&models.ApplicationTimeline{}).Where("application_id = ? AND status = ?", appID, lost).Count(&losts)
+	if app.Status != want || wants == 0 || losts != 0 {
+		e.t.Errorf("%s: status %q, %d %q and %d %q timeline entries, want %q and no %q", appID, app.Status, wants, want, losts, lost, want, lost)
+	}
+}
+
+func offerStatus(id string) string {
+	var o models.Offer
+	models.DB.Where("id = ?", id).First(&o)
+	return o.Status
+}
+
+// TestConcurrentWithdrawAndHire races the candidate withdrawing against HR
+// hiring: one of them wins and the other is refused, never both applied.
+func TestConcurrentWithdrawAndHire(t *testing.T) {
+	concurrentDBs(t, func(t *testing.T, db *gorm.DB) {
+		e := newAPIEnvOn(t, db)
+		job := e.jobs(1)[0]
+		slowQueries(t, db, "applications", "offers")
+		for i := range rounds {
+			token, app, _ := e.offerStage(fmt.Sprint("c", i), job, false)
+			var withdraw, hire string
+			race(2, func(j int) error {
+				if j == 0 {
+					withdraw = outcome(e.send("POST", "/api/applications/"+app+"/withdraw", token, `{}`))
+				} else {
+					hire = outcome(e.send("PATCH", "/api/applications/"+app+"/status", e.hr, `{"status":"hired"}`))
+				}
+				return nil
+			})
+			switch {
+			case withdraw == "200 " && hire == "400 APPLICATION_CLOSED":
+				e.history(app, "withdrawn", "hired")
+			case hire == "200 " && withdraw == "400 APPLICATION_CLOSED":
+				e.history(app, "hired", "withdrawn")
+			default:
+				t.Errorf("round %d: withdraw %q, hire %q", i, withdraw, hire)
+			}
+		}
+	})
+}
+
+// TestConcurrentOfferAcceptAndWithdraw races the candidate accepting an
+// offer against HR withdrawing it.
+func TestConcurrentOfferAcceptAndWithdraw(t *testing.T) {
+	concurrentDBs(t, func(t *testing.T, db *gorm.DB) {
+		e := newAPIEnvOn(t, db)
+		job := e.jobs(1)[0]
+		slowQueries(t, db, "applications", "offers")
+		for i := range rounds {
+			token, app, offer := e.offerStage(fmt.Sprint("c", i), job, true)
+			var accept, withdraw string
+			race(2, func(j int) error {
+				if j == 0 {
+					accept = outcome(e.send("POST", "/api/offers/"+offer+"/respond", token, `{"decision":"accept"}`))
+				} else {
+					withdraw = outcome(e.send("POST", "/api/offers/"+offer+"/withdraw", e.hr, `{}`))
+				}
+				return nil
+			})
+			switch {
+			case accept == "200 " && withdraw == "400 OFFER_CLOSED":
+				e.history(app, "hired", "withdrawn")
+				if s := offerStatus(offer); s != "accepted" {
+					t.Errorf("round %d: offer %q after the accept won", i, s)
+				}
+			case withdraw == "200 " && accept == "409 OFFER_CLOSED":
+				e.history(app, "offer", "hired")
+				if s := offerStatus(offer); s != "withdrawn" {
+					t.Errorf("round %d: offer %q after the withdrawal won", i, s)
+				}
+			default:
+				t.Errorf("round %d: accept %q, withdraw %q", i, accept, withdraw)
+			}
+		}
+	})
+}
+
+// TestConcurrentOfferAcceptAndApplicationWithdraw races accepting an offer
+// against withdrawing the application it belongs to.
+func TestConcurrentOfferAcceptAndApplicationWithdraw(t *testing.T) {
+	concurrentDBs(t, func(t *testing.T, db *gorm.DB) {
+		e := newAPIEnvOn(t, db)
+		job := e.jobs(1)[0]
+		slowQueries(t, db, "applications", "offers")
+		for i := range rounds {
+			token, app, offer := e.offerStage(fmt.Sprint("c", i), job, true)
+			var accept, withdraw string
+			race(2, func(j int) error {
+				if j == 0 {
+					accept = outcome(e.send("POST", "/api/offers/"+offer+"/respond", token, `{"decision":"accept"}`))
+				} else {
+					withdraw = outcome(e.send("POST", "/api/applications/"+app+"/withdraw", token, `{}`))
+				}
+				return nil
+			})
+			switch {
+			case accept == "200 " && withdraw == "400 APPLICATION_CLOSED":
+				e.history(app, "hired", "withdrawn")
+			case withdraw == "200 " && accept == "409 OFFER_CLOSED":
+				e.history(app, "withdrawn", "hired")
+				if s := offerStatus(offer); s != "withdrawn" {
+					t.Errorf("round %d: offer %q after the withdrawal won", i, s)
+				}
+			default:
+				t.Errorf("round %d: accept %q, withdraw %q", i, accept, withdraw)
+			}
+		}
+	})
+}
+
+// TestConcurrentOfferEditsAndWithdraw races HR submitting or editing a
+// draft offer against withdrawing it: a withdrawn offer stays withdrawn.
+func TestConcurrentOfferEditsAndWithdraw(t *testing.T) {
+	concurrentDBs(t, func(t *testing.T, db *gorm.DB) {
+		e := newAPIEnvOn(t, db)
+		job := e.jobs(1)[0]
+		slowQueries(t, db, "applications", "offers", "offer_approvals")
+		edit := map[bool]string{
+			false: `{}`,
+			true:  `{"salary":50000,"start_date":"2030-01-01","expires_at":"2030-01-01"}`,
+		}
+		for i := range rounds {
+			_, app, _ := e.offerStage(fmt.Sprint("c", i), job, false)
+			offer := models.Offer{ID: fmt.Sprint("offer-c", i), ApplicationID: app, Status: "draft", ExpiresAt: time.Now().Add(24 * time.Hour), CreatedBy: "hr1"}
+			if err := models.DB.Create(&offer).Error; err != nil {
+				t.Fatal(err)
+			}
+			update := i%2 == 1
+			var change, withdraw string
+			race(2, func(j int) error {
+				switch {
+				case j == 1:
+					withdraw = outcome(e.send("POST", "/api/offers/"+offer.ID+"/withdraw", e.hr, `{}`))
+				case update:
+					change = outcome(e.send("PUT", "/api/offers/"+offer.ID, e.hr, edit[update]))
+				default:
+					change = outcome(e.send("POST", "/api/offers/"+offer.ID+"/submit", e.hr, edit[update]))
+				}
+				return nil
+			})
+			if withdraw != "200 " || (change != "200 " && change != "400 OFFER_NOT_DRAFT" && change != "400 OFFER_NOT_EDITABLE") {
+				t.Errorf("round %d: change %q, withdraw %q", i, change, withdraw)
+			}
+			if s := offerStatus(offer.ID); s != "withdrawn" {
+				t.Errorf("round %d: offer %q after the withdrawal", i, s)
+			}
+		}
+	})
+}
+
+// TestConcurrentReferralsOfOneCandidate submits the same referral twice at
+// once: the unique index lets only one through.
+func TestConcurrentReferralsOfOneCandidate(t *testing.T) {
+	concurrentDBs(t, func(t *testing.T, db *gorm.DB) {
+		e := newAPIEnvOn(t, db)
+		jobs := e.jobs(rounds)
+		emp := e.user("emp", "employee")
+		slowQueries(t, db, "referrals")
+		for i, job := range jobs {
+			codes := make([]string, 2)
+			race(2, func(j int) error {
+				email := []string{"ann@example.com", "ANN@example.com"}[j]
+				codes[j] = outcome(e.send("POST", "/api/referrals", emp, `{"job_id":"`+job+`","candidate_name":"Ann","candidate_email":"`+email+`"}`))
+				return nil
+			})
+			slices.Sort(codes)
+			if codes[0] != "201 " || codes[1] != "409 REFERRAL_DUPLICATE" {
+				t.Errorf("round %d: %v", i, codes)
+			}
+			if n := count(t, &models.Referral{}, "job_id = ?", job); n != 1 {
+				t.Errorf("round %d: %d referrals saved", i, n)
+			}
+		}
+	})
+}
@@ -2,15 +2,24 @@ package tests
 
 import (
 	"testing"
-	"AATS-System/be_clean/config"
+
+	"aats-backend-clean/config"
 )
 
 func TestLoadConfig(t *testing.T) {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		t.Fatalf("failed to load config: %v", err)
+	t.Setenv("PORT", "")
+	t.Setenv("DATABASE_URL", "postgres://aats@localhost/aats")
+	t.Setenv("JWT_SECRET", "s3cret")
+	cfg := config.Load()
+	if cfg.Port != "8081" {
+		t.Errorf("default port: got %q, want 8081", cfg.Port)
+	}
+	if cfg.DatabaseURL != "postgres://aats@localhost/aats" || cfg.JWTSecret != "s3cret" {
+		t.Errorf("env not read: %+v", cfg)
 	}
-	if cfg.DatabaseURL == "" {
-		t.Errorf("DatabaseURL should not be empty")
+
+	t.Setenv("PORT", "9000")
+	if got := config.Load().Port; got != "9000" {
+		t.Errorf("PORT: got %q, want 9000", got)
 	}
 }
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"aats-backend-clean/models"
+)
+
+func TestAnalyticsTrends(t *testing.T) {
+	e := newAPIEnv(t)
+	day := func(s string) *time.Time {
+		d, err := time.Parse("2006-01-02 15:04", s+" 10:00")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+	for _, f := range []models.AnalyticsApplicationFact{
+		{ApplicationID: "a", JobID: "j1", SubmittedDate: *day("2024-01-03"), HiredAt: day("2024-01-16")},    // Wed, hired on a Tue
+		{ApplicationID: "b", JobID: "j1", SubmittedDate: *day("2024-01-08"), RejectedAt: day("2024-01-14")}, // Mon, rejected on a Sun
+		{ApplicationID: "c", JobID: "j2", SubmittedDate: *day("2023-12-31")},
+		{ApplicationID: "d", JobID: "j1", SubmittedDate: *day("2024-02-01")},
+	} {
+		if err := models.DB.Create(&f).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for query, want := range map[string]string{
+		"bucket=week&from=2024-01-01&to=2024-01-31": "2024-01-01 1/0/0, 2024-01-08 1/0/1, 2024-01-15 0/1/0",
+		"bucket=month":               "2023-12-01 1/0/0, 2024-01-01 2/1/1, 2024-02-01 1/0/0",
+		"bucket=day&job_id=j2":       "2023-12-31 1/0/0",
+		"bucket=day&from=2024-01-15": "2024-01-16 0/1/0, 2024-02-01 1/0/0",
+	} {
+		status, out := call(t, e.r, "GET", "/api/analytics/trends?"+query, e.hr, nil)
+		if status != http.StatusOK {
+			t.Fatalf("%s: %d %v", query, status, out)
+		}
+		got := ""
+		for i, p := range out["series"].([]any) {
+			p := p.(map[string]any)
+			if i > 0 {
+				got += ", "
+			}
+			got += fmt.Sprintf("%s %v/%v/%v", p["bucket"], p["applications"], p["hired"], p["rejected"])
+		}
+		if got != want {
+			t.Errorf("%s:\n got %s\nwant %s", query, got, want)
+		}
+	}
+}
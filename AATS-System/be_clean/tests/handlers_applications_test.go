@@ -1,17 +1,231 @@
 package tests
 
 import (
+	"encoding/csv"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
-	"AATS-System/be_clean/handlers"
+
+	"aats-backend-clean/models"
 )
 
-func TestApplicationsHandler(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/applications", nil)
+// listed is the ids of GET path's applications as the token's user.
+func (e *apiEnv) listed(token, path string) []string {
+	e.t.Helper()
+	status, out := call(e.t, e.r, "GET", path, token, nil)
+	if status != http.StatusOK {
+		e.t.Fatalf("GET %s: %d %v", path, status, out)
+	}
+	var ids []string
+	apps, _ := out["apps"].([]any) // null when nothing matches
+	for _, a := range apps {
+		ids = append(ids, a.(map[string]any)["application"].(map[string]any)["ID"].(string))
+	}
+	return ids
+}
+
+func TestListApplicationsSearch(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	ann := e.applyWith(e.user("ann", "candidate"), map[string]any{"job_id": jobs[0], "cover_letter": "I build Go services", "skills": "SQL"})
+	bob := e.applyWith(e.user("bob", "candidate"), map[string]any{"job_id": jobs[0], "cover_letter": "Python and 100% uptime", "education": "BSc Golang studies"})
+
+	for q, want := range map[string][]string{
+		"go":     {ann, bob}, // cover letter and education, any case
+		"PYTHON": {bob},
+		"sql":    {ann},
+		"%":      {bob}, // a literal percent sign, not a wildcard
+		"rust":   nil,
+	} {
+		got := e.listed(e.hr, "/api/applications?q="+strings.ReplaceAll(q, "%", "%25"))
+		if !sameIDs(got, want) {
+			t.Errorf("q=%s: %v, want %v", q, got, want)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/applications/export?format=csv&q=python", nil)
+	req.Header.Set("Authorization", e.hr)
+	w := httptest.NewRecorder()
+	e.r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), bob) || strings.Contains(w.Body.String(), ann) {
+		t.Errorf("export q=python: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestExportQuotesFormulas(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	e.applyWith(e.user("eve", "candidate"), map[string]any{"job_id": jobs[0]})
+	models.DB.Model(&models.User{}).Where("id = ?", "eve").Update("name", `=HYPERLINK("http://evil.example","Ann")`)
+
+	req := httptest.NewRequest("GET", "/api/applications/export?format=csv", nil)
+	req.Header.Set("Authorization", e.hr)
 	w := httptest.NewRecorder()
-	handlers.ApplicationsHandler(w, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+	e.r.ServeHTTP(w, req)
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(w.Body.String(), "\ufeff"))).ReadAll()
+	if w.Code != http.StatusOK || err != nil || len(rows) != 2 {
+		t.Fatalf("export: %d %v %s", w.Code, err, w.Body.String())
+	}
+	if !slices.Contains(rows[1], `'=HYPERLINK("http://evil.example","Ann")`) {
+		t.Errorf("formula not quoted: %q", rows[1])
+	}
+}
+
+func TestListApplicationsCustomFields(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	field := map[string]any{"entity": "application", "key": "years", "type": "number"}
+	if status, out := call(t, e.r, "POST", "/api/custom-fields", e.hr, field); status != http.StatusCreated {
+		t.Fatalf("create field: %d %v", status, out)
+	}
+	ann := e.applyWith(e.user("ann", "candidate"), map[string]any{"job_id": jobs[0], "custom_fields": map[string]any{"years": 2}})
+	bob := e.applyWith(e.user("bob", "candidate"), map[string]any{"job_id": jobs[0], "custom_fields": map[string]any{"years": 5}})
+	cat := e.applyWith(e.user("cat", "candidate"), map[string]any{"job_id": jobs[0]})
+
+	for query, want := range map[string][]string{
+		"cf.years=2":       {ann},
+		"cf.years.min=3":   {bob},
+		"cf.years.max=5":   {ann, bob},
+		"cf.years.max=abc": nil,
+		"cf.unknown=1":     nil, // a field that does not exist matches nothing
+	} {
+		if got := e.listed(e.hr, "/api/applications?"+query); !sameIDs(got, want) {
+			t.Errorf("%s: %v, want %v", query, got, want)
+		}
+	}
+	// records without a value sort last either way
+	if got := e.listed(e.hr, "/api/applications?sort=cf.years&order=asc"); !slices.Equal(got, []string{ann, bob, cat}) {
+		t.Errorf("sort asc: %v", got)
+	}
+	if got := e.listed(e.hr, "/api/applications?sort=cf.years"); !slices.Equal(got, []string{bob, ann, cat}) {
+		t.Errorf("sort desc: %v", got)
+	}
+}
+
+func TestGetApplicationOwnOnly(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	ann := e.user("ann", "candidate")
+	app := e.applyWith(ann, map[string]any{"job_id": jobs[0]})
+
+	for _, tc := range []struct {
+		token string
+		want  int
+	}{{ann, http.StatusOK}, {e.hr, http.StatusOK}, {e.user("bob", "candidate"), http.StatusForbidden}} {
+		if status, out := call(t, e.r, "GET", "/api/applications/"+app, tc.token, nil); status != tc.want {
+			t.Errorf("GET as %s: %d %v, want %d", tc.token, status, out, tc.want)
+		}
+	}
+	status, out := call(t, e.r, "GET", "/api/applications/"+app, ann, nil)
+	if tl, _ := out["timeline"].([]any); status != http.StatusOK || len(tl) != 1 || out["job"] == nil || out["applicant"] == nil {
+		t.Errorf("own application: %v", out)
+	}
+	if status, _ := call(t, e.r, "GET", "/api/applications/missing", e.hr, nil); status != http.StatusNotFound {
+		t.Errorf("missing application: %d", status)
+	}
+}
+
+func TestScreeningOutcomeHiddenFromCandidates(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	q := models.ScreeningQuestion{ID: "q1", JobID: jobs[0], Position: 1, Prompt: "Years of Go?", Type: "numeric",
+		KnockoutAction: "flag", KnockoutOperator: "lt", KnockoutValue: "2"}
+	if err := models.DB.Create(&q).Error; err != nil {
+		t.Fatal(err)
+	}
+	ann := e.user("ann", "candidate")
+	status, out := call(t, e.r, "POST", "/api/applications", ann, map[string]any{
+		"job_id": jobs[0], "screening_answers": []map[string]any{{"question_id": "q1", "value": 1}},
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("apply: %d %v", status, out)
+	}
+	app := out["application"].(map[string]any)
+	id := app["ID"].(string)
+
+	// decided is what the knockout rules decided as a response shows it
+	decided := func(app map[string]any, answers any) (outcome, knockout string) {
+		outcome, _ = app["ScreeningOutcome"].(string)
+		if list, _ := answers.([]any); len(list) == 1 {
+			knockout, _ = list[0].(map[string]any)["Knockout"].(string)
+		}
+		return outcome, knockout
+	}
+	screening := out["screening"].(map[string]any)
+	if o, k := decided(app, screening["answers"]); o != "" || k != "" || screening["outcome"] != "" {
+		t.Errorf("apply response shows outcome %q, knockout %q", o, k)
+	}
+	_, own := call(t, e.r, "GET", "/api/applications/"+id, ann, nil)
+	if o, k := decided(own["application"].(map[string]any), own["screening"]); o != "" || k != "" {
+		t.Errorf("candidate's GET shows outcome %q, knockout %q", o, k)
+	}
+	_, list := call(t, e.r, "GET", "/api/applications?include_details=true", ann, nil)
+	item := list["apps"].([]any)[0].(map[string]any)
+	if o, k := decided(item["application"].(map[string]any), item["raw"].(map[string]any)["screening"]); o != "" || k != "" {
+		t.Errorf("candidate's list shows outcome %q, knockout %q", o, k)
+	}
+	_, staff := call(t, e.r, "GET", "/api/applications/"+id, e.hr, nil)
+	if o, k := decided(staff["application"].(map[string]any), staff["screening"]); o != "flagged" || k != "flag" {
+		t.Errorf("HR's GET shows outcome %q, knockout %q", o, k)
+	}
+}
+
+// sameIDs compares id lists ignoring order.
+func sameIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := map[string]int{}
+	for _, id := range a {
+		seen[id]++
+	}
+	for _, id := range b {
+		if seen[id]--; seen[id] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMatchScoreHiddenFromCandidates(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	ann := e.user("ann", "candidate")
+	id := e.mustApply(ann, jobs[0])
+	models.DB.Model(&models.Application{}).Where("id = ?", id).
+		Updates(map[string]any{"match_score": 40, "match_breakdown": `{"score":40,"missing_must_have":["go"]}`})
+
+	// shown is which of the match fields a response carries
+	shown := func(m map[string]any) (fields []string) {
+		for _, f := range []string{"match_score", "match_breakdown", "MatchScore", "MatchBreakdown"} {
+			if _, ok := m[f]; ok {
+				fields = append(fields, f)
+			}
+		}
+		return fields
+	}
+	for _, who := range []struct {
+		name, token string
+		want        int
+	}{{"candidate", ann, 0}, {"hr", e.hr, 2}} {
+		_, one := call(t, e.r, "GET", "/api/applications/"+id, who.token, nil)
+		_, list := call(t, e.r, "GET", "/api/applications", who.token, nil)
+		item := list["apps"].([]any)[0].(map[string]any)
+		if got := shown(one); len(got) != who.want || len(shown(one["application"].(map[string]any))) != 0 {
+			t.Errorf("GET as %s: %v", who.name, got)
+		}
+		if got := shown(item["meta"].(map[string]any)); len(got) != who.want {
+			t.Errorf("list meta as %s: %v", who.name, got)
+		}
+	}
+	_, list := call(t, e.r, "GET", "/api/applications", e.hr, nil)
+	if score := list["apps"].([]any)[0].(map[string]any)["meta"].(map[string]any)["match_score"]; score != float64(40) {
+		t.Errorf("HR list score %v", score)
+	}
+	_, profile := call(t, e.r, "GET", "/api/candidates/ann", e.hr, nil)
+	if apps, _ := profile["applications"].([]any); len(apps) != 1 || apps[0].(map[string]any)["match_score"] != float64(40) {
+		t.Errorf("candidate profile applications: %v", profile["applications"])
 	}
 }
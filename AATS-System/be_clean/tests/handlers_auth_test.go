@@ -2,16 +2,60 @@ package tests
 
 import (
 	"net/http"
-	"net/http/httptest"
 	"testing"
-	"AATS-System/be_clean/handlers"
+
+	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/handlers"
+	"aats-backend-clean/middleware"
+	"aats-backend-clean/store/memstore"
 )
 
-func TestAuthHandler(t *testing.T) {
-	req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
-	w := httptest.NewRecorder()
-	handlers.AuthHandler(w, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+func authRouter(t *testing.T) *gin.Engine {
+	useServices(t, nil, memstore.New(), newClock())
+	r := gin.New()
+	r.POST("/api/auth/register", handlers.Register)
+	r.POST("/api/auth/login", handlers.Login)
+	r.GET("/api/auth/me", middleware.AuthMiddleware(), handlers.Me)
+	return r
+}
+
+func TestAuthRegisterLoginMe(t *testing.T) {
+	r := authRouter(t)
+	// staff accounts skip duplicate detection, which needs the database
+	reg := map[string]any{"email": "hr@example.com", "password": "secret1", "name": "Hana", "role": "hr"}
+	code, out := call(t, r, "POST", "/api/auth/register", "", reg)
+	if code != http.StatusCreated {
+		t.Fatalf("register: %d %v", code, out)
+	}
+	if code, out := call(t, r, "POST", "/api/auth/register", "", reg); code != http.StatusConflict || out["code"] != "EMAIL_TAKEN" {
+		t.Errorf("second register: %d %v", code, out)
+	}
+
+	if code, out := call(t, r, "POST", "/api/auth/login", "", map[string]any{"email": "hr@example.com", "password": "wrong!"}); code != http.StatusUnauthorized || out["code"] != "INVALID_CREDENTIALS" {
+		t.Errorf("wrong password: %d %v", code, out)
+	}
+	code, out = call(t, r, "POST", "/api/auth/login", "", map[string]any{"email": "hr@example.com", "password": "secret1"})
+	if code != http.StatusOK {
+		t.Fatalf("login: %d %v", code, out)
+	}
+	token, _ := out["token"].(string)
+
+	code, out = call(t, r, "GET", "/api/auth/me", "Bearer "+token, nil)
+	if code != http.StatusOK {
+		t.Fatalf("me: %d %v", code, out)
+	}
+	if u := out["user"].(map[string]any); u["email"] != "hr@example.com" || u["role"] != "hr" || u["name"] != "Hana" {
+		t.Errorf("me: %v", u)
+	}
+}
+
+func TestAuthMeRequiresToken(t *testing.T) {
+	r := authRouter(t)
+	if code, out := call(t, r, "GET", "/api/auth/me", "", nil); code != http.StatusUnauthorized || out["code"] != "AUTH_HEADER_MISSING" {
+		t.Errorf("no header: %d %v", code, out)
+	}
+	if code, out := call(t, r, "GET", "/api/auth/me", "Bearer nope", nil); code != http.StatusUnauthorized || out["code"] != "TOKEN_INVALID" {
+		t.Errorf("bad token: %d %v", code, out)
 	}
 }
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"aats-backend-clean/handlers"
+	"aats-backend-clean/models"
+)
+
+// bulkDone waits for the runner to finish bulk job id and returns the job
+// as GET /api/bulk-jobs/:id shows it.
+func (e *apiEnv) bulkDone(id string) (job map[string]any, progress float64) {
+	e.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, out := call(e.t, e.r, "GET", "/api/bulk-jobs/"+id, e.hr, nil)
+		if status != http.StatusOK {
+			e.t.Fatalf("GET bulk job: %d %v", status, out)
+		}
+		job = out["job"].(map[string]any)
+		if s := job["State"]; s != "queued" && s != "running" {
+			return job, out["progress"].(float64)
+		}
+		if time.Now().After(deadline) {
+			e.t.Fatalf("bulk job %s still %v", id, job["State"])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBulkStatusReportsEachItem(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	ann := e.mustApply(e.user("ann", "candidate"), jobs[0])
+	bob := e.mustApply(e.user("bob", "candidate"), jobs[0])
+	cat := e.user("cat", "candidate")
+	gone := e.mustApply(cat, jobs[0])
+	if status, out := call(t, e.r, "POST", "/api/applications/"+gone+"/withdraw", cat, nil); status != http.StatusOK {
+		t.Fatalf("withdraw: %d %v", status, out)
+	}
+
+	// the withdrawn application fails ValidateStatusChange, the others move
+	status, out := call(t, e.r, "POST", "/api/applications/bulk/status", e.hr, map[string]any{
+		"application_ids": []string{ann, gone, bob, ann}, "status": "interview",
+	})
+	if status != http.StatusAccepted {
+		t.Fatalf("bulk status: %d %v", status, out)
+	}
+	id := out["job"].(map[string]any)["ID"].(string)
+	job, progress := e.bulkDone(id)
+	if job["State"] != "completed_with_errors" || job["Total"] != 3.0 || job["Processed"] != 3.0 ||
+		job["Succeeded"] != 2.0 || job["Failed"] != 1.0 || progress != 100 {
+		t.Errorf("job: %v, progress %v", job, progress)
+	}
+
+	_, out = call(t, e.r, "GET", "/api/bulk-jobs/"+id+"/items", e.hr, nil)
+	var got []string
+	for _, it := range out["items"].([]any) {
+		it := it.(map[string]any)
+		got = append(got, it["ApplicationID"].(string)+" "+it["State"].(string)+" "+it["Error"].(string))
+	}
+	want := []string{ann + " succeeded ", gone + " failed APPLICATION_CLOSED", bob + " succeeded "}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("items:\n got %q\nwant %q", got, want)
+	}
+	for app, want := range map[string]string{ann: "interview", bob: "interview", gone: "withdrawn"} {
+		var a models.Application
+		models.DB.First(&a, "id = ?", app)
+		if a.Status != want {
+			t.Errorf("%s: %s, want %s", app, a.Status, want)
+		}
+	}
+	if n := count(t, &models.ApplicationTimeline{}, "status = ?", "interview"); n != 2 {
+		t.Errorf("%d interview timeline entries, want 2", n)
+	}
+
+	// a job where every item fails has failed
+	_, out = call(t, e.r, "POST", "/api/applications/bulk/status", e.hr, map[string]any{
+		"application_ids": []string{gone}, "status": "interview",
+	})
+	if job, _ := e.bulkDone(out["job"].(map[string]any)["ID"].(string)); job["State"] != "failed" {
+		t.Errorf("all items failed: %v", job)
+	}
+}
+
+func TestBulkRunnerResumesUnfinishedJobs(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	ann := e.mustApply(e.user("ann", "candidate"), jobs[0])
+	bob := e.mustApply(e.user("bob", "candidate"), jobs[0])
+	// a job the previous process stopped halfway through: ann was done
+	job := models.BulkJob{ID: "bulk-1", Action: "tag", Params: `{"add_tags":["go"]}`, State: "running", Total: 2, Processed: 1, Succeeded: 1}
+	items := []models.BulkJobItem{
+		{ID: "item-1", BulkJobID: job.ID, Position: 0, ApplicationID: ann, State: "succeeded"},
+		{ID: "item-2", BulkJobID: job.ID, Position: 1, ApplicationID: bob, State: "pending"},
+	}
+	if err := models.DB.Create(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := models.DB.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	handlers.StartBulkRunner(1)
+	if got, _ := e.bulkDone(job.ID); got["State"] != "completed" || got["Succeeded"] != 2.0 || got["Failed"] != 0.0 {
+		t.Errorf("resumed job: %v", got)
+	}
+	if n := count(t, &models.ApplicationTag{}, "application_id = ? AND tag = ?", bob, "go"); n != 1 {
+		t.Errorf("pending item not run: %d tags on bob", n)
+	}
+	if n := count(t, &models.ApplicationTag{}, "application_id = ?", ann); n != 0 {
+		t.Errorf("finished item run again: %d tags on ann", n)
+	}
+}
+
+func TestBulkRejectsTooManyItems(t *testing.T) {
+	e := newAPIEnv(t)
+	ids := make([]string, 5001)
+	for i := range ids {
+		ids[i] = fmt.Sprint("app-", i)
+	}
+	status, out := call(t, e.r, "POST", "/api/applications/bulk/status", e.hr, map[string]any{"application_ids": ids, "status": "screening"})
+	if status != http.StatusBadRequest || out["code"] != "BULK_TOO_MANY" || out["max"] != float64(5000) {
+		t.Errorf("5001 items: %d %v", status, out)
+	}
+	if n := count(t, &models.BulkJob{}); n != 0 {
+		t.Errorf("%d bulk jobs saved", n)
+	}
+}
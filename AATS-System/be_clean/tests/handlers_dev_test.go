@@ -1,17 +0,0 @@
-package tests
-
-import (
-	"net/http"
-	"net/http/httptest"
-	"testing"
-	"AATS-System/be_clean/handlers"
-)
-
-func TestDevHandler(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/dev", nil)
-	w := httptest.NewRecorder()
-	handlers.DevHandler(w, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
-	}
-}
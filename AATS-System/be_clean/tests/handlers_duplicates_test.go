@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"aats-backend-clean/models"
+)
+
+func TestMergeMovesUserCustomFields(t *testing.T) {
+	e := newAPIEnv(t)
+	e.user("ann", "candidate")
+	e.user("bob", "candidate")
+	for _, f := range []models.CustomField{
+		{ID: "f-level", Entity: "user", Key: "level", Label: "Level", Type: "text"},
+		{ID: "f-visa", Entity: "user", Key: "visa", Label: "Visa", Type: "text"},
+	} {
+		if err := models.DB.Create(&f).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, v := range []models.CustomFieldValue{
+		{ID: "ann-level", FieldID: "f-level", EntityID: "ann", ValueText: "senior"},
+		{ID: "bob-level", FieldID: "f-level", EntityID: "bob", ValueText: "junior"},
+		{ID: "bob-visa", FieldID: "f-visa", EntityID: "bob", ValueText: "yes"},
+	} {
+		if err := models.DB.Create(&v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// owners is who each value belongs to, as "id:entity"
+	owners := func() (got []string) {
+		var vals []models.CustomFieldValue
+		models.DB.Order("id").Find(&vals)
+		for _, v := range vals {
+			got = append(got, v.ID+":"+v.EntityID)
+		}
+		return got
+	}
+
+	status, out := call(t, e.r, "POST", "/api/candidate-merges", e.hr, map[string]any{"survivor_id": "ann", "merged_id": "bob"})
+	if status != http.StatusCreated {
+		t.Fatalf("merge: %d %v", status, out)
+	}
+	// ann keeps her own level and gains bob's visa
+	if got := owners(); !sameIDs(got, []string{"ann-level:ann", "bob-level:bob", "bob-visa:ann"}) {
+		t.Errorf("after the merge: %v", got)
+	}
+
+	id := out["merge"].(map[string]any)["ID"].(string)
+	if status, out := call(t, e.r, "POST", "/api/candidate-merges/"+id+"/undo", e.hr, nil); status != http.StatusOK {
+		t.Fatalf("undo: %d %v", status, out)
+	}
+	if got := owners(); !sameIDs(got, []string{"ann-level:ann", "bob-level:bob", "bob-visa:bob"}) {
+		t.Errorf("after the undo: %v", got)
+	}
+}
@@ -2,16 +2,57 @@ package tests
 
 import (
 	"net/http"
-	"net/http/httptest"
 	"testing"
-	"AATS-System/be_clean/handlers"
+
+	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/handlers"
+	"aats-backend-clean/middleware"
+	"aats-backend-clean/models"
+	"aats-backend-clean/store/memstore"
 )
 
-func TestEvaluationHandler(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/evaluation", nil)
-	w := httptest.NewRecorder()
-	handlers.EvaluationHandler(w, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+func TestEvaluationHandlers(t *testing.T) {
+	st := memstore.New()
+	useServices(t, nil, st, newClock())
+	r := gin.New()
+	r.POST("/api/applications/:id/evaluation", middleware.AuthMiddleware(), handlers.CreateEvaluation)
+	r.GET("/api/applications/:id/evaluation", middleware.AuthMiddleware(), handlers.GetEvaluation)
+
+	for _, a := range []models.Application{{ID: "early", Status: "screening"}, {ID: "app", Status: "interview"}} {
+		if err := st.Applications().Create(&a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hm := tokenFor(t, models.User{ID: "hm1", Role: "hm"})
+	scores := map[string]any{"technical_skills": 4, "communication": 3, "problem_solving": 5, "cultural_fit": 4}
+
+	if code, out := call(t, r, "GET", "/api/applications/app/evaluation", hm, nil); code != http.StatusNotFound || out["code"] != "EVALUATION_NOT_FOUND" {
+		t.Errorf("before evaluating: %d %v", code, out)
+	}
+	if code, out := call(t, r, "POST", "/api/applications/early/evaluation", hm, scores); code != http.StatusBadRequest || out["code"] != "EVALUATION_TOO_EARLY" {
+		t.Errorf("screening application: %d %v", code, out)
+	}
+	if code, out := call(t, r, "POST", "/api/applications/missing/evaluation", hm, scores); code != http.StatusNotFound || out["code"] != "APPLICATION_NOT_FOUND" {
+		t.Errorf("missing application: %d %v", code, out)
+	}
+	if code, out := call(t, r, "POST", "/api/applications/app/evaluation", hm, map[string]any{"technical_skills": 4}); code != http.StatusBadRequest || out["code"] != "INVALID_BODY" {
+		t.Errorf("incomplete scores: %d %v", code, out)
+	}
+
+	code, out := call(t, r, "POST", "/api/applications/app/evaluation", hm, scores)
+	if code != http.StatusCreated {
+		t.Fatalf("evaluate: %d %v", code, out)
+	}
+	if ev := out["evaluation"].(map[string]any); ev["OverallScore"] != 4.0 || ev["EvaluatorID"] != "hm1" {
+		t.Errorf("evaluation: %v", ev)
+	}
+	scores["overall_score"] = 4.5
+	if code, out := call(t, r, "POST", "/api/applications/app/evaluation", hm, scores); code != http.StatusOK {
+		t.Errorf("re-evaluate: %d %v", code, out)
+	}
+	code, out = call(t, r, "GET", "/api/applications/app/evaluation", hm, nil)
+	if ev, _ := out["evaluation"].(map[string]any); code != http.StatusOK || ev["OverallScore"] != 4.5 {
+		t.Errorf("get: %d %v", code, out)
 	}
 }
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"aats-backend-clean/handlers"
+	"aats-backend-clean/models"
+)
+
+// importCSV runs an import of csv into the env's database.
+func importCSV(t *testing.T, kind, csv string, dryRun bool) *handlers.ImportReport {
+	t.Helper()
+	report, err := handlers.ImportCSV(models.DB, kind, strings.NewReader(csv), nil, dryRun)
+	if err != nil {
+		t.Fatalf("import %s: %v", kind, err)
+	}
+	return report
+}
+
+func count(t *testing.T, model any, where ...any) int64 {
+	t.Helper()
+	var n int64
+	q := models.DB.Model(model)
+	if len(where) > 0 {
+		q = q.Where(where[0], where[1:]...)
+	}
+	if err := q.Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+const importJobsCSV = "external_id,title,min_experience_years\nJ1,Go developer,2\nJ2,Designer,0\n"
+
+func TestImportDryRunReportsWithoutSaving(t *testing.T) {
+	newAPIEnv(t)
+	r := importCSV(t, "jobs", importJobsCSV, true)
+	if !r.DryRun || r.Committed || r.Rows != 2 || r.Created != 2 || len(r.Errors) != 0 {
+		t.Errorf("dry run report: %+v", r)
+	}
+	if n := count(t, &models.JobPosting{}); n != 0 {
+		t.Errorf("dry run saved %d jobs", n)
+	}
+}
+
+func TestImportRollsBackOnRowError(t *testing.T) {
+	newAPIEnv(t)
+	r := importCSV(t, "jobs", importJobsCSV+"J3,Tester,lots\n", false)
+	if r.Committed || len(r.Errors) != 1 || r.Errors[0].Row != 4 || r.Errors[0].Field != "min_experience_years" {
+		t.Errorf("report: %+v", r)
+	}
+	if n := count(t, &models.JobPosting{}); n != 0 {
+		t.Errorf("%d jobs kept from a file with an invalid row", n)
+	}
+}
+
+func TestImportUpsertsByExternalID(t *testing.T) {
+	newAPIEnv(t)
+	if r := importCSV(t, "jobs", importJobsCSV, false); !r.Committed || r.Created != 2 {
+		t.Fatalf("first import: %+v", r)
+	}
+	r := importCSV(t, "jobs", "external_id,title\nJ1,Senior Go developer\n", false)
+	if !r.Committed || r.Created != 0 || r.Updated != 1 {
+		t.Errorf("second import: %+v", r)
+	}
+	var job models.JobPosting
+	models.DB.Where("external_id = ?", "J1").First(&job)
+	if job.Title != "Senior Go developer" || job.MinExperienceYears != 2 {
+		t.Errorf("J1 after update: title %q, min years %v", job.Title, job.MinExperienceYears)
+	}
+	if n := count(t, &models.JobPosting{}); n != 2 {
+		t.Errorf("%d jobs, want 2", n)
+	}
+}
+
+func TestImportApplicationsDedupeApplicantsByEmail(t *testing.T) {
+	e := newAPIEnv(t)
+	e.user("ann", "candidate") // ann@example.com has an account already
+	importCSV(t, "jobs", importJobsCSV, false)
+
+	r := importCSV(t, "applications", "job_external_id,email,name\n"+
+		"J1,bob@example.com,Bob\nJ2,BOB@Example.com,Bob\nJ1,Ann@Example.com,Ann\n", false)
+	if !r.Committed || r.Created != 3 || r.ApplicantsCreated != 1 {
+		t.Errorf("report: %+v", r)
+	}
+	if n := count(t, &models.User{}, "LOWER(email) = ?", "bob@example.com"); n != 1 {
+		t.Errorf("%d accounts for bob", n)
+	}
+	if n := count(t, &models.Application{}, "applicant_id = ?", "ann"); n != 1 {
+		t.Errorf("ann's row not added to her account: %d applications", n)
+	}
+}
+
+func TestImportApplicationHistory(t *testing.T) {
+	newAPIEnv(t)
+	importCSV(t, "jobs", importJobsCSV, false)
+	csv := "external_id,job_external_id,email,submitted_date,interview_date,rejected_date\n" +
+		"A1,J1,ann@example.com,2024-01-05,2024-01-20,2024-02-01\n"
+
+	for run := 1; run <= 2; run++ { // running a file twice replaces its history
+		r := importCSV(t, "applications", csv, false)
+		if !r.Committed || r.TimelineRows != 3 {
+			t.Fatalf("run %d: %+v", run, r)
+		}
+		var app models.Application
+		models.DB.Where("external_id = ?", "A1").First(&app)
+		if app.Status != "rejected" || app.SubmittedDate.Format("2006-01-02") != "2024-01-05" {
+			t.Errorf("run %d: status %q submitted %v", run, app.Status, app.SubmittedDate)
+		}
+		var tls []models.ApplicationTimeline
+		models.DB.Where("application_id = ?", app.ID).Order("date asc").Find(&tls)
+		var got []string
+		for _, tl := range tls {
+			got = append(got, tl.Date.Format("2006-01-02")+" "+tl.Status)
+		}
+		if want := "2024-01-05 submitted,2024-01-20 interview,2024-02-01 rejected"; strings.Join(got, ",") != want {
+			t.Errorf("run %d: timeline %v, want %s", run, got, want)
+		}
+	}
+}
+
+func TestImportMatchesApplicationWithoutExternalID(t *testing.T) {
+	e := newAPIEnv(t)
+	importCSV(t, "jobs", importJobsCSV, false)
+	var job models.JobPosting
+	models.DB.Where("external_id = ?", "J1").First(&job)
+	app := e.applyWith(e.user("ann", "candidate"), map[string]any{"job_id": job.ID})
+
+	r := importCSV(t, "applications", "external_id,job_external_id,email,status\nA1,J1,ann@example.com,interview\n", false)
+	if !r.Committed || r.Created != 0 || r.Updated != 1 {
+		t.Errorf("report: %+v", r)
+	}
+	var got models.Application
+	models.DB.First(&got, "id = ?", app)
+	if got.ExternalID != "A1" || got.Status != "interview" {
+		t.Errorf("application made before the import: external id %q status %q", got.ExternalID, got.Status)
+	}
+	if n := count(t, &models.Application{}); n != 1 {
+		t.Errorf("%d applications, want 1", n)
+	}
+}
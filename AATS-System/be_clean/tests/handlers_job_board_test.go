@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"aats-backend-clean/handlers"
+	"aats-backend-clean/models"
+)
+
+// publicJobs is the ids the public job board lists for query.
+func (e *apiEnv) publicJobs(query string) []string {
+	e.t.Helper()
+	status, out := call(e.t, e.r, "GET", "/api/public/jobs"+query, "", nil)
+	if status != http.StatusOK {
+		e.t.Fatalf("public jobs%s: %d %v", query, status, out)
+	}
+	var ids []string
+	jobs, _ := out["jobs"].([]any)
+	for _, j := range jobs {
+		ids = append(ids, j.(map[string]any)["id"].(string))
+	}
+	return ids
+}
+
+func TestJobBoardBackfilledAtStartup(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(2) // written straight to the database: no slug or search text
+
+	if got := e.publicJobs("?q=job"); len(got) != 0 {
+		t.Errorf("search before the backfill: %v", got)
+	}
+	if n := count(t, &models.JobPosting{}, "slug <> ''"); n != 0 {
+		t.Errorf("reading the job board wrote %d slugs", n)
+	}
+
+	if err := handlers.BackfillJobBoard(models.DB); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.publicJobs("?q=%22job+b%22"); !sameIDs(got, jobs[1:]) {
+		t.Errorf("search after the backfill: %v", got)
+	}
+	var job models.JobPosting
+	models.DB.First(&job, "id = ?", jobs[0])
+	if status, out := call(t, e.r, "GET", "/api/public/jobs/"+job.Slug, "", nil); status != http.StatusOK || job.Slug == "" {
+		t.Errorf("job by slug %q: %d %v", job.Slug, status, out)
+	}
+}
+
+func TestJobFacetsCountPublishedJobs(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(3)
+	models.DB.Model(&models.JobPosting{}).Where("id IN ?", jobs[:2]).Update("department", "IT")
+	models.DB.Model(&models.JobPosting{}).Where("id = ?", jobs[1]).Update("status", "draft")
+
+	status, out := call(t, e.r, "GET", "/api/public/job-facets", "", nil)
+	if status != http.StatusOK {
+		t.Fatalf("facets: %d %v", status, out)
+	}
+	if _, ok := out["ok"]; ok {
+		t.Errorf("facets carry an ok flag: %v", out)
+	}
+	deps, _ := out["departments"].([]any)
+	if len(deps) != 1 || deps[0].(map[string]any)["value"] != "IT" || deps[0].(map[string]any)["count"] != float64(1) {
+		t.Errorf("departments: %v", deps)
+	}
+}
+
+// rssFeed fetches the RSS feed with the conditional request headers.
+func (e *apiEnv) rssFeed(headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/api/feeds/jobs.rss", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	e.r.ServeHTTP(w, req)
+	return w
+}
+
+func TestFeedChangesWhenJobDeleted(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(2)
+	models.DB.Model(&models.JobPosting{}).Where("id = ?", jobs[1]).Update("updated_at", time.Now().Add(time.Hour))
+
+	first := e.rssFeed(nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("feed: %d, etag %q", first.Code, etag)
+	}
+	if w := e.rssFeed(map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Errorf("unchanged feed: %d", w.Code)
+	}
+
+	models.DB.Where("id = ?", jobs[1]).Delete(&models.JobPosting{}) // the most recently edited job
+	for _, h := range []map[string]string{
+		{"If-None-Match": etag},
+		{"If-Modified-Since": time.Now().Add(2 * time.Hour).UTC().Format(http.TimeFormat)},
+	} {
+		if w := e.rssFeed(h); w.Code != http.StatusOK || strings.Contains(w.Body.String(), jobs[1]) {
+			t.Errorf("%v after deleting a job: %d", h, w.Code)
+		}
+	}
+}
@@ -2,16 +2,76 @@ package tests
 
 import (
 	"net/http"
-	"net/http/httptest"
 	"testing"
-	"AATS-System/be_clean/handlers"
+
+	"aats-backend-clean/models"
 )
 
-func TestJobsHandler(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
-	w := httptest.NewRecorder()
-	handlers.JobsHandler(w, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+// listedJobs is the ids of GET path's jobs as the token's user.
+func (e *apiEnv) listedJobs(token, path string) []string {
+	e.t.Helper()
+	status, out := call(e.t, e.r, "GET", path, token, nil)
+	if status != http.StatusOK {
+		e.t.Fatalf("GET %s: %d %v", path, status, out)
+	}
+	var ids []string
+	jobs, _ := out["jobs"].([]any)
+	for _, j := range jobs {
+		ids = append(ids, j.(map[string]any)["ID"].(string))
+	}
+	return ids
+}
+
+func TestListJobsHidesDrafts(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(2)
+	models.DB.Model(&models.JobPosting{}).Where("id = ?", jobs[1]).Update("status", "draft")
+
+	if got := e.listedJobs("", "/api/jobs"); !sameIDs(got, jobs[:1]) {
+		t.Errorf("anonymous: %v", got)
+	}
+	if got := e.listedJobs(e.hr, "/api/jobs"); !sameIDs(got, jobs) {
+		t.Errorf("hr: %v", got)
+	}
+	if got := e.listedJobs(e.hr, "/api/jobs?status=draft"); !sameIDs(got, jobs[1:]) {
+		t.Errorf("hr, status=draft: %v", got)
+	}
+}
+
+func TestUpdateJobKeepsUnsentFields(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	models.DB.Model(&models.JobPosting{}).Where("id = ?", jobs[0]).Updates(map[string]any{"location": "Bangkok", "min_experience_years": 3})
+
+	status, out := call(t, e.r, "PUT", "/api/jobs/"+jobs[0], e.hr, map[string]any{"title": "Go developer"})
+	if status != http.StatusOK {
+		t.Fatalf("update: %d %v", status, out)
+	}
+	var job models.JobPosting
+	models.DB.First(&job, "id = ?", jobs[0])
+	if job.Title != "Go developer" || job.Location != "Bangkok" || job.MinExperienceYears != 3 || job.Slug == "" {
+		t.Errorf("after update: %+v", job)
+	}
+	if status, _ := call(t, e.r, "PUT", "/api/jobs/missing", e.hr, map[string]any{"title": "x"}); status != http.StatusNotFound {
+		t.Errorf("missing job: %d", status)
+	}
+}
+
+func TestUpdateJobResetsMinExperience(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(1)
+	models.DB.Model(&models.JobPosting{}).Where("id = ?", jobs[0]).Update("min_experience_years", 3)
+
+	status, out := call(t, e.r, "PUT", "/api/jobs/"+jobs[0], e.hr, map[string]any{"title": "Job A", "min_experience_years": 0})
+	if status != http.StatusOK {
+		t.Fatalf("update: %d %v", status, out)
+	}
+	var job models.JobPosting
+	models.DB.First(&job, "id = ?", jobs[0])
+	if job.MinExperienceYears != 0 {
+		t.Errorf("min experience after an explicit 0: %v", job.MinExperienceYears)
+	}
+	if status, _ := call(t, e.r, "PUT", "/api/jobs/"+jobs[0], e.hr, map[string]any{"title": "Job A", "min_experience_years": -1}); status != http.StatusBadRequest {
+		t.Errorf("negative min experience: %d", status)
 	}
 }
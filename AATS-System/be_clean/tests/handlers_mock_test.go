@@ -4,14 +4,27 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	"AATS-System/be_clean/handlers"
+
+	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/handlers"
+	"aats-backend-clean/store/memstore"
 )
 
-func TestMockHandler(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/mock", nil)
-	w := httptest.NewRecorder()
-	handlers.MockHandler(w, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+func TestLegacyMockData(t *testing.T) {
+	t.Chdir("..") // mock files are served from ./public
+	useServices(t, nil, memstore.New(), newClock())
+	r := gin.New()
+	handlers.RegisterLegacyRoutes(r)
+
+	for _, path := range []string{"/api/mock/jobs", "/api/mock/applications"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" || w.Body.Len() == 0 {
+			t.Errorf("%s: %d %q", path, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+	if code, out := call(t, r, "GET", "/health", "", nil); code != http.StatusOK || out["status"] != "ok" {
+		t.Errorf("health: %d %v", code, out)
 	}
 }
@@ -1,17 +0,0 @@
-package tests
-
-import (
-	"net/http"
-	"net/http/httptest"
-	"testing"
-	"AATS-System/be_clean/handlers"
-)
-
-func TestNotesEvalHandler(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/notes_eval", nil)
-	w := httptest.NewRecorder()
-	handlers.NotesEvalHandler(w, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
-	}
-}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"aats-backend-clean/handlers"
+	"aats-backend-clean/models"
+)
+
+// referredHire is a referral by an employee of ann for job, with ann's
+// application through the invite hired: the referral and application ids.
+func (e *apiEnv) referredHire(job string) (refID, appID string) {
+	e.t.Helper()
+	emp := e.user("emp", "employee")
+	status, out := call(e.t, e.r, "POST", "/api/referrals", emp, map[string]any{
+		"job_id": job, "candidate_name": "Ann", "candidate_email": "Ann@Example.com",
+	})
+	if status != http.StatusCreated {
+		e.t.Fatalf("refer: %d %v", status, out)
+	}
+	ref := out["referral"].(map[string]any)
+	if status, out := call(e.t, e.r, "POST", "/api/referrals", emp, map[string]any{
+		"job_id": job, "candidate_name": "Ann", "candidate_email": "ann@example.com",
+	}); status != http.StatusConflict || out["code"] != "REFERRAL_DUPLICATE" {
+		e.t.Errorf("second referral: %d %v", status, out)
+	}
+
+	ann := e.user("ann", "candidate")
+	appID = e.applyWith(ann, map[string]any{"job_id": job, "referral_token": ref["InviteToken"]})
+	if status, out := call(e.t, e.r, "POST", "/api/applications", e.user("bob", "candidate"), map[string]any{
+		"job_id": job, "referral_token": ref["InviteToken"],
+	}); status != http.StatusBadRequest || out["code"] != "REFERRAL_INVITE_USED" {
+		e.t.Errorf("invite used twice: %d %v", status, out)
+	}
+	ev := models.Evaluation{ID: "ev-ann", ApplicationID: appID, EvaluatorID: "hm1", TechnicalSkills: 4, Communication: 4, ProblemSolving: 4, CulturalFit: 4, OverallScore: 4}
+	if err := models.DB.Create(&ev).Error; err != nil {
+		e.t.Fatal(err)
+	}
+	e.mustSetStatus(appID, "offer")
+	e.mustSetStatus(appID, "hired")
+	return ref["ID"].(string), appID
+}
+
+// bonus is the referral's bonus status and who forfeited it.
+func bonus(t *testing.T, refID string) (status, forfeitedBy string) {
+	t.Helper()
+	var ref models.Referral
+	if err := models.DB.First(&ref, "id = ?", refID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return ref.BonusStatus, ref.BonusForfeitedBy
+}
+
+func TestReferralBonusFollowsTheHire(t *testing.T) {
+	e := newAPIEnv(t)
+	refID, app := e.referredHire(e.jobs(1)[0])
+	var ref models.Referral
+	models.DB.First(&ref, "id = ?", refID)
+	if ref.ApplicationID != app || ref.BonusStatus != "pending" || ref.HiredAt == nil || ref.BonusEligibleAt == nil {
+		t.Fatalf("after the hire: %+v", ref)
+	}
+
+	// leaving hired during probation forfeits the bonus, coming back restores it
+	e.mustSetStatus(app, "interview")
+	if s, by := bonus(t, refID); s != "forfeited" || by != "system" {
+		t.Errorf("after leaving hired: %s by %q", s, by)
+	}
+	e.mustSetStatus(app, "hired")
+	if s, _ := bonus(t, refID); s != "pending" {
+		t.Errorf("hired again: %s", s)
+	}
+
+	// a bonus HR forfeited stays forfeited
+	if status, out := call(t, e.r, "PATCH", "/api/referrals/"+refID+"/bonus", e.hr, map[string]any{"status": "forfeited"}); status != http.StatusOK {
+		t.Fatalf("forfeit: %d %v", status, out)
+	}
+	e.mustSetStatus(app, "interview")
+	e.mustSetStatus(app, "hired")
+	if s, by := bonus(t, refID); s != "forfeited" || by != "hr1" {
+		t.Errorf("after HR's forfeit and a new hire: %s by %q", s, by)
+	}
+}
+
+func TestReferralBonusEligibleAfterProbation(t *testing.T) {
+	t.Setenv("REFERRAL_PROBATION_DAYS", "0")
+	e := newAPIEnv(t)
+	refID, _ := e.referredHire(e.jobs(1)[0])
+
+	if status, out := call(t, e.r, "PATCH", "/api/referrals/"+refID+"/bonus", e.hr, map[string]any{"status": "paid"}); status != http.StatusBadRequest || out["code"] != "REFERRAL_BONUS_NOT_ELIGIBLE" {
+		t.Errorf("paying a pending bonus: %d %v", status, out)
+	}
+	if n, err := handlers.PromoteReferralBonuses(models.DB); err != nil || n != 1 {
+		t.Fatalf("promote: %d %v", n, err)
+	}
+	if status, out := call(t, e.r, "PATCH", "/api/referrals/"+refID+"/bonus", e.hr, map[string]any{"status": "paid"}); status != http.StatusOK {
+		t.Fatalf("pay: %d %v", status, out)
+	}
+
+	// the referrer sees the outcome, not the pipeline
+	status, out := call(t, e.r, "GET", "/api/referrals/mine", tokenFor(t, models.User{ID: "emp", Role: "employee"}), nil)
+	refs, _ := out["referrals"].([]any)
+	if status != http.StatusOK || len(refs) != 1 {
+		t.Fatalf("mine: %d %v", status, out)
+	}
+	if r := refs[0].(map[string]any); r["status"] != "hired" || r["bonus_status"] != "paid" {
+		t.Errorf("referrer's view: %v", r)
+	}
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"aats-backend-clean/models"
+)
+
+func TestWithdrawClipsReasonOnCharacters(t *testing.T) {
+	e := newAPIEnv(t)
+	ann := e.user("ann", "candidate")
+	app := e.applyWith(ann, map[string]any{"job_id": e.jobs(1)[0]})
+
+	// 400 Thai characters are 1200 bytes; the reason keeps 1000 at most
+	status, out := call(t, e.r, "POST", "/api/applications/"+app+"/withdraw", ann, map[string]any{"reason": strings.Repeat("ก", 400)})
+	if status != http.StatusOK {
+		t.Fatalf("withdraw: %d %v", status, out)
+	}
+	var tl models.ApplicationTimeline
+	models.DB.Where("application_id = ? AND status = ?", app, "withdrawn").First(&tl)
+	if !utf8.ValidString(tl.Description) || strings.ContainsRune(tl.Description, utf8.RuneError) {
+		t.Errorf("reason cut inside a character: %q", tl.Description)
+	}
+	if !strings.Contains(tl.Description, strings.Repeat("ก", 333)) || strings.Contains(tl.Description, strings.Repeat("ก", 334)) {
+		t.Errorf("want the first 333 characters (999 bytes) of the reason, got %q", tl.Description)
+	}
+}
+
+func TestWithdrawClosesOpenOffers(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(2)
+	ann, app, offerID := e.offerStage("ann", jobs[0], true)
+
+	if status, out := call(t, e.r, "POST", "/api/applications/"+app+"/withdraw", e.user("bob", "candidate"), nil); status != http.StatusForbidden {
+		t.Errorf("someone else's application: %d %v", status, out)
+	}
+	status, out := call(t, e.r, "POST", "/api/applications/"+app+"/withdraw", ann, map[string]any{"reason": "took another job"})
+	if status != http.StatusOK {
+		t.Fatalf("withdraw: %d %v", status, out)
+	}
+	var offer models.Offer
+	models.DB.First(&offer, "id = ?", offerID)
+	if offer.Status != "withdrawn" || offer.RespondedAt != nil {
+		t.Errorf("sent offer after withdrawing: %s, responded %v", offer.Status, offer.RespondedAt)
+	}
+	if status, out := call(t, e.r, "POST", "/api/applications/"+app+"/withdraw", ann, nil); status != http.StatusBadRequest || out["code"] != "APPLICATION_CLOSED" {
+		t.Errorf("withdrawing twice: %d %v", status, out)
+	}
+
+	// an offer still waiting for approval is withdrawn too
+	cat, pending, _ := e.offerStage("cat", jobs[1], false)
+	waiting := models.Offer{ID: "offer-cat", ApplicationID: pending, Status: "pending_approval", CreatedBy: "hr1"}
+	if err := models.DB.Create(&waiting).Error; err != nil {
+		t.Fatal(err)
+	}
+	if status, out := call(t, e.r, "POST", "/api/applications/"+pending+"/withdraw", cat, nil); status != http.StatusOK {
+		t.Fatalf("withdraw cat: %d %v", status, out)
+	}
+	if s := offerStatus(waiting.ID); s != "withdrawn" {
+		t.Errorf("offer pending approval after withdrawing: %s", s)
+	}
+}
+
+func TestEditApplicationKeepsRevisions(t *testing.T) {
+	e := newAPIEnv(t)
+	ann := e.user("ann", "candidate")
+	app := e.applyWith(ann, map[string]any{"job_id": e.jobs(1)[0], "cover_letter": "v1"})
+
+	if status, out := call(t, e.r, "PATCH", "/api/applications/"+app, ann, map[string]any{}); status != http.StatusBadRequest || out["code"] != "NOTHING_TO_UPDATE" {
+		t.Errorf("empty edit: %d %v", status, out)
+	}
+	for i, letter := range []string{"v2", "v3"} {
+		status, out := call(t, e.r, "PATCH", "/api/applications/"+app, ann, map[string]any{"cover_letter": letter})
+		if status != http.StatusOK || out["version"] != float64(i+2) {
+			t.Fatalf("edit to %s: %d %v", letter, status, out)
+		}
+	}
+
+	// version 1 is the application as submitted, each edit adds one
+	_, out := call(t, e.r, "GET", "/api/applications/"+app+"/revisions", ann, nil)
+	var got []string
+	for _, r := range out["revisions"].([]any) {
+		r := r.(map[string]any)
+		got = append(got, fmt.Sprintf("%v:%v", r["version"], r["cover_letter"]))
+	}
+	if strings.Join(got, " ") != "1:v1 2:v2 3:v3" {
+		t.Errorf("revisions: %v", got)
+	}
+	if status, _ := call(t, e.r, "GET", "/api/applications/"+app+"/revisions", e.user("bob", "candidate"), nil); status != http.StatusForbidden {
+		t.Errorf("someone else's revisions: %d", status)
+	}
+
+	// once HR moves it on, the candidate can no longer edit
+	e.mustSetStatus(app, "interview")
+	if status, out := call(t, e.r, "PATCH", "/api/applications/"+app, ann, map[string]any{"cover_letter": "v4"}); status != http.StatusBadRequest || out["code"] != "APPLICATION_NOT_EDITABLE" {
+		t.Errorf("edit after screening: %d %v", status, out)
+	}
+}
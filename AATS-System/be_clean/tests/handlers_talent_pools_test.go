@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"aats-backend-clean/models"
+)
+
+func TestTalentPoolConsentAndInvites(t *testing.T) {
+	e := newAPIEnv(t)
+	jobs := e.jobs(2)
+	ann := e.user("ann", "candidate")
+	annApp := e.applyWith(ann, map[string]any{"job_id": jobs[0], "talent_pool_consent": true})
+	e.applyWith(e.user("bob", "candidate"), map[string]any{"job_id": jobs[0]})
+	e.applyWith(e.user("cat", "candidate"), map[string]any{"job_id": jobs[1], "talent_pool_consent": true}) // already applied to the invite job
+
+	status, out := call(t, e.r, "POST", "/api/talent-pools", e.hr, map[string]any{"name": "Backend"})
+	if status != http.StatusCreated {
+		t.Fatalf("create pool: %d %v", status, out)
+	}
+	pool := "/api/talent-pools/" + out["pool"].(map[string]any)["ID"].(string)
+
+	for _, tc := range []struct {
+		body map[string]any
+		want int
+		code string
+	}{
+		{map[string]any{"candidate_id": "bob"}, http.StatusConflict, "TALENT_POOL_CONSENT_MISSING"},
+		{map[string]any{"application_id": annApp, "tags": []string{"Go"}}, http.StatusCreated, ""},
+		{map[string]any{"candidate_id": "cat"}, http.StatusCreated, ""},
+		{map[string]any{"candidate_id": "ann"}, http.StatusConflict, "POOL_MEMBER_EXISTS"},
+	} {
+		status, out := call(t, e.r, "POST", pool+"/members", e.hr, tc.body)
+		if code, _ := out["code"].(string); status != tc.want || code != tc.code {
+			t.Errorf("add %v: %d %v, want %d %s", tc.body, status, out, tc.want, tc.code)
+		}
+	}
+
+	// invited is who got the invitation, skipped who the policy blocks
+	invite := func() (invited []any, skipped []any) {
+		t.Helper()
+		status, out := call(t, e.r, "POST", pool+"/invite", e.hr, map[string]any{"job_id": jobs[1]})
+		if status != http.StatusOK {
+			t.Fatalf("invite: %d %v", status, out)
+		}
+		invited, _ = out["invited"].([]any)
+		skipped, _ = out["skipped"].([]any)
+		return invited, skipped
+	}
+	invited, skipped := invite()
+	if len(invited) != 1 || invited[0] != "ann" || len(skipped) != 1 || skipped[0].(map[string]any)["candidate_id"] != "cat" {
+		t.Errorf("invite: invited %v, skipped %v", invited, skipped)
+	}
+	if n := count(t, &models.TalentPoolInvite{}, "candidate_id = ?", "ann"); n != 1 {
+		t.Errorf("%d invites recorded for ann", n)
+	}
+
+	// withdrawing consent takes the candidate out of every pool
+	if status, out := call(t, e.r, "PUT", "/api/me/talent-pool-consent", ann, map[string]any{"consent": false}); status != http.StatusOK {
+		t.Fatalf("withdraw consent: %d %v", status, out)
+	}
+	if n := count(t, &models.TalentPoolMember{}, "candidate_id = ?", "ann"); n != 0 {
+		t.Errorf("%d memberships left after withdrawing consent", n)
+	}
+	if n := count(t, &models.TalentPoolTag{}); n != 0 {
+		t.Errorf("%d member tags left after withdrawing consent", n)
+	}
+	if invited, _ := invite(); len(invited) != 0 {
+		t.Errorf("invited after withdrawing consent: %v", invited)
+	}
+}
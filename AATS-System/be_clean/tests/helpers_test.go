@@ -0,0 +1,262 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"aats-backend-clean/handlers"
+	"aats-backend-clean/middleware"
+	"aats-backend-clean/models"
+	"aats-backend-clean/services"
+	"aats-backend-clean/store"
+)
+
+const testSecret = "test-secret"
+
+// openSQLite is an empty, migrated in-memory SQLite database of its own.
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+uuid.NewString()+"?mode=memory&cache=shared&_pragma=busy_timeout(5000)"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := models.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// clock is a settable time source for the services.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// AddMonths moves the clock like time.AddDate, the way the policy counts.
+func (c *clock) AddMonths(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.AddDate(0, n, 0)
+}
+
+// useServices points the handlers at st (and models.DB at db, which may be
+// nil for handlers that only use the store) with the application and
+// evaluation rules on clk, restoring the previous wiring when the test ends.
+// Tokens keep the real clock: the middleware checks them against it.
+func useServices(t *testing.T, db *gorm.DB, st store.Store, clk *clock) *services.Services {
+	t.Helper()
+	t.Setenv("JWT_SECRET", testSecret)
+	prevDB, prevStore, prevServices := models.DB, handlers.Store, handlers.Services
+	t.Cleanup(func() { models.DB, handlers.Store, handlers.Services = prevDB, prevStore, prevServices })
+
+	svc := services.New(st, testSecret)
+	svc.Applications.Now, svc.Evaluations.Now = clk.Now, clk.Now
+	models.DB, handlers.Store, handlers.Services = db, st, svc
+	gin.SetMode(gin.TestMode)
+	return svc
+}
+
+// tokenFor is a bearer token for u, signed like a login would.
+func tokenFor(t *testing.T, u models.User) string {
+	t.Helper()
+	tok, err := services.NewAuthService(nil, testSecret).Token(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + tok
+}
+
+// call sends a JSON request and decodes the response: "data" for
+// successes, the problem for errors.
+func call(t *testing.T, r http.Handler, method, path, auth string, body any) (int, map[string]any) {
+	t.Helper()
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var out map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("%s %s: %d %s", method, path, w.Code, w.Body.String())
+	}
+	if data, ok := out["data"].(map[string]any); ok {
+		return w.Code, data
+	}
+	return w.Code, out
+}
+
+// apiEnv is the API on its own SQLite database, wired and routed like main
+// does (the routes the tests use), with the services on a fake clock.
+type apiEnv struct {
+	t   *testing.T
+	r   *gin.Engine
+	clk *clock
+	hr  string
+	hm  string
+}
+
+func newAPIEnv(t *testing.T) *apiEnv {
+	return newAPIEnvOn(t, openSQLite(t))
+}
+
+// newAPIEnvOn is newAPIEnv on db.
+func newAPIEnvOn(t *testing.T, db *gorm.DB) *apiEnv {
+	clk := newClock()
+	useServices(t, db, store.New(db), clk)
+
+	auth := middleware.AuthMiddleware()
+	hr := middleware.RequireRoles("hr")
+	r := gin.New()
+	api := r.Group("/api")
+	api.POST("/applications", auth, handlers.CreateApplication)
+	api.GET("/applications", auth, handlers.ListApplications)
+	api.GET("/applications/export", auth, middleware.RequireRoles("hr", "hm"), handlers.ExportApplications)
+	api.GET("/applications/:id", auth, handlers.GetApplication)
+	api.PATCH("/applications/:id", auth, middleware.RequireRoles("candidate"), handlers.EditApplication)
+	api.GET("/applications/:id/revisions", auth, handlers.ListApplicationRevisions)
+	api.PATCH("/applications/:id/status", auth, handlers.UpdateApplicationStatus)
+	api.POST("/applications/:id/evaluation", auth, handlers.CreateEvaluation)
+	api.POST("/applications/bulk/status", auth, hr, handlers.BulkUpdateStatus)
+	api.GET("/bulk-jobs/:id", auth, hr, handlers.GetBulkJob)
+	api.GET("/bulk-jobs/:id/items", auth, hr, handlers.ListBulkJobItems)
+	api.POST("/applications/:id/withdraw", auth, handlers.WithdrawApplication)
+	api.PUT("/offers/:id", auth, hr, handlers.UpdateOffer)
+	api.POST("/offers/:id/submit", auth, hr, handlers.SubmitOffer)
+	api.POST("/offers/:id/withdraw", auth, hr, handlers.WithdrawOffer)
+	api.POST("/offers/:id/respond", auth, handlers.RespondToOffer)
+	api.GET("/jobs", middleware.OptionalAuth(), handlers.ListJobs)
+	api.PUT("/jobs/:id", auth, handlers.UpdateJob)
+	api.POST("/custom-fields", auth, hr, handlers.CreateCustomField)
+	api.POST("/referrals", auth, middleware.RequireRoles("employee", "hr", "hm"), handlers.CreateReferral)
+	api.GET("/referrals/mine", auth, middleware.RequireRoles("employee", "hr", "hm"), handlers.ListMyReferrals)
+	api.PATCH("/referrals/:id/bonus", auth, hr, handlers.UpdateReferralBonus)
+	api.GET("/public/jobs", handlers.PublicListJobs)
+	api.GET("/public/jobs/:slug", handlers.PublicGetJob)
+	api.GET("/public/job-facets", handlers.PublicJobFacets)
+	api.GET("/feeds/jobs.rss", handlers.JobsRSSFeed)
+	api.GET("/candidates/:id", auth, hr, handlers.GetCandidateProfile)
+	api.POST("/candidate-merges", auth, hr, handlers.MergeCandidates)
+	api.POST("/candidate-merges/:id/undo", auth, hr, handlers.UndoCandidateMerge)
+	api.POST("/talent-pools", auth, hr, handlers.CreateTalentPool)
+	api.POST("/talent-pools/:id/members", auth, hr, handlers.AddPoolMember)
+	api.POST("/talent-pools/:id/invite", auth, hr, handlers.InvitePoolToJob)
+	api.PUT("/me/talent-pool-consent", auth, middleware.RequireRoles("candidate"), handlers.UpdateTalentPoolConsent)
+	api.POST("/policies", auth, hr, handlers.CreatePolicy)
+	api.GET("/analytics/trends", auth, hr, handlers.AnalyticsTrends)
+	api.GET("/policies/effective", auth, hr, handlers.EffectivePolicy)
+	api.GET("/policies/explain", auth, handlers.ExplainPolicy)
+
+	e := &apiEnv{t: t, r: r, clk: clk}
+	e.hr = e.user("hr1", "hr")
+	e.hm = e.user("hm1", "hm")
+	return e
+}
+
+// user saves an account and returns its bearer token.
+func (e *apiEnv) user(id, role string) string {
+	u := models.User{ID: id, Email: id + "@example.com", Name: id, Role: role}
+	if err := models.DB.Create(&u).Error; err != nil {
+		e.t.Fatal(err)
+	}
+	return tokenFor(e.t, u)
+}
+
+// jobs saves n published jobs and returns their ids.
+func (e *apiEnv) jobs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		j := models.JobPosting{ID: "job-" + string(rune('a'+i)), Title: "Job " + string(rune('A'+i)), Status: "active"}
+		if err := models.DB.Create(&j).Error; err != nil {
+			e.t.Fatal(err)
+		}
+		ids[i] = j.ID
+	}
+	return ids
+}
+
+// apply submits an application as the token's user; the id on success,
+// the problem code on refusal.
+func (e *apiEnv) apply(token, jobID string) (id, code string) {
+	e.t.Helper()
+	status, out := call(e.t, e.r, "POST", "/api/applications", token, map[string]any{"job_id": jobID})
+	if status == http.StatusCreated {
+		return out["application"].(map[string]any)["ID"].(string), ""
+	}
+	c, _ := out["code"].(string)
+	return "", c
+}
+
+// applyWith submits body (job_id and the rest) as the token's user and
+// returns the new application's id.
+func (e *apiEnv) applyWith(token string, body map[string]any) string {
+	e.t.Helper()
+	status, out := call(e.t, e.r, "POST", "/api/applications", token, body)
+	if status != http.StatusCreated {
+		e.t.Fatalf("apply %v: %d %v", body, status, out)
+	}
+	return out["application"].(map[string]any)["ID"].(string)
+}
+
+// mustApply is apply that fails the test on refusal.
+func (e *apiEnv) mustApply(token, jobID string) string {
+	e.t.Helper()
+	id, code := e.apply(token, jobID)
+	if code != "" {
+		e.t.Fatalf("apply to %s: %s", jobID, code)
+	}
+	return id
+}
+
+// setStatus moves an application as HR; "" on success, else the code.
+func (e *apiEnv) setStatus(appID, status string) string {
+	e.t.Helper()
+	code, out := call(e.t, e.r, "PATCH", "/api/applications/"+appID+"/status", e.hr, map[string]any{"status": status})
+	if code == http.StatusOK {
+		return ""
+	}
+	c, _ := out["code"].(string)
+	return c
+}
+
+func (e *apiEnv) mustSetStatus(appID, status string) {
+	e.t.Helper()
+	if code := e.setStatus(appID, status); code != "" {
+		e.t.Fatalf("%s → %s: %s", appID, status, code)
+	}
+}
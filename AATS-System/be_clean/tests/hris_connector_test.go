@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"aats-backend-clean/hris"
+)
+
+func sampleNewHire() hris.NewHire {
+	h := hris.NewHire{
+		ApplicationID: "app-1", CandidateID: "u-1", FullName: "สมชาย ใจดี", Email: "somchai@example.com",
+		JobTitle: "Go Developer", Department: "IT", Salary: 55000, Currency: "THB", StartDate: "2026-11-01",
+		HiredAt: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC),
+	}
+	h.SplitName()
+	return h
+}
+
+func TestHRISHTTPConnectorSendsJSON(t *testing.T) {
+	var got hris.NewHire
+	var auth, key string
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, key = r.Header.Get("Authorization"), r.Header.Get("Idempotency-Key")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"employee_id": 4711}`))
+	}))
+	defer stub.Close()
+
+	res, err := hris.NewHTTPConnector(stub.URL, "tok").Send(context.Background(), sampleNewHire())
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if res.ExternalID != "4711" {
+		t.Errorf("external id = %q", res.ExternalID)
+	}
+	if auth != "Bearer tok" || key != "new-hire-app-1" {
+		t.Errorf("headers: auth=%q key=%q", auth, key)
+	}
+	if got.FirstName != "สมชาย" || got.LastName != "ใจดี" || got.StartDate != "2026-11-01" || got.Salary != 55000 {
+		t.Errorf("record = %+v", got)
+	}
+}
+
+func TestHRISHTTPConnectorErrors(t *testing.T) {
+	cases := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusUnprocessableEntity, true},
+		{http.StatusUnauthorized, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, tc := range cases {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			w.Write([]byte("nope"))
+		}))
+		_, err := hris.NewHTTPConnector(stub.URL, "").Send(context.Background(), sampleNewHire())
+		stub.Close()
+		if err == nil {
+			t.Errorf("%d: no error", tc.status)
+			continue
+		}
+		if hris.IsPermanent(err) != tc.permanent {
+			t.Errorf("%d: permanent = %v, want %v", tc.status, hris.IsPermanent(err), tc.permanent)
+		}
+		if !strings.Contains(err.Error(), "nope") {
+			t.Errorf("%d: error does not carry the answer: %v", tc.status, err)
+		}
+	}
+}
+
+func TestHRISCSVConnectorReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	conn := &hris.CSVConnector{Dir: dir}
+	h := sampleNewHire()
+	for i := 0; i < 2; i++ { // a retry overwrites instead of adding a second file
+		res, err := conn.Send(context.Background(), h)
+		if err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		if res.ExternalID != "new_hire_app-1.csv" {
+			t.Errorf("external id = %q", res.ExternalID)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("files = %d, want 1", len(entries))
+	}
+	raw, _ := os.ReadFile(filepath.Join(dir, "new_hire_app-1.csv"))
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(raw), "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("rows = %v, err = %v", rows, err)
+	}
+	if strings.Join(rows[0], ",") != strings.Join(hris.CSVColumns, ",") {
+		t.Errorf("header = %v", rows[0])
+	}
+	if rows[1][2] != "สมชาย" || rows[1][12] != "55000.00" || rows[1][15] != "2026-11-01" {
+		t.Errorf("row = %v", rows[1])
+	}
+}
+
+func TestHRISBackoff(t *testing.T) {
+	if hris.Backoff(1) != time.Minute || hris.Backoff(3) != 4*time.Minute {
+		t.Errorf("backoff 1=%v 3=%v", hris.Backoff(1), hris.Backoff(3))
+	}
+	if hris.Backoff(20) != hris.MaxBackoff {
+		t.Errorf("backoff not capped: %v", hris.Backoff(20))
+	}
+}
+
+func TestHRISFromEnv(t *testing.T) {
+	t.Setenv("HRIS_CONNECTOR", "")
+	if c, err := hris.FromEnv(); c != nil || err != nil {
+		t.Errorf("unset: %v %v", c, err)
+	}
+	t.Setenv("HRIS_CONNECTOR", "csv")
+	t.Setenv("HRIS_CSV_DIR", "")
+	if _, err := hris.FromEnv(); err == nil {
+		t.Error("csv without dir accepted")
+	}
+	t.Setenv("HRIS_CSV_DIR", t.TempDir())
+	if c, err := hris.FromEnv(); err != nil || c.Name() != "csv" {
+		t.Errorf("csv: %v %v", c, err)
+	}
+	t.Setenv("HRIS_CONNECTOR", "sftp")
+	if _, err := hris.FromEnv(); err == nil {
+		t.Error("unknown connector accepted")
+	}
+}
@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"aats-backend-clean/i18n"
+)
+
+func TestI18nCataloguesMatch(t *testing.T) {
+	en := map[string]bool{}
+	for _, k := range i18n.Keys(i18n.English) {
+		en[k] = true
+	}
+	for _, lang := range i18n.Supported[1:] {
+		keys := map[string]bool{}
+		for _, k := range i18n.Keys(lang) {
+			keys[k] = true
+			if !en[k] {
+				t.Errorf("%s has %s, en does not", lang, k)
+			}
+		}
+		for k := range en {
+			if !keys[k] {
+				t.Errorf("%s is missing %s", lang, k)
+			}
+		}
+	}
+}
+
+// Every error code the handlers and middleware write needs a message.
+func TestI18nHasEveryErrorCode(t *testing.T) {
+	codeRe := regexp.MustCompile(`respond\.(?:Error|New)\((?:[\w.]+, )*"([A-Z_]+)"|Code:\s+"([A-Z_]+)"`)
+	files, _ := filepath.Glob("../handlers/*.go")
+	more, _ := filepath.Glob("../middleware/*.go")
+	files = append(append(files, more...), "../utils/policy.go", "../main.go")
+	seen := 0
+	for _, f := range files {
+		src, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range codeRe.FindAllStringSubmatch(string(src), -1) {
+			code := m[1] + m[2]
+			seen++
+			if !i18n.Has("errors." + code) {
+				t.Errorf("%s: no message for %s", filepath.Base(f), code)
+			}
+		}
+	}
+	if seen == 0 {
+		t.Fatal("found no error codes; has the respond API changed?")
+	}
+	if !i18n.Has("errors.INTERNAL_ERROR") {
+		t.Error("no message for INTERNAL_ERROR")
+	}
+}
+
+func TestI18nNegotiate(t *testing.T) {
+	cases := map[string]i18n.Lang{
+		"":                         i18n.English,
+		"th":                       i18n.Thai,
+		"th-TH,th;q=0.9,en;q=0.8":  i18n.Thai,
+		"en-US,th;q=0.5":           i18n.English,
+		"fr,th;q=0.3":              i18n.Thai,
+		"de":                       i18n.English,
+		"en;q=0.2, TH_th;q=0.7, *": i18n.Thai,
+		"th;q=0, en;q=0.1":         i18n.English,
+	}
+	for header, want := range cases {
+		if got := i18n.Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestI18nT(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	got := i18n.T(i18n.English, "errors.APPLICATION_COOLDOWN_ACTIVE", map[string]any{
+		"allowed_at": at, "months": 3, "stage": "screening", "rejected_at": at.AddDate(0, -3, 0),
+	})
+	if want := "You can apply for this job again from 2026-03-01 (3 months after the screening rejection on 2025-12-01)"; got != want {
+		t.Errorf("en = %q", got)
+	}
+	if got := i18n.T(i18n.Thai, "errors.JOB_NOT_FOUND", nil); got != "ไม่พบตำแหน่งงาน" {
+		t.Errorf("th = %q", got)
+	}
+	if got := i18n.T(i18n.Thai, "errors.NO_SUCH_CODE", nil); got != "errors.NO_SUCH_CODE" {
+		t.Errorf("unknown key = %q", got)
+	}
+}
+
+// textSources are the files that produce text people read; dev.go only
+// seeds sample data.
+func textSources(t *testing.T) []string {
+	var files []string
+	for _, dir := range []string{"../handlers", "../middleware", "../hris", "../worker", "../respond"} {
+		fs, _ := filepath.Glob(dir + "/*.go")
+		for _, f := range fs {
+			if !strings.HasSuffix(f, "_test.go") && filepath.Base(f) != "dev.go" {
+				files = append(files, f)
+			}
+		}
+	}
+	if len(files) == 0 {
+		t.Fatal("no sources found")
+	}
+	return append(files, "../main.go")
+}
+
+// literalText reports whether e is a string literal or a concatenation
+// with one.
+func literalText(e ast.Expr) bool {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.STRING
+	case *ast.ParenExpr:
+		return literalText(e.X)
+	case *ast.BinaryExpr:
+		return e.Op == token.ADD && (literalText(e.X) || literalText(e.Y))
+	}
+	return false
+}
+
+// Text shown to people must come from the catalogue: stored notifications
+// and timeline entries may not be written from literals, i18n.Text is only
+// for what people typed, every key looked up must exist, and no Thai text
+// is left in the code.
+func TestI18nNoUnkeyedText(t *testing.T) {
+	textFields := map[string]bool{"Title": true, "Message": true, "Description": true}
+	textTypes := map[string]bool{"Notification": true, "ApplicationTimeline": true, "Notif": true}
+	fset := token.NewFileSet()
+	consts := map[string]string{}
+	var files []*ast.File
+	for _, f := range textSources(t) {
+		file, err := parser.ParseFile(fset, f, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+		for _, d := range file.Decls {
+			if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.CONST {
+				for _, spec := range gd.Specs {
+					vs := spec.(*ast.ValueSpec)
+					for i, v := range vs.Values {
+						if lit, ok := v.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							consts[vs.Names[i].Name], _ = strconv.Unquote(lit.Value)
+						}
+					}
+				}
+			}
+		}
+	}
+	keyOf := func(e ast.Expr) (string, bool) {
+		switch e := e.(type) {
+		case *ast.BasicLit:
+			s, err := strconv.Unquote(e.Value)
+			return s, err == nil
+		case *ast.Ident:
+			s, ok := consts[e.Name]
+			return s, ok
+		}
+		return "", false
+	}
+	keys := 0
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CompositeLit:
+				var name string
+				switch typ := n.Type.(type) {
+				case *ast.Ident:
+					name = typ.Name
+				case *ast.SelectorExpr:
+					name = typ.Sel.Name
+				}
+				if !textTypes[name] {
+					break
+				}
+				for _, el := range n.Elts {
+					kv, ok := el.(*ast.KeyValueExpr)
+					if !ok {
+						continue
+					}
+					if field, ok := kv.Key.(*ast.Ident); ok && textFields[field.Name] && literalText(kv.Value) {
+						t.Errorf("%s: %s.%s is not from the catalogue", fset.Position(kv.Pos()), name, field.Name)
+					}
+				}
+			case *ast.CallExpr:
+				sel, ok := n.Fun.(*ast.SelectorExpr)
+				if !ok {
+					break
+				}
+				if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "i18n" {
+					break
+				}
+				arg := -1
+				switch sel.Sel.Name {
+				case "M":
+					arg = 0
+				case "T":
+					arg = 1
+				case "Text":
+					if len(n.Args) == 1 && literalText(n.Args[0]) {
+						t.Errorf("%s: i18n.Text of a literal; add a key instead", fset.Position(n.Pos()))
+					}
+				}
+				if arg < 0 || len(n.Args) <= arg {
+					break
+				}
+				key, ok := keyOf(n.Args[arg])
+				if !ok || key == "" { // M("", ...) only merges params
+					break
+				}
+				keys++
+				if !i18n.Has(key) {
+					t.Errorf("%s: no message for %q", fset.Position(n.Pos()), key)
+				}
+			case *ast.BasicLit:
+				if n.Kind == token.STRING && strings.IndexFunc(n.Value, func(r rune) bool { return unicode.Is(unicode.Thai, r) }) >= 0 {
+					t.Errorf("%s: Thai text in code; move it to the catalogue", fset.Position(n.Pos()))
+				}
+			}
+			return true
+		})
+	}
+	if keys == 0 {
+		t.Fatal("found no catalogue lookups; has the i18n API changed?")
+	}
+}
+
+func TestI18nDecodeRoundTrip(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	m := i18n.M("timeline.TAGS_CHANGED", map[string]any{"added": []string{"a", "b"}, "removed": []string{"c"}})
+	back := i18n.Decode(m.Key, m.EncodeParams(), "")
+	if got, want := back.In(i18n.English), m.In(i18n.English); got != want {
+		t.Errorf("round trip = %q, want %q", got, want)
+	}
+	m = i18n.M("notifications.OFFER_RECEIVED", map[string]any{"expires_at": at})
+	if got := i18n.Decode(m.Key, m.EncodeParams(), "").In(i18n.Thai); !strings.Contains(got, "2026-03-01") {
+		t.Errorf("time param = %q", got)
+	}
+	if got := i18n.Decode("", "", "typed by HR").In(i18n.Thai); got != "typed by HR" {
+		t.Errorf("plain text = %q", got)
+	}
+}
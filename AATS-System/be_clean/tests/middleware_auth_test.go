@@ -1,19 +1,105 @@
 package tests
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	"AATS-System/be_clean/middleware"
+
+	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/middleware"
+	"aats-backend-clean/models"
 )
 
+// whoami answers with what the auth middleware put in the context.
+func whoami(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"user_id": c.GetString("user_id"), "role": c.GetString("user_role")}})
+}
+
 func TestAuthMiddleware(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/", nil)
-	w := httptest.NewRecorder()
-	middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})).ServeHTTP(w, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", w.Code)
+	t.Setenv("JWT_SECRET", testSecret)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", middleware.AuthMiddleware(), whoami)
+
+	cases := []struct {
+		auth string
+		code int
+		want string
+	}{
+		{"", http.StatusUnauthorized, "AUTH_HEADER_MISSING"},
+		{"Token abc", http.StatusUnauthorized, "AUTH_HEADER_INVALID"},
+		{"Bearer not-a-jwt", http.StatusUnauthorized, "TOKEN_INVALID"},
+	}
+	for _, tc := range cases {
+		if code, out := call(t, r, "GET", "/", tc.auth, nil); code != tc.code || out["code"] != tc.want {
+			t.Errorf("%q: %d %v", tc.auth, code, out)
+		}
+	}
+	code, out := call(t, r, "GET", "/", tokenFor(t, models.User{ID: "u1", Role: "hr"}), nil)
+	if code != http.StatusOK || out["user_id"] != "u1" || out["role"] != "hr" {
+		t.Errorf("valid token: %d %v", code, out)
+	}
+}
+
+func TestOptionalAuth(t *testing.T) {
+	t.Setenv("JWT_SECRET", testSecret)
+	r := gin.New()
+	r.GET("/", middleware.OptionalAuth(), whoami)
+	if code, out := call(t, r, "GET", "/", "", nil); code != http.StatusOK || out["user_id"] != "" {
+		t.Errorf("anonymous: %d %v", code, out)
+	}
+	if code, out := call(t, r, "GET", "/", "Bearer not-a-jwt", nil); code != http.StatusOK || out["user_id"] != "" {
+		t.Errorf("bad token: %d %v", code, out)
+	}
+	if _, out := call(t, r, "GET", "/", tokenFor(t, models.User{ID: "u1", Role: "candidate"}), nil); out["user_id"] != "u1" {
+		t.Errorf("valid token: %v", out)
+	}
+}
+
+func TestRequireRoles(t *testing.T) {
+	t.Setenv("JWT_SECRET", testSecret)
+	r := gin.New()
+	r.GET("/staff", middleware.AuthMiddleware(), middleware.RequireRoles("hr", "hm"), whoami)
+	r.GET("/anonymous", middleware.RequireRoles("hr"), whoami)
+	for role, want := range map[string]int{"hr": http.StatusOK, "hm": http.StatusOK, "candidate": http.StatusForbidden} {
+		if code, _ := call(t, r, "GET", "/staff", tokenFor(t, models.User{ID: "u", Role: role}), nil); code != want {
+			t.Errorf("%s: got %d, want %d", role, code, want)
+		}
+	}
+	if code, out := call(t, r, "GET", "/anonymous", "", nil); code != http.StatusUnauthorized || out["code"] != "UNAUTHENTICATED" {
+		t.Errorf("without auth: %d %v", code, out)
+	}
+}
+
+func TestRecoveryAndNotFound(t *testing.T) {
+	prev := gin.DefaultErrorWriter
+	gin.DefaultErrorWriter = io.Discard // keep the expected stack trace out of the log
+	t.Cleanup(func() { gin.DefaultErrorWriter = prev })
+	r := gin.New()
+	r.Use(middleware.Recovery())
+	r.NoRoute(middleware.NotFound)
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+	if code, out := call(t, r, "GET", "/panic", "", nil); code != http.StatusInternalServerError || out["code"] != "INTERNAL_ERROR" {
+		t.Errorf("panic: %d %v", code, out)
+	}
+	if code, out := call(t, r, "GET", "/nowhere", "", nil); code != http.StatusNotFound || out["code"] != "ROUTE_NOT_FOUND" {
+		t.Errorf("unknown route: %d %v", code, out)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.CORS())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	for origin, allowed := range map[string]bool{"http://localhost:5173": true, "http://localhost:4000": true, "https://evil.example": false} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Header().Get("Access-Control-Allow-Origin") == origin; got != allowed {
+			t.Errorf("%s: allowed=%v, want %v", origin, got, allowed)
+		}
 	}
 }
@@ -2,13 +2,19 @@ package tests
 
 import (
 	"testing"
-	"AATS-System/be_clean/models"
+
+	"aats-backend-clean/models"
 )
 
-func TestDatabaseConnection(t *testing.T) {
-	db, err := models.ConnectDatabase()
-	if err != nil {
-		t.Fatalf("failed to connect to database: %v", err)
+func TestMigrate(t *testing.T) {
+	db := openSQLite(t)
+	for _, m := range []any{&models.User{}, &models.JobPosting{}, &models.Application{}, &models.ApplicationTimeline{}, &models.Evaluation{}, &models.ApplicationPolicy{}} {
+		if !db.Migrator().HasTable(m) {
+			t.Errorf("no table for %T", m)
+		}
+	}
+	// migrating an up-to-date schema is a no-op
+	if err := models.Migrate(db); err != nil {
+		t.Fatalf("second migrate: %v", err)
 	}
-	db.Close()
 }
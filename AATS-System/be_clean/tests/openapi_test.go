@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"aats-backend-clean/openapi"
+)
+
+// The committed spec must be what the generator produces from the current
+// routes and handlers; fix with: go run ./cmd/openapi
+func TestOpenAPISpecMatchesHandlers(t *testing.T) {
+	doc, err := openapi.Generate("..")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	raw, err := openapi.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !bytes.Equal(raw, openapi.JSON()) {
+		t.Fatal("openapi/openapi.json is out of date with the handlers; run: go run ./cmd/openapi")
+	}
+}
+
+func TestOpenAPISpecDescribesRoutes(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	op := doc.Operation("POST", "/api/jobs")
+	if op == nil || op.RequestBody == nil {
+		t.Fatal("POST /api/jobs has no request body")
+	}
+	if ref := op.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/CreateJobBody" {
+		t.Errorf("POST /api/jobs body = %q", ref)
+	}
+	if op.Responses["401"] == nil {
+		t.Error("POST /api/jobs does not document 401")
+	}
+	op = doc.Operation("PUT", "/api/webhooks/:id")
+	if op == nil || len(op.Roles) != 1 || op.Roles[0] != "hr" || op.Responses["403"] == nil {
+		t.Errorf("PUT /api/webhooks/{id} roles: %+v", op)
+	}
+	if doc.Operation("GET", "/api/public/jobs/:slug") == nil {
+		t.Error("GET /api/public/jobs/{slug} missing")
+	}
+}
+
+func TestOpenAPIValidateJSON(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	jobs := doc.Operation("POST", "/api/jobs")
+	cases := []struct {
+		body string
+		want map[string]string
+	}{
+		{`{"title": "Go Developer", "must_have_skills": ["go"]}`, nil},
+		{`{"Title": "Go Developer"}`, nil},
+		{`{"title": 5}`, map[string]string{"title": "must be a string"}},
+		{`{"department": "IT"}`, map[string]string{"title": "is required"}},
+		{`{"title": "x", "salary": 1}`, map[string]string{"salary": "unknown field"}},
+		{`{"title": "x", "must_have_skills": ["go", 1]}`, map[string]string{"must_have_skills[1]": "must be a string"}},
+		{`[]`, map[string]string{"body": "must be an object"}},
+		{`{"title": `, map[string]string{"body": "invalid JSON"}},
+		{``, map[string]string{"body": "is required"}},
+	}
+	for _, tc := range cases {
+		got := doc.ValidateJSON(jobs, []byte(tc.body))
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.body, got, tc.want)
+			continue
+		}
+		for k, v := range tc.want {
+			if got[k] != v {
+				t.Errorf("%s: %s = %q, want %q", tc.body, k, got[k], v)
+			}
+		}
+	}
+	apps := doc.Operation("POST", "/api/applications")
+	if errs := doc.ValidateJSON(apps, []byte(`{"job_id": "j1", "resumeUrl": "/uploads/cv.pdf"}`)); errs["resumeUrl"] != "unknown field" {
+		t.Errorf("resumeUrl accepted: %v", errs)
+	}
+	// a body the handler treats as optional may be left out
+	if errs := doc.ValidateJSON(doc.Operation("POST", "/api/offers/:id/approve"), nil); errs != nil {
+		t.Errorf("empty approve body rejected: %v", errs)
+	}
+}
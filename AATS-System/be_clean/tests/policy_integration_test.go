@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"aats-backend-clean/models"
+)
+
+func TestPolicyActiveApplicationCap(t *testing.T) {
+	e := newAPIEnv(t)
+	ann := e.user("ann", "candidate")
+	jobs := e.jobs(7)
+
+	first := e.mustApply(ann, jobs[0])
+	if _, code := e.apply(ann, jobs[0]); code != "APPLICATION_ALREADY_ACTIVE" {
+		t.Errorf("same job twice: %q", code)
+	}
+	for _, j := range jobs[1:5] {
+		e.mustApply(ann, j)
+	}
+	status, out := call(t, e.r, "POST", "/api/applications", ann, map[string]any{"job_id": jobs[5]})
+	if status != http.StatusBadRequest || out["code"] != "APPLICATION_LIMIT_REACHED" || out["rule"] != "max_active_applications" {
+		t.Fatalf("sixth application: %d %v", status, out)
+	}
+	var n int64
+	models.DB.Model(&models.Application{}).Where("applicant_id = ?", "ann").Count(&n)
+	if n != 5 {
+		t.Errorf("%d applications saved, want 5", n)
+	}
+
+	// a withdrawn (closed) application frees the slot
+	models.DB.Model(&models.Application{}).Where("id = ?", first).Update("status", "withdrawn")
+	e.mustApply(ann, jobs[5])
+	if _, code := e.apply(ann, jobs[6]); code != "APPLICATION_LIMIT_REACHED" {
+		t.Errorf("back at the cap: %q", code)
+	}
+}
+
+func TestPolicyRejectionWaits(t *testing.T) {
+	e := newAPIEnv(t)
+	ann := e.user("ann", "candidate")
+	jobs := e.jobs(2)
+
+	// rejected at screening: three months
+	app := e.mustApply(ann, jobs[0])
+	e.mustSetStatus(app, "screening")
+	e.mustSetStatus(app, "rejected")
+	e.clk.AddMonths(3)
+	e.clk.Advance(-time.Hour)
+	if _, code := e.apply(ann, jobs[0]); code != "APPLICATION_COOLDOWN_ACTIVE" {
+		t.Errorf("just under three months after screening rejection: %q", code)
+	}
+	e.clk.Advance(time.Hour)
+	e.mustApply(ann, jobs[0])
+
+	// rejected after an interview: six months
+	app = e.mustApply(ann, jobs[1])
+	e.clk.Advance(24 * time.Hour)
+	e.mustSetStatus(app, "interview")
+	e.clk.Advance(24 * time.Hour)
+	e.mustSetStatus(app, "rejected")
+	e.clk.AddMonths(5)
+	if _, code := e.apply(ann, jobs[1]); code != "APPLICATION_COOLDOWN_ACTIVE" {
+		t.Errorf("five months after interview rejection: %q", code)
+	}
+	e.clk.AddMonths(1)
+	e.mustApply(ann, jobs[1])
+}
+
+func TestPolicyHireCooldownAndEvaluation(t *testing.T) {
+	e := newAPIEnv(t)
+	ann := e.user("ann", "candidate")
+	jobs := e.jobs(2)
+
+	app := e.mustApply(ann, jobs[0])
+	e.mustSetStatus(app, "interview")
+	if code := e.setStatus(app, "withdrawn"); code != "WITHDRAW_CANDIDATE_ONLY" {
+		t.Errorf("HR withdrawing: %q", code)
+	}
+	if code := e.setStatus(app, "offer"); code != "EVALUATION_REQUIRED" {
+		t.Errorf("offer without evaluation: %q", code)
+	}
+	scores := map[string]any{"technical_skills": 4, "communication": 4, "problem_solving": 4, "cultural_fit": 4}
+	if code, out := call(t, e.r, "POST", "/api/applications/"+app+"/evaluation", e.hr, scores); code != http.StatusCreated {
+		t.Fatalf("HR evaluation: %d %v", code, out)
+	}
+	if code := e.setStatus(app, "offer"); code != "EVALUATION_NOT_BY_HM" {
+		t.Errorf("offer on HR evaluation: %q", code)
+	}
+	if code, out := call(t, e.r, "POST", "/api/applications/"+app+"/evaluation", e.hm, scores); code != http.StatusOK {
+		t.Fatalf("HM evaluation: %d %v", code, out)
+	}
+	e.mustSetStatus(app, "offer")
+	e.mustSetStatus(app, "hired")
+
+	var tls []models.ApplicationTimeline
+	models.DB.Where("application_id = ?", app).Order("date").Find(&tls)
+	var history []string
+	for _, tl := range tls {
+		history = append(history, tl.Status)
+	}
+	if want := []string{"submitted", "interview", "offer", "hired"}; !slices.Equal(history, want) {
+		t.Errorf("timeline %v, want %v", history, want)
+	}
+
+	if _, code := e.apply(ann, jobs[1]); code != "HIRE_COOLDOWN_ACTIVE" {
+		t.Errorf("right after the hire: %q", code)
+	}
+	e.clk.AddMonths(3)
+	e.mustApply(ann, jobs[1])
+}
+
+func TestPolicyJobOverrideAndExplain(t *testing.T) {
+	e := newAPIEnv(t)
+	ann := e.user("ann", "candidate")
+	jobs := e.jobs(3)
+
+	// job c accepts only applicants with no other active application
+	body := map[string]any{"scope": "job", "job_id": jobs[2], "rules": map[string]any{"max_active_applications": 1}, "effective_from": "2020-01-01"}
+	if code, out := call(t, e.r, "POST", "/api/policies", e.hr, body); code != http.StatusCreated {
+		t.Fatalf("create policy: %d %v", code, out)
+	}
+	if code, _ := call(t, e.r, "POST", "/api/policies", ann, body); code != http.StatusForbidden {
+		t.Errorf("candidate creating a policy: %d", code)
+	}
+	_, out := call(t, e.r, "GET", "/api/policies/effective?job_id="+jobs[2], e.hr, nil)
+	if cfg := out["policy"].(map[string]any); cfg["max_active_applications"] != 1.0 || cfg["interview_reject_wait_months"] != 6.0 {
+		t.Errorf("effective policy: %v", cfg)
+	}
+
+	e.mustApply(ann, jobs[0])
+	e.mustApply(ann, jobs[1])
+
+	_, out = call(t, e.r, "GET", "/api/policies/explain?job_id="+jobs[2], ann, nil)
+	violations, _ := out["violations"].([]any)
+	if out["allowed"] != false || len(violations) != 1 || violations[0].(map[string]any)["code"] != "APPLICATION_LIMIT_REACHED" {
+		t.Errorf("explain job c: %v", out)
+	}
+	if _, out = call(t, e.r, "GET", "/api/policies/explain?job_id="+jobs[0], ann, nil); out["allowed"] != false {
+		t.Errorf("explain job a (already applied): %v", out)
+	}
+	if _, code := e.apply(ann, jobs[2]); code != "APPLICATION_LIMIT_REACHED" {
+		t.Errorf("apply to job c: %q", code)
+	}
+}
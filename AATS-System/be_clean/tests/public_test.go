@@ -1,12 +1,35 @@
 package tests
 
 import (
+	"encoding/json"
+	"os"
 	"testing"
 )
 
-func TestPublicFolderExists(t *testing.T) {
-	// This is a placeholder test to check public folder
-	if false {
-		t.Error("public folder should exist")
+// The mock data in public/ is what the frontend develops against; every
+// record needs an id and the fields the screens link on.
+func TestPublicMockData(t *testing.T) {
+	for file, fields := range map[string][]string{
+		"../public/mock_jobs.json":         {"id", "title"},
+		"../public/mock_applications.json": {"id", "jobId", "status"},
+	} {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var records []map[string]any
+		if err := json.Unmarshal(raw, &records); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if len(records) == 0 {
+			t.Errorf("%s: no records", file)
+		}
+		for i, rec := range records {
+			for _, f := range fields {
+				if v, _ := rec[f].(string); v == "" {
+					t.Errorf("%s[%d]: missing %s", file, i, f)
+				}
+			}
+		}
 	}
 }
@@ -0,0 +1,284 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"aats-backend-clean/i18n"
+	"aats-backend-clean/models"
+	"aats-backend-clean/respond"
+	"aats-backend-clean/services"
+	"aats-backend-clean/store"
+	"aats-backend-clean/store/memstore"
+)
+
+// code is the problem or policy code err carries, "" for nil.
+func code(err error) string {
+	var p *respond.Problem
+	var pe *services.PolicyError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &p):
+		return p.Code
+	case errors.As(err, &pe):
+		return pe.Error()
+	}
+	return "unexpected: " + err.Error()
+}
+
+// newServices is the services on an empty in-memory store, on clk.
+func newServices(clk *clock) (*services.Services, store.Store) {
+	st := memstore.New()
+	svc := services.New(st, testSecret)
+	svc.Applications.Now, svc.Evaluations.Now = clk.Now, clk.Now
+	return svc, st
+}
+
+func TestAuthService(t *testing.T) {
+	svc, st := newServices(newClock())
+	auth := svc.Auth
+
+	u, err := auth.Register(services.Registration{Email: "ann@example.com", Password: "secret1", Name: "Ann", Role: "candidate", Phone: "081-234-5678"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Password == "secret1" || u.PhoneKey != "0812345678" {
+		t.Errorf("registered user: password %q, phone key %q", u.Password, u.PhoneKey)
+	}
+	if _, err := auth.Register(services.Registration{Email: "ann@example.com", Password: "x", Role: "candidate"}); code(err) != "EMAIL_TAKEN" {
+		t.Errorf("duplicate email: %v", err)
+	}
+
+	if _, _, err := auth.Login("ann@example.com", "nope"); code(err) != "INVALID_CREDENTIALS" {
+		t.Errorf("wrong password: %v", err)
+	}
+	if _, _, err := auth.Login("bob@example.com", "secret1"); code(err) != "INVALID_CREDENTIALS" {
+		t.Errorf("unknown email: %v", err)
+	}
+	got, token, err := auth.Login("ann@example.com", "secret1")
+	if err != nil || got.ID != u.ID || token == "" {
+		t.Fatalf("login: %v %v %q", err, got.ID, token)
+	}
+
+	merged := models.User{ID: "old", Email: "old@example.com", Password: u.Password, Role: "candidate", MergedInto: u.ID}
+	if err := st.Users().Create(&merged); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := auth.Login("old@example.com", "secret1"); code(err) != "ACCOUNT_MERGED" {
+		t.Errorf("merged account: %v", err)
+	}
+
+	if _, err := auth.User("missing"); code(err) != "USER_NOT_FOUND" {
+		t.Errorf("missing user: %v", err)
+	}
+	if _, err := services.NewAuthService(st.Users(), "").Token(u); err == nil {
+		t.Error("token signed without a secret")
+	}
+}
+
+func TestJobService(t *testing.T) {
+	svc, st := newServices(newClock())
+	for _, j := range []models.JobPosting{{ID: "open", Title: "Go developer", Status: "published"}, {ID: "draft", Title: "Secret", Status: "draft"}} {
+		if err := st.Jobs().Create(&j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := svc.Jobs.Get("open", false); err != nil {
+		t.Errorf("published job: %v", err)
+	}
+	if _, err := svc.Jobs.Get("draft", false); code(err) != "JOB_NOT_FOUND" {
+		t.Errorf("draft to the public: %v", err)
+	}
+	if _, err := svc.Jobs.Get("draft", true); err != nil {
+		t.Errorf("draft to staff: %v", err)
+	}
+	if _, err := svc.Jobs.Get("missing", true); code(err) != "JOB_NOT_FOUND" {
+		t.Errorf("missing job: %v", err)
+	}
+	jobs, total, err := svc.Jobs.List(store.JobFilter{ExcludeStatus: "draft"}, store.Page{})
+	if err != nil || total != 1 || jobs[0].ID != "open" {
+		t.Errorf("list: %v %d %v", err, total, jobs)
+	}
+}
+
+// submit applies applicant to job through the service.
+func submit(t *testing.T, apps *services.ApplicationService, applicant, job string) (models.Application, error) {
+	t.Helper()
+	app := models.Application{ApplicantID: applicant, JobID: job}
+	_, err := apps.Submit(&app)
+	return app, err
+}
+
+func TestApplicationServiceSubmit(t *testing.T) {
+	clk := newClock()
+	svc, st := newServices(clk)
+	apps := svc.Applications
+
+	app, err := submit(t, apps, "ann", "j1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Status != "submitted" || !app.SubmittedDate.Equal(clk.Now()) {
+		t.Errorf("submitted application: %+v", app)
+	}
+	tls, _ := st.Timelines().ForApplication(app.ID)
+	if len(tls) != 1 || tls[0].Status != "submitted" || tls[0].DescriptionKey != "timeline.APPLICATION_SUBMITTED" {
+		t.Errorf("timeline: %+v", tls)
+	}
+
+	if _, err := submit(t, apps, "ann", "j1"); code(err) != "APPLICATION_ALREADY_ACTIVE" {
+		t.Errorf("same job twice: %v", err)
+	}
+	for _, job := range []string{"j2", "j3", "j4", "j5"} {
+		if _, err := submit(t, apps, "ann", job); err != nil {
+			t.Fatalf("%s: %v", job, err)
+		}
+	}
+	_, err = submit(t, apps, "ann", "j6")
+	var pe *services.PolicyError
+	if !errors.As(err, &pe) || pe.Violations[0].Code != "APPLICATION_LIMIT_REACHED" {
+		t.Fatalf("sixth application: %v", err)
+	}
+	if all, _ := st.Applications().ForApplicant("ann"); len(all) != 5 {
+		t.Errorf("refused application was saved: %d applications", len(all))
+	}
+
+	// closing one frees a slot
+	if _, err := apps.SetStatus(&app, "rejected", i18n.M("timeline.STATUS_CHANGED")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := submit(t, apps, "ann", "j6"); err != nil {
+		t.Errorf("after a rejection: %v", err)
+	}
+}
+
+func TestApplicationServiceCooldowns(t *testing.T) {
+	clk := newClock()
+	svc, _ := newServices(clk)
+	apps := svc.Applications
+	desc := i18n.M("timeline.STATUS_CHANGED")
+
+	// rejected at screening: three months before reapplying
+	screened, _ := submit(t, apps, "ann", "j1")
+	apps.SetStatus(&screened, "rejected", desc)
+	clk.AddMonths(2)
+	if _, err := submit(t, apps, "ann", "j1"); code(err) != "APPLICATION_COOLDOWN_ACTIVE" {
+		t.Errorf("two months after screening rejection: %v", err)
+	}
+	clk.AddMonths(1)
+	if _, err := submit(t, apps, "ann", "j1"); err != nil {
+		t.Errorf("three months after screening rejection: %v", err)
+	}
+
+	// rejected after an interview: six months
+	interviewed, _ := submit(t, apps, "bob", "j2")
+	clk.Advance(24 * time.Hour)
+	apps.SetStatus(&interviewed, "interview", desc)
+	clk.Advance(24 * time.Hour)
+	apps.SetStatus(&interviewed, "rejected", desc)
+	clk.AddMonths(4)
+	if _, err := submit(t, apps, "bob", "j2"); code(err) != "APPLICATION_COOLDOWN_ACTIVE" {
+		t.Errorf("four months after interview rejection: %v", err)
+	}
+	clk.AddMonths(2)
+	if _, err := submit(t, apps, "bob", "j2"); err != nil {
+		t.Errorf("six months after interview rejection: %v", err)
+	}
+
+	// hired: three months before applying elsewhere
+	hired, _ := submit(t, apps, "cat", "j3")
+	apps.SetStatus(&hired, "hired", desc)
+	if _, err := submit(t, apps, "cat", "j4"); code(err) != "HIRE_COOLDOWN_ACTIVE" {
+		t.Errorf("right after a hire: %v", err)
+	}
+	clk.AddMonths(3)
+	if _, err := submit(t, apps, "cat", "j4"); err != nil {
+		t.Errorf("three months after a hire: %v", err)
+	}
+}
+
+func TestApplicationServiceJobPolicy(t *testing.T) {
+	clk := newClock()
+	svc, st := newServices(clk)
+	if err := st.Policies().Create(&models.ApplicationPolicy{ID: "p1", Scope: "job", JobID: "j1", Rules: `{"max_active_applications":1}`}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := svc.Applications.Policy("j1", clk.Now()); cfg.MaxActiveApplications != 1 {
+		t.Errorf("j1 cap: %d", cfg.MaxActiveApplications)
+	}
+	if cfg, _ := svc.Applications.Policy("j2", clk.Now()); cfg.MaxActiveApplications != 5 {
+		t.Errorf("j2 cap: %d", cfg.MaxActiveApplications)
+	}
+	submit(t, svc.Applications, "ann", "j2")
+	if _, err := submit(t, svc.Applications, "ann", "j1"); code(err) != "APPLICATION_LIMIT_REACHED" {
+		t.Errorf("j1 with one active application: %v", err)
+	}
+}
+
+func TestApplicationServiceChangeStatus(t *testing.T) {
+	svc, st := newServices(newClock())
+	for _, u := range []models.User{{ID: "hm1", Email: "hm@example.com", Role: "hm"}, {ID: "hr1", Email: "hr@example.com", Role: "hr"}} {
+		st.Users().Create(&u)
+	}
+	app, _ := submit(t, svc.Applications, "ann", "j1")
+	desc := i18n.M("timeline.STATUS_CHANGED")
+
+	if _, err := svc.Applications.ChangeStatus("missing", "screening", desc); code(err) != "APPLICATION_NOT_FOUND" {
+		t.Errorf("missing application: %v", err)
+	}
+	if _, err := svc.Applications.ChangeStatus(app.ID, "withdrawn", desc); code(err) != "WITHDRAW_CANDIDATE_ONLY" {
+		t.Errorf("staff withdrawing: %v", err)
+	}
+	change, err := svc.Applications.ChangeStatus(app.ID, "interview", desc)
+	if err != nil || change.Previous != "submitted" || change.Application.Status != "interview" || change.Timeline.Status != "interview" {
+		t.Fatalf("to interview: %v %+v", err, change)
+	}
+	if _, err := svc.Applications.ChangeStatus(app.ID, "offer", desc); code(err) != "EVALUATION_REQUIRED" {
+		t.Errorf("offer without evaluation: %v", err)
+	}
+	if _, _, err := svc.Evaluations.Submit(app.ID, "hr1", services.Scores{TechnicalSkills: 4, Communication: 4, ProblemSolving: 4, CulturalFit: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Applications.ChangeStatus(app.ID, "offer", desc); code(err) != "EVALUATION_NOT_BY_HM" {
+		t.Errorf("offer on an HR evaluation: %v", err)
+	}
+	svc.Evaluations.Submit(app.ID, "hm1", services.Scores{TechnicalSkills: 4, Communication: 4, ProblemSolving: 4, CulturalFit: 4})
+	if _, err := svc.Applications.ChangeStatus(app.ID, "offer", desc); err != nil {
+		t.Errorf("offer on an HM evaluation: %v", err)
+	}
+	if tls, _ := st.Timelines().ForApplication(app.ID); len(tls) != 3 {
+		t.Errorf("timeline has %d entries, want 3", len(tls))
+	}
+}
+
+func TestEvaluationService(t *testing.T) {
+	clk := newClock()
+	svc, st := newServices(clk)
+	st.Applications().Create(&models.Application{ID: "a1", Status: "interview"})
+	st.Applications().Create(&models.Application{ID: "a2", Status: "submitted"})
+
+	if _, _, err := svc.Evaluations.Submit("a2", "hm1", services.Scores{}); code(err) != "EVALUATION_TOO_EARLY" {
+		t.Errorf("submitted application: %v", err)
+	}
+	ev, created, err := svc.Evaluations.Submit("a1", "hm1", services.Scores{TechnicalSkills: 5, Communication: 4, ProblemSolving: 3, CulturalFit: 4})
+	if err != nil || !created || ev.OverallScore != 4 || !ev.EvaluatedAt.Equal(clk.Now()) {
+		t.Fatalf("submit: %v %v %+v", err, created, ev)
+	}
+	if _, created, _ := svc.Evaluations.Submit("a1", "hm1", services.Scores{TechnicalSkills: 1, Communication: 1, ProblemSolving: 1, CulturalFit: 1, OverallScore: 2.5}); created {
+		t.Error("second submit created another evaluation")
+	}
+
+	// the legacy single score keeps the criteria
+	if _, err := svc.Evaluations.Rate("a1", models.User{ID: "hm2", Name: "Mali"}, 4.5, "strong"); err != nil {
+		t.Fatal(err)
+	}
+	ev, err = svc.Evaluations.Get("a1")
+	if err != nil || ev.OverallScore != 4.5 || ev.TechnicalSkills != 1 || ev.EvaluatorName != "Mali" || ev.Comments != "strong" {
+		t.Errorf("after rate: %v %+v", err, ev)
+	}
+	if _, err := svc.Evaluations.Get("a2"); code(err) != "EVALUATION_NOT_FOUND" {
+		t.Errorf("unevaluated: %v", err)
+	}
+}
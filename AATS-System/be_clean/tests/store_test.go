@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"aats-backend-clean/models"
+	"aats-backend-clean/store"
+	"aats-backend-clean/store/memstore"
+	"aats-backend-clean/store/storetest"
+)
+
+func TestStoreSQLite(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store { return store.New(openSQLite(t)) })
+}
+
+func TestStoreMemory(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store { return memstore.New() })
+}
+
+// TestStorePostgres runs against TEST_DATABASE_URL, whose tables it empties.
+func TestStorePostgres(t *testing.T) {
+	db := openPostgres(t)
+	storetest.Run(t, func(t *testing.T) store.Store {
+		emptyPostgres(t, db)
+		return store.New(db)
+	})
+}
+
+// openPostgres is the migrated TEST_DATABASE_URL database; the test is
+// skipped when it is not set.
+func openPostgres(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := models.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// emptyPostgres empties every table of the schema.
+func emptyPostgres(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	var tables []string
+	if err := db.Raw("SELECT tablename FROM pg_tables WHERE schemaname = current_schema()").Scan(&tables).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) == 0 {
+		return
+	}
+	if err := db.Exec(`TRUNCATE "` + strings.Join(tables, `", "`) + `"`).Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"aats-backend-clean/utils"
+)
+
+func TestValidateCustomValue(t *testing.T) {
+	visa := utils.FieldDef{Key: "visa", Type: utils.FieldEnum, Options: []string{"Citizen", "Work permit", "None"}}
+	v, err := utils.ValidateCustomValue(visa, "work PERMIT")
+	if err != nil || v.Text != "Work permit" {
+		t.Errorf("enum: got %+v %v", v, err)
+	}
+	if _, err := utils.ValidateCustomValue(visa, "tourist"); err == nil {
+		t.Error("enum: expected an error for an unknown option")
+	}
+
+	salary := utils.FieldDef{Key: "salary", Type: utils.FieldNumber}
+	for _, raw := range []interface{}{45000.0, " 45000 "} {
+		v, err := utils.ValidateCustomValue(salary, raw)
+		if err != nil || v.Number == nil || *v.Number != 45000 || v.Text != "45000" {
+			t.Errorf("number %v: got %+v %v", raw, v, err)
+		}
+	}
+	if _, err := utils.ValidateCustomValue(salary, "lots"); err == nil {
+		t.Error("number: expected an error")
+	}
+
+	start := utils.FieldDef{Key: "start", Type: utils.FieldDate}
+	v, err = utils.ValidateCustomValue(start, "2026-11-02T15:04:05+07:00")
+	if err != nil || v.Text != "2026-11-02" || v.Date == nil {
+		t.Errorf("date: got %+v %v", v, err)
+	}
+	if _, err := utils.ValidateCustomValue(start, "next week"); err == nil {
+		t.Error("date: expected an error")
+	}
+
+	langs := utils.FieldDef{Key: "langs", Type: utils.FieldMultiSelect, Options: []string{"Thai", "English", "Japanese"}}
+	v, err = utils.ValidateCustomValue(langs, []interface{}{"japanese", "Thai", "thai"})
+	if err != nil || v.Text != `["Thai","Japanese"]` {
+		t.Errorf("multi_select: got %+v %v", v, err)
+	}
+	if got := utils.DecodeCustomValue(utils.FieldMultiSelect, v); !reflect.DeepEqual(got, []string{"Thai", "Japanese"}) {
+		t.Errorf("decode multi_select: got %v", got)
+	}
+	if _, err := utils.ValidateCustomValue(langs, "Thai"); err == nil {
+		t.Error("multi_select: expected an error for a non-list")
+	}
+}
+
+func TestValidateFieldOptions(t *testing.T) {
+	if _, err := utils.ValidateFieldOptions(utils.FieldEnum, []string{"A", " a "}); err == nil {
+		t.Error("expected an error for duplicate options")
+	}
+	if _, err := utils.ValidateFieldOptions(utils.FieldMultiSelect, []string{" "}); err == nil {
+		t.Error("expected an error for no options")
+	}
+	if got, err := utils.ValidateFieldOptions(utils.FieldText, []string{"x"}); err != nil || got != nil {
+		t.Errorf("text fields take no options: got %v %v", got, err)
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"testing"
+
+	"aats-backend-clean/utils"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	cases := map[string]string{
+		"081-234-5678":     "0812345678",
+		"+66 81 234 5678":  "0812345678",
+		"(+66) 2 123 4567": "021234567",
+		"0066812345678":    "0812345678",
+		"1234":             "",
+		"":                 "",
+	}
+	for in, want := range cases {
+		if got := utils.NormalizePhone(in); got != want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	if got := utils.NameSimilarity("Dr. Doe, John", "john  doe"); got != 1 {
+		t.Errorf("reordered name with title: got %v, want 1", got)
+	}
+	if got := utils.NameSimilarity("Somchai Jaidee", "Somchai Jaidii"); got < utils.DuplicateNameThreshold {
+		t.Errorf("one-letter typo: got %v, want >= %v", got, utils.DuplicateNameThreshold)
+	}
+	if got := utils.NameSimilarity("Somchai Jaidee", "Anan Srisuk"); got >= utils.DuplicateNameThreshold {
+		t.Errorf("different people: got %v", got)
+	}
+	if got := utils.NameSimilarity("", "John"); got != 0 {
+		t.Errorf("empty name: got %v, want 0", got)
+	}
+}
+
+func TestScoreDuplicate(t *testing.T) {
+	a := utils.DuplicateProfile{ID: "a", Name: "John Doe", Phone: "0812345678", ResumeHashes: []string{"h1"}}
+
+	// a similar name alone is not enough
+	score, _ := utils.ScoreDuplicate(a, utils.DuplicateProfile{ID: "b", Name: "Jon Doe"})
+	if score >= utils.DuplicateThreshold {
+		t.Errorf("name only: score %v should be below the threshold", score)
+	}
+
+	score, reasons := utils.ScoreDuplicate(a, utils.DuplicateProfile{ID: "c", Name: "Someone Else", Phone: "0812345678"})
+	if score < utils.DuplicateThreshold || len(reasons) != 1 || reasons[0] != utils.DuplicateReasonPhone {
+		t.Errorf("same phone: got %v %v", score, reasons)
+	}
+
+	score, reasons = utils.ScoreDuplicate(a, utils.DuplicateProfile{ID: "d", Name: "john doe", Phone: "0812345678", ResumeHashes: []string{"x", "h1"}})
+	if score != 1 || len(reasons) != 3 {
+		t.Errorf("everything matches: got %v %v", score, reasons)
+	}
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"aats-backend-clean/utils"
+)
+
+func feedFixture() (utils.FeedMeta, []utils.FeedJob) {
+	posted := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	meta := utils.FeedMeta{Title: "ACME jobs", Publisher: "ACME", Link: "https://jobs.example.com", Updated: posted}
+	jobs := []utils.FeedJob{{
+		JobPostingInfo: utils.JobPostingInfo{
+			ID: "j1", Title: "R&D <Lead>", Description: "Ends with ]]> oops", Department: "R&D",
+			Location: "Bangkok", PostedDate: posted, URL: "https://jobs.example.com/jobs/rd-lead-j1", OrganizationName: "ACME", Country: "TH",
+		},
+		Slug: "rd-lead-j1", UpdatedAt: posted, Custom: map[string]string{"band": "P2"},
+	}}
+	return meta, jobs
+}
+
+func TestRSSAndAtomAreWellFormed(t *testing.T) {
+	meta, jobs := feedFixture()
+	var rss struct {
+		Items []struct {
+			Title string `xml:"title"`
+			Link  string `xml:"link"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(utils.RSSFeed(meta, jobs), &rss); err != nil {
+		t.Fatalf("rss: %v", err)
+	}
+	if len(rss.Items) != 1 || rss.Items[0].Title != "R&D <Lead>" || rss.Items[0].Link != jobs[0].URL {
+		t.Errorf("rss items = %+v", rss.Items)
+	}
+	var atom struct {
+		Entries []struct {
+			ID string `xml:"id"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(utils.AtomFeed(meta, jobs), &atom); err != nil {
+		t.Fatalf("atom: %v", err)
+	}
+	if len(atom.Entries) != 1 || atom.Entries[0].ID != "urn:jobs:job:j1" {
+		t.Errorf("atom entries = %+v", atom.Entries)
+	}
+}
+
+func TestJobXMLFeedMapping(t *testing.T) {
+	meta, jobs := feedFixture()
+	fields := []utils.FeedField{
+		{Element: "title", Source: "title", CDATA: true},
+		{Element: "desc", Source: "description", CDATA: true},
+		{Element: "band", Source: "cf.band"},
+		{Element: "jobtype", Source: "const:fulltime"},
+		{Element: "date", Source: "posted_date_iso"},
+	}
+	out := utils.JobXMLFeed(meta, "source", "job", fields, jobs)
+	var feed struct {
+		Jobs []struct {
+			Title   string `xml:"title"`
+			Desc    string `xml:"desc"`
+			Band    string `xml:"band"`
+			JobType string `xml:"jobtype"`
+			Date    string `xml:"date"`
+		} `xml:"job"`
+	}
+	if err := xml.Unmarshal(out, &feed); err != nil {
+		t.Fatalf("xml: %v\n%s", err, out)
+	}
+	j := feed.Jobs[0]
+	if j.Title != "R&D <Lead>" || j.Desc != "Ends with ]]> oops" || j.Band != "P2" || j.JobType != "fulltime" || j.Date != "2026-03-01" {
+		t.Errorf("mapped job = %+v", j)
+	}
+}
+
+func TestValidateFeedFields(t *testing.T) {
+	if err := utils.ValidateFeedFields(utils.DefaultJobXMLFields); err != nil {
+		t.Errorf("default fields rejected: %v", err)
+	}
+	bad := [][]utils.FeedField{
+		nil,
+		{{Element: "1title", Source: "title"}},
+		{{Element: "xmlfoo", Source: "title"}},
+		{{Element: "title", Source: "salary"}},
+		{{Element: "title", Source: "cf."}},
+	}
+	for _, f := range bad {
+		if err := utils.ValidateFeedFields(f); err == nil {
+			t.Errorf("accepted %+v", f)
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tag := utils.FeedETag("body")
+	if tag != utils.FeedETag("body") || tag == utils.FeedETag("body2") || !strings.HasPrefix(tag, `"`) {
+		t.Fatalf("FeedETag not stable/quoted: %s", tag)
+	}
+	for _, h := range []string{tag, `"x", ` + tag, "W/" + tag, "*"} {
+		if !utils.ETagMatches(h, tag) {
+			t.Errorf("If-None-Match %q should match", h)
+		}
+	}
+	if utils.ETagMatches(`"other"`, tag) {
+		t.Error("different tag matched")
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"aats-backend-clean/utils"
+)
+
+func TestJobSlug(t *testing.T) {
+	cases := map[string]string{
+		"Senior Go Engineer (Backend)": "senior-go-engineer-backend-3f9a2c1d",
+		"  C++ / Qt  ":                 "c-qt-3f9a2c1d",
+		"นักพัฒนาซอฟต์แวร์ (Frontend)": "นักพัฒนาซอฟต์แวร์-frontend-3f9a2c1d",
+		"!!!": "job-3f9a2c1d",
+	}
+	for title, want := range cases {
+		if got := utils.JobSlug(title, "3f9a2c1d-0000-4000-8000-000000000000"); got != want {
+			t.Errorf("JobSlug(%q) = %q, want %q", title, got, want)
+		}
+	}
+	if got := utils.Slugify(strings.Repeat("word ", 40)); len([]rune(got)) > 80 || strings.HasSuffix(got, "-") {
+		t.Errorf("long title not trimmed cleanly: %q", got)
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	got := utils.SearchTerms(`Go  "Data   Engineer" go remote`)
+	want := []string{"go", "data engineer", "remote"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchTerms = %q, want %q", got, want)
+	}
+	if got := utils.SearchText("  Data\nEngineer ", "PYTHON"); got != "data engineer python" {
+		t.Errorf("SearchText = %q", got)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	in := utils.JobCursor{Sort: "newest", Key: time.Date(2026, 3, 1, 9, 30, 0, 123000, time.UTC), ID: "j1"}
+	out, err := utils.DecodeCursor(utils.EncodeCursor(in))
+	if err != nil || out.ID != in.ID || out.Sort != in.Sort || !out.Key.Equal(in.Key) {
+		t.Fatalf("round trip = %+v, %v", out, err)
+	}
+	if _, err := utils.DecodeCursor("not a cursor"); err == nil {
+		t.Error("garbage cursor accepted")
+	}
+}
+
+func TestJobPostingJSONLD(t *testing.T) {
+	ld := utils.JobPostingJSONLD(utils.JobPostingInfo{
+		ID: "j1", Title: "Data Engineer", Description: "Build <pipelines>", Requirements: []string{"SQL"},
+		Location: "Head office / Remote", MinExperienceYears: 2.5, OrganizationName: "ACME", Country: "TH",
+		PostedDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), URL: "https://jobs.example.com/jobs/data-engineer-j1",
+	})
+	if ld["@type"] != "JobPosting" || ld["datePosted"] != "2026-03-01" || ld["url"] == nil {
+		t.Errorf("basic fields: %v", ld)
+	}
+	if _, ok := ld["validThrough"]; ok {
+		t.Error("validThrough set for an open-ended job")
+	}
+	if desc := ld["description"].(string); !strings.Contains(desc, "&lt;pipelines&gt;") || !strings.Contains(desc, "<li>SQL</li>") {
+		t.Errorf("description = %q", desc)
+	}
+	if ld["jobLocationType"] != "TELECOMMUTE" {
+		t.Error("remote job without jobLocationType")
+	}
+	addr := ld["jobLocation"].(map[string]interface{})["address"].(map[string]interface{})
+	if addr["addressLocality"] != "Head office" {
+		t.Errorf("locality = %v", addr["addressLocality"])
+	}
+	if exp := ld["experienceRequirements"].(map[string]interface{}); exp["monthsOfExperience"] != 30 {
+		t.Errorf("experience = %v", exp)
+	}
+}
@@ -0,0 +1,19 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"aats-backend-clean/utils"
+)
+
+func TestParseMentions(t *testing.T) {
+	got := utils.ParseMentions("@Jane.Doe@corp.com can you check? cc @bob@corp.co.th, and again @jane.doe@corp.com. mail me at ann@corp.com")
+	want := []string{"jane.doe@corp.com", "bob@corp.co.th"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := utils.ParseMentions("no mentions here @ all"); len(got) != 0 {
+		t.Errorf("got %v, want none", got)
+	}
+}
@@ -1,17 +1,32 @@
 package tests
 
 import (
+	"net/http/httptest"
 	"testing"
-	"AATS-System/be_clean/utils"
+
+	"github.com/gin-gonic/gin"
+
+	"aats-backend-clean/utils"
 )
 
-func TestPaginate(t *testing.T) {
-	items := []int{1, 2, 3, 4, 5}
-	page, total := utils.Paginate(items, 1, 2)
-	if total != 5 {
-		t.Errorf("expected total 5, got %d", total)
+func TestParsePagination(t *testing.T) {
+	cases := []struct {
+		query               string
+		page, limit, offset int
+	}{
+		{"", 1, 20, 0},
+		{"page=3&limit=10", 3, 10, 20},
+		{"page=0&limit=-5", 1, 20, 0},
+		{"page=x&limit=y", 1, 20, 0},
+		{"page=2&limit=1000", 2, 100, 100},
+		{"page=2&pageSize=5", 2, 20, 20},
 	}
-	if len(page) != 2 {
-		t.Errorf("expected page size 2, got %d", len(page))
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/?"+tc.query, nil)
+		page, limit, offset := utils.ParsePagination(c, 1, 20, 100, "limit")
+		if page != tc.page || limit != tc.limit || offset != tc.offset {
+			t.Errorf("%q: got %d/%d/%d, want %d/%d/%d", tc.query, page, limit, offset, tc.page, tc.limit, tc.offset)
+		}
 	}
 }
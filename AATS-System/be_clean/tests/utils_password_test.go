@@ -2,16 +2,22 @@ package tests
 
 import (
 	"testing"
-	"AATS-System/be_clean/utils"
+
+	"aats-backend-clean/utils"
 )
 
 func TestHashPassword(t *testing.T) {
-	password := "secret"
-	hash, err := utils.HashPassword(password)
+	hash, err := utils.HashPassword("secret")
 	if err != nil {
 		t.Fatalf("failed to hash password: %v", err)
 	}
-	if !utils.CheckPasswordHash(password, hash) {
-		t.Errorf("password hash does not match")
+	if hash == "secret" {
+		t.Fatal("password stored in clear")
+	}
+	if !utils.CheckPasswordHash(hash, "secret") {
+		t.Error("password hash does not match")
+	}
+	if utils.CheckPasswordHash(hash, "Secret") {
+		t.Error("wrong password accepted")
 	}
 }
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"aats-backend-clean/utils"
+)
+
+func TestHTMLToText(t *testing.T) {
+	in := `<html><head><style>p{color:red}</style></head><body>
+<h1>Offer   of employment</h1>
+<p>Dear Ann,<br>we are pleased &amp; proud.</p>
+<ul><li>Health</li><li>Bonus</li></ul></body></html>`
+	want := []string{"Offer of employment", "", "Dear Ann,", "we are pleased & proud.", "", "- Health", "- Bonus"}
+	if got := utils.HTMLToText(in); !reflect.DeepEqual(got, want) {
+		t.Fatalf("HTMLToText = %q, want %q", got, want)
+	}
+}
+
+func TestWriteTextPDF(t *testing.T) {
+	lines := []string{"Salary (monthly): 50,000 THB", strings.Repeat("word ", 60)}
+	for i := 0; i < 60; i++ {
+		lines = append(lines, "line")
+	}
+	var buf bytes.Buffer
+	if err := utils.WriteTextPDF(&buf, "Offer", lines); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-1.4") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if !strings.Contains(out, `(Salary \(monthly\): 50,000 THB) Tj`) {
+		t.Error("parentheses in text are not escaped")
+	}
+	if !strings.Contains(out, "/Count 2") {
+		t.Error("expected the long letter to break onto a second page")
+	}
+	checkXref(t, out)
+}
+
+// checkXref checks every xref entry points at its object.
+func checkXref(t *testing.T, out string) {
+	t.Helper()
+	i := strings.Index(out, "xref\n")
+	rows := strings.Split(out[i:], "\n")
+	n, _ := strconv.Atoi(strings.Fields(rows[1])[1])
+	for obj := 1; obj < n; obj++ {
+		off, err := strconv.Atoi(rows[2+obj][:10])
+		if err != nil || !strings.HasPrefix(out[off:], strconv.Itoa(obj)+" 0 obj") {
+			t.Errorf("xref entry %q does not point at object %d", rows[2+obj], obj)
+		}
+	}
+}
+
+// testFont builds a TrueType font with just the tables the PDF writer reads:
+// .notdef is glyph 0, space glyph 1 and chars[i] glyph i+2, all 500 units
+// wide on a 1000 unit em (marks 0).
+func testFont(chars []rune) []byte {
+	be := binary.BigEndian
+	runes := append([]rune{' '}, chars...)
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	glyph := map[rune]uint16{' ': 1}
+	for i, r := range chars {
+		glyph[r] = uint16(i + 2)
+	}
+
+	// cmap format 4: one segment per char plus the closing 0xFFFF one
+	segs := len(runes) + 1
+	sub := make([]byte, 16+8*segs)
+	be.PutUint16(sub[0:], 4)
+	be.PutUint16(sub[2:], uint16(len(sub)))
+	be.PutUint16(sub[6:], uint16(2*segs))
+	for i := 0; i < segs; i++ {
+		c, delta := uint16(0xFFFF), uint16(1)
+		if i < len(runes) {
+			c, delta = uint16(runes[i]), glyph[runes[i]]-uint16(runes[i])
+		}
+		be.PutUint16(sub[14+2*i:], c)
+		be.PutUint16(sub[16+2*segs+2*i:], c)
+		be.PutUint16(sub[16+4*segs+2*i:], delta)
+	}
+	cmap := append([]byte{0, 0, 0, 1, 0, 3, 0, 1, 0, 0, 0, 12}, sub...)
+
+	head := make([]byte, 54)
+	be.PutUint16(head[18:], 1000)
+	for i, v := range []int16{0, -200, 1000, 900} {
+		be.PutUint16(head[36+2*i:], uint16(v))
+	}
+	hhea := make([]byte, 36)
+	be.PutUint16(hhea[4:], 800)
+	be.PutUint16(hhea[6:], uint16(0xFFFF-200+1)) // -200
+	be.PutUint16(hhea[34:], uint16(len(chars)+2))
+	hmtx := make([]byte, 4*(len(chars)+2))
+	for g := range len(chars) + 2 {
+		w := 500
+		if g >= 2 && unicode.Is(unicode.Mn, chars[g-2]) {
+			w = 0
+		}
+		be.PutUint16(hmtx[4*g:], uint16(w))
+	}
+	maxp := make([]byte, 6)
+	be.PutUint16(maxp[4:], uint16(len(chars)+2))
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{{"cmap", cmap}, {"head", head}, {"hhea", hhea}, {"hmtx", hmtx}, {"maxp", maxp}}
+	font := make([]byte, 12+16*len(tables))
+	be.PutUint32(font, 0x00010000)
+	be.PutUint16(font[4:], uint16(len(tables)))
+	for i, tb := range tables {
+		rec := font[12+16*i:]
+		copy(rec, tb.tag)
+		be.PutUint32(rec[8:], uint32(len(font)))
+		be.PutUint32(rec[12:], uint32(len(tb.data)))
+		font = append(font, tb.data...)
+		for len(font)%4 != 0 {
+			font = append(font, 0)
+		}
+	}
+	return font
+}
+
+func TestWriteTextPDFThai(t *testing.T) {
+	font, err := utils.ParseFont("Sarabun Regular", testFont([]rune("สวัดีครบข้อเน")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.PDFFont = font
+	t.Cleanup(func() { utils.PDFFont = nil })
+
+	var buf bytes.Buffer
+	if err := utils.WriteTextPDF(&buf, "ข้อเสนอ", []string{"สวัสดีครับ", strings.Repeat("สวัสดี", 40)}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/Subtype /Type0 /BaseFont /SarabunRegular /Encoding /Identity-H",
+		"/Subtype /CIDFontType2",
+		"/FontFile2 ",
+		"/Title <FEFF0E020E490E2D0E400E2A0E190E2D>",     // ข้อเสนอ in UTF-16
+		"<0002000300040002000500060007000800040009> Tj", // สวัสดีครับ as glyph ids
+		"<0002> <0E2A>",             // ToUnicode: glyph 2 is ส
+		"/W [2 [500] 3 [500] 4 [0]", // the mark ั takes no room
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("PDF lacks %q", want)
+		}
+	}
+	if strings.Contains(out, ") Tj") {
+		t.Error("text written as a Latin-1 string")
+	}
+	// 240 Thai characters are 160 that take room: two lines, not three
+	if n := strings.Count(out, " Tj T*"); n != 3 {
+		t.Errorf("%d lines, want 3", n)
+	}
+	checkXref(t, out)
+
+	if _, err := utils.ParseFont("bad", []byte("not a font")); err == nil {
+		t.Error("ParseFont accepted garbage")
+	}
+}
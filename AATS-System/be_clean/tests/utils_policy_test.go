@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"aats-backend-clean/utils"
+)
+
+func intp(v int) *int { return &v }
+
+func TestResolvePolicy(t *testing.T) {
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	jun := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	layers := []utils.PolicyLayer{
+		{Scope: utils.PolicyScopeJob, Rules: utils.PolicyRules{InterviewRejectWaitMonths: intp(12)}, EffectiveFrom: jan},
+		{Scope: utils.PolicyScopeGlobal, Rules: utils.PolicyRules{MaxActiveApplications: intp(3), InterviewRejectWaitMonths: intp(4)}, EffectiveFrom: jan},
+		{Scope: utils.PolicyScopeGlobal, Rules: utils.PolicyRules{MaxActiveApplications: intp(10)}, EffectiveFrom: jun},
+		{Scope: utils.PolicyScopeGlobal, Rules: utils.PolicyRules{HiredCooldownMonths: intp(0)}, EffectiveFrom: jan, EffectiveTo: &jun},
+	}
+
+	got := utils.ResolvePolicy(utils.DefaultPolicy, layers, jan.AddDate(0, 1, 0))
+	want := utils.PolicyConfig{MaxActiveApplications: 3, ScreeningRejectWaitMonths: 3, InterviewRejectWaitMonths: 12, HiredCooldownMonths: 0}
+	if got != want {
+		t.Errorf("February: got %+v, want %+v", got, want)
+	}
+
+	// the newer global layer takes over; the windowed one has ended
+	got = utils.ResolvePolicy(utils.DefaultPolicy, layers, jun)
+	want = utils.PolicyConfig{MaxActiveApplications: 10, ScreeningRejectWaitMonths: 3, InterviewRejectWaitMonths: 12, HiredCooldownMonths: 3}
+	if got != want {
+		t.Errorf("June: got %+v, want %+v", got, want)
+	}
+
+	if got := utils.ResolvePolicy(utils.DefaultPolicy, layers, jan.AddDate(-1, 0, 0)); got != utils.DefaultPolicy {
+		t.Errorf("before any layer: got %+v, want defaults", got)
+	}
+}
+
+func TestEvaluateApplyPolicy(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	cfg := utils.DefaultPolicy
+	rules := func(v []utils.PolicyViolation) []string {
+		out := []string{}
+		for _, x := range v {
+			out = append(out, x.Rule)
+		}
+		return out
+	}
+
+	cases := []struct {
+		name  string
+		job   string
+		prior []utils.PriorApplication
+		want  []string
+		retry time.Time
+	}{
+		{"no history", "j1", nil, []string{}, time.Time{}},
+		{"active on same job", "j1", []utils.PriorApplication{{JobID: "j1", Status: "screening"}}, []string{utils.RuleDuplicate}, time.Time{}},
+		{"screening rejection 2 months ago", "j1", []utils.PriorApplication{{JobID: "j1", Status: "rejected", ClosedAt: now.AddDate(0, -2, 0)}},
+			[]string{utils.RuleScreeningWait}, now.AddDate(0, 1, 0)},
+		{"screening rejection 4 months ago", "j1", []utils.PriorApplication{{JobID: "j1", Status: "rejected", ClosedAt: now.AddDate(0, -4, 0)}}, []string{}, time.Time{}},
+		{"interview rejection 4 months ago", "j1", []utils.PriorApplication{{JobID: "j1", Status: "rejected", ClosedAt: now.AddDate(0, -4, 0), InterviewedBeforeRejection: true}},
+			[]string{utils.RuleInterviewWait}, now.AddDate(0, 2, 0)},
+		{"withdrawn reapplies at once", "j1", []utils.PriorApplication{{JobID: "j1", Status: "withdrawn"}}, []string{}, time.Time{}},
+		{"hired elsewhere last month", "j2", []utils.PriorApplication{{JobID: "j1", Status: "hired", ClosedAt: now.AddDate(0, -1, 0)}},
+			[]string{utils.RuleHiredCooldown}, now.AddDate(0, 2, 0)},
+		{"hired on the same job", "j1", []utils.PriorApplication{{JobID: "j1", Status: "hired", ClosedAt: now.AddDate(0, -1, 0)}}, []string{}, time.Time{}},
+	}
+	for _, tc := range cases {
+		v := utils.EvaluateApplyPolicy(cfg, tc.job, tc.prior, now)
+		got := rules(v)
+		if len(got) != len(tc.want) || (len(got) > 0 && got[0] != tc.want[0]) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		if !tc.retry.IsZero() {
+			if at, ok := utils.EarliestRetry(v); !ok || !at.Equal(tc.retry) {
+				t.Errorf("%s: retry at %v, want %v", tc.name, at, tc.retry)
+			}
+		}
+	}
+}
+
+func TestEvaluateApplyPolicyCap(t *testing.T) {
+	now := time.Now()
+	prior := []utils.PriorApplication{}
+	for _, j := range []string{"a", "b", "c"} {
+		prior = append(prior, utils.PriorApplication{JobID: j, Status: "submitted"})
+	}
+	cfg := utils.DefaultPolicy
+	cfg.MaxActiveApplications = 3
+	v := utils.EvaluateApplyPolicy(cfg, "d", prior, now)
+	if len(v) != 1 || v[0].Rule != utils.RuleMaxActive {
+		t.Fatalf("got %+v, want the active cap", v)
+	}
+	if _, ok := utils.EarliestRetry(v); ok {
+		t.Error("the active cap has no retry date")
+	}
+	cfg.MaxActiveApplications = 0 // no cap
+	if v := utils.EvaluateApplyPolicy(cfg, "d", prior, now); len(v) != 0 {
+		t.Errorf("cap of 0 should disable the rule, got %+v", v)
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"testing"
+
+	"aats-backend-clean/utils"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	if v, err := utils.NormalizeAnswer(utils.QuestionYesNo, "", "True"); err != nil || v != "yes" {
+		t.Errorf("expected yes, got %q (%v)", v, err)
+	}
+	if _, err := utils.NormalizeAnswer(utils.QuestionYesNo, "", "maybe"); err == nil {
+		t.Error("expected error for invalid yes/no answer")
+	}
+	if v, err := utils.NormalizeAnswer(utils.QuestionSingleChoice, `["Bangkok","Remote"]`, "remote"); err != nil || v != "Remote" {
+		t.Errorf("expected Remote, got %q (%v)", v, err)
+	}
+	if _, err := utils.NormalizeAnswer(utils.QuestionSingleChoice, `["Bangkok","Remote"]`, "Chiang Mai"); err == nil {
+		t.Error("expected error for answer outside options")
+	}
+	if v, err := utils.NormalizeAnswer(utils.QuestionNumeric, "", "3.0"); err != nil || v != "3" {
+		t.Errorf("expected 3, got %q (%v)", v, err)
+	}
+}
+
+func TestKnockoutTriggered(t *testing.T) {
+	cases := []struct {
+		rule   utils.ScreeningRule
+		answer string
+		want   bool
+	}{
+		{utils.ScreeningRule{Action: utils.KnockoutReject, Operator: "eq", Value: "no"}, "no", true},
+		{utils.ScreeningRule{Action: utils.KnockoutReject, Operator: "eq", Value: "no"}, "yes", false},
+		{utils.ScreeningRule{Action: utils.KnockoutFlag, Operator: "lt", Value: "2"}, "1", true},
+		{utils.ScreeningRule{Action: utils.KnockoutFlag, Operator: "lt", Value: "2"}, "2", false},
+		{utils.ScreeningRule{Action: utils.KnockoutReject, Operator: "not_in", Value: `["Bangkok","Remote"]`}, "Phuket", true},
+		{utils.ScreeningRule{Action: utils.KnockoutReject, Operator: "in", Value: `["Bangkok","Remote"]`}, "remote", true},
+		{utils.ScreeningRule{Action: utils.KnockoutFlag, Operator: "contains", Value: "visa"}, "Need VISA support", true},
+		{utils.ScreeningRule{Action: "", Operator: "eq", Value: "no"}, "no", false},
+		{utils.ScreeningRule{Action: utils.KnockoutReject, Operator: "eq", Value: "no"}, "", false},
+	}
+	for i, tc := range cases {
+		if got := utils.KnockoutTriggered(tc.rule, tc.answer); got != tc.want {
+			t.Errorf("case %d: got %v, want %v", i, got, tc.want)
+		}
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	if err := utils.ValidateRule(utils.QuestionYesNo, utils.ScreeningRule{Action: "reject", Operator: "gt", Value: "1"}); err == nil {
+		t.Error("numeric operator on yes/no question should be rejected")
+	}
+	if err := utils.ValidateRule(utils.QuestionNumeric, utils.ScreeningRule{Action: "flag", Operator: "gte", Value: "3"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := utils.ValidateRule(utils.QuestionText, utils.ScreeningRule{Action: "delete", Operator: "eq", Value: "x"}); err == nil {
+		t.Error("unknown action should be rejected")
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"testing"
+
+	"aats-backend-clean/utils"
+)
+
+func newTestTaxonomy() *utils.SkillTaxonomy {
+	tax := utils.NewSkillTaxonomy()
+	tax.Add("Go", "language", "Golang", "go lang")
+	tax.Add("React", "framework", "React.js", "ReactJS")
+	tax.Add("PostgreSQL", "database", "Postgres")
+	return tax
+}
+
+func TestSkillNormalizeSynonyms(t *testing.T) {
+	tax := newTestTaxonomy()
+	got := tax.Normalize([]string{"Golang", "go lang", " GO ", "react.js", "Docker"})
+	want := []string{"Go", "React", "Docker"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+	if tax.Category("Go") != "language" {
+		t.Errorf("expected category language, got %q", tax.Category("Go"))
+	}
+}
+
+func TestParseSkillList(t *testing.T) {
+	if got := utils.ParseSkillList(`["Go","SQL"]`); len(got) != 2 || got[1] != "SQL" {
+		t.Errorf("json array not parsed: %v", got)
+	}
+	if got := utils.ParseSkillList("Go, SQL ,Docker"); len(got) != 3 || got[1] != "SQL" {
+		t.Errorf("csv not parsed: %v", got)
+	}
+}
+
+func TestExperienceYears(t *testing.T) {
+	cases := map[string]float64{
+		`{"position":"Intern","company":"TestCo","duration":"1 ปี"}`: 1,
+		`{"years": 4}`: 4,
+		`[{"duration":"2 years"},{"duration":"6 months"}]`: 2.5,
+		"18 เดือน": 1.5,
+		"":         0,
+	}
+	for in, want := range cases {
+		if got := utils.ExperienceYears(in); got != want {
+			t.Errorf("ExperienceYears(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestComputeMatch(t *testing.T) {
+	b := utils.ComputeMatch([]string{"Go", "React"}, 1, []string{"Go", "PostgreSQL"}, []string{"React"}, 2)
+	if b.MustHaveScore != 30 || b.NiceToHaveScore != 25 || b.ExperienceScore != 7.5 {
+		t.Errorf("unexpected breakdown: %+v", b)
+	}
+	if b.Score != 62.5 {
+		t.Errorf("expected score 62.5, got %v", b.Score)
+	}
+	if len(b.MustHaveMissing) != 1 || b.MustHaveMissing[0] != "PostgreSQL" {
+		t.Errorf("expected PostgreSQL missing, got %v", b.MustHaveMissing)
+	}
+
+	full := utils.ComputeMatch(nil, 0, nil, nil, 0)
+	if full.Score != 100 {
+		t.Errorf("job without requirements should score 100, got %v", full.Score)
+	}
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"aats-backend-clean/utils"
+)
+
+func TestPercentile(t *testing.T) {
+	vals := []float64{10, 1, 4, 2, 3}
+	if got := utils.Percentile(vals, 50); got != 3 {
+		t.Errorf("median: got %v, want 3", got)
+	}
+	if got := utils.Percentile(vals, 90); math.Abs(got-7.6) > 1e-9 {
+		t.Errorf("p90: got %v, want 7.6", got)
+	}
+	if vals[0] != 10 {
+		t.Error("Percentile must not reorder its input")
+	}
+	if got := utils.Percentile(nil, 50); got != 0 {
+		t.Errorf("empty: got %v, want 0", got)
+	}
+}
+
+func TestStageSpans(t *testing.T) {
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	spans := utils.StageSpans([]utils.StatusEvent{
+		{Status: "submitted", At: t0},
+		{Status: "screening", At: t0.Add(24 * time.Hour)},
+		{Status: "screening", At: t0.Add(30 * time.Hour)}, // note, same status
+		{Status: "interview", At: t0.Add(72 * time.Hour)},
+	})
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 closed spans, got %d", len(spans))
+	}
+	if spans[0].Stage != "submitted" || spans[0].Hours != 24 {
+		t.Errorf("unexpected first span %+v", spans[0])
+	}
+	if spans[1].Stage != "screening" || spans[1].Hours != 48 {
+		t.Errorf("unexpected second span %+v", spans[1])
+	}
+}
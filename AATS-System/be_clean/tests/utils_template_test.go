@@ -0,0 +1,16 @@
+package tests
+
+import (
+	"testing"
+
+	"aats-backend-clean/utils"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	vars := map[string]string{"name": "Somchai", "job_title": "Go Developer"}
+	got := utils.RenderTemplate("Hi {{name}}, thanks for applying to {{ job_title }}. {{unknown}}", vars)
+	want := "Hi Somchai, thanks for applying to Go Developer. {{unknown}}"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
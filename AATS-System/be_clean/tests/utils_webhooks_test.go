@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"aats-backend-clean/utils"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	now := time.Unix(1767225600, 0)
+	body := []byte(`{"type":"job.created"}`)
+	header := utils.SignWebhook("whsec_test", now, body)
+	if !strings.HasPrefix(header, "t=1767225600,v1=") {
+		t.Fatalf("header = %s", header)
+	}
+	if err := utils.VerifyWebhookSignature("whsec_test", header, body, 5*time.Minute, now.Add(time.Minute)); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := utils.VerifyWebhookSignature("other", header, body, 5*time.Minute, now); err == nil {
+		t.Error("wrong secret accepted")
+	}
+	if err := utils.VerifyWebhookSignature("whsec_test", header, []byte(`{}`), 5*time.Minute, now); err == nil {
+		t.Error("tampered body accepted")
+	}
+	if err := utils.VerifyWebhookSignature("whsec_test", header, body, 5*time.Minute, now.Add(time.Hour)); err == nil {
+		t.Error("stale timestamp accepted")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, w := range want {
+		if got := utils.WebhookBackoff(i + 1); got != w {
+			t.Errorf("attempt %d: got %v, want %v", i+1, got, w)
+		}
+	}
+	if got := utils.WebhookBackoff(50); got != utils.WebhookMaxBackoff {
+		t.Errorf("cap: got %v", got)
+	}
+}
+
+func TestWebhookEventMatches(t *testing.T) {
+	cases := []struct {
+		pattern, event string
+		want           bool
+	}{
+		{"*", "job.created", true},
+		{"job.*", "job.status.closed", true},
+		{"application.status.*", "application.status.hired", true},
+		{"application.status.*", "application.note", false},
+		{"application.status.hired", "application.status.hired", true},
+		{"job.*", "jobs.created", false},
+	}
+	for _, c := range cases {
+		if got := utils.WebhookEventMatches(c.pattern, c.event); got != c.want {
+			t.Errorf("%q vs %q = %v", c.pattern, c.event, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"aats-backend-clean/utils"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	x, err := utils.NewXLSXWriter(&buf, "Applications")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.WriteRow([]interface{}{"name", "score"})
+	x.WriteRow([]interface{}{"A & B <dev>", 87.5, nil})
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a valid zip: %v", err)
+	}
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(b)
+		}
+	}
+	for _, want := range []string{`<c r="A2" t="inlineStr">`, "A &amp; B &lt;dev&gt;", `<c r="B2"><v>87.5</v></c>`, "</sheetData></worksheet>"} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet missing %q", want)
+		}
+	}
+}
+
+func TestCSVText(t *testing.T) {
+	for in, want := range map[string]string{
+		"=1+1":       "'=1+1",
+		"+66 81 234": "'+66 81 234",
+		"-2":         "'-2",
+		"@SUM(A1)":   "'@SUM(A1)",
+		"\tcmd":      "'\tcmd",
+		"\r=1":       "'\r=1",
+		"Ann = Bob":  "Ann = Bob",
+		"":           "",
+		"สมชาย":      "สมชาย",
+	} {
+		if got := utils.CSVText(in); got != want {
+			t.Errorf("CSVText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
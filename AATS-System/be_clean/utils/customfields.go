@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Custom field types.
+const (
+	FieldText        = "text"
+	FieldNumber      = "number"
+	FieldDate        = "date"
+	FieldEnum        = "enum"
+	FieldMultiSelect = "multi_select"
+)
+
+// ValidFieldType reports whether t is one of the custom field types.
+func ValidFieldType(t string) bool {
+	switch t {
+	case FieldText, FieldNumber, FieldDate, FieldEnum, FieldMultiSelect:
+		return true
+	}
+	return false
+}
+
+// FieldDef is the part of a custom field definition needed to validate values.
+type FieldDef struct {
+	Key     string
+	Type    string
+	Options []string // allowed values for enum and multi_select
+}
+
+// CustomValue is a validated value in its storage form. Text holds text,
+// enum values, dates as YYYY-MM-DD and multi_select values as a JSON array;
+// Number and Date are also set for their types so they can be compared and
+// sorted in SQL.
+type CustomValue struct {
+	Text   string
+	Number *float64
+	Date   *time.Time
+}
+
+// maxCustomText caps free-text custom values.
+const maxCustomText = 2000
+
+// matchOption finds v among the options, ignoring case, and returns the
+// option as defined.
+func matchOption(options []string, v string) (string, bool) {
+	for _, o := range options {
+		if strings.EqualFold(o, strings.TrimSpace(v)) {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+// ValidateCustomValue checks a value decoded from JSON against a field and
+// returns it in storage form.
+func ValidateCustomValue(def FieldDef, raw interface{}) (CustomValue, error) {
+	switch def.Type {
+	case FieldText:
+		s, ok := raw.(string)
+		if !ok {
+			return CustomValue{}, errors.New("must be text")
+		}
+		s = strings.TrimSpace(s)
+		if len(s) > maxCustomText {
+			return CustomValue{}, errors.New("is too long")
+		}
+		return CustomValue{Text: s}, nil
+	case FieldNumber:
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return CustomValue{}, errors.New("must be a number")
+			}
+			n = f
+		default:
+			return CustomValue{}, errors.New("must be a number")
+		}
+		return CustomValue{Text: strconv.FormatFloat(n, 'f', -1, 64), Number: &n}, nil
+	case FieldDate:
+		s, _ := raw.(string)
+		t, err := time.Parse("2006-01-02", strings.TrimSpace(s))
+		if err != nil {
+			if t, err = time.Parse(time.RFC3339, strings.TrimSpace(s)); err != nil {
+				return CustomValue{}, errors.New("must be a date (YYYY-MM-DD)")
+			}
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		return CustomValue{Text: t.Format("2006-01-02"), Date: &t}, nil
+	case FieldEnum:
+		s, _ := raw.(string)
+		o, ok := matchOption(def.Options, s)
+		if !ok {
+			return CustomValue{}, errors.New("must be one of " + strings.Join(def.Options, ", "))
+		}
+		return CustomValue{Text: o}, nil
+	case FieldMultiSelect:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return CustomValue{}, errors.New("must be a list")
+		}
+		picked := map[string]bool{}
+		for _, item := range list {
+			s, _ := item.(string)
+			o, ok := matchOption(def.Options, s)
+			if !ok {
+				return CustomValue{}, errors.New("values must be among " + strings.Join(def.Options, ", "))
+			}
+			picked[o] = true
+		}
+		// keep the order the options were defined in
+		out := []string{}
+		for _, o := range def.Options {
+			if picked[o] {
+				out = append(out, o)
+			}
+		}
+		b, _ := json.Marshal(out)
+		return CustomValue{Text: string(b)}, nil
+	}
+	return CustomValue{}, errors.New("unknown field type")
+}
+
+// DecodeCustomValue turns a stored value back into its API form: string,
+// float64, "YYYY-MM-DD" string or []string.
+func DecodeCustomValue(fieldType string, v CustomValue) interface{} {
+	switch fieldType {
+	case FieldNumber:
+		if v.Number != nil {
+			return *v.Number
+		}
+		return nil
+	case FieldMultiSelect:
+		out := []string{}
+		_ = json.Unmarshal([]byte(v.Text), &out)
+		return out
+	}
+	return v.Text
+}
+
+// ValidateFieldOptions checks the options of an enum or multi_select field:
+// at least one, none blank, no duplicates ignoring case.
+func ValidateFieldOptions(fieldType string, options []string) ([]string, error) {
+	if fieldType != FieldEnum && fieldType != FieldMultiSelect {
+		return nil, nil
+	}
+	seen := map[string]bool{}
+	out := []string{}
+	for _, o := range options {
+		o = strings.TrimSpace(o)
+		if o == "" {
+			continue
+		}
+		if seen[strings.ToLower(o)] {
+			return nil, errors.New("duplicate option " + o)
+		}
+		seen[strings.ToLower(o)] = true
+		out = append(out, o)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("options are required for " + fieldType + " fields")
+	}
+	return out, nil
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// NormalizePhone reduces a phone number to its digits so that
+// "+66 81-234-5678", "081 234 5678" and "0812345678" compare equal. Thai
+// numbers in international form get their leading 0 back. Anything shorter
+// than 9 digits is not a usable phone number and yields "".
+func NormalizePhone(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	d := b.String()
+	if strings.HasPrefix(d, "0066") {
+		d = d[2:]
+	}
+	if strings.HasPrefix(d, "66") && (len(d) == 10 || len(d) == 11) {
+		d = "0" + d[2:]
+	}
+	if len(d) < 9 {
+		return ""
+	}
+	return d
+}
+
+// nameTitles are honorifics dropped before names are compared.
+var nameTitles = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "miss": true, "dr": true,
+	"นาย": true, "นาง": true, "นางสาว": true, "น.ส.": true,
+}
+
+// NormalizeName lower-cases a name, drops punctuation and titles and sorts
+// the words, so "Dr. Doe, John" and "john  doe" normalize the same.
+func NormalizeName(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == '-' || r == '_'
+	})
+	words := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if nameTitles[f] {
+			continue // "น.ส." keeps its dots
+		}
+		if f = strings.TrimRight(f, "."); f != "" && !nameTitles[f] {
+			words = append(words, f)
+		}
+	}
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// NameSimilarity is 1 - edit distance / length of the longer normalized
+// name: 1 for the same name, 0 when either is empty.
+func NameSimilarity(a, b string) float64 {
+	ra, rb := []rune(NormalizeName(a)), []rune(NormalizeName(b))
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	longest := max(len(ra), len(rb))
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// HashFile is the hex SHA-256 of a file's contents.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DuplicateProfile is what the detector compares between two candidates.
+type DuplicateProfile struct {
+	ID           string
+	Name         string
+	Phone        string   // normalized, see NormalizePhone
+	ResumeHashes []string // SHA-256 of uploaded resumes
+}
+
+// Duplicate match reasons.
+const (
+	DuplicateReasonPhone  = "phone"
+	DuplicateReasonResume = "resume"
+	DuplicateReasonName   = "name"
+)
+
+// Scoring: the same phone or the same resume file is strong evidence on its
+// own; a similar name only adds weight to one of them.
+const (
+	DuplicateThreshold     = 0.5
+	DuplicateNameThreshold = 0.85
+	duplicatePhoneWeight   = 0.6
+	duplicateResumeWeight  = 0.6
+	duplicateNameWeight    = 0.4
+)
+
+// ScoreDuplicate rates how likely a and b are the same person, in [0, 1],
+// and lists the signals that matched. Pairs scoring at least
+// DuplicateThreshold are reported to HR.
+func ScoreDuplicate(a, b DuplicateProfile) (float64, []string) {
+	score := 0.0
+	reasons := []string{}
+	if a.Phone != "" && a.Phone == b.Phone {
+		score += duplicatePhoneWeight
+		reasons = append(reasons, DuplicateReasonPhone)
+	}
+	hashes := map[string]bool{}
+	for _, h := range a.ResumeHashes {
+		if h != "" {
+			hashes[h] = true
+		}
+	}
+	for _, h := range b.ResumeHashes {
+		if hashes[h] {
+			score += duplicateResumeWeight
+			reasons = append(reasons, DuplicateReasonResume)
+			break
+		}
+	}
+	if sim := NameSimilarity(a.Name, b.Name); sim >= DuplicateNameThreshold {
+		score += duplicateNameWeight * sim
+		reasons = append(reasons, DuplicateReasonName)
+	}
+	if score > 1 {
+		score = 1
+	}
+	return score, reasons
+}
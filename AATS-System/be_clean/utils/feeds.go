@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FeedJob is one job as published in a syndication feed.
+type FeedJob struct {
+	JobPostingInfo
+	Slug      string
+	UpdatedAt time.Time
+	Custom    map[string]string // non-HR-only custom fields, by key
+}
+
+// FeedMeta describes the feed itself.
+type FeedMeta struct {
+	Title        string
+	Description  string
+	Link         string // careers site
+	SelfURL      string // URL the feed was requested at
+	Publisher    string
+	PublisherURL string
+	Updated      time.Time
+}
+
+// FeedField maps one element of a job in an XML job feed to a job field.
+type FeedField struct {
+	Element string `json:"element"`
+	Source  string `json:"source"`
+	CDATA   bool   `json:"cdata"`
+}
+
+// DefaultJobXMLFields is the common XML job feed layout aggregators accept
+// (the one popularised by Indeed): <source><job><title>… per job.
+var DefaultJobXMLFields = []FeedField{
+	{"title", "title", true},
+	{"date", "posted_date", true},
+	{"referencenumber", "id", true},
+	{"url", "url", true},
+	{"company", "company", true},
+	{"city", "location", true},
+	{"country", "country", true},
+	{"description", "description_html", true},
+	{"category", "department", true},
+	{"experience", "experience_level", true},
+	{"expirationdate", "closing_date", true},
+}
+
+// feedSources are the job fields a feed element can take its value from;
+// "cf.<key>" (custom field) and "const:<text>" (fixed value) also work.
+var feedSources = map[string]func(j FeedJob) string{
+	"id":                   func(j FeedJob) string { return j.ID },
+	"slug":                 func(j FeedJob) string { return j.Slug },
+	"title":                func(j FeedJob) string { return j.Title },
+	"description":          func(j FeedJob) string { return j.Description },
+	"description_html":     func(j FeedJob) string { return JobDescriptionHTML(j.JobPostingInfo) },
+	"department":           func(j FeedJob) string { return j.Department },
+	"location":             func(j FeedJob) string { return j.Location },
+	"experience_level":     func(j FeedJob) string { return j.ExperienceLevel },
+	"min_experience_years": func(j FeedJob) string { return strconv.FormatFloat(j.MinExperienceYears, 'f', -1, 64) },
+	"requirements":         func(j FeedJob) string { return strings.Join(j.Requirements, "; ") },
+	"responsibilities":     func(j FeedJob) string { return strings.Join(j.Responsibilities, "; ") },
+	"skills":               func(j FeedJob) string { return strings.Join(j.Skills, ", ") },
+	"url":                  func(j FeedJob) string { return j.URL },
+	"company":              func(j FeedJob) string { return j.OrganizationName },
+	"company_url":          func(j FeedJob) string { return j.OrganizationURL },
+	"country":              func(j FeedJob) string { return j.Country },
+	"posted_date":          func(j FeedJob) string { return feedDate(j.PostedDate, feedTimeFormat) },
+	"posted_date_iso":      func(j FeedJob) string { return feedDate(j.PostedDate, "2006-01-02") },
+	"closing_date":         func(j FeedJob) string { return feedDate(j.ClosingDate, feedTimeFormat) },
+	"closing_date_iso":     func(j FeedJob) string { return feedDate(j.ClosingDate, "2006-01-02") },
+}
+
+// feedTimeFormat is RFC 1123 with the GMT zone name feed readers expect
+// (time.RFC1123 would print "UTC").
+const feedTimeFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+func feedDate(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(layout)
+}
+
+// FeedSourceValue resolves a FeedField.Source for a job.
+func FeedSourceValue(j FeedJob, source string) string {
+	switch {
+	case strings.HasPrefix(source, "const:"):
+		return strings.TrimPrefix(source, "const:")
+	case strings.HasPrefix(source, "cf."):
+		return j.Custom[strings.TrimPrefix(source, "cf.")]
+	}
+	if f, ok := feedSources[source]; ok {
+		return f(j)
+	}
+	return ""
+}
+
+var xmlNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]{0,63}$`)
+
+// ValidXMLName reports whether s can be used as an element name.
+func ValidXMLName(s string) bool {
+	return xmlNameRe.MatchString(s) && !strings.HasPrefix(strings.ToLower(s), "xml")
+}
+
+// ValidateFeedFields checks a board's field mapping.
+func ValidateFeedFields(fields []FeedField) error {
+	if len(fields) == 0 {
+		return errors.New("fields are required")
+	}
+	for _, f := range fields {
+		if !ValidXMLName(f.Element) {
+			return errors.New("invalid element name " + strconv.Quote(f.Element))
+		}
+		_, known := feedSources[f.Source]
+		if !known && !strings.HasPrefix(f.Source, "const:") && !(strings.HasPrefix(f.Source, "cf.") && len(f.Source) > 3) {
+			return errors.New("unknown source " + strconv.Quote(f.Source) + " for element " + f.Element)
+		}
+	}
+	return nil
+}
+
+// feedWriter writes XML by hand so element order and CDATA are under our
+// control (encoding/xml cannot emit CDATA for dynamic element names).
+type feedWriter struct{ bytes.Buffer }
+
+func (w *feedWriter) text(s string) { _ = xml.EscapeText(w, []byte(s)) }
+
+func (w *feedWriter) elem(name, value string) {
+	w.WriteString("<" + name + ">")
+	w.text(value)
+	w.WriteString("</" + name + ">")
+}
+
+func (w *feedWriter) cdata(name, value string) {
+	// "]]>" cannot appear inside CDATA; split it across two sections
+	w.WriteString("<" + name + "><![CDATA[" + strings.ReplaceAll(value, "]]>", "]]]]><![CDATA[>") + "]]></" + name + ">")
+}
+
+func (w *feedWriter) attr(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// RSSFeed renders jobs as RSS 2.0.
+func RSSFeed(meta FeedMeta, jobs []FeedJob) []byte {
+	var w feedWriter
+	w.WriteString(xml.Header)
+	w.WriteString(`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>`)
+	w.elem("title", meta.Title)
+	w.elem("link", meta.Link)
+	w.elem("description", meta.Description)
+	w.elem("lastBuildDate", meta.Updated.UTC().Format(time.RFC1123Z))
+	if meta.SelfURL != "" {
+		w.WriteString(`<atom:link href="` + w.attr(meta.SelfURL) + `" rel="self" type="application/rss+xml"/>`)
+	}
+	for _, j := range jobs {
+		w.WriteString("<item>")
+		w.elem("title", j.Title)
+		if j.URL != "" {
+			w.elem("link", j.URL)
+		}
+		w.WriteString(`<guid isPermaLink="false">`)
+		w.text("job:" + j.ID)
+		w.WriteString("</guid>")
+		w.elem("pubDate", j.PostedDate.UTC().Format(time.RFC1123Z))
+		w.elem("description", JobDescriptionHTML(j.JobPostingInfo))
+		if j.Department != "" {
+			w.elem("category", j.Department)
+		}
+		w.WriteString("</item>")
+	}
+	w.WriteString("</channel></rss>")
+	return w.Bytes()
+}
+
+// AtomFeed renders jobs as Atom 1.0.
+func AtomFeed(meta FeedMeta, jobs []FeedJob) []byte {
+	var w feedWriter
+	w.WriteString(xml.Header)
+	w.WriteString(`<feed xmlns="http://www.w3.org/2005/Atom">`)
+	w.elem("title", meta.Title)
+	w.elem("subtitle", meta.Description)
+	w.elem("id", "urn:jobs:"+Slugify(meta.Publisher))
+	w.elem("updated", meta.Updated.UTC().Format(time.RFC3339))
+	if meta.SelfURL != "" {
+		w.WriteString(`<link rel="self" type="application/atom+xml" href="` + w.attr(meta.SelfURL) + `"/>`)
+	}
+	if meta.Link != "" {
+		w.WriteString(`<link rel="alternate" type="text/html" href="` + w.attr(meta.Link) + `"/>`)
+	}
+	w.WriteString("<author>")
+	w.elem("name", meta.Publisher)
+	w.WriteString("</author>")
+	for _, j := range jobs {
+		w.WriteString("<entry>")
+		w.elem("title", j.Title)
+		w.elem("id", "urn:jobs:job:"+j.ID)
+		w.elem("published", j.PostedDate.UTC().Format(time.RFC3339))
+		w.elem("updated", j.UpdatedAt.UTC().Format(time.RFC3339))
+		if j.URL != "" {
+			w.WriteString(`<link rel="alternate" type="text/html" href="` + w.attr(j.URL) + `"/>`)
+		}
+		if j.Department != "" {
+			w.WriteString(`<category term="` + w.attr(j.Department) + `"/>`)
+		}
+		w.WriteString(`<summary type="html">`)
+		w.text(JobDescriptionHTML(j.JobPostingInfo))
+		w.WriteString("</summary></entry>")
+	}
+	w.WriteString("</feed>")
+	return w.Bytes()
+}
+
+// JobXMLFeed renders jobs in a board's XML layout: <root> with publisher
+// details, then one <item> per job holding the mapped fields.
+func JobXMLFeed(meta FeedMeta, root, item string, fields []FeedField, jobs []FeedJob) []byte {
+	var w feedWriter
+	w.WriteString(xml.Header)
+	w.WriteString("<" + root + ">")
+	w.elem("publisher", meta.Publisher)
+	w.elem("publisherurl", meta.PublisherURL)
+	w.elem("lastBuildDate", meta.Updated.UTC().Format(feedTimeFormat))
+	for _, j := range jobs {
+		w.WriteString("<" + item + ">")
+		for _, f := range fields {
+			if v := FeedSourceValue(j, f.Source); f.CDATA {
+				w.cdata(f.Element, v)
+			} else {
+				w.elem(f.Element, v)
+			}
+		}
+		w.WriteString("</" + item + ">")
+	}
+	w.WriteString("</" + root + ">")
+	return w.Bytes()
+}
+
+// FeedETag is a strong entity tag over everything a feed is built from.
+func FeedETag(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// ETagMatches implements If-None-Match: a list of tags, weak or strong,
+// or "*".
+func ETagMatches(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Slugify turns a title into a URL path segment: lower case letters and
+// digits (Thai included) separated by single hyphens.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		// Thai vowel and tone marks are unicode.Mn; dropping them would mangle words
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	out := []rune(b.String())
+	if len(out) > 80 {
+		out = []rune(strings.TrimRight(string(out[:80]), "-"))
+	}
+	return string(out)
+}
+
+// JobSlug is the public slug of a job: the slugified title plus the first
+// 8 characters of its id, so it stays unique and does not change when the
+// title is edited later.
+func JobSlug(title, id string) string {
+	suffix := strings.ReplaceAll(id, "-", "")
+	if len(suffix) > 8 {
+		suffix = suffix[:8]
+	}
+	base := Slugify(title)
+	if base == "" {
+		base = "job"
+	}
+	return base + "-" + strings.ToLower(suffix)
+}
+
+// SearchText joins the searchable parts of a record into one lower-cased,
+// whitespace-collapsed string for substring search.
+func SearchText(parts ...string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.Join(parts, " "))), " ")
+}
+
+// SearchTerms splits a query into distinct lower-cased terms; quoted
+// phrases stay together. At most 8 terms are used.
+func SearchTerms(q string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	add := func(t string) {
+		t = strings.Join(strings.Fields(strings.ToLower(t)), " ")
+		if t != "" && !seen[t] && len(terms) < 8 {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	for i, part := range strings.Split(q, `"`) {
+		if i%2 == 1 {
+			add(part)
+			continue
+		}
+		for _, w := range strings.Fields(part) {
+			add(w)
+		}
+	}
+	return terms
+}
+
+// JobCursor marks a position in a keyset-paginated job list: the sort key
+// of the last job returned and its id as tie-breaker.
+type JobCursor struct {
+	Sort string    `json:"s"`
+	Key  time.Time `json:"k"`
+	ID   string    `json:"i"`
+}
+
+// EncodeCursor makes an opaque, URL-safe cursor.
+func EncodeCursor(c JobCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor reverses EncodeCursor.
+func DecodeCursor(s string) (JobCursor, error) {
+	var c JobCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(raw, &c) != nil || c.ID == "" {
+		return JobCursor{}, errors.New("invalid cursor")
+	}
+	return c, nil
+}
+
+// JobPostingInfo is what the schema.org JobPosting markup is built from.
+type JobPostingInfo struct {
+	ID                 string
+	Title              string
+	Description        string
+	Requirements       []string
+	Responsibilities   []string
+	Skills             []string
+	Department         string
+	Location           string
+	ExperienceLevel    string
+	MinExperienceYears float64
+	PostedDate         time.Time
+	ClosingDate        time.Time // zero = open-ended
+	URL                string
+	OrganizationName   string
+	OrganizationURL    string
+	Country            string // ISO 3166-1 alpha-2
+}
+
+// JobDescriptionHTML renders a job's description, responsibilities and
+// requirements as escaped HTML, the form job boards and Google expect.
+func JobDescriptionHTML(j JobPostingInfo) string {
+	var desc strings.Builder
+	for _, p := range strings.Split(strings.TrimSpace(j.Description), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			desc.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(p), "\n", "<br>") + "</p>")
+		}
+	}
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		desc.WriteString("<p><strong>" + title + "</strong></p><ul>")
+		for _, it := range items {
+			desc.WriteString("<li>" + html.EscapeString(it) + "</li>")
+		}
+		desc.WriteString("</ul>")
+	}
+	list("Responsibilities", j.Responsibilities)
+	list("Requirements", j.Requirements)
+	if desc.Len() == 0 {
+		desc.WriteString("<p>" + html.EscapeString(j.Title) + "</p>")
+	}
+	return desc.String()
+}
+
+// JobPostingJSONLD builds schema.org JobPosting structured data as used by
+// Google for Jobs.
+func JobPostingJSONLD(j JobPostingInfo) map[string]interface{} {
+	org := map[string]interface{}{"@type": "Organization", "name": j.OrganizationName}
+	if j.OrganizationURL != "" {
+		org["sameAs"] = j.OrganizationURL
+	}
+	ld := map[string]interface{}{
+		"@context":           "https://schema.org/",
+		"@type":              "JobPosting",
+		"title":              j.Title,
+		"description":        JobDescriptionHTML(j),
+		"datePosted":         j.PostedDate.Format("2006-01-02"),
+		"hiringOrganization": org,
+		"identifier":         map[string]interface{}{"@type": "PropertyValue", "name": j.OrganizationName, "value": j.ID},
+	}
+	if !j.ClosingDate.IsZero() {
+		ld["validThrough"] = j.ClosingDate.Format(time.RFC3339)
+	}
+	if j.URL != "" {
+		ld["url"] = j.URL
+	}
+	if j.Department != "" {
+		ld["occupationalCategory"] = j.Department
+	}
+	if len(j.Skills) > 0 {
+		ld["skills"] = strings.Join(j.Skills, ", ")
+	}
+	if j.MinExperienceYears > 0 {
+		ld["experienceRequirements"] = map[string]interface{}{
+			"@type":              "OccupationalExperienceRequirements",
+			"monthsOfExperience": int(j.MinExperienceYears * 12),
+		}
+	}
+	// Remote jobs need jobLocationType plus where applicants may live; a
+	// location like "Head office / Remote" gets both.
+	loc := strings.TrimSpace(j.Location)
+	remote := strings.Contains(strings.ToLower(loc), "remote")
+	if remote {
+		ld["jobLocationType"] = "TELECOMMUTE"
+		ld["applicantLocationRequirements"] = map[string]interface{}{"@type": "Country", "name": j.Country}
+	}
+	if place := strings.TrimSpace(strings.Trim(strings.ReplaceAll(strings.ReplaceAll(loc, "Remote", ""), "remote", ""), "/,-| ")); place != "" || !remote {
+		ld["jobLocation"] = map[string]interface{}{
+			"@type": "Place",
+			"address": map[string]interface{}{
+				"@type":           "PostalAddress",
+				"addressLocality": place,
+				"addressCountry":  j.Country,
+			},
+		}
+	}
+	return ld
+}
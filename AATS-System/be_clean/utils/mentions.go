@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionRe matches "@" followed by an email address, e.g. "@jane.doe@corp.com".
+var mentionRe = regexp.MustCompile(`(^|[^\w.])@([A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,})`)
+
+// ParseMentions returns the lower-cased email addresses mentioned in text,
+// in order of first appearance and without repeats.
+func ParseMentions(text string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, m := range mentionRe.FindAllStringSubmatch(text, -1) {
+		email := strings.ToLower(m[2])
+		if !seen[email] {
+			seen[email] = true
+			out = append(out, email)
+		}
+	}
+	return out
+}
@@ -0,0 +1,319 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+var (
+	pdfSkipRe  = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	pdfTagRe   = regexp.MustCompile(`<\s*(/?)\s*([a-zA-Z0-9]+)[^>]*>`)
+	pdfSpaceRe = regexp.MustCompile(`\s+`)
+)
+
+// HTMLToText turns simple letter HTML into plain lines. Paragraphs, headings
+// and table rows end with a blank line, <br> and list items start a new line,
+// every other tag is dropped and entities are decoded.
+func HTMLToText(s string) []string {
+	s = pdfSkipRe.ReplaceAllString(s, "")
+	s = pdfSpaceRe.ReplaceAllString(s, " ")
+	s = pdfTagRe.ReplaceAllStringFunc(s, func(tag string) string {
+		m := pdfTagRe.FindStringSubmatch(tag)
+		closing, name := m[1] == "/", strings.ToLower(m[2])
+		switch name {
+		case "br":
+			return "\n"
+		case "li":
+			if closing {
+				return ""
+			}
+			return "\n- "
+		case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "tr", "ul", "ol", "table":
+			return "\n\n"
+		case "td", "th":
+			if closing {
+				return " "
+			}
+		}
+		return ""
+	})
+	s = html.UnescapeString(s)
+
+	var out []string
+	blank := true // drop leading blank lines
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		out = append(out, line)
+		blank = false
+	}
+	if len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// PDF page layout (A4, points).
+const (
+	pdfPageW    = 595
+	pdfPageH    = 842
+	pdfMargin   = 56
+	pdfFontSize = 11
+	pdfLeading  = 15
+	pdfWrapAt   = 88 // characters per line for 11pt text on A4
+)
+
+// textLen counts the characters of s that take up room on the line; Thai
+// vowel and tone marks sit on the character before them.
+func textLen(s string) int {
+	n := 0
+	for _, r := range s {
+		if !unicode.Is(unicode.Mn, r) {
+			n++
+		}
+	}
+	return n
+}
+
+// cutText splits s after width characters, keeping marks with their base.
+func cutText(s string, width int) (string, string) {
+	n := 0
+	for i, r := range s {
+		if !unicode.Is(unicode.Mn, r) {
+			if n == width {
+				return s[:i], s[i:]
+			}
+			n++
+		}
+	}
+	return s, ""
+}
+
+// wrapText breaks a line on spaces so no piece is longer than width
+// characters. A word longer than the line (Thai has no spaces between
+// words) is cut where the line ends.
+func wrapText(line string, width int) []string {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	var out []string
+	cur := ""
+	for _, w := range words {
+		for textLen(w) > width { // a single word longer than the line
+			if cur != "" {
+				out = append(out, cur)
+				cur = ""
+			}
+			var head string
+			head, w = cutText(w, width)
+			out = append(out, head)
+		}
+		switch {
+		case cur == "":
+			cur = w
+		case textLen(cur)+1+textLen(w) <= width:
+			cur += " " + w
+		default:
+			out = append(out, cur)
+			cur = w
+		}
+	}
+	return append(out, cur)
+}
+
+// pdfString encodes s as a PDF literal string. The standard Helvetica font
+// only covers Latin-1, so other characters are printed as '?'.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 256:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfTextString encodes s for the document information: a literal when it is
+// ASCII, UTF-16 with a byte order mark otherwise.
+func pdfTextString(s string) string {
+	for _, r := range s {
+		if r >= 128 {
+			return "<FEFF" + utf16Hex(s) + ">"
+		}
+	}
+	return pdfString(s)
+}
+
+// utf16Hex is s in UTF-16BE as hex digits.
+func utf16Hex(s string) string {
+	var b strings.Builder
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	return b.String()
+}
+
+// glyphString encodes s as a hex string of f's glyph ids (Identity-H) and
+// records which character each glyph stands for.
+func glyphString(f *Font, s string, used map[uint16]rune) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		if r < 32 {
+			r = ' '
+		}
+		g := f.Glyph(r)
+		if _, ok := used[g]; !ok && g != 0 {
+			used[g] = r
+		}
+		fmt.Fprintf(&b, "%04X", g)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// type0Font is the objects of an embedded font from first on: the CID font,
+// its descriptor, the font file and the ToUnicode map that lets readers
+// copy and search the text. dict is the Type0 font that refers to them.
+func type0Font(f *Font, used map[uint16]rune, first int) (dict string, objects []string) {
+	gids := make([]int, 0, len(used))
+	for g := range used {
+		gids = append(gids, int(g))
+	}
+	sort.Ints(gids)
+
+	var widths strings.Builder
+	for _, g := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", g, f.Width(uint16(g)))
+	}
+	var file bytes.Buffer
+	zw := zlib.NewWriter(&file)
+	zw.Write(f.Data)
+	zw.Close()
+
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for i := 0; i < len(gids); i += 100 { // at most 100 entries per block
+		block := gids[i:min(i+100, len(gids))]
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", len(block))
+		for _, g := range block {
+			fmt.Fprintf(&cmap, "<%04X> <%s>\n", g, utf16Hex(string(used[uint16(g)])))
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+
+	dict = fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", f.Name, first, first+3)
+	objects = []string{
+		fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW %d /W [%s] /CIDToGIDMap /Identity >>",
+			f.Name, first+1, f.Width(0), strings.TrimSpace(widths.String())),
+		fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+			f.Name, f.scale(f.BBox[0]), f.scale(f.BBox[1]), f.scale(f.BBox[2]), f.scale(f.BBox[3]), f.scale(f.Ascent), f.scale(f.Descent), f.scale(f.Ascent), first+2),
+		fmt.Sprintf("<< /Length %d /Length1 %d /Filter /FlateDecode >>\nstream\n%s\nendstream", file.Len(), len(f.Data), file.String()),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", cmap.Len(), cmap.String()),
+	}
+	return dict, objects
+}
+
+// WriteTextPDF writes lines as an A4 PDF in 11pt PDFFont, or Helvetica when
+// no font is set. Long lines are wrapped and pages break automatically.
+func WriteTextPDF(w io.Writer, title string, lines []string) error {
+	var wrapped []string
+	for _, l := range lines {
+		wrapped = append(wrapped, wrapText(l, pdfWrapAt)...)
+	}
+	perPage := (pdfPageH - 2*pdfMargin) / pdfLeading
+	var pages [][]string
+	for len(wrapped) > perPage {
+		pages = append(pages, wrapped[:perPage])
+		wrapped = wrapped[perPage:]
+	}
+	pages = append(pages, wrapped)
+
+	// objects: 1 catalog, 2 page tree, 3 font, 4 info, then page + content
+	// per page, then the embedded font's objects
+	bw := bufio.NewWriter(w)
+	offset := 0
+	var offsets []int
+	write := func(s string) {
+		n, _ := bw.WriteString(s)
+		offset += n
+	}
+	object := func(body string) {
+		offsets = append(offsets, offset)
+		write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", len(offsets), body))
+	}
+
+	write("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	font, used := PDFFont, map[uint16]rune{}
+	contents := make([]string, len(pages))
+	for i, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageH-pdfMargin-pdfFontSize)
+		for _, l := range page {
+			if font != nil {
+				content.WriteString(glyphString(font, l, used) + " Tj T*\n")
+			} else {
+				content.WriteString(pdfString(l) + " Tj T*\n")
+			}
+		}
+		content.WriteString("ET")
+		contents[i] = content.String()
+	}
+	fontDict, fontObjects := "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", []string(nil)
+	if font != nil {
+		fontDict, fontObjects = type0Font(font, used, 5+2*len(pages))
+	}
+	object(fontDict)
+	object(fmt.Sprintf("<< /Title %s /Producer (AATS) >>", pdfTextString(title)))
+	for i, content := range contents {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageW, pdfPageH, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+	for _, o := range fontObjects {
+		object(o)
+	}
+
+	xref := offset
+	write(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1))
+	for _, o := range offsets {
+		write(fmt.Sprintf("%010d 00000 n \n", o))
+	}
+	write(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref))
+	return bw.Flush()
+}
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"sort"
+	"time"
+)
+
+// PolicyRules is one layer of application policy as stored in config.
+// Nil fields inherit from the layer below (job → global → DefaultPolicy).
+type PolicyRules struct {
+	MaxActiveApplications     *int `json:"max_active_applications,omitempty"` // 0 = no cap
+	ScreeningRejectWaitMonths *int `json:"screening_reject_wait_months,omitempty"`
+	InterviewRejectWaitMonths *int `json:"interview_reject_wait_months,omitempty"`
+	HiredCooldownMonths       *int `json:"hired_cooldown_months,omitempty"` // before applying to another job
+}
+
+// PolicyConfig is the policy in force for one job at one moment.
+type PolicyConfig struct {
+	MaxActiveApplications     int `json:"max_active_applications"`
+	ScreeningRejectWaitMonths int `json:"screening_reject_wait_months"`
+	InterviewRejectWaitMonths int `json:"interview_reject_wait_months"`
+	HiredCooldownMonths       int `json:"hired_cooldown_months"`
+}
+
+// DefaultPolicy is used when no configured layer sets a rule.
+var DefaultPolicy = PolicyConfig{
+	MaxActiveApplications:     5,
+	ScreeningRejectWaitMonths: 3,
+	InterviewRejectWaitMonths: 6,
+	HiredCooldownMonths:       3,
+}
+
+// Policy scopes; job layers override global ones.
+const (
+	PolicyScopeGlobal = "global"
+	PolicyScopeJob    = "job"
+)
+
+// PolicyLayer is a PolicyRules with its scope and effective window.
+type PolicyLayer struct {
+	Scope         string
+	Rules         PolicyRules
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time // exclusive; nil = open-ended
+}
+
+// ActiveAt reports whether the layer is in force at t.
+func (l PolicyLayer) ActiveAt(t time.Time) bool {
+	return !t.Before(l.EffectiveFrom) && (l.EffectiveTo == nil || t.Before(*l.EffectiveTo))
+}
+
+// Validate returns the name of the first negative rule, "" when the rules
+// are fine.
+func (r PolicyRules) Validate() string {
+	for name, v := range map[string]*int{
+		"max_active_applications":      r.MaxActiveApplications,
+		"screening_reject_wait_months": r.ScreeningRejectWaitMonths,
+		"interview_reject_wait_months": r.InterviewRejectWaitMonths,
+		"hired_cooldown_months":        r.HiredCooldownMonths,
+	} {
+		if v != nil && *v < 0 {
+			return name
+		}
+	}
+	return ""
+}
+
+// ResolvePolicy applies the layers active at `at` on top of base. Global
+// layers go first and job layers last; within a scope the layer with the
+// latest EffectiveFrom wins.
+func ResolvePolicy(base PolicyConfig, layers []PolicyLayer, at time.Time) PolicyConfig {
+	active := make([]PolicyLayer, 0, len(layers))
+	for _, l := range layers {
+		if l.ActiveAt(at) {
+			active = append(active, l)
+		}
+	}
+	rank := func(l PolicyLayer) int {
+		if l.Scope == PolicyScopeJob {
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		if rank(active[i]) != rank(active[j]) {
+			return rank(active[i]) < rank(active[j])
+		}
+		return active[i].EffectiveFrom.Before(active[j].EffectiveFrom)
+	})
+	cfg := base
+	for _, l := range active {
+		for dst, v := range map[*int]*int{
+			&cfg.MaxActiveApplications:     l.Rules.MaxActiveApplications,
+			&cfg.ScreeningRejectWaitMonths: l.Rules.ScreeningRejectWaitMonths,
+			&cfg.InterviewRejectWaitMonths: l.Rules.InterviewRejectWaitMonths,
+			&cfg.HiredCooldownMonths:       l.Rules.HiredCooldownMonths,
+		} {
+			if v != nil {
+				*dst = *v
+			}
+		}
+	}
+	return cfg
+}
+
+// PriorApplication is what the policy needs to know about an earlier application.
+type PriorApplication struct {
+	ID                         string
+	JobID                      string
+	Status                     string
+	SubmittedAt                time.Time
+	ClosedAt                   time.Time // when it was rejected or hired
+	InterviewedBeforeRejection bool
+}
+
+// Policy rule names reported in violations.
+const (
+	RuleMaxActive     = "max_active_applications"
+	RuleDuplicate     = "duplicate_active_application"
+	RuleScreeningWait = "screening_reject_wait"
+	RuleInterviewWait = "interview_reject_wait"
+	RuleHiredCooldown = "hired_cooldown"
+)
+
+// PolicyViolation is one rule that blocks an application. Code and Params
+// name the error message (see i18n, "errors.<Code>"); Message is filled in
+// by the handler in the caller's language.
+type PolicyViolation struct {
+	Rule    string         `json:"rule"`
+	Code    string         `json:"code"`
+	Params  map[string]any `json:"params,omitempty"`
+	Message string         `json:"message,omitempty"`
+	RetryAt *time.Time     `json:"retry_at,omitempty"` // nil = not time based (e.g. close another application first)
+}
+
+// closedStatus: applications in these statuses do not count as active.
+func closedStatus(s string) bool {
+	return s == "rejected" || s == "hired" || s == "withdrawn"
+}
+
+// ReapplyWaitMonths is how long to wait after a rejection, and the stage it
+// happened at ("screening" or "interview").
+func ReapplyWaitMonths(cfg PolicyConfig, interviewed bool) (int, string) {
+	if interviewed {
+		return cfg.InterviewRejectWaitMonths, "interview"
+	}
+	return cfg.ScreeningRejectWaitMonths, "screening"
+}
+
+// EvaluateApplyPolicy returns every rule that stops the applicant applying to
+// jobID at now, given their earlier applications. No violations = allowed.
+func EvaluateApplyPolicy(cfg PolicyConfig, jobID string, prior []PriorApplication, now time.Time) []PolicyViolation {
+	var out []PolicyViolation
+
+	// 1) cap on concurrent active applications
+	active := 0
+	for _, p := range prior {
+		if !closedStatus(p.Status) {
+			active++
+		}
+	}
+	if cfg.MaxActiveApplications > 0 && active >= cfg.MaxActiveApplications {
+		out = append(out, PolicyViolation{
+			Rule:   RuleMaxActive,
+			Code:   "APPLICATION_LIMIT_REACHED",
+			Params: map[string]any{"limit": cfg.MaxActiveApplications},
+		})
+	}
+
+	// 2) the latest application for the same job: still active, or rejected recently
+	var last *PriorApplication
+	for i := range prior {
+		if prior[i].JobID == jobID && (last == nil || prior[i].SubmittedAt.After(last.SubmittedAt)) {
+			last = &prior[i]
+		}
+	}
+	if last != nil {
+		switch {
+		case !closedStatus(last.Status):
+			out = append(out, PolicyViolation{
+				Rule:   RuleDuplicate,
+				Code:   "APPLICATION_ALREADY_ACTIVE",
+				Params: map[string]any{"status": last.Status},
+			})
+		case last.Status == "rejected":
+			months, stage := ReapplyWaitMonths(cfg, last.InterviewedBeforeRejection)
+			allowedAt := last.ClosedAt.AddDate(0, months, 0)
+			if now.Before(allowedAt) {
+				rule := RuleScreeningWait
+				if stage == "interview" {
+					rule = RuleInterviewWait
+				}
+				out = append(out, PolicyViolation{
+					Rule: rule,
+					Code: "APPLICATION_COOLDOWN_ACTIVE",
+					Params: map[string]any{
+						"allowed_at": allowedAt, "months": months, "stage": stage, "rejected_at": last.ClosedAt,
+					},
+					RetryAt: &allowedAt,
+				})
+			}
+		}
+	}
+
+	// 3) cooldown on other jobs after the most recent hire
+	var hired *PriorApplication
+	for i := range prior {
+		if prior[i].Status == "hired" && (hired == nil || prior[i].ClosedAt.After(hired.ClosedAt)) {
+			hired = &prior[i]
+		}
+	}
+	if hired != nil && hired.JobID != "" && hired.JobID != jobID {
+		allowedAt := hired.ClosedAt.AddDate(0, cfg.HiredCooldownMonths, 0)
+		if now.Before(allowedAt) {
+			out = append(out, PolicyViolation{
+				Rule: RuleHiredCooldown,
+				Code: "HIRE_COOLDOWN_ACTIVE",
+				Params: map[string]any{
+					"allowed_at": allowedAt, "months": cfg.HiredCooldownMonths, "hired_at": hired.ClosedAt,
+				},
+				RetryAt: &allowedAt,
+			})
+		}
+	}
+	return out
+}
+
+// EarliestRetry is when every time-based violation has lapsed. ok is false if
+// any violation is not time based.
+func EarliestRetry(violations []PolicyViolation) (t time.Time, ok bool) {
+	for _, v := range violations {
+		if v.RetryAt == nil {
+			return time.Time{}, false
+		}
+		if v.RetryAt.After(t) {
+			t = *v.RetryAt
+		}
+	}
+	return t, true
+}
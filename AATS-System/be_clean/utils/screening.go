@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Screening question types
+const (
+	QuestionYesNo        = "yes_no"
+	QuestionSingleChoice = "single_choice"
+	QuestionNumeric      = "numeric"
+	QuestionText         = "text"
+)
+
+// Knockout actions
+const (
+	KnockoutReject = "reject"
+	KnockoutFlag   = "flag"
+)
+
+// ScreeningRule is the knockout part of a screening question. The rule fires
+// when the answer satisfies Operator/Value; Action says what happens then.
+type ScreeningRule struct {
+	Action   string // reject | flag | "" (no rule)
+	Operator string // eq | neq | in | not_in | gt | gte | lt | lte | contains | not_contains
+	Value    string // plain value, or JSON array for in/not_in
+}
+
+// ValidQuestionType reports whether t is a supported question type.
+func ValidQuestionType(t string) bool {
+	switch t {
+	case QuestionYesNo, QuestionSingleChoice, QuestionNumeric, QuestionText:
+		return true
+	}
+	return false
+}
+
+// NormalizeAnswer validates a raw answer for a question type and returns the
+// canonical stored form ("yes"/"no", trimmed choice, formatted number, text).
+// options is the JSON array of choices for single_choice questions.
+func NormalizeAnswer(qType, options, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch qType {
+	case QuestionYesNo:
+		switch strings.ToLower(raw) {
+		case "yes", "y", "true", "1", "ใช่":
+			return "yes", nil
+		case "no", "n", "false", "0", "ไม่ใช่":
+			return "no", nil
+		}
+		return "", errors.New("answer must be yes or no")
+	case QuestionSingleChoice:
+		var opts []string
+		_ = json.Unmarshal([]byte(options), &opts)
+		for _, o := range opts {
+			if strings.EqualFold(strings.TrimSpace(o), raw) {
+				return o, nil
+			}
+		}
+		return "", errors.New("answer is not one of the options")
+	case QuestionNumeric:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return "", errors.New("answer must be a number")
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case QuestionText:
+		return raw, nil
+	}
+	return "", errors.New("unknown question type")
+}
+
+// ValidateRule checks that a knockout rule makes sense for a question type.
+func ValidateRule(qType string, r ScreeningRule) error {
+	if r.Action == "" {
+		return nil
+	}
+	if r.Action != KnockoutReject && r.Action != KnockoutFlag {
+		return errors.New("knockout action must be reject or flag")
+	}
+	switch r.Operator {
+	case "eq", "neq":
+	case "in", "not_in":
+		var arr []string
+		if json.Unmarshal([]byte(r.Value), &arr) != nil {
+			return errors.New("in/not_in value must be a JSON array")
+		}
+	case "gt", "gte", "lt", "lte":
+		if qType != QuestionNumeric {
+			return errors.New("numeric operators need a numeric question")
+		}
+		if _, err := strconv.ParseFloat(r.Value, 64); err != nil {
+			return errors.New("numeric operator value must be a number")
+		}
+	case "contains", "not_contains":
+		if qType != QuestionText {
+			return errors.New("contains operators need a text question")
+		}
+	default:
+		return errors.New("unknown knockout operator")
+	}
+	return nil
+}
+
+// KnockoutTriggered evaluates a rule against a normalized answer. Blank
+// answers to optional questions never trigger a rule.
+func KnockoutTriggered(r ScreeningRule, answer string) bool {
+	if r.Action == "" || answer == "" {
+		return false
+	}
+	switch r.Operator {
+	case "eq":
+		return strings.EqualFold(answer, r.Value)
+	case "neq":
+		return !strings.EqualFold(answer, r.Value)
+	case "in", "not_in":
+		var arr []string
+		_ = json.Unmarshal([]byte(r.Value), &arr)
+		found := false
+		for _, v := range arr {
+			if strings.EqualFold(answer, v) {
+				found = true
+				break
+			}
+		}
+		return found == (r.Operator == "in")
+	case "gt", "gte", "lt", "lte":
+		a, err1 := strconv.ParseFloat(answer, 64)
+		v, err2 := strconv.ParseFloat(r.Value, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		switch r.Operator {
+		case "gt":
+			return a > v
+		case "gte":
+			return a >= v
+		case "lt":
+			return a < v
+		}
+		return a <= v
+	case "contains":
+		return strings.Contains(strings.ToLower(answer), strings.ToLower(r.Value))
+	case "not_contains":
+		return !strings.Contains(strings.ToLower(answer), strings.ToLower(r.Value))
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SkillKey folds a free-text skill into a lookup key so that "Golang",
+// "go-lang" and "Go Lang" all collide. Symbols that carry meaning (C++, C#)
+// are kept.
+func SkillKey(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '-', '_', '.', '/':
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SkillTaxonomy maps lookup keys (canonical names and synonyms) to canonical
+// skill names and their categories.
+type SkillTaxonomy struct {
+	canonical map[string]string // key -> canonical name
+	category  map[string]string // canonical name -> category
+}
+
+// NewSkillTaxonomy returns an empty taxonomy. Unknown skills pass through
+// Normalize unchanged (trimmed), so an empty taxonomy is still usable.
+func NewSkillTaxonomy() *SkillTaxonomy {
+	return &SkillTaxonomy{canonical: map[string]string{}, category: map[string]string{}}
+}
+
+// Add registers a canonical skill with its category and synonyms.
+func (t *SkillTaxonomy) Add(name, category string, synonyms ...string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+	t.canonical[SkillKey(name)] = name
+	t.category[name] = category
+	for _, s := range synonyms {
+		if k := SkillKey(s); k != "" {
+			t.canonical[k] = name
+		}
+	}
+}
+
+// Canonical resolves a single skill. ok is false when the skill is not part
+// of the taxonomy; the trimmed input is returned in that case.
+func (t *SkillTaxonomy) Canonical(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if name, ok := t.canonical[SkillKey(raw)]; ok {
+		return name, true
+	}
+	return raw, false
+}
+
+// Category returns the category of a canonical skill ("" when unknown).
+func (t *SkillTaxonomy) Category(name string) string {
+	return t.category[name]
+}
+
+// Normalize resolves every skill to its canonical name and drops duplicates
+// and blanks, keeping the first-seen order.
+func (t *SkillTaxonomy) Normalize(raw []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(raw))
+	for _, r := range raw {
+		name, _ := t.Canonical(r)
+		if name == "" {
+			continue
+		}
+		k := SkillKey(name)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, name)
+	}
+	return out
+}
+
+// ParseSkillList accepts the formats skills are stored in: a JSON array
+// string or a comma/newline separated list.
+func ParseSkillList(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	var arr []string
+	if strings.HasPrefix(s, "[") && json.Unmarshal([]byte(s), &arr) == nil {
+		return arr
+	}
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == ';' })
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// SkillListJSON encodes a skill list the way the models store it.
+func SkillListJSON(skills []string) string {
+	if skills == nil {
+		skills = []string{}
+	}
+	b, _ := json.Marshal(skills)
+	return string(b)
+}
+
+var numberRe = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// ExperienceYears estimates years of experience from Application.Experience.
+// It understands {"years": 3}, {"duration": "2 ปี"}, arrays of such objects
+// and plain strings such as "18 months".
+func ExperienceYears(raw string) float64 {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	var list []map[string]interface{}
+	if strings.HasPrefix(raw, "[") && json.Unmarshal([]byte(raw), &list) == nil {
+		total := 0.0
+		for _, m := range list {
+			total += experienceFromObject(m)
+		}
+		return total
+	}
+	var obj map[string]interface{}
+	if strings.HasPrefix(raw, "{") && json.Unmarshal([]byte(raw), &obj) == nil {
+		return experienceFromObject(obj)
+	}
+	return durationYears(raw)
+}
+
+func experienceFromObject(m map[string]interface{}) float64 {
+	for _, k := range []string{"years", "years_of_experience", "experience_years"} {
+		switch v := m[k].(type) {
+		case float64:
+			return v
+		case string:
+			return durationYears(v)
+		}
+	}
+	if d, ok := m["duration"].(string); ok {
+		return durationYears(d)
+	}
+	return 0
+}
+
+func durationYears(s string) float64 {
+	m := numberRe.FindString(s)
+	if m == "" {
+		return 0
+	}
+	n, err := strconv.ParseFloat(m, 64)
+	if err != nil {
+		return 0
+	}
+	ls := strings.ToLower(s)
+	if strings.Contains(ls, "month") || strings.Contains(ls, "เดือน") {
+		return n / 12
+	}
+	return n
+}
+
+// DefaultMinYears maps JobPosting.ExperienceLevel to a minimum number of
+// years when a job does not set one explicitly.
+func DefaultMinYears(level string) float64 {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "mid":
+		return 2
+	case "senior":
+		return 5
+	case "lead", "principal":
+		return 8
+	}
+	return 0
+}
+
+// Match score weights. Must-have coverage dominates; nice-to-have and
+// experience refine the ranking between candidates who cover the basics.
+const (
+	MatchWeightMustHave   = 60.0
+	MatchWeightNiceToHave = 25.0
+	MatchWeightExperience = 15.0
+)
+
+// MatchBreakdown explains how a match score was computed.
+type MatchBreakdown struct {
+	Score             float64  `json:"score"`
+	MustHaveScore     float64  `json:"must_have_score"`
+	NiceToHaveScore   float64  `json:"nice_to_have_score"`
+	ExperienceScore   float64  `json:"experience_score"`
+	MustHaveMatched   []string `json:"must_have_matched"`
+	MustHaveMissing   []string `json:"must_have_missing"`
+	NiceToHaveMatched []string `json:"nice_to_have_matched"`
+	CandidateYears    float64  `json:"candidate_years"`
+	RequiredYears     float64  `json:"required_years"`
+}
+
+// ComputeMatch scores canonical candidate skills and experience against a
+// job's canonical must-have and nice-to-have skills. The result is 0-100.
+func ComputeMatch(candidate []string, years float64, mustHave, niceToHave []string, requiredYears float64) MatchBreakdown {
+	have := map[string]bool{}
+	for _, s := range candidate {
+		have[SkillKey(s)] = true
+	}
+	b := MatchBreakdown{
+		MustHaveMatched:   []string{},
+		MustHaveMissing:   []string{},
+		NiceToHaveMatched: []string{},
+		CandidateYears:    round2(years),
+		RequiredYears:     requiredYears,
+	}
+
+	if len(mustHave) == 0 {
+		b.MustHaveScore = MatchWeightMustHave
+	} else {
+		for _, s := range mustHave {
+			if have[SkillKey(s)] {
+				b.MustHaveMatched = append(b.MustHaveMatched, s)
+			} else {
+				b.MustHaveMissing = append(b.MustHaveMissing, s)
+			}
+		}
+		b.MustHaveScore = MatchWeightMustHave * float64(len(b.MustHaveMatched)) / float64(len(mustHave))
+	}
+
+	if len(niceToHave) == 0 {
+		b.NiceToHaveScore = MatchWeightNiceToHave
+	} else {
+		for _, s := range niceToHave {
+			if have[SkillKey(s)] {
+				b.NiceToHaveMatched = append(b.NiceToHaveMatched, s)
+			}
+		}
+		b.NiceToHaveScore = MatchWeightNiceToHave * float64(len(b.NiceToHaveMatched)) / float64(len(niceToHave))
+	}
+
+	if requiredYears <= 0 {
+		b.ExperienceScore = MatchWeightExperience
+	} else {
+		b.ExperienceScore = MatchWeightExperience * math.Min(years/requiredYears, 1)
+	}
+
+	b.MustHaveScore = round2(b.MustHaveScore)
+	b.NiceToHaveScore = round2(b.NiceToHaveScore)
+	b.ExperienceScore = round2(b.ExperienceScore)
+	b.Score = round2(b.MustHaveScore + b.NiceToHaveScore + b.ExperienceScore)
+	return b
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// FunnelStages is the hiring funnel in order. Rejected/withdrawn are exits,
+// not stages.
+var FunnelStages = []string{"submitted", "screening", "interview", "offer", "hired"}
+
+// StageRank returns the funnel position of a status, or -1 for exits.
+func StageRank(status string) int {
+	for i, s := range FunnelStages {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// StatusEvent is one timeline entry reduced to what analytics needs.
+type StatusEvent struct {
+	Status string
+	At     time.Time
+}
+
+// StageSpan is a completed stay in one status.
+type StageSpan struct {
+	Stage     string
+	EnteredAt time.Time
+	Hours     float64
+}
+
+// StageSpans turns a chronological list of events into the time spent in each
+// status. Repeated events with the same status (notes, tags) do not end a
+// stay, and the current status has no span because it has not ended yet.
+func StageSpans(events []StatusEvent) []StageSpan {
+	spans := []StageSpan{}
+	if len(events) == 0 {
+		return spans
+	}
+	cur := events[0]
+	for _, e := range events[1:] {
+		if e.Status == cur.Status {
+			continue
+		}
+		spans = append(spans, StageSpan{Stage: cur.Status, EnteredAt: cur.At, Hours: e.At.Sub(cur.At).Hours()})
+		cur = e
+	}
+	return spans
+}
+
+// Percentile returns the p-th percentile (0-100) of values using linear
+// interpolation between closest ranks. values does not need to be sorted.
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), values...)
+	sort.Float64s(s)
+	if p <= 0 {
+		return s[0]
+	}
+	if p >= 100 {
+		return s[len(s)-1]
+	}
+	pos := p / 100 * float64(len(s)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return s[lo] + (s[hi]-s[lo])*(pos-float64(lo))
+}
+
+// Summary is the usual set of statistics reported for a duration or score.
+type Summary struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+}
+
+// Summarize computes count, mean, median and p90, rounded to 2 decimals.
+func Summarize(values []float64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	r := func(v float64) float64 { return math.Round(v*100) / 100 }
+	return Summary{
+		Count:  len(values),
+		Mean:   r(sum / float64(len(values))),
+		Median: r(Percentile(values, 50)),
+		P90:    r(Percentile(values, 90)),
+	}
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_]+)\s*\}\}`)
+
+// RenderTemplate replaces {{key}} placeholders with values from vars.
+// Unknown placeholders are left as-is so typos are visible in the output.
+func RenderTemplate(tpl string, vars map[string]string) string {
+	return placeholderRe.ReplaceAllStringFunc(tpl, func(m string) string {
+		key := strings.TrimSpace(m[2 : len(m)-2])
+		if v, ok := vars[key]; ok {
+			return v
+		}
+		return m
+	})
+}
@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Font is a TrueType font read far enough to embed it in a PDF: the glyph
+// for each character, the glyph widths and the metrics of the font
+// descriptor. Glyphs are placed one per character with no shaping, which is
+// enough for Latin and Thai text in fonts whose marks have no advance.
+type Font struct {
+	Name       string // PostScript-safe name written as /BaseFont
+	Data       []byte // the font file, embedded as is
+	UnitsPerEm int
+	Ascent     int
+	Descent    int
+	BBox       [4]int
+	glyphs     map[rune]uint16
+	widths     []uint16 // advance per glyph; the last repeats for the rest
+}
+
+// PDFFont is the font WriteTextPDF embeds. When nil the PDF uses the
+// built-in Helvetica, which only covers Latin-1. main sets it from PDF_FONT.
+var PDFFont *Font
+
+// LoadFont reads a .ttf file and names the font after the file.
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), data)
+}
+
+// ParseFont reads the head, hhea, maxp, hmtx and cmap tables of a TrueType
+// font. Characters the cmap does not cover map to glyph 0 (.notdef).
+func ParseFont(name string, data []byte) (*Font, error) {
+	tables := map[string][]byte{}
+	if len(data) < 12 {
+		return nil, errors.New("font: file too short")
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < n; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errors.New("font: table directory truncated")
+		}
+		off, size := binary.BigEndian.Uint32(data[rec+8:]), binary.BigEndian.Uint32(data[rec+12:])
+		if uint64(off)+uint64(size) > uint64(len(data)) {
+			return nil, fmt.Errorf("font: table %q out of range", data[rec:rec+4])
+		}
+		tables[string(data[rec:rec+4])] = data[off : off+size]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "cmap"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("font: no %s table (only TrueType fonts are supported)", tag)
+		}
+	}
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errors.New("font: header tables truncated")
+	}
+	s16 := func(b []byte, off int) int { return int(int16(binary.BigEndian.Uint16(b[off:]))) }
+	f := &Font{
+		Name:       pdfName(name),
+		Data:       data,
+		UnitsPerEm: int(binary.BigEndian.Uint16(head[18:])),
+		Ascent:     s16(hhea, 4),
+		Descent:    s16(hhea, 6),
+		BBox:       [4]int{s16(head, 36), s16(head, 38), s16(head, 40), s16(head, 42)},
+	}
+	if f.UnitsPerEm == 0 {
+		return nil, errors.New("font: unitsPerEm is 0")
+	}
+
+	metrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := tables["hmtx"]
+	if metrics == 0 || len(hmtx) < 4*metrics {
+		return nil, errors.New("font: hmtx truncated")
+	}
+	f.widths = make([]uint16, metrics)
+	for i := range f.widths {
+		f.widths[i] = binary.BigEndian.Uint16(hmtx[4*i:])
+	}
+
+	glyphs, err := readCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.glyphs = glyphs
+	return f, nil
+}
+
+// Glyph is the glyph id of r, 0 when the font has none.
+func (f *Font) Glyph(r rune) uint16 { return f.glyphs[r] }
+
+// Width is the advance of glyph g in 1/1000 of the font size, as PDF wants.
+func (f *Font) Width(g uint16) int {
+	w := f.widths[len(f.widths)-1]
+	if int(g) < len(f.widths) {
+		w = f.widths[g]
+	}
+	return int(w) * 1000 / f.UnitsPerEm
+}
+
+// scale converts font units to 1/1000 of the font size.
+func (f *Font) scale(v int) int { return v * 1000 / f.UnitsPerEm }
+
+// readCmap picks the Unicode subtable of a cmap, preferring the full
+// repertoire (format 12) over the BMP one (format 4).
+func readCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errors.New("font: cmap truncated")
+	}
+	var bmp, full []byte
+	for i := 0; i < int(binary.BigEndian.Uint16(cmap[2:])); i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform, encoding := binary.BigEndian.Uint16(cmap[rec:]), binary.BigEndian.Uint16(cmap[rec+2:])
+		off := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if off+2 > len(cmap) || !(platform == 0 || platform == 3 && (encoding == 1 || encoding == 10)) {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[off:]) {
+		case 4:
+			bmp = cmap[off:]
+		case 12:
+			full = cmap[off:]
+		}
+	}
+	switch {
+	case full != nil:
+		return cmapFormat12(full)
+	case bmp != nil:
+		return cmapFormat4(bmp)
+	}
+	return nil, errors.New("font: no Unicode cmap (format 4 or 12)")
+}
+
+func cmapFormat4(t []byte) (map[rune]uint16, error) {
+	if len(t) < 14 {
+		return nil, errors.New("font: cmap format 4 truncated")
+	}
+	segs := int(binary.BigEndian.Uint16(t[6:])) / 2
+	ends, starts, deltas, ranges := 14, 16+2*segs, 16+4*segs, 16+6*segs
+	if len(t) < ranges+2*segs {
+		return nil, errors.New("font: cmap format 4 truncated")
+	}
+	u16 := func(off int) int { return int(binary.BigEndian.Uint16(t[off:])) }
+	out := map[rune]uint16{}
+	for i := 0; i < segs; i++ {
+		start, end, delta, rangeOff := u16(starts+2*i), u16(ends+2*i), u16(deltas+2*i), u16(ranges+2*i)
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			g := (c + delta) & 0xFFFF
+			if rangeOff != 0 {
+				at := ranges + 2*i + rangeOff + 2*(c-start)
+				if at+2 > len(t) {
+					break
+				}
+				if g = u16(at); g != 0 {
+					g = (g + delta) & 0xFFFF
+				}
+			}
+			if g != 0 {
+				out[rune(c)] = uint16(g)
+			}
+		}
+	}
+	return out, nil
+}
+
+func cmapFormat12(t []byte) (map[rune]uint16, error) {
+	if len(t) < 16 {
+		return nil, errors.New("font: cmap format 12 truncated")
+	}
+	groups := int(binary.BigEndian.Uint32(t[12:]))
+	if len(t) < 16+12*groups {
+		return nil, errors.New("font: cmap format 12 truncated")
+	}
+	out := map[rune]uint16{}
+	for i := 0; i < groups; i++ {
+		g := t[16+12*i:]
+		start, end, glyph := binary.BigEndian.Uint32(g), binary.BigEndian.Uint32(g[4:]), binary.BigEndian.Uint32(g[8:])
+		for c := start; c <= end && c <= 0x10FFFF; c++ {
+			out[rune(c)] = uint16(glyph + c - start)
+		}
+	}
+	return out, nil
+}
+
+// pdfName keeps the characters a PDF name can hold without escapes.
+func pdfName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r > ' ' && r < 127 && !strings.ContainsRune("()<>[]{}/%#", r) {
+			return r
+		}
+		return -1
+	}, s)
+	if s == "" {
+		return "Font"
+	}
+	return s
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook request headers.
+const (
+	WebhookSignatureHeader = "X-AATS-Signature"
+	WebhookEventHeader     = "X-AATS-Event"
+	WebhookDeliveryHeader  = "X-AATS-Delivery"
+)
+
+// Delivery retry policy: attempt n waits WebhookBaseBackoff * 2^(n-1),
+// capped at WebhookMaxBackoff; after WebhookMaxAttempts the delivery fails.
+const (
+	WebhookMaxAttempts = 8
+	WebhookBaseBackoff = 30 * time.Second
+	WebhookMaxBackoff  = 6 * time.Hour
+)
+
+// NewWebhookSecret returns a random signing secret.
+func NewWebhookSecret() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// SignWebhook builds the signature header value for a body sent at ts:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Signing the
+// timestamp lets receivers reject replayed requests.
+func SignWebhook(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + t + ",v1=" + webhookMAC(secret, t, body)
+}
+
+func webhookMAC(secret, t string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(t + "."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// VerifyWebhookSignature checks a signature header the way a receiver
+// should: the MAC must match and the timestamp be within tolerance of now.
+func VerifyWebhookSignature(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t, sig string
+	for _, part := range strings.Split(header, ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			switch k {
+			case "t":
+				t = v
+			case "v1":
+				sig = v
+			}
+		}
+	}
+	sec, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || sig == "" {
+		return errors.New("malformed signature header")
+	}
+	if !hmac.Equal([]byte(sig), []byte(webhookMAC(secret, t, body))) {
+		return errors.New("signature mismatch")
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return errors.New("timestamp outside tolerance")
+	}
+	return nil
+}
+
+// WebhookBackoff is how long to wait after the given (1-based) failed attempt.
+func WebhookBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := WebhookBaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= WebhookMaxBackoff {
+			return WebhookMaxBackoff
+		}
+	}
+	return d
+}
+
+// WebhookEventMatches reports whether an event type is selected by a
+// subscription pattern: "*" for everything, "application.*" for a prefix,
+// otherwise the exact type.
+func WebhookEventMatches(pattern, eventType string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == eventType
+}
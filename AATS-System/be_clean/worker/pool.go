@@ -0,0 +1,65 @@
+// Package worker runs background jobs in-process. Job state lives in the
+// database (see models.BulkJob), so the pool only carries job IDs and a
+// restarted server can pick unfinished jobs up again.
+package worker
+
+import (
+	"log"
+	"sync"
+)
+
+// Pool is a fixed set of goroutines draining a queue of job IDs.
+type Pool struct {
+	queue   chan string
+	process func(id string)
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// NewPool starts `workers` goroutines that call process for every enqueued
+// ID. buffer bounds how many IDs can wait before Enqueue reports false.
+func NewPool(workers, buffer int, process func(id string)) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{queue: make(chan string, buffer), process: process}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.loop()
+	}
+	return p
+}
+
+func (p *Pool) loop() {
+	defer p.wg.Done()
+	for id := range p.queue {
+		p.run(id)
+	}
+}
+
+// run isolates panics so one bad job does not take a worker down.
+func (p *Pool) run(id string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("worker: job %s panicked: %v", id, r)
+		}
+	}()
+	p.process(id)
+}
+
+// Enqueue schedules a job. It returns false when the queue is full; the
+// caller decides what becomes of the job.
+func (p *Pool) Enqueue(id string) bool {
+	select {
+	case p.queue <- id:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting work and waits for running jobs to finish.
+func (p *Pool) Close() {
+	p.once.Do(func() { close(p.queue) })
+	p.wg.Wait()
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"log"
+	"time"
+)
+
+// Every calls fn every d until stop is called. Runs never overlap: a slow run
+// delays the next tick instead of stacking up. Panics are logged.
+func Every(d time.Duration, fn func()) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(d)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				func() {
+					defer func() {
+						if r := recover(); r != nil {
+							log.Printf("worker: scheduled task panicked: %v", r)
+						}
+					}()
+					fn()
+				}()
+			}
+		}
+	}()
+	return func() { close(done) }
+}
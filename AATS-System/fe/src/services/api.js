@@ -11,6 +11,7 @@ const api = axios.create({
   timeout: 10000,
   headers: {
     'Content-Type': 'application/json',
+    'Accept-Language': 'th, en;q=0.8',
   },
 });
 
@@ -31,7 +32,17 @@ api.interceptors.request.use(
 // Response interceptor to handle errors
 api.interceptors.response.use(
   (response) => {
-    return response.data; // Return only the data part
+    // BE wraps every success body as { data, meta? }. Flatten objects so
+    // callers keep reading response.jobs, response.total, ...
+    const body = response.data;
+    if (body && typeof body === 'object' && 'data' in body) {
+      const { data, meta } = body;
+      if (data && typeof data === 'object' && !Array.isArray(data)) {
+        return { ok: true, ...data, ...(meta || {}), meta };
+      }
+      return data;
+    }
+    return body;
   },
   (error) => {
     // Handle common errors
@@ -55,9 +66,14 @@ api.interceptors.response.use(
       }
     }
     
-    // Return error message from server or default message
-    const errorMessage = error.response?.data?.message || error.message || 'An error occurred';
-    return Promise.reject(new Error(errorMessage));
+    // Errors are problem+json: { code, detail, ... }; detail is already in
+    // the language asked for above
+    const problem = error.response?.data;
+    const errorMessage = problem?.detail || problem?.message || error.message || 'An error occurred';
+    const err = new Error(errorMessage);
+    err.code = problem?.code;
+    err.problem = problem;
+    return Promise.reject(err);
   }
 );
 